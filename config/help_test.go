@@ -197,7 +197,8 @@ func testServiceURL(id string) *service.Service {
 	svc.DeployedVersionLookup.Init(
 		&deployedver.Lookup{}, &deployedver.Lookup{},
 		&svc.Status,
-		&svc.Options)
+		&svc.Options,
+		svc.LatestVersion.Require)
 	svc.Status.WebURL = &svc.Dashboard.WebURL
 
 	svc.Status.SetLastQueried("")
@@ -93,7 +93,14 @@ func queryRow(t *testing.T, db *sql.DB, serviceID string) *svcstatus.Status {
 		latest_version_timestamp,
 		deployed_version,
 		deployed_version_timestamp,
-		approved_version
+		approved_version,
+		latest_version_release,
+		pending_version,
+		pending_version_timestamp,
+		latest_version_digest,
+		latest_version_created,
+		latest_version_urls,
+		latest_version_message
 	FROM status
 	WHERE id = ?;`
 	// Retry up-to 10 times incase 'database is locked'
@@ -118,9 +125,16 @@ func queryRow(t *testing.T, db *sql.DB, serviceID string) *svcstatus.Status {
 		dv  string
 		dvt string
 		av  string
+		lvr string
+		pv  string
+		pvt string
+		lvd string
+		lvc string
+		lvu string
+		lvm string
 	)
 	for row.Next() {
-		err = row.Scan(&id, &lv, &lvt, &dv, &dvt, &av)
+		err = row.Scan(&id, &lv, &lvt, &dv, &dvt, &av, &lvr, &pv, &pvt, &lvd, &lvc, &lvu, &lvm)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -135,6 +149,15 @@ func queryRow(t *testing.T, db *sql.DB, serviceID string) *svcstatus.Status {
 	status.SetDeployedVersion(dv, false)
 	status.SetDeployedVersionTimestamp(dvt)
 	status.SetApprovedVersion(av, false)
+	status.SetPendingVersion(pv, pvt, false)
+	status.SetLatestVersionDigest(lvd, lvc, false)
+	status.SetLatestVersionMessage(lvm, false)
+	if err = status.SetLatestVersionURLsJSON(lvu); err != nil {
+		t.Fatal(err)
+	}
+	if err = status.SetLatestVersionReleaseJSON(lvr); err != nil {
+		t.Fatal(err)
+	}
 
 	return &status
 }
@@ -96,12 +96,58 @@ func (api *api) initialise() {
 			latest_version_timestamp DATETIME DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
 			deployed_version STRING DEFAULT '',
 			deployed_version_timestamp DATETIME DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
-			approved_version STRING DEFAULT ''
+			approved_version STRING DEFAULT '',
+			latest_version_release STRING DEFAULT '',
+			pending_version STRING DEFAULT '',
+			pending_version_timestamp STRING DEFAULT '',
+			latest_version_digest STRING DEFAULT '',
+			latest_version_created STRING DEFAULT '',
+			latest_version_urls STRING DEFAULT '',
+			latest_version_message STRING DEFAULT ''
 		);`
 	_, err = db.Exec(sqlStmt)
 	jLog.Fatal(util.ErrorToString(err), *logFrom, err != nil)
 
 	api.db = db
+	api.addMissingColumns()
+}
+
+// addMissingColumns will add the columns that are newer than the status table of the db.
+func (api *api) addMissingColumns() {
+	columns := []struct {
+		name       string
+		definition string
+	}{
+		{name: "latest_version_release", definition: "STRING DEFAULT ''"},
+		{name: "pending_version", definition: "STRING DEFAULT ''"},
+		{name: "pending_version_timestamp", definition: "STRING DEFAULT ''"},
+		{name: "latest_version_digest", definition: "STRING DEFAULT ''"},
+		{name: "latest_version_created", definition: "STRING DEFAULT ''"},
+		{name: "latest_version_urls", definition: "STRING DEFAULT ''"},
+		{name: "latest_version_message", definition: "STRING DEFAULT ''"}}
+
+	for _, column := range columns {
+		var count int
+		err := api.db.QueryRow(`
+			SELECT COUNT(*)
+			FROM pragma_table_info('status')
+			WHERE name = ?;`,
+			column.name).Scan(&count)
+		jLog.Fatal(
+			fmt.Sprintf("addMissingColumns %s: %s", column.name, util.ErrorToString(err)),
+			*logFrom,
+			err != nil)
+		if count != 0 {
+			continue
+		}
+
+		_, err = api.db.Exec(fmt.Sprintf("ALTER TABLE status ADD COLUMN %s %s;",
+			column.name, column.definition))
+		jLog.Fatal(
+			fmt.Sprintf("addMissingColumns %s: %s", column.name, util.ErrorToString(err)),
+			*logFrom,
+			err != nil)
+	}
 }
 
 // removeUnknownServices will remove rows with an id not in config.Order
@@ -138,7 +184,14 @@ func (api *api) extractServiceStatus() {
 		latest_version_timestamp,
 		deployed_version,
 		deployed_version_timestamp,
-		approved_version
+		approved_version,
+		latest_version_release,
+		pending_version,
+		pending_version_timestamp,
+		latest_version_digest,
+		latest_version_created,
+		latest_version_urls,
+		latest_version_message
 	FROM status;`)
 	jLog.Fatal(err, *logFrom, err != nil)
 	defer rows.Close()
@@ -153,8 +206,15 @@ func (api *api) extractServiceStatus() {
 			dv  string
 			dvt string
 			av  string
+			lvr string
+			pv  string
+			pvt string
+			lvd string
+			lvc string
+			lvu string
+			lvm string
 		)
-		err = rows.Scan(&id, &lv, &lvt, &dv, &dvt, &av)
+		err = rows.Scan(&id, &lv, &lvt, &dv, &dvt, &av, &lvr, &pv, &pvt, &lvd, &lvc, &lvu, &lvm)
 		jLog.Fatal(
 			fmt.Sprintf("extractServiceStatus row: %s", util.ErrorToString(err)),
 			*logFrom,
@@ -164,6 +224,19 @@ func (api *api) extractServiceStatus() {
 		api.config.Service[id].Status.SetDeployedVersion(dv, false)
 		api.config.Service[id].Status.SetDeployedVersionTimestamp(dvt)
 		api.config.Service[id].Status.SetApprovedVersion(av, false)
+		api.config.Service[id].Status.SetPendingVersion(pv, pvt, false)
+		api.config.Service[id].Status.SetLatestVersionDigest(lvd, lvc, false)
+		api.config.Service[id].Status.SetLatestVersionMessage(lvm, false)
+		err = api.config.Service[id].Status.SetLatestVersionReleaseJSON(lvr)
+		jLog.Error(
+			fmt.Sprintf("extractServiceStatus %q: %s", id, util.ErrorToString(err)),
+			*logFrom,
+			err != nil)
+		err = api.config.Service[id].Status.SetLatestVersionURLsJSON(lvu)
+		jLog.Error(
+			fmt.Sprintf("extractServiceStatus %q: %s", id, util.ErrorToString(err)),
+			*logFrom,
+			err != nil)
 	}
 	err = rows.Err()
 	jLog.Fatal(
@@ -17,6 +17,7 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
 	"regexp"
@@ -145,6 +146,98 @@ func TestAPI_Initialise(t *testing.T) {
 	os.Remove(*api.config.Settings.Data.DatabaseFile)
 }
 
+func TestAPI_AddMissingColumns(t *testing.T) {
+	// GIVEN a db with a status table from before latest_version_release/pending_version/latest_version_digest/latest_version_urls
+	initLogging()
+	cfg := testConfig()
+	api := api{config: &cfg}
+	*api.config.Settings.Data.DatabaseFile = "TestAddMissingColumns.db"
+	db, err := sql.Open("sqlite", *api.config.Settings.Data.DatabaseFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE status
+		(
+			id STRING NOT NULL PRIMARY KEY,
+			latest_version STRING DEFAULT '',
+			latest_version_timestamp DATETIME DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+			deployed_version STRING DEFAULT '',
+			deployed_version_timestamp DATETIME DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+			approved_version STRING DEFAULT ''
+		);
+	INSERT INTO status (id, latest_version) VALUES ('keep0', '1.2.3');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN the db is initialised
+	api.initialise()
+	defer os.Remove(*api.config.Settings.Data.DatabaseFile)
+	defer api.db.Close()
+
+	// THEN the existing rows can still be read
+	got := queryRow(t, api.db, "keep0")
+	if got.GetLatestVersion() != "1.2.3" {
+		t.Errorf("want latest_version %q, got %q",
+			"1.2.3", got.GetLatestVersion())
+	}
+	// AND the release metadata of the latest version can be stored
+	api.updateRow("keep0", []dbtype.Cell{
+		{Column: "latest_version_release", Value: `{"name":"v1.2.3","body":"## Changelog","author":"someone"}`}})
+	got = queryRow(t, api.db, "keep0")
+	want := util.ReleaseInfo{Name: "v1.2.3", Body: "## Changelog", Author: "someone"}
+	if got.GetLatestVersionRelease() != want {
+		t.Errorf("want latest_version_release %+v, got %+v",
+			want, got.GetLatestVersionRelease())
+	}
+	// AND the pending version can be stored
+	api.updateRow("keep0", []dbtype.Cell{
+		{Column: "pending_version", Value: "1.2.4"},
+		{Column: "pending_version_timestamp", Value: "2023-01-02T03:04:05Z"}})
+	got = queryRow(t, api.db, "keep0")
+	if got.GetPendingVersion() != "1.2.4" || got.GetPendingVersionTimestamp() != "2023-01-02T03:04:05Z" {
+		t.Errorf("want pending_version %q (%q), got %q (%q)",
+			"1.2.4", "2023-01-02T03:04:05Z", got.GetPendingVersion(), got.GetPendingVersionTimestamp())
+	}
+	// AND the digest of the latest version can be stored
+	api.updateRow("keep0", []dbtype.Cell{
+		{Column: "latest_version_digest", Value: "sha256:0123456789abcdef"},
+		{Column: "latest_version_created", Value: "2023-01-02T03:04:05Z"}})
+	got = queryRow(t, api.db, "keep0")
+	if got.GetLatestVersionDigest() != "sha256:0123456789abcdef" || got.GetLatestVersionCreated() != "2023-01-02T03:04:05Z" {
+		t.Errorf("want latest_version_digest %q (%q), got %q (%q)",
+			"sha256:0123456789abcdef", "2023-01-02T03:04:05Z", got.GetLatestVersionDigest(), got.GetLatestVersionCreated())
+	}
+	// AND the urls and commit message of the latest version can be stored
+	api.updateRow("keep0", []dbtype.Cell{
+		{Column: "latest_version_urls", Value: `["https://example.com/chart-1.2.3.tgz"]`},
+		{Column: "latest_version_message", Value: "fix: something"}})
+	got = queryRow(t, api.db, "keep0")
+	if urls := got.GetLatestVersionURLs(); len(urls) != 1 || urls[0] != "https://example.com/chart-1.2.3.tgz" ||
+		got.GetLatestVersionMessage() != "fix: something" {
+		t.Errorf("want latest_version_urls %q and latest_version_message %q, got %q and %q",
+			[]string{"https://example.com/chart-1.2.3.tgz"}, "fix: something", urls, got.GetLatestVersionMessage())
+	}
+	// AND they're restored to the Service on the next start
+	api.extractServiceStatus()
+	if got := api.config.Service["keep0"].Status.GetLatestVersionDigest(); got != "sha256:0123456789abcdef" {
+		t.Errorf("want the Service's latest_version_digest %q, got %q",
+			"sha256:0123456789abcdef", got)
+	}
+	if got := api.config.Service["keep0"].Status.GetLatestVersionURLs(); len(got) != 1 || got[0] != "https://example.com/chart-1.2.3.tgz" {
+		t.Errorf("want the Service's latest_version_urls %q, got %q",
+			[]string{"https://example.com/chart-1.2.3.tgz"}, got)
+	}
+	if got := api.config.Service["keep0"].Status.GetLatestVersionMessage(); got != "fix: something" {
+		t.Errorf("want the Service's latest_version_message %q, got %q",
+			"fix: something", got)
+	}
+	// AND the columns aren't added again on the next initialise
+	api.addMissingColumns()
+}
+
 func TestAPI_ConvertServiceStatus(t *testing.T) {
 	// GIVEN a blank DB
 	initLogging()
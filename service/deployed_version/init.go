@@ -15,6 +15,7 @@
 package deployedver
 
 import (
+	"github.com/release-argus/Argus/service/latest_version/filter"
 	opt "github.com/release-argus/Argus/service/options"
 	svcstatus "github.com/release-argus/Argus/service/status"
 	"github.com/release-argus/Argus/util"
@@ -32,6 +33,7 @@ func (l *Lookup) Init(
 	hardDefaults *Lookup,
 	status *svcstatus.Status,
 	options *opt.Options,
+	require *filter.Require,
 ) {
 	if l == nil {
 		return
@@ -41,6 +43,7 @@ func (l *Lookup) Init(
 	l.HardDefaults = hardDefaults
 	l.Status = status
 	l.Options = options
+	l.Require = require
 }
 
 // InitMetrics for this Lookup.
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/release-argus/Argus/service/latest_version/filter"
 	opt "github.com/release-argus/Argus/service/options"
 	svcstatus "github.com/release-argus/Argus/service/status"
 	"github.com/release-argus/Argus/util"
@@ -61,12 +62,14 @@ func TestLookup_Init(t *testing.T) {
 	var hardDefaults *Lookup = &Lookup{}
 	status := svcstatus.Status{ServiceID: stringPtr("TestInit")}
 	var options opt.Options
+	var require filter.Require
 
 	// WHEN Init is called on it
 	lookup.Init(
 		defaults, hardDefaults,
 		&status,
-		&options)
+		&options,
+		&require)
 
 	// THEN pointers to those vars are handed out to the Lookup
 	// defaults
@@ -89,12 +92,18 @@ func TestLookup_Init(t *testing.T) {
 		t.Errorf("Options were not handed to the Lookup correctly\n want: %v\ngot:  %v",
 			&options, lookup.Options)
 	}
+	// require
+	if lookup.Require != &require {
+		t.Errorf("Require was not handed to the Lookup correctly\n want: %v\ngot:  %v",
+			&require, lookup.Require)
+	}
 
 	var nilLookup *Lookup
 	nilLookup.Init(
 		defaults, hardDefaults,
 		&status,
-		&options)
+		&options,
+		&require)
 	if nilLookup != nil {
 		t.Error("Init on nil shouldn't have initialised the Lookup")
 	}
@@ -24,8 +24,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/coreos/go-semver/semver"
+	"github.com/release-argus/Argus/service/latest_version/filter"
 	"github.com/release-argus/Argus/util"
+	"github.com/release-argus/Argus/util/vercmp"
 	metric "github.com/release-argus/Argus/web/metrics"
 )
 
@@ -114,22 +115,44 @@ func (l *Lookup) query(logFrom *util.LogFrom) (string, error) {
 		}
 
 		version = texts[index]
+		// Build the version from the named capture groups.
+		if l.RegexTemplate != "" {
+			version, err = util.TemplateRegexGroups(l.RegexTemplate, re, texts)
+			if err != nil {
+				err = fmt.Errorf("regex_template %q failed: %w",
+					l.RegexTemplate, err)
+				jLog.Warn(err, *logFrom, true)
+				return "", err
+			}
+		}
 	}
 
-	// If semantic versioning is enabled, check that the version is in the correct format.
-	if l.Options.GetSemanticVersioning() {
-		_, err = semver.NewVersion(version)
-		if err != nil {
-			err = fmt.Errorf("failed converting %q to a semantic version. If all "+
+	// If there's a version scheme, check that the version is in the correct format.
+	if scheme := vercmp.GetScheme(l.Options.GetVersionScheme()); scheme != nil {
+		if !scheme.Valid(version) {
+			err = fmt.Errorf("failed converting %q to %s. If all "+
 				"versions are in this style, consider adding json/regex to get the version into the "+
-				"style of 'MAJOR.MINOR.PATCH' (https://semver.org/), or disabling semantic versioning "+
-				"(globally with defaults.service.semantic_versioning or just for this service with the semantic_versioning var)",
-				version)
+				"style of %s, or changing the version_scheme "+
+				"(globally with defaults.service.options.version_scheme or just for this service with the version_scheme option)",
+				version, scheme.Description, scheme.Style)
 			jLog.Error(err, *logFrom, true)
 			return "", err
 		}
 	}
 
+	// If a version_constraint is required, check that the version satisfies it.
+	if l.Require != nil && l.Require.VersionConstraint != "" {
+		satisfied, err := filter.CheckVersionConstraint(l.Require.VersionConstraint, version)
+		if err == nil && !satisfied {
+			err = fmt.Errorf("version %q doesn't satisfy the version_constraint %q",
+				version, l.Require.VersionConstraint)
+		}
+		if err != nil {
+			jLog.Warn(err, *logFrom, true)
+			return "", err //nolint:wrapcheck
+		}
+	}
+
 	return version, nil
 }
 
@@ -185,15 +208,11 @@ func (l *Lookup) HandleNewVersion(version string, writeToDB bool) {
 		l.Status.SetLatestVersion(l.Status.GetDeployedVersion(), writeToDB)
 		l.Status.SetLatestVersionTimestamp(l.Status.GetDeployedVersionTimestamp())
 		l.Status.AnnounceQueryNewVersion()
-	} else if version != latestVersion &&
-		l.Options.GetSemanticVersioning() {
-		//#nosec G104 -- Disregard as deployedVersion will always be semantic if GetSemanticVersioning
-		deployedVersionSV, _ := semver.NewVersion(version)
-		//#nosec G104 -- Disregard as LatestVersion will always be semantic if GetSemanticVersioning
-		latestVersionSV, _ := semver.NewVersion(latestVersion)
-
+	} else if scheme := vercmp.GetScheme(l.Options.GetVersionScheme()); version != latestVersion &&
+		scheme != nil {
 		// Update LatestVersion to DeployedVersion if it's newer
-		if latestVersionSV.LessThan(*deployedVersionSV) {
+		// (both will follow the scheme, having been validated by it)
+		if scheme.Compare(latestVersion, version) < 0 {
 			l.Status.SetLatestVersion(l.Status.GetDeployedVersion(), writeToDB)
 			l.Status.SetLatestVersionTimestamp(l.Status.GetDeployedVersionTimestamp())
 			l.Status.AnnounceQueryNewVersion()
@@ -18,6 +18,8 @@ package deployedver
 
 import (
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
 	"testing"
@@ -25,6 +27,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	dbtype "github.com/release-argus/Argus/db/types"
+	"github.com/release-argus/Argus/service/latest_version/filter"
 	opt "github.com/release-argus/Argus/service/options"
 	svcstatus "github.com/release-argus/Argus/service/status"
 	"github.com/release-argus/Argus/util"
@@ -179,6 +182,223 @@ func TestLookup_Query(t *testing.T) {
 	}
 }
 
+func TestLookup_QueryRegexTemplate(t *testing.T) {
+	// GIVEN a Lookup with a regex (and template) on a page with the version split across it
+	testLogging()
+	page := "<p>Version 5.6</p>\n<p>Build 1234</p>"
+	tests := map[string]struct {
+		regex                string
+		regexTemplate        string
+		noSemanticVersioning bool
+		errRegex             string
+		wantVersion          string
+	}{
+		"regex without template gives the capture group": {
+			regex:                `Build ([0-9]+)`,
+			noSemanticVersioning: true,
+			errRegex:             "^$",
+			wantVersion:          "1234"},
+		"template with the named groups": {
+			regex:         `(?s)Version (?P<major>[0-9]+)\.(?P<minor>[0-9]+).*Build (?P<build>[0-9]+)`,
+			regexTemplate: "{{ major }}.{{ minor }}.{{ build }}",
+			errRegex:      "^$",
+			wantVersion:   "5.6.1234"},
+		"template with filters": {
+			regex:                `Build (?P<build>[0-9]+)`,
+			regexTemplate:        "build-{{ build|slice:':2' }}",
+			noSemanticVersioning: true,
+			errRegex:             "^$",
+			wantVersion:          "build-12"},
+		"template with a group that didn't match": {
+			regex:         `Version (?P<major>[0-9]+)\.(?P<minor>[0-9]+)(?:\.(?P<patch>[0-9]+))?`,
+			regexTemplate: "{{ major }}.{{ minor }}.{{ patch|default:'0' }}",
+			errRegex:      "^$",
+			wantVersion:   "5.6.0"},
+		"regex doesn't match": {
+			regex:         `Release (?P<major>[0-9]+)`,
+			regexTemplate: "{{ major }}",
+			errRegex:      "regex .* didn't return any matches"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(page))
+			}))
+			defer server.Close()
+			dvl := testLookup()
+			dvl.URL = server.URL
+			dvl.JSON = ""
+			dvl.Regex = tc.regex
+			dvl.RegexTemplate = tc.regexTemplate
+			*dvl.Options.SemanticVersioning = !tc.noSemanticVersioning
+
+			// WHEN Query is called on it
+			version, err := dvl.Query(false, &util.LogFrom{})
+
+			// THEN the version is built from the groups
+			if version != tc.wantVersion {
+				t.Errorf("want version=%q\ngot  version=%q",
+					tc.wantVersion, version)
+			}
+			// AND any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryVersionConstraint(t *testing.T) {
+	// GIVEN a Lookup with a Require that may have a version_constraint
+	testLogging()
+	tests := map[string]struct {
+		require              *filter.Require
+		version              string
+		noSemanticVersioning bool
+		errRegex             string
+		wantVersion          string
+	}{
+		"no require": {
+			version:     "1.2.3",
+			errRegex:    "^$",
+			wantVersion: "1.2.3"},
+		"no version_constraint": {
+			require:     &filter.Require{RegexVersion: "^2"},
+			version:     "1.2.3",
+			errRegex:    "^$",
+			wantVersion: "1.2.3"},
+		"version satisfies the version_constraint": {
+			require:     &filter.Require{VersionConstraint: "^1.2"},
+			version:     "1.9.0",
+			errRegex:    "^$",
+			wantVersion: "1.9.0"},
+		"version doesn't satisfy the version_constraint": {
+			require:  &filter.Require{VersionConstraint: ">=2.3 <3"},
+			version:  "1.2.3",
+			errRegex: `version "1.2.3" doesn't satisfy the version_constraint ">=2.3 <3"`},
+		"non-semantic version with a version_constraint": {
+			require:              &filter.Require{VersionConstraint: "^1"},
+			version:              "release-one",
+			noSemanticVersioning: true,
+			errRegex:             `"release-one" is not a semantic version`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.version))
+			}))
+			defer server.Close()
+			dvl := testLookup()
+			dvl.URL = server.URL
+			dvl.JSON = ""
+			dvl.Regex = ""
+			dvl.Require = tc.require
+			*dvl.Options.SemanticVersioning = !tc.noSemanticVersioning
+
+			// WHEN Query is called on it
+			version, err := dvl.Query(false, &util.LogFrom{})
+
+			// THEN the version is only returned if it satisfies the version_constraint
+			if version != tc.wantVersion {
+				t.Errorf("want version=%q\ngot  version=%q",
+					tc.wantVersion, version)
+			}
+			// AND any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryVersionScheme(t *testing.T) {
+	// GIVEN a Lookup with a version_scheme
+	testLogging()
+	tests := map[string]struct {
+		versionScheme string
+		version       string
+		errRegex      string
+		wantVersion   string
+	}{
+		"semver": {
+			versionScheme: "semver",
+			version:       "1.2.3",
+			errRegex:      "^$",
+			wantVersion:   "1.2.3"},
+		"calver": {
+			versionScheme: "calver",
+			version:       "2024.05.01",
+			errRegex:      "^$",
+			wantVersion:   "2024.05.01"},
+		"pep440": {
+			versionScheme: "pep440",
+			version:       "1.2.0rc1",
+			errRegex:      "^$",
+			wantVersion:   "1.2.0rc1"},
+		"none": {
+			versionScheme: "none",
+			version:       "release-one",
+			errRegex:      "^$",
+			wantVersion:   "release-one"},
+		"version not of the scheme": {
+			versionScheme: "calver",
+			version:       "1.2.3",
+			errRegex:      `failed converting "1.2.3" to a calendar version.*style of 'YYYY.MM.DD'.*version_scheme`},
+		"semver version not semantic": {
+			versionScheme: "semver",
+			version:       "1.2.3.4",
+			errRegex:      `failed converting "1.2.3.4" to a semantic version`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.version))
+			}))
+			defer server.Close()
+			dvl := testLookup()
+			dvl.URL = server.URL
+			dvl.JSON = ""
+			dvl.Regex = ""
+			dvl.Options.SemanticVersioning = nil
+			dvl.Options.VersionScheme = tc.versionScheme
+
+			// WHEN Query is called on it
+			version, err := dvl.Query(false, &util.LogFrom{})
+
+			// THEN the version is only returned if it follows the version_scheme
+			if version != tc.wantVersion {
+				t.Errorf("want version=%q\ngot  version=%q",
+					tc.wantVersion, version)
+			}
+			// AND any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
 func TestLookup_Track(t *testing.T) {
 	// GIVEN a Lookup
 	testLogging()
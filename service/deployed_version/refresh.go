@@ -30,7 +30,9 @@ func (l *Lookup) applyOverrides(
 	headers *string,
 	json *string,
 	regex *string,
+	regexTemplate *string,
 	semanticVersioning *string,
+	versionScheme *string,
 	url *string,
 	serviceID *string,
 	logFrom *util.LogFrom,
@@ -55,11 +57,19 @@ func (l *Lookup) applyOverrides(
 	useJSON := util.GetValue(json, l.JSON)
 	// regex
 	useRegex := util.GetValue(regex, l.Regex)
+	// regex_template
+	useRegexTemplate := util.GetValue(regexTemplate, l.RegexTemplate)
 	// semantic_versioning
 	useSemanticVersioning := l.Options.SemanticVersioning
 	if semanticVersioning != nil {
 		useSemanticVersioning = util.StringToBoolPtr(*semanticVersioning)
 	}
+	// version_scheme
+	useVersionScheme := util.GetValue(versionScheme, l.Options.VersionScheme)
+	// (a semantic_versioning override is of the scheme)
+	if semanticVersioning != nil && versionScheme == nil {
+		useVersionScheme = ""
+	}
 	// url
 	useURL := util.GetValue(url, l.URL)
 
@@ -71,11 +81,14 @@ func (l *Lookup) applyOverrides(
 		Headers:           *useHeaders,
 		JSON:              useJSON,
 		Regex:             useRegex,
+		RegexTemplate:     useRegexTemplate,
 		Options: &opt.Options{
 			SemanticVersioning: useSemanticVersioning,
+			VersionScheme:      useVersionScheme,
 			Defaults:           l.Options.Defaults,
 			HardDefaults:       l.Options.HardDefaults},
 		Status:       &svcstatus.Status{},
+		Require:      l.Require,
 		Defaults:     l.Defaults,
 		HardDefaults: l.HardDefaults}
 	if err := lookup.CheckValues(""); err != nil {
@@ -97,7 +110,9 @@ func (l *Lookup) Refresh(
 	headers *string,
 	json *string,
 	regex *string,
+	regexTemplate *string,
 	semanticVersioning *string,
+	versionScheme *string,
 	url *string,
 ) (version string, announceUpdate bool, err error) {
 	serviceID := *l.Status.ServiceID
@@ -110,7 +125,9 @@ func (l *Lookup) Refresh(
 		headers,
 		json,
 		regex,
+		regexTemplate,
 		semanticVersioning,
+		versionScheme,
 		url,
 		&serviceID,
 		&logFrom)
@@ -128,9 +145,11 @@ func (l *Lookup) Refresh(
 	// Whether overrides were provided or not, we can update the status if not.
 	overrides := headers != nil ||
 		semanticVersioning != nil ||
+		versionScheme != nil ||
 		url != nil ||
 		json != nil ||
-		regex != nil
+		regex != nil ||
+		regexTemplate != nil
 
 	// Query the lookup.
 	version, err = lookup.Query(!overrides, &logFrom)
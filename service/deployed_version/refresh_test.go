@@ -159,11 +159,14 @@ func TestLookup_ApplyOverrides(t *testing.T) {
 		headers            *string
 		json               *string
 		regex              *string
+		regexTemplate      *string
 		semanticVersioning *string
+		versionScheme      *string
 		url                *string
 		previous           *Lookup
 		errRegex           string
 		want               *Lookup
+		wantVersionScheme  string
 	}{
 		"all nil": {
 			previous: testLookup(),
@@ -234,6 +237,27 @@ func TestLookup_ApplyOverrides(t *testing.T) {
 				Options:           test.Options,
 				Status:            test.Status},
 		},
+		"regex and regex_template": {
+			regex:         stringPtr(`(?P<major>[0-9]+)\.(?P<minor>[0-9]+)`),
+			regexTemplate: stringPtr("{{ major }}.{{ minor }}.0"),
+
+			previous: testLookup(),
+			want: &Lookup{
+				Regex:         `(?P<major>[0-9]+)\.(?P<minor>[0-9]+)`,
+				RegexTemplate: "{{ major }}.{{ minor }}.0",
+
+				URL:               test.URL,
+				AllowInvalidCerts: test.AllowInvalidCerts,
+				JSON:              test.JSON,
+				Options:           test.Options,
+				Status:            test.Status},
+		},
+		"override with regex_template without named groups": {
+			regexTemplate: stringPtr("{{ major }}"),
+			previous:      testLookup(),
+			want:          nil,
+			errRegex:      "regex_template: .+ <invalid> \\(regex requires named capture groups",
+		},
 		"semantic versioning": {
 			semanticVersioning: stringPtr("false"),
 
@@ -250,6 +274,32 @@ func TestLookup_ApplyOverrides(t *testing.T) {
 				JSON:              test.JSON,
 				Status:            test.Status},
 		},
+		"version scheme": {
+			versionScheme: stringPtr("numeric"),
+
+			previous: testLookup(),
+			want: &Lookup{
+				URL:               test.URL,
+				AllowInvalidCerts: test.AllowInvalidCerts,
+				JSON:              test.JSON,
+				Status:            test.Status},
+			wantVersionScheme: "numeric",
+		},
+		"semantic versioning overrides the version scheme": {
+			semanticVersioning: stringPtr("true"),
+
+			previous: func() *Lookup {
+				lookup := testLookup()
+				lookup.Options.VersionScheme = "calver"
+				return lookup
+			}(),
+			want: &Lookup{
+				URL:               test.URL,
+				AllowInvalidCerts: test.AllowInvalidCerts,
+				JSON:              test.JSON,
+				Status:            test.Status},
+			wantVersionScheme: "semver",
+		},
 		"url": {
 			url: stringPtr("https://valid.release-argus.io/json"),
 
@@ -288,7 +338,9 @@ func TestLookup_ApplyOverrides(t *testing.T) {
 				tc.headers,
 				tc.json,
 				tc.regex,
+				tc.regexTemplate,
 				tc.semanticVersioning,
+				tc.versionScheme,
 				tc.url,
 				&name,
 				&util.LogFrom{Primary: name})
@@ -312,6 +364,11 @@ func TestLookup_ApplyOverrides(t *testing.T) {
 			if tc.want.String() != got.String() {
 				t.Errorf("expected:\n%v\nbut got:\n%v", tc.want, got)
 			}
+			// AND the version_scheme is overridden
+			if tc.wantVersionScheme != "" && got.Options.GetVersionScheme() != tc.wantVersionScheme {
+				t.Errorf("want version_scheme %q, got %q",
+					tc.wantVersionScheme, got.Options.GetVersionScheme())
+			}
 		})
 	}
 }
@@ -331,7 +388,9 @@ func TestLookup_Refresh(t *testing.T) {
 		headers                  *string
 		json                     *string
 		regex                    *string
+		regexTemplate            *string
 		semanticVersioning       *string
+		versionScheme            *string
 		url                      *string
 		lookup                   *Lookup
 		deployedVersion          string
@@ -407,7 +466,9 @@ func TestLookup_Refresh(t *testing.T) {
 				tc.headers,
 				tc.json,
 				tc.regex,
+				tc.regexTemplate,
 				tc.semanticVersioning,
+				tc.versionScheme,
 				tc.url)
 
 			// THEN we get an error if expected
@@ -435,7 +496,7 @@ func TestLookup_Refresh(t *testing.T) {
 			}
 			// AND the timestamp only changes if the version changed
 			// and the possible query-changing overrides are nil
-			if tc.headers == nil && tc.json == nil && tc.regex == nil && tc.semanticVersioning == nil && tc.url == nil {
+			if tc.headers == nil && tc.json == nil && tc.regex == nil && tc.regexTemplate == nil && tc.semanticVersioning == nil && tc.versionScheme == nil && tc.url == nil {
 				// If the version changed
 				if previousStatus.GetDeployedVersion() != tc.lookup.Status.GetDeployedVersion() {
 					// then so should the timestamp
@@ -15,6 +15,7 @@
 package deployedver
 
 import (
+	"github.com/release-argus/Argus/service/latest_version/filter"
 	opt "github.com/release-argus/Argus/service/options"
 	svcstatus "github.com/release-argus/Argus/service/status"
 	"github.com/release-argus/Argus/util"
@@ -33,8 +34,10 @@ type Lookup struct {
 	Headers           []Header          `yaml:"headers,omitempty" json:"headers,omitempty"`                         // Headers for the HTTP(S) request.
 	JSON              string            `yaml:"json,omitempty" json:"json,omitempty"`                               // JSON key to use e.g. version_current.
 	Regex             string            `yaml:"regex,omitempty" json:"regex,omitempty"`                             // Regex to get the DeployedVersion
+	RegexTemplate     string            `yaml:"regex_template,omitempty" json:"regex_template,omitempty"`           // Template for the DeployedVersion with the named capture groups of the regex, e.g. "{{ major }}.{{ minor }}-{{ build }}"
 	Options           *opt.Options      `yaml:"-" json:"-"`                                                         // Options for the lookups
 	Status            *svcstatus.Status `yaml:"-" json:"-"`                                                         // Service Status
+	Require           *filter.Require   `yaml:"-" json:"-"`                                                         // Requirements of the LatestVersion (version_constraint)
 	Defaults          *Lookup           `yaml:"-" json:"-"`                                                         // Default values.
 	HardDefaults      *Lookup           `yaml:"-" json:"-"`                                                         // Hardcoded default values.
 }
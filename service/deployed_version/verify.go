@@ -49,6 +49,8 @@ func (l *Lookup) Print(prefix string) {
 		fmt.Sprintf("%sjson: %q", prefix, l.JSON))
 	util.PrintlnIfNotDefault(l.Regex,
 		fmt.Sprintf("%sregex: %q", prefix, l.Regex))
+	util.PrintlnIfNotDefault(l.RegexTemplate,
+		fmt.Sprintf("%sregex_template: %q", prefix, l.RegexTemplate))
 }
 
 // CheckValues of the Lookup.
@@ -69,6 +71,16 @@ func (l *Lookup) CheckValues(prefix string) (errs error) {
 		errs = fmt.Errorf("%s%s  regex: %q <invalid> (Invalid RegEx)\\",
 			util.ErrorToString(errs), prefix, l.Regex)
 	}
+	// RegEx Template
+	if l.RegexTemplate != "" {
+		if !util.RegexHasNamedGroups(l.Regex) {
+			errs = fmt.Errorf("%s%s  regex_template: %q <invalid> (regex requires named capture groups, e.g. (?P<major>[0-9]+))\\",
+				util.ErrorToString(errs), prefix, l.RegexTemplate)
+		} else if !util.CheckTemplate(l.RegexTemplate) {
+			errs = fmt.Errorf("%s%s  regex_template: %q <invalid> (didn't pass templating)\\",
+				util.ErrorToString(errs), prefix, l.RegexTemplate)
+		}
+	}
 
 	if errs != nil {
 		errs = fmt.Errorf("%sdeployed_version:\\%w",
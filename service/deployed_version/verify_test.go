@@ -122,11 +122,12 @@ func TestLookup_Print(t *testing.T) {
 func TestLookup_CheckValues(t *testing.T) {
 	// GIVEN a Lookup
 	tests := map[string]struct {
-		url        string
-		regex      string
-		defaults   *Lookup
-		errRegex   string
-		nilService bool
+		url           string
+		regex         string
+		regexTemplate string
+		defaults      *Lookup
+		errRegex      string
+		nilService    bool
 	}{
 		"nil service": {
 			errRegex:   `^$`,
@@ -148,6 +149,27 @@ func TestLookup_CheckValues(t *testing.T) {
 			regex:    "[0-",
 			defaults: &Lookup{},
 		},
+		"valid regex_template": {
+			errRegex:      `^$`,
+			url:           "https://example.com",
+			regex:         `(?P<major>[0-9]+)\.(?P<minor>[0-9]+)`,
+			regexTemplate: "{{ major }}.{{ minor }}.0",
+			defaults:      &Lookup{},
+		},
+		"regex_template without named groups": {
+			errRegex:      `regex_template: .* <invalid> \(regex requires named capture groups`,
+			url:           "https://example.com",
+			regex:         `([0-9]+)`,
+			regexTemplate: "{{ major }}",
+			defaults:      &Lookup{},
+		},
+		"invalid regex_template": {
+			errRegex:      `regex_template: .* <invalid> \(didn't pass templating\)`,
+			url:           "https://example.com",
+			regex:         `(?P<major>[0-9]+)`,
+			regexTemplate: "{{ major }",
+			defaults:      &Lookup{},
+		},
 		"all errs": {
 			errRegex: `url: <missing>`,
 			url:      "",
@@ -170,6 +192,7 @@ func TestLookup_CheckValues(t *testing.T) {
 			lookup = testLookup()
 			lookup.URL = tc.url
 			lookup.Regex = tc.regex
+			lookup.RegexTemplate = tc.regexTemplate
 			lookup.Defaults = nil
 			if tc.defaults != nil {
 				lookup.Defaults = tc.defaults
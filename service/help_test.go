@@ -169,7 +169,8 @@ func testServiceURL(id string) *Service {
 	svc.DeployedVersionLookup.Init(
 		&deployedver.Lookup{}, &deployedver.Lookup{},
 		&svc.Status,
-		&svc.Options)
+		&svc.Options,
+		svc.LatestVersion.Require)
 	return svc
 }
 
@@ -92,20 +92,21 @@ func (s *Service) Init(
 	s.DeployedVersionLookup.Init(
 		s.Defaults.DeployedVersionLookup, s.HardDefaults.DeployedVersionLookup,
 		&s.Status,
-		&s.Options)
+		&s.Options,
+		s.LatestVersion.Require)
 
 	// Convert from old format
 	s.Convert()
 }
 
-// GetServiceInfo returns info about the service.
+// GetServiceInfo returns info about the service (the Status' ServiceInfo with the Service's URLs).
 func (s *Service) GetServiceInfo() *util.ServiceInfo {
-	return &util.ServiceInfo{
-		ID:            s.ID,
-		URL:           s.LatestVersion.GetServiceURL(true),
-		WebURL:        s.Status.GetWebURL(),
-		LatestVersion: s.Status.GetLatestVersion(),
-	}
+	serviceInfo := s.Status.GetServiceInfo()
+	serviceInfo.ID = s.ID
+	serviceInfo.URL = s.LatestVersion.GetServiceURL(true)
+	serviceInfo.WebURL = s.Status.GetWebURL()
+
+	return &serviceInfo
 }
 
 // GetIconURL returns the URL Icon for the Service.
@@ -17,6 +17,7 @@
 package service
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -38,6 +39,11 @@ func TestService_GetServiceInfo(t *testing.T) {
 	svc.Dashboard.WebURL = webURL
 	latestVersion := "latest.version"
 	svc.Status.SetLatestVersion(latestVersion, false)
+	digest := "sha256:0123456789abcdef"
+	created := "2023-01-02T03:04:05Z"
+	svc.Status.SetLatestVersionDigest(digest, created, false)
+	release := util.ReleaseInfo{Name: "v" + latestVersion}
+	svc.Status.SetLatestVersionRelease(release, false)
 	time.Sleep(10 * time.Millisecond)
 	time.Sleep(time.Second)
 
@@ -48,10 +54,13 @@ func TestService_GetServiceInfo(t *testing.T) {
 		URL:           url,
 		WebURL:        webURL,
 		LatestVersion: latestVersion,
+		Digest:        digest,
+		Created:       created,
+		Release:       release,
 	}
 
 	// THEN we get the correct ServiceInfo
-	if *got != want {
+	if !reflect.DeepEqual(*got, want) {
 		t.Errorf("GetServiceInfo didn't get the correct data\nwant: %#v\ngot:  %#v",
 			want, got)
 	}
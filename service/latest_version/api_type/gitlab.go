@@ -0,0 +1,90 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// GitLabRelease is the format of a Release on gitlab.com/api/v4/projects/ID/releases.
+type GitLabRelease struct {
+	TagName         string           `json:"tag_name"`
+	Name            string           `json:"name,omitempty"`
+	Description     string           `json:"description,omitempty"`
+	Author          *GitLabAuthor    `json:"author,omitempty"`
+	UpcomingRelease bool             `json:"upcoming_release,omitempty"`
+	ReleasedAt      string           `json:"released_at,omitempty"`
+	Commit          GitLabCommit     `json:"commit,omitempty"`
+	Links           GitLabLinks      `json:"_links,omitempty"`
+	Assets          GitLabAssetGroup `json:"assets,omitempty"`
+}
+
+// GitLabAuthor is the author of a GitLabRelease.
+type GitLabAuthor struct {
+	Username string `json:"username"`
+}
+
+// GitLabCommit is the commit of the tag of a GitLabRelease.
+type GitLabCommit struct {
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// GitLabLinks are the links of a GitLabRelease.
+type GitLabLinks struct {
+	Self string `json:"self,omitempty"` // Web page of the release
+}
+
+// GitLabAssetGroup is the format of the Assets of a GitLabRelease.
+type GitLabAssetGroup struct {
+	Links []GitLabAsset `json:"links,omitempty"`
+}
+
+// GitLabAsset is the format of an Asset link on a GitLabRelease.
+type GitLabAsset struct {
+	ID             uint   `json:"id"`
+	Name           string `json:"name,omitempty"`
+	URL            string `json:"url,omitempty"`
+	DirectAssetURL string `json:"direct_asset_url,omitempty"`
+}
+
+// Release converts the GitLabRelease to a Release.
+//
+// upcoming_release is treated as a prerelease.
+func (r *GitLabRelease) Release() Release {
+	release := Release{
+		HTMLURL:     r.Links.Self,
+		TagName:     r.TagName,
+		Name:        r.Name,
+		Body:        r.Description,
+		PreRelease:  r.UpcomingRelease,
+		CreatedAt:   r.Commit.CreatedAt,
+		PublishedAt: r.ReleasedAt}
+	if r.Author != nil {
+		release.Author = &Author{Login: r.Author.Username}
+	}
+
+	if len(r.Assets.Links) != 0 {
+		release.Assets = make([]Asset, len(r.Assets.Links))
+		for i, link := range r.Assets.Links {
+			downloadURL := link.DirectAssetURL
+			if downloadURL == "" {
+				downloadURL = link.URL
+			}
+			release.Assets[i] = Asset{
+				ID:                 link.ID,
+				Name:               link.Name,
+				URL:                link.URL,
+				BrowserDownloadURL: downloadURL}
+		}
+	}
+
+	return release
+}
@@ -0,0 +1,76 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package types
+
+import (
+	"testing"
+)
+
+func TestGitLabRelease_Release(t *testing.T) {
+	tests := map[string]struct {
+		release GitLabRelease
+		want    string
+	}{
+		"empty": {
+			release: GitLabRelease{},
+			want:    "{}"},
+		"upcoming_release is a prerelease": {
+			release: GitLabRelease{
+				TagName:         "v1.2.3",
+				UpcomingRelease: true},
+			want: `{"tag_name":"v1.2.3","prerelease":true}`},
+		"released_at and the date of the commit": {
+			release: GitLabRelease{
+				TagName:    "v1.2.3",
+				ReleasedAt: "2023-01-03T00:00:00Z",
+				Commit:     GitLabCommit{CreatedAt: "2023-01-02T00:00:00Z"}},
+			want: `{"tag_name":"v1.2.3","created_at":"2023-01-02T00:00:00Z","published_at":"2023-01-03T00:00:00Z"}`},
+		"name, description and author": {
+			release: GitLabRelease{
+				TagName:     "v1.2.3",
+				Name:        "Release 1.2.3",
+				Description: "## Changes\n- fix",
+				Author:      &GitLabAuthor{Username: "release-argus"}},
+			want: `{"tag_name":"v1.2.3","name":"Release 1.2.3","body":"## Changes\n- fix","author":{"login":"release-argus"}}`},
+		"asset links use the direct_asset_url for downloads": {
+			release: GitLabRelease{
+				TagName: "v1.2.3",
+				Links:   GitLabLinks{Self: "https://gitlab.com/group/project/-/releases/v1.2.3"},
+				Assets: GitLabAssetGroup{
+					Links: []GitLabAsset{
+						{ID: 1, Name: "linux", URL: "https://example.com/linux", DirectAssetURL: "https://gitlab.com/group/project/-/releases/v1.2.3/downloads/linux"},
+						{ID: 2, Name: "windows", URL: "https://example.com/windows"}}}},
+			want: `{"html_url":"https://gitlab.com/group/project/-/releases/v1.2.3","tag_name":"v1.2.3","assets":[{"id":1,"name":"linux","url":"https://example.com/linux","browser_download_url":"https://gitlab.com/group/project/-/releases/v1.2.3/downloads/linux"},{"id":2,"name":"windows","url":"https://example.com/windows","browser_download_url":"https://example.com/windows"}]}`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN the GitLabRelease is converted to a Release
+			release := tc.release.Release()
+
+			// THEN the result is as expected
+			got := release.String()
+			if got != tc.want {
+				t.Errorf("got:\n%q\nwant:\n%q",
+					got, tc.want)
+			}
+		})
+	}
+}
@@ -18,16 +18,28 @@ import (
 	"encoding/json"
 
 	"github.com/coreos/go-semver/semver"
+	"github.com/release-argus/Argus/util"
 )
 
 // Release is the format of a Release on api.github.com/repos/OWNER/REPO/releases.
 type Release struct {
 	URL             string          `json:"url,omitempty"`
+	HTMLURL         string          `json:"html_url,omitempty"`
 	AssetsURL       string          `json:"assets_url,omitempty"`
 	SemanticVersion *semver.Version `json:"-"`
 	TagName         string          `json:"tag_name,omitempty"`
+	Name            string          `json:"name,omitempty"`
+	Body            string          `json:"body,omitempty"`
+	Author          *Author         `json:"author,omitempty"`
 	PreRelease      bool            `json:"prerelease,omitempty"`
+	Draft           bool            `json:"draft,omitempty"`
+	CreatedAt       string          `json:"created_at,omitempty"` // Date of the commit of the tag (GitHub/Gitea/GitLab)
+	PublishedAt     string          `json:"published_at,omitempty"`
 	Assets          []Asset         `json:"assets,omitempty"`
+	Digest          string          `json:"digest,omitempty"`  // Docker manifest/Helm chart/package digest
+	Created         string          `json:"created,omitempty"` // Docker image/Helm chart/package created date, or feed entry published date
+	URLs            []string        `json:"urls,omitempty"`    // Helm chart/package download URLs, or feed entry link/enclosures
+	Message         string          `json:"message,omitempty"` // Commit message (GitHub branch)
 }
 
 // String returns a string representation of the Release.
@@ -36,6 +48,24 @@ func (r *Release) String() string {
 	return string(jsonBytes)
 }
 
+// ReleaseInfo returns the metadata of the release.
+func (r *Release) ReleaseInfo() util.ReleaseInfo {
+	info := util.ReleaseInfo{
+		Name:        r.Name,
+		Body:        r.Body,
+		HTMLURL:     r.HTMLURL,
+		PublishedAt: r.PublishedAt,
+		Draft:       r.Draft}
+	if r.Author != nil {
+		info.Author = r.Author.Login
+	}
+	return info
+}
+
+type Author struct {
+	Login string `json:"login"`
+}
+
 // Asset is the format of an Asset on api.github.com/repos/OWNER/REPO/releases.
 type Asset struct {
 	ID                 uint   `json:"id"`
@@ -49,3 +79,15 @@ func (a *Asset) String() string {
 	jsonBytes, _ := json.Marshal(a)
 	return string(jsonBytes)
 }
+
+// Commit is the format of a Commit on api.github.com/repos/OWNER/REPO/commits/REF.
+type Commit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url,omitempty"`
+	Commit  struct {
+		Message   string `json:"message,omitempty"`
+		Committer struct {
+			Date string `json:"date,omitempty"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
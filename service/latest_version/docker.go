@@ -0,0 +1,308 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	net_url "net/url"
+	"regexp"
+	"strings"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/service/latest_version/filter"
+	"github.com/release-argus/Argus/util"
+)
+
+const (
+	// dockerHubRegistry is the registry used for images without a registry host.
+	dockerHubRegistry = "registry-1.docker.io"
+	// dockerTagsPageSize is the number of tags to request per page.
+	dockerTagsPageSize = 1000
+	// dockerTagsMaxPages is the maximum number of pages of tags to request.
+	dockerTagsMaxPages = 10
+)
+
+// dockerImageNameRegex matches a valid repository name on a Docker Registry.
+var dockerImageNameRegex = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+
+// dockerImage is an image reference split into its registry and repository name.
+type dockerImage struct {
+	Scheme        string // Scheme of the registry API, https unless the url is prefixed with http://
+	Registry      string // Registry host, e.g. ghcr.io
+	Name          string // Repository name, e.g. release-argus/argus
+	Tag           string // Tag, e.g. latest
+	authorization string // Authorization header for the registry, from the last challenge of this query
+}
+
+// parseDockerImage will parse an image reference like
+// "nginx", "release-argus/argus:latest", "ghcr.io/release-argus/argus" or "http://localhost:5000/image".
+func parseDockerImage(ref string) (image dockerImage) {
+	image.Scheme = "https"
+	if strings.HasPrefix(ref, "http://") {
+		image.Scheme = "http"
+	}
+	ref = strings.TrimPrefix(strings.TrimPrefix(ref, "http://"), "https://")
+	ref = strings.TrimSuffix(ref, "/")
+
+	// Digest
+	if index := strings.Index(ref, "@"); index != -1 {
+		ref = ref[:index]
+	}
+
+	// Registry
+	image.Registry = dockerHubRegistry
+	if parts := strings.SplitN(ref, "/", 2); len(parts) == 2 &&
+		(strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		image.Registry = parts[0]
+		ref = parts[1]
+	}
+	if util.Contains([]string{"docker.io", "index.docker.io", "registry.hub.docker.com"}, image.Registry) {
+		image.Registry = dockerHubRegistry
+	}
+
+	// Tag
+	if index := strings.LastIndex(ref, ":"); index != -1 && !strings.Contains(ref[index:], "/") {
+		image.Tag = ref[index+1:]
+		ref = ref[:index]
+	}
+
+	image.Name = ref
+	// Official images are in the library namespace.
+	if image.Registry == dockerHubRegistry && !strings.Contains(image.Name, "/") {
+		image.Name = "library/" + image.Name
+	}
+	return
+}
+
+// apiURL returns the URL of `path` on the registry API for this image.
+//
+// e.g. tags/list -> https://ghcr.io/v2/release-argus/argus/tags/list
+func (i *dockerImage) apiURL(path string) string {
+	return fmt.Sprintf("%s://%s/v2/%s/%s",
+		i.Scheme, i.Registry, i.Name, path)
+}
+
+// webURL returns the URL of this image on the registry's web UI.
+func (i *dockerImage) webURL() string {
+	switch i.Registry {
+	case dockerHubRegistry:
+		if strings.HasPrefix(i.Name, "library/") {
+			return "https://hub.docker.com/_/" + strings.TrimPrefix(i.Name, "library/")
+		}
+		return "https://hub.docker.com/r/" + i.Name
+	case "quay.io":
+		return "https://quay.io/repository/" + i.Name
+	}
+	return fmt.Sprintf("%s://%s/%s",
+		i.Scheme, i.Registry, i.Name)
+}
+
+// String returns the image reference without the tag, e.g. ghcr.io/release-argus/argus.
+func (i *dockerImage) String() string {
+	if i.Registry == dockerHubRegistry {
+		return strings.TrimPrefix(i.Name, "library/")
+	}
+	return fmt.Sprintf("%s/%s", i.Registry, i.Name)
+}
+
+// dockerTags is the format of /v2/NAME/tags/list on a Docker Registry.
+type dockerTags struct {
+	Name string   `json:"name,omitempty"`
+	Tags []string `json:"tags"`
+}
+
+// dockerTagsRequest will list all the tags of the image at URL on its Docker Registry.
+//
+// The returned body is in the same format as a single page of tags/list.
+func (l *Lookup) dockerTagsRequest(logFrom *util.LogFrom) (rawBody []byte, err error) {
+	image := parseDockerImage(l.URL)
+
+	allTags := dockerTags{Name: image.Name}
+	url := image.apiURL(fmt.Sprintf("tags/list?n=%d", dockerTagsPageSize))
+	for page := 0; url != "" && page < dockerTagsMaxPages; page++ {
+		var resp *http.Response
+		resp, err = l.registryRequest(&image, http.MethodGet, url, "", logFrom)
+		if err != nil {
+			return
+		}
+
+		var body []byte
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			jLog.Error(err, *logFrom, true)
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("%s - %s",
+				image.String(), strings.TrimSpace(string(body)))
+			jLog.Error(err, *logFrom, true)
+			return
+		}
+
+		var tags dockerTags
+		if err = json.Unmarshal(body, &tags); err != nil {
+			jLog.Error(err, *logFrom, true)
+			err = fmt.Errorf("unmarshal of Docker Registry tags failed\n%w",
+				err)
+			jLog.Error(err, *logFrom, true)
+			return
+		}
+		allTags.Tags = append(allTags.Tags, tags.Tags...)
+
+		url = nextPageURL(resp, url)
+	}
+
+	rawBody, _ = json.Marshal(allTags)
+	return
+}
+
+// nextPageURL returns the URL of the next page from the `Link` header of `resp`
+// (relative to `currentURL`), or "" if there's no next page.
+//
+// e.g. Link: </v2/release-argus/argus/tags/list?last=1.2.3&n=1000>; rel="next"
+func nextPageURL(resp *http.Response, currentURL string) string {
+	for _, link := range resp.Header.Values("Link") {
+		for _, part := range strings.Split(link, ",") {
+			if !strings.Contains(part, `rel="next"`) {
+				continue
+			}
+			start := strings.Index(part, "<")
+			end := strings.Index(part, ">")
+			if start == -1 || end < start {
+				continue
+			}
+
+			base, err := net_url.Parse(currentURL)
+			if err != nil {
+				return ""
+			}
+			next, err := base.Parse(part[start+1 : end])
+			if err != nil {
+				return ""
+			}
+			return next.String()
+		}
+	}
+	return ""
+}
+
+// registryRequest will send a `method` request to `url` on the registry of `image`, responding to any
+// WWW-Authenticate challenge and retrying with the token it gets.
+func (l *Lookup) registryRequest(
+	image *dockerImage,
+	method string,
+	url string,
+	accept string,
+	logFrom *util.LogFrom,
+) (resp *http.Response, err error) {
+	client := l.httpClient()
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		req.Header.Set("Connection", "close")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if image.authorization != "" {
+			req.Header.Set("Authorization", image.authorization)
+		}
+		return client.Do(req) //nolint:wrapcheck
+	}
+
+	resp, err = do()
+	if err != nil {
+		// Don't crash on invalid certs.
+		if strings.Contains(err.Error(), "x509") {
+			err = fmt.Errorf("x509 (certificate invalid)")
+			jLog.Warn(err, *logFrom, true)
+			return
+		}
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return
+	}
+
+	// (Re)authenticate.
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	image.authorization, err = l.dockerCredentials(image).RegistryAuthorization(
+		challenge, fmt.Sprintf("repository:%s:pull", image.Name),
+		client)
+	if err != nil {
+		err = fmt.Errorf("%s - %w",
+			image.String(), err)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	resp, err = do()
+	if err != nil {
+		jLog.Error(err, *logFrom, true)
+	}
+	return
+}
+
+// dockerCredentials returns the credentials for the registry of `image`. These are the
+// username/access_token of this Lookup, or else those of a require.docker on the same registry.
+func (l *Lookup) dockerCredentials(image *dockerImage) *filter.DockerCheck {
+	if l.Username != "" || util.DefaultIfNil(l.AccessToken) != "" {
+		return &filter.DockerCheck{
+			Username: l.Username,
+			Token:    util.DefaultIfNil(l.AccessToken)}
+	}
+	if l.Require != nil && l.Require.Docker.Registry() == image.Registry {
+		return l.Require.Docker
+	}
+	return &filter.DockerCheck{}
+}
+
+// checkDockerTagsBody will convert the tags in the body to releases.
+//
+// Registries list tags in lexical order, so the releases are reversed to have
+// the most likely newest first when semantic versioning isn't used.
+func (l *Lookup) checkDockerTagsBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	var tags dockerTags
+	if err = json.Unmarshal(*body, &tags); err != nil {
+		jLog.Error(err, *logFrom, true)
+		err = fmt.Errorf("unmarshal of Docker Registry tags failed\n%w",
+			err)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	releases = make([]github_types.Release, len(tags.Tags))
+	for i := range tags.Tags {
+		releases[len(tags.Tags)-1-i] = github_types.Release{TagName: tags.Tags[i]}
+	}
+	return
+}
+
+// checkDockerURL will check that the URL is a valid image reference.
+func (l *Lookup) checkDockerURL() error {
+	image := parseDockerImage(l.URL)
+	if !dockerImageNameRegex.MatchString(image.Name) {
+		return fmt.Errorf("url: %q <invalid> (image name, e.g. ghcr.io/release-argus/argus)",
+			l.URL)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+// dockerManifestAccept is the Accept header for manifests, preferring multi-arch indexes
+// so that the digest matches what `docker pull` resolves the tag to.
+const dockerManifestAccept = "application/vnd.oci.image.index.v1+json, " +
+	"application/vnd.docker.distribution.manifest.list.v2+json, " +
+	"application/vnd.oci.image.manifest.v1+json, " +
+	"application/vnd.docker.distribution.manifest.v2+json"
+
+// dockerDigest is the digest of a tag, and the created date of its image.
+type dockerDigest struct {
+	Digest  string `json:"digest"`            // Manifest digest, e.g. sha256:abc...
+	Created string `json:"created,omitempty"` // Created date of the image, e.g. 2023-01-02T03:04:05Z
+}
+
+// dockerManifest is the subset of an image manifest/index needed to find the created date.
+type dockerManifest struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			Architecture string `json:"architecture"`
+			OS           string `json:"os"`
+		} `json:"platform"`
+	} `json:"manifests"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// trackDigest returns whether this Lookup tracks the digest of a tag (e.g. nginx:stable)
+// rather than the tags of an image.
+func (l *Lookup) trackDigest() bool {
+	return l.Type == "docker" && parseDockerImage(l.URL).Tag != ""
+}
+
+// dockerDigestRequest will get the manifest digest of the tag in URL (and the created date
+// of its image when available).
+func (l *Lookup) dockerDigestRequest(logFrom *util.LogFrom) (rawBody []byte, err error) {
+	image := parseDockerImage(l.URL)
+
+	// HEAD requests don't count towards the Docker Hub rate limit.
+	resp, err := l.registryRequest(&image, http.MethodHead, image.apiURL("manifests/"+image.Tag), dockerManifestAccept, logFrom)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s:%s - manifest not found (%s)",
+			image.String(), image.Tag, resp.Status)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	digest := dockerDigest{Digest: resp.Header.Get("Docker-Content-Digest")}
+	// Unchanged digest, so the created date is too.
+	if digest.Digest != "" && digest.Digest == l.Status.GetLatestVersion() && l.Status.GetLatestVersionCreated() != "" {
+		digest.Created = l.Status.GetLatestVersionCreated()
+	} else {
+		var manifest []byte
+		manifest, err = l.dockerManifest(&image, image.Tag, logFrom)
+		if err != nil {
+			return
+		}
+		// Not all registries send the Docker-Content-Digest header.
+		if digest.Digest == "" {
+			sum := sha256.Sum256(manifest)
+			digest.Digest = "sha256:" + hex.EncodeToString(sum[:])
+		}
+		digest.Created = l.dockerImageCreated(&image, manifest, logFrom)
+	}
+
+	rawBody, _ = json.Marshal(digest)
+	return
+}
+
+// dockerManifest will GET the manifest of `reference` (a tag or digest) for `image`.
+func (l *Lookup) dockerManifest(image *dockerImage, reference string, logFrom *util.LogFrom) (body []byte, err error) {
+	resp, err := l.registryRequest(image, http.MethodGet, image.apiURL("manifests/"+reference), dockerManifestAccept, logFrom)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err = io.ReadAll(resp.Body)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s:%s - manifest not found (%s)",
+			image.String(), reference, resp.Status)
+	}
+	if err != nil {
+		jLog.Error(err, *logFrom, true)
+	}
+	return
+}
+
+// dockerImageCreated returns the created date of the image with `manifest`,
+// or "" if it's not available.
+//
+// The date is taken from the org.opencontainers.image.created annotation, or the image config.
+// For multi-arch images, the linux/amd64 (or first) image is used.
+func (l *Lookup) dockerImageCreated(image *dockerImage, manifestBody []byte, logFrom *util.LogFrom) string {
+	var manifest dockerManifest
+	if err := json.Unmarshal(manifestBody, &manifest); err != nil {
+		jLog.Verbose(fmt.Sprintf("%s - unmarshal of manifest failed: %s", image.String(), err), *logFrom, true)
+		return ""
+	}
+	if created := manifest.Annotations["org.opencontainers.image.created"]; created != "" {
+		return created
+	}
+
+	// Image index, use one of its images.
+	if len(manifest.Manifests) != 0 {
+		digest := manifest.Manifests[0].Digest
+		for _, m := range manifest.Manifests {
+			if m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+				digest = m.Digest
+				break
+			}
+		}
+		body, err := l.dockerManifest(image, digest, logFrom)
+		if err != nil {
+			return ""
+		}
+		manifest = dockerManifest{}
+		if err = json.Unmarshal(body, &manifest); err != nil {
+			return ""
+		}
+		if created := manifest.Annotations["org.opencontainers.image.created"]; created != "" {
+			return created
+		}
+	}
+	if manifest.Config.Digest == "" {
+		return ""
+	}
+
+	// Image config.
+	resp, err := l.registryRequest(image, http.MethodGet, image.apiURL("blobs/"+manifest.Config.Digest), "", logFrom)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	var config struct {
+		Created string `json:"created"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return ""
+	}
+	return config.Created
+}
+
+// checkDockerDigestBody will convert the digest in the body to a release.
+func (l *Lookup) checkDockerDigestBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	var digest dockerDigest
+	if err = json.Unmarshal(*body, &digest); err != nil || digest.Digest == "" {
+		if err == nil {
+			err = fmt.Errorf("no digest found for %s", l.URL)
+		}
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	releases = []github_types.Release{{
+		TagName: digest.Digest,
+		Digest:  digest.Digest,
+		Created: digest.Created}}
+	return
+}
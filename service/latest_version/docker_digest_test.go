@@ -0,0 +1,181 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+
+	"github.com/release-argus/Argus/util"
+)
+
+func TestLookup_TrackDigest(t *testing.T) {
+	// GIVEN a Lookup
+	tests := map[string]struct {
+		lType string
+		url   string
+		want  bool
+	}{
+		"docker without a tag": {
+			lType: "docker", url: "release-argus/argus", want: false},
+		"docker with a tag": {
+			lType: "docker", url: "release-argus/argus:latest", want: true},
+		"docker on a registry with a port": {
+			lType: "docker", url: "localhost:5000/argus", want: false},
+		"docker on a registry with a port and a tag": {
+			lType: "docker", url: "localhost:5000/argus:stable", want: true},
+		"github": {
+			lType: "github", url: "release-argus/argus", want: false},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := Lookup{Type: tc.lType, URL: tc.url}
+
+			// WHEN trackDigest is called
+			got := lookup.trackDigest()
+
+			// THEN the result is as expected
+			if got != tc.want {
+				t.Errorf("want %t, got %t",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+// testDockerDigestRegistry returns a registry with a multi-arch "owner/image:stable"
+// whose index digest is `digest`. `manifestGETs` counts the GET requests for manifests.
+func testDockerDigestRegistry(digest *string, manifestGETs *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/owner/image/manifests/stable":
+			w.Header().Set("Docker-Content-Digest", *digest)
+			w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+			if r.Method == http.MethodHead {
+				return
+			}
+			atomic.AddInt32(manifestGETs, 1)
+			w.Write([]byte(`{"schemaVersion":2,"manifests":[
+				{"digest":"sha256:arm64","platform":{"architecture":"arm64","os":"linux"}},
+				{"digest":"sha256:amd64","platform":{"architecture":"amd64","os":"linux"}}]}`))
+		case "/v2/owner/image/manifests/sha256:amd64":
+			atomic.AddInt32(manifestGETs, 1)
+			w.Write([]byte(`{"schemaVersion":2,"config":{"digest":"sha256:config"}}`))
+		case "/v2/owner/image/blobs/sha256:config":
+			w.Write([]byte(`{"architecture":"amd64","created":"2023-01-02T03:04:05Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`))
+		}
+	}))
+}
+
+func TestLookup_QueryDockerDigest(t *testing.T) {
+	// GIVEN a Docker Registry with a mutable tag
+	testLogging("WARN")
+	tests := map[string]struct {
+		tag         string
+		errRegex    string
+		wantCreated string
+	}{
+		"digest of the tag": {
+			tag:         "stable",
+			errRegex:    "^$",
+			wantCreated: "2023-01-02T03:04:05Z"},
+		"unknown tag": {
+			tag:      "unknown",
+			errRegex: "manifest not found"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			digest := "sha256:1111"
+			var manifestGETs int32
+			server := testDockerDigestRegistry(&digest, &manifestGETs)
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "docker"
+			lookup.URL = server.URL + "/owner/image:" + tc.tag
+			lookup.GitHubData = nil
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			if err != nil {
+				return
+			}
+			// AND the digest is the latest version
+			if got := lookup.Status.GetLatestVersion(); got != digest {
+				t.Errorf("want latest_version %q, got %q",
+					digest, got)
+			}
+			// AND the digest/created date are in the Status
+			if got := lookup.Status.GetLatestVersionDigest(); got != digest {
+				t.Errorf("want latest_version_digest %q, got %q",
+					digest, got)
+			}
+			if got := lookup.Status.GetLatestVersionCreated(); got != tc.wantCreated {
+				t.Errorf("want latest_version_created %q, got %q",
+					tc.wantCreated, got)
+			}
+
+			// WHEN Query is called again with the digest unchanged
+			newVersion, err := lookup.Query(false, &util.LogFrom{})
+			// THEN it's not a new version
+			if err != nil || newVersion {
+				t.Errorf("unchanged digest - want no new version and no err, got %t, %v",
+					newVersion, err)
+			}
+			// AND the manifests weren't fetched again
+			if got := atomic.LoadInt32(&manifestGETs); got != 2 {
+				t.Errorf("want 2 manifest GETs, got %d",
+					got)
+			}
+
+			// WHEN the digest changes
+			digest = "sha256:2222"
+			newVersion, err = lookup.Query(false, &util.LogFrom{})
+			// THEN it's a new version
+			if err != nil || !newVersion {
+				t.Errorf("changed digest - want a new version and no err, got %t, %v",
+					newVersion, err)
+			}
+			if got := lookup.Status.GetLatestVersion(); got != digest {
+				t.Errorf("want latest_version %q, got %q",
+					digest, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/release-argus/Argus/service/latest_version/filter"
+	"github.com/release-argus/Argus/util"
+)
+
+func TestParseDockerImage(t *testing.T) {
+	// GIVEN an image reference
+	tests := map[string]struct {
+		ref        string
+		want       dockerImage
+		wantWebURL string
+	}{
+		"official image": {
+			ref: "nginx",
+			want: dockerImage{
+				Scheme: "https", Registry: dockerHubRegistry, Name: "library/nginx"},
+			wantWebURL: "https://hub.docker.com/_/nginx"},
+		"docker hub image with tag": {
+			ref: "releaseargus/argus:latest",
+			want: dockerImage{
+				Scheme: "https", Registry: dockerHubRegistry, Name: "releaseargus/argus", Tag: "latest"},
+			wantWebURL: "https://hub.docker.com/r/releaseargus/argus"},
+		"docker.io prefix": {
+			ref: "docker.io/library/alpine",
+			want: dockerImage{
+				Scheme: "https", Registry: dockerHubRegistry, Name: "library/alpine"},
+			wantWebURL: "https://hub.docker.com/_/alpine"},
+		"ghcr": {
+			ref: "ghcr.io/release-argus/argus",
+			want: dockerImage{
+				Scheme: "https", Registry: "ghcr.io", Name: "release-argus/argus"},
+			wantWebURL: "https://ghcr.io/release-argus/argus"},
+		"quay with digest": {
+			ref: "quay.io/argoproj/argocd@sha256:0123456789abcdef",
+			want: dockerImage{
+				Scheme: "https", Registry: "quay.io", Name: "argoproj/argocd"},
+			wantWebURL: "https://quay.io/repository/argoproj/argocd"},
+		"registry with port and tag": {
+			ref: "http://localhost:5000/image:1.2.3",
+			want: dockerImage{
+				Scheme: "http", Registry: "localhost:5000", Name: "image", Tag: "1.2.3"},
+			wantWebURL: "http://localhost:5000/image"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN parseDockerImage is called on it
+			got := parseDockerImage(tc.ref)
+
+			// THEN the image is parsed correctly
+			if got != tc.want {
+				t.Errorf("want: %+v\ngot:  %+v",
+					tc.want, got)
+			}
+			// AND the web URL is as expected
+			if webURL := got.webURL(); webURL != tc.wantWebURL {
+				t.Errorf("web URL - want: %q\ngot:  %q",
+					tc.wantWebURL, webURL)
+			}
+		})
+	}
+}
+
+// testDockerRegistry returns a registry that lists `tags` for "owner/image" 2 per page,
+// requiring a bearer token from its /token endpoint (and basic auth there if `username` is set).
+func testDockerRegistry(tags []string, username, password string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.URL.Query().Get("scope") != "repository:owner/image:pull" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if username != "" {
+				if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte(`{"details":"incorrect username or password"}`))
+					return
+				}
+			}
+			w.Write([]byte(`{"token":"registry-token"}`))
+		case "/v2/owner/image/tags/list":
+			if r.Header.Get("Authorization") != "Bearer registry-token" {
+				w.Header().Set("WWW-Authenticate",
+					fmt.Sprintf(`Bearer realm="%s/token",service="registry.test",scope="repository:owner/image:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`))
+				return
+			}
+			// Paginate 2 tags at a time.
+			start := 0
+			if last := r.URL.Query().Get("last"); last != "" {
+				for i := range tags {
+					if tags[i] == last {
+						start = i + 1
+					}
+				}
+			}
+			end := start + 2
+			if end < len(tags) {
+				w.Header().Set("Link",
+					fmt.Sprintf(`</v2/owner/image/tags/list?last=%s&n=2>; rel="next"`, tags[end-1]))
+			} else {
+				end = len(tags)
+			}
+			fmt.Fprintf(w, `{"name":"owner/image","tags":["%s"]}`,
+				strings.Join(tags[start:end], `","`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"code":"NAME_UNKNOWN","message":"repository name not known to registry"}]}`))
+		}
+	}))
+	return server
+}
+
+func TestLookup_QueryDocker(t *testing.T) {
+	// GIVEN a Docker Registry
+	testLogging("WARN")
+	tags := []string{"1.10.0", "1.2.0", "1.9.0", "2.0.0-rc.1", "latest"}
+	tests := map[string]struct {
+		image         string
+		username      string
+		accessToken   *string
+		registryUser  string
+		urlCommands   filter.URLCommandSlice
+		usePreRelease bool
+		regexVersion  string
+		want          string
+		errRegex      string
+	}{
+		"highest semantic version across pages": {
+			urlCommands: filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`^([0-9.]+)$`)}},
+			want:     "1.10.0",
+			errRegex: "^$"},
+		"require.regex_version filters the tags": {
+			regexVersion: `^1\.[0-9]\.`,
+			urlCommands: filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`^([0-9.]+)$`)}},
+			want:     "1.9.0",
+			errRegex: "^$"},
+		"credentials are used for the token": {
+			username:     "user",
+			accessToken:  stringPtr("pass"),
+			registryUser: "user",
+			urlCommands: filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`^([0-9.]+)$`)}},
+			want:     "1.10.0",
+			errRegex: "^$"},
+		"invalid credentials": {
+			username:     "user",
+			accessToken:  stringPtr("wrong"),
+			registryUser: "user",
+			errRegex:     "incorrect username or password"},
+		"unknown image": {
+			image:    "other/image",
+			errRegex: "NAME_UNKNOWN"},
+		"no tags match the url_commands": {
+			urlCommands: filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`^v([0-9.]+)$`)}},
+			errRegex: "no releases were found matching the url_commands"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := testDockerRegistry(tags, tc.registryUser, "pass")
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "docker"
+			lookup.URL = server.URL + "/" +
+				util.GetFirstNonDefault(tc.image, "owner/image")
+			lookup.Username = tc.username
+			lookup.AccessToken = tc.accessToken
+			lookup.GitHubData = nil
+			lookup.URLCommands = tc.urlCommands
+			lookup.UsePreRelease = &tc.usePreRelease
+			lookup.Require.RegexVersion = tc.regexVersion
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_DockerTagsRequestConcurrent(t *testing.T) {
+	// GIVEN a docker Lookup on a Docker Registry that requires a token
+	testLogging("WARN")
+	tags := []string{"1.10.0", "1.2.0", "1.9.0", "2.0.0-rc.1", "latest"}
+	server := testDockerRegistry(tags, "", "pass")
+	defer server.Close()
+	lookup := testLookup(false, false)
+	lookup.Type = "docker"
+	lookup.URL = server.URL + "/owner/image"
+	lookup.GitHubData = nil
+
+	// WHEN the tags are requested concurrently (e.g. a scheduled query and a refresh)
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := lookup.dockerTagsRequest(&util.LogFrom{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// THEN they all authenticate with the registry (without sharing state on the Lookup)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+}
+
+func TestLookup_DockerCredentials(t *testing.T) {
+	// GIVEN a docker Lookup
+	tests := map[string]struct {
+		url          string
+		username     string
+		accessToken  *string
+		dockerCheck  *filter.DockerCheck
+		wantUsername string
+		wantToken    string
+	}{
+		"no credentials": {
+			url: "release-argus/argus"},
+		"username/access_token": {
+			url:          "release-argus/argus",
+			username:     "user",
+			accessToken:  stringPtr("pass"),
+			dockerCheck:  &filter.DockerCheck{Type: "hub", Username: "other", Token: "other"},
+			wantUsername: "user",
+			wantToken:    "pass"},
+		"require.docker on the same registry": {
+			url:          "release-argus/argus",
+			dockerCheck:  &filter.DockerCheck{Type: "hub", Username: "hub-user", Token: "hub-pass"},
+			wantUsername: "hub-user",
+			wantToken:    "hub-pass"},
+		"require.docker of ghcr": {
+			url:         "ghcr.io/release-argus/argus",
+			dockerCheck: &filter.DockerCheck{Type: "ghcr", Token: "ghp_token"},
+			wantToken:   "ghp_token"},
+		"require.docker on another registry": {
+			url:         "quay.io/release-argus/argus",
+			dockerCheck: &filter.DockerCheck{Type: "hub", Username: "hub-user", Token: "hub-pass"}},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := Lookup{
+				Type:        "docker",
+				URL:         tc.url,
+				Username:    tc.username,
+				AccessToken: tc.accessToken,
+				Require:     &filter.Require{Docker: tc.dockerCheck}}
+			image := parseDockerImage(lookup.URL)
+
+			// WHEN dockerCredentials is called on it
+			got := lookup.dockerCredentials(&image)
+
+			// THEN the credentials are those for the registry
+			if got.Username != tc.wantUsername || got.Token != tc.wantToken {
+				t.Errorf("want %q:%q, got %q:%q",
+					tc.wantUsername, tc.wantToken, got.Username, got.Token)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// feedEntry is an RSS item or Atom entry.
+type feedEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href  string `xml:"href,attr"` // Atom
+		Rel   string `xml:"rel,attr"`  // Atom
+		Value string `xml:",chardata"` // RSS
+	} `xml:"link"`
+	GUID       string `xml:"guid"`      // RSS
+	PubDate    string `xml:"pubDate"`   // RSS 2.0
+	Date       string `xml:"date"`      // RSS 1.0 (dc:date)
+	Published  string `xml:"published"` // Atom
+	Updated    string `xml:"updated"`   // Atom
+	Enclosures []struct {
+		URL string `xml:"url,attr"`
+	} `xml:"enclosure"` // RSS
+}
+
+// feedDateLayouts are the date formats used by RSS (RFC 822) and Atom (RFC 3339) feeds.
+var feedDateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, _2 Jan 2006 15:04:05 -0700",
+	"Mon, _2 Jan 2006 15:04:05 MST",
+	"_2 Jan 2006 15:04:05 -0700",
+	"_2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC850,
+	"2006-01-02"}
+
+// link returns the link of the entry, preferring the alternate link of Atom entries.
+func (e *feedEntry) link() string {
+	for _, link := range e.Links {
+		if link.Href != "" && (link.Rel == "" || link.Rel == "alternate") {
+			return link.Href
+		}
+	}
+	for _, link := range e.Links {
+		if value := util.GetFirstNonDefault(link.Href, strings.TrimSpace(link.Value)); value != "" {
+			return value
+		}
+	}
+	// RSS items may only have a permalink GUID.
+	if strings.HasPrefix(e.GUID, "http://") || strings.HasPrefix(e.GUID, "https://") {
+		return strings.TrimSpace(e.GUID)
+	}
+	return ""
+}
+
+// date returns the publish date of the entry in RFC 3339 (UTC), or as-is if it's not in a known format
+// (which sortByCreated puts after every entry with a valid date).
+func (e *feedEntry) date() string {
+	date := strings.TrimSpace(util.GetFirstNonDefault(e.Published, e.PubDate, e.Date, e.Updated))
+	for _, layout := range feedDateLayouts {
+		if parsed, err := time.Parse(layout, date); err == nil {
+			return parsed.UTC().Format(time.RFC3339)
+		}
+	}
+	return date
+}
+
+// feedCharsets are the non-UTF-8 encodings that feeds may declare.
+var feedCharsets = map[string]*charmap.Charmap{
+	"iso-8859-1":   charmap.ISO8859_1,
+	"iso8859-1":    charmap.ISO8859_1,
+	"latin1":       charmap.ISO8859_1,
+	"latin-1":      charmap.ISO8859_1,
+	"us-ascii":     charmap.ISO8859_1,
+	"windows-1252": charmap.Windows1252,
+	"cp1252":       charmap.Windows1252,
+}
+
+// feedCharsetReader converts the Latin-1/Windows-1252 encodings that feeds may declare to UTF-8.
+func feedCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	if encoding, ok := feedCharsets[strings.ToLower(charset)]; ok {
+		return encoding.NewDecoder().Reader(input), nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q",
+		charset)
+}
+
+// checkFeedBody will convert the entries of the RSS/Atom feed body to releases,
+// with the title as the version (and the link as a fallback for url_commands).
+func (l *Lookup) checkFeedBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	decoder := xml.NewDecoder(bytes.NewReader(*body))
+	decoder.CharsetReader = feedCharsetReader
+	// Feeds are often not strictly valid XML (e.g. HTML entities in titles).
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	root := ""
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr != nil {
+			if !errors.Is(tokenErr, io.EOF) {
+				err = tokenErr
+			}
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root == "" {
+			root = start.Name.Local
+			if root != "rss" && root != "feed" && root != "RDF" {
+				break
+			}
+			continue
+		}
+		if start.Name.Local != "item" && start.Name.Local != "entry" {
+			continue
+		}
+
+		var entry feedEntry
+		if err = decoder.DecodeElement(&entry, &start); err != nil {
+			break
+		}
+		release := github_types.Release{
+			TagName: strings.TrimSpace(entry.Title),
+			URL:     entry.link(),
+			Created: entry.date()}
+		if release.URL != "" {
+			release.URLs = []string{release.URL}
+		}
+		for _, enclosure := range entry.Enclosures {
+			if enclosure.URL != "" {
+				release.URLs = append(release.URLs, enclosure.URL)
+			}
+		}
+		releases = append(releases, release)
+	}
+	if err == nil && root != "rss" && root != "feed" && root != "RDF" {
+		err = errors.New("not an RSS or Atom feed")
+	}
+	if err != nil {
+		jLog.Error(err, *logFrom, true)
+		err = fmt.Errorf("unmarshal of feed %s failed\n%w",
+			l.URL, err)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	sortByCreated(releases)
+	return
+}
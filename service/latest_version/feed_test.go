@@ -0,0 +1,270 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/release-argus/Argus/service/latest_version/filter"
+	"github.com/release-argus/Argus/util"
+)
+
+var testRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+  <title>Example releases</title>
+  <link>https://example.com</link>
+  <item>
+    <title>Example 1.2.0 &mdash; bug fixes</title>
+    <link>https://example.com/releases/1.2.0</link>
+    <pubDate>Wed, 01 Feb 2023 00:00:00 +0000</pubDate>
+    <enclosure url="https://example.com/download/example-1.2.0.tar.gz" type="application/gzip" length="1"/>
+  </item>
+  <item>
+    <title>Example 1.10.0</title>
+    <link>https://example.com/releases/1.10.0</link>
+    <pubDate>Mon, 06 Mar 2023 12:00:00 GMT</pubDate>
+  </item>
+  <item>
+    <title>Security advisory</title>
+    <guid isPermaLink="true">https://example.com/releases/1.9.1</guid>
+    <pubDate>Sun, 05 Mar 2023 00:00:00 +0000</pubDate>
+  </item>
+</channel>
+</rss>`
+
+var testAtomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example releases</title>
+  <entry>
+    <title>v2.0.0</title>
+    <link rel="enclosure" href="https://example.com/download/2.0.0.zip"/>
+    <link rel="alternate" href="https://example.com/releases/v2.0.0"/>
+    <id>tag:example.com,2023:2.0.0</id>
+    <updated>2023-04-01T00:00:00Z</updated>
+  </entry>
+  <entry>
+    <title>v2.1.0</title>
+    <link href="https://example.com/releases/v2.1.0"/>
+    <id>tag:example.com,2023:2.1.0</id>
+    <published>2023-05-01T02:00:00+02:00</published>
+    <updated>2023-06-01T00:00:00Z</updated>
+  </entry>
+</feed>`
+
+func TestLookup_CheckFeedBody(t *testing.T) {
+	// GIVEN a feed
+	testLogging("WARN")
+	tests := map[string]struct {
+		body        string
+		wantTags    []string
+		wantURLs    []string
+		wantCreated []string
+		errRegex    string
+	}{
+		"RSS, sorted newest first": {
+			body:        testRSSFeed,
+			wantTags:    []string{"Example 1.10.0", "Security advisory", "Example 1.2.0 — bug fixes"},
+			wantURLs:    []string{"https://example.com/releases/1.10.0", "https://example.com/releases/1.9.1", "https://example.com/releases/1.2.0", "https://example.com/download/example-1.2.0.tar.gz"},
+			wantCreated: []string{"2023-03-06T12:00:00Z", "2023-03-05T00:00:00Z", "2023-02-01T00:00:00Z"},
+			errRegex:    "^$"},
+		"Atom, alternate link and published date": {
+			body:        testAtomFeed,
+			wantTags:    []string{"v2.1.0", "v2.0.0"},
+			wantURLs:    []string{"https://example.com/releases/v2.1.0", "https://example.com/releases/v2.0.0"},
+			wantCreated: []string{"2023-05-01T00:00:00Z", "2023-04-01T00:00:00Z"},
+			errRegex:    "^$"},
+		"RSS 1.0": {
+			body: `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <item><title>1.0.0</title><link>https://example.com/1.0.0</link><dc:date>2023-01-01</dc:date></item>
+</rdf:RDF>`,
+			wantTags:    []string{"1.0.0"},
+			wantURLs:    []string{"https://example.com/1.0.0"},
+			wantCreated: []string{"2023-01-01T00:00:00Z"},
+			errRegex:    "^$"},
+		"dates of unknown formats are sorted last": {
+			body: `<rss><channel>
+  <item><title>1.0.0</title><pubDate>sometime in 2024</pubDate></item>
+  <item><title>1.1.0</title><pubDate>Mon, 02 Jan 2023 10:00:00 +0100</pubDate></item>
+  <item><title>1.2.0</title><pubDate>2023-01-02T09:30:00.5+00:00</pubDate></item>
+  <item><title>0.9.0</title><pubDate>Monday, 02-Jan-06 15:04:05 UTC</pubDate></item>
+</channel></rss>`,
+			wantTags:    []string{"1.2.0", "1.1.0", "0.9.0", "1.0.0"},
+			wantCreated: []string{"2023-01-02T09:30:00Z", "2023-01-02T09:00:00Z", "2006-01-02T15:04:05Z", "sometime in 2024"},
+			errRegex:    "^$"},
+		"Latin-1 encoding": {
+			body:     "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<rss><channel><item><title>Caf\xe9 1.0</title></item></channel></rss>",
+			wantTags: []string{"Café 1.0"},
+			errRegex: "^$"},
+		"Windows-1252 encoding": {
+			body:     "<?xml version=\"1.0\" encoding=\"windows-1252\"?>\n<rss><channel><item><title>\x93Caf\xe9\x94 \x96 1.0</title></item></channel></rss>",
+			wantTags: []string{"“Café” – 1.0"},
+			errRegex: "^$"},
+		"unsupported encoding": {
+			body:     "<?xml version=\"1.0\" encoding=\"EBCDIC\"?>\n<rss></rss>",
+			errRegex: `unsupported charset "EBCDIC"`},
+		"not a feed": {
+			body:     `<html><body>1.0.0</body></html>`,
+			errRegex: "not an RSS or Atom feed"},
+		"invalid xml": {
+			body:     `<rss><channel><item><title>1.0.0</title>`,
+			errRegex: "unmarshal of feed .* failed"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			body := []byte(tc.body)
+			lookup := Lookup{Type: "feed", URL: "https://example.com/feed"}
+
+			// WHEN checkFeedBody is called on this body
+			releases, err := lookup.checkFeedBody(&body, &util.LogFrom{})
+
+			// THEN it err's when expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the entries are returned as releases
+			var gotTags, gotURLs, gotCreated []string
+			for i := range releases {
+				gotTags = append(gotTags, releases[i].TagName)
+				gotURLs = append(gotURLs, releases[i].URLs...)
+				if releases[i].Created != "" {
+					gotCreated = append(gotCreated, releases[i].Created)
+				}
+			}
+			if strings.Join(gotTags, ",") != strings.Join(tc.wantTags, ",") {
+				t.Errorf("want releases %q, got %q",
+					tc.wantTags, gotTags)
+			}
+			if strings.Join(gotURLs, ",") != strings.Join(tc.wantURLs, ",") {
+				t.Errorf("want urls %q, got %q",
+					tc.wantURLs, gotURLs)
+			}
+			if strings.Join(gotCreated, ",") != strings.Join(tc.wantCreated, ",") {
+				t.Errorf("want created %q, got %q",
+					tc.wantCreated, gotCreated)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryFeed(t *testing.T) {
+	// GIVEN an RSS feed
+	testLogging("ERROR")
+	tests := map[string]struct {
+		semanticVersioning  bool
+		regex               string
+		requireRegexContent string
+		want                string
+		wantCreated         string
+		wantURLs            []string
+		errRegex            string
+	}{
+		"version from the title, semantic sorting": {
+			semanticVersioning: true,
+			regex:              `([0-9]+\.[0-9]+\.[0-9]+)`,
+			want:               "1.10.0",
+			wantCreated:        "2023-03-06T12:00:00Z",
+			wantURLs:           []string{"https://example.com/releases/1.10.0"},
+			errRegex:           "^$"},
+		"version from the link when not in the title": {
+			semanticVersioning:  true,
+			regex:               `([0-9]+\.[0-9]+\.[0-9]+)$`,
+			requireRegexContent: `"created":"2023-03-05`,
+			want:                "1.9.1",
+			wantCreated:         "2023-03-05T00:00:00Z",
+			wantURLs:            []string{"https://example.com/releases/1.9.1"},
+			errRegex:            "^$"},
+		"no semantic versioning, newest entry": {
+			want:        "Example 1.10.0",
+			wantCreated: "2023-03-06T12:00:00Z",
+			wantURLs:    []string{"https://example.com/releases/1.10.0"},
+			errRegex:    "^$"},
+		"require.regex_content on the enclosures": {
+			semanticVersioning:  true,
+			regex:               `([0-9]+\.[0-9]+\.[0-9]+)`,
+			requireRegexContent: `\.tar\.gz`,
+			want:                "1.2.0",
+			wantCreated:         "2023-02-01T00:00:00Z",
+			wantURLs:            []string{"https://example.com/releases/1.2.0", "https://example.com/download/example-1.2.0.tar.gz"},
+			errRegex:            "^$"},
+		"no entries match": {
+			semanticVersioning: true,
+			regex:              `^v([0-9.]+)$`,
+			errRegex:           "no releases were found matching the url_commands"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/rss+xml")
+				w.Write([]byte(testRSSFeed))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "feed"
+			lookup.URL = server.URL
+			lookup.GitHubData = nil
+			lookup.URLCommands = nil
+			if tc.regex != "" {
+				lookup.URLCommands = filter.URLCommandSlice{{Type: "regex", Regex: &tc.regex}}
+			}
+			lookup.Options.SemanticVersioning = &tc.semanticVersioning
+			lookup.Require.RegexContent = tc.requireRegexContent
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			// AND the entry date and links are in the Status
+			if got := lookup.Status.GetLatestVersionCreated(); got != tc.wantCreated {
+				t.Errorf("want latest_version_created %q, got %q",
+					tc.wantCreated, got)
+			}
+			if got := lookup.Status.GetLatestVersionURLs(); strings.Join(got, ",") != strings.Join(tc.wantURLs, ",") {
+				t.Errorf("want urls %q, got %q",
+					tc.wantURLs, got)
+			}
+		})
+	}
+}
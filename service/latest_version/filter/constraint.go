@@ -0,0 +1,73 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/release-argus/Argus/util"
+)
+
+// parseVersionConstraint parses a version_constraint, e.g. "^1.2 || >=2.3 <3"
+// (nil for an empty constraint, which any version satisfies).
+//
+// Prereleases only satisfy the comparisons that have a prerelease in them (e.g. ">=2.0.0-0 <3.0.0-0").
+func parseVersionConstraint(constraint string) (*semver.Constraints, error) {
+	if strings.TrimSpace(constraint) == "" {
+		return nil, nil
+	}
+	return semver.NewConstraint(constraint) //nolint:wrapcheck
+}
+
+// CheckVersionConstraint returns whether `version` satisfies the version_constraint `constraint`.
+func CheckVersionConstraint(constraint string, version string) (bool, error) {
+	parsed, err := parseVersionConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+
+	// Allow partial versions (e.g. 'v1.2').
+	semVersion, err := semver.NewVersion(strings.TrimSpace(version))
+	if err != nil {
+		return false, fmt.Errorf("%q is not a semantic version", version)
+	}
+
+	return parsed == nil || parsed.Check(semVersion), nil
+}
+
+// VersionConstraintCheck returns an error if `version` doesn't satisfy the VersionConstraint.
+func (r *Require) VersionConstraintCheck(
+	version string,
+	logFrom *util.LogFrom,
+) error {
+	if r == nil || r.VersionConstraint == "" {
+		return nil
+	}
+
+	satisfied, err := CheckVersionConstraint(r.VersionConstraint, version)
+	if err == nil && !satisfied {
+		err = fmt.Errorf("version %q doesn't satisfy the version_constraint %q",
+			version, r.VersionConstraint)
+	}
+	if err != nil {
+		r.Status.RegexMissVersion()
+		jLog.Info(err, *logFrom, r.Status.RegexMissesVersion() == 1)
+		return err //nolint:wrapcheck
+	}
+
+	return nil
+}
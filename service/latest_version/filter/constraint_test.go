@@ -0,0 +1,331 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package filter
+
+import (
+	"regexp"
+	"testing"
+
+	svcstatus "github.com/release-argus/Argus/service/status"
+	"github.com/release-argus/Argus/util"
+)
+
+func TestParseVersionConstraint(t *testing.T) {
+	// GIVEN a version_constraint
+	tests := map[string]struct {
+		constraint string
+		errRegex   string
+	}{
+		"empty":                   {constraint: "", errRegex: "^$"},
+		"exact":                   {constraint: "1.2.3", errRegex: "^$"},
+		"partial":                 {constraint: "1.2", errRegex: "^$"},
+		"wildcards":               {constraint: "1.x || 2.* || *", errRegex: "^$"},
+		"comparisons":             {constraint: ">=2.3 <3", errRegex: "^$"},
+		"comparisons with commas": {constraint: ">=2.3, <3", errRegex: "^$"},
+		"spaced operators":        {constraint: ">= 2.3 < 3", errRegex: "^$"},
+		"caret and tilde":         {constraint: "^1.2 || ~3.4.5 || ~>6", errRegex: "^$"},
+		"hyphen range":            {constraint: "1.2 - 2.3.4", errRegex: "^$"},
+		"v prefix":                {constraint: ">=v1.2.3", errRegex: "^$"},
+		"prerelease":              {constraint: ">=1.2.3-beta.1", errRegex: "^$"},
+		"operator without a version": {
+			constraint: "^1 || >=",
+			errRegex:   `^improper constraint: +>=$`},
+		"invalid version": {
+			constraint: "^foo",
+			errRegex:   `^improper constraint: \^foo$`},
+		"double operator": {
+			constraint: ">>1",
+			errRegex:   `^improper constraint: >>1$`},
+		"too many parts": {
+			constraint: "1.2.3.4",
+			errRegex:   `^improper constraint: 1\.2\.3\.4$`},
+		"unknown operator": {
+			constraint: "==1.2",
+			errRegex:   `^improper constraint: ==1\.2$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN parseVersionConstraint is called on it
+			_, err := parseVersionConstraint(tc.constraint)
+
+			// THEN the err is what we expect
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
+func TestCheckVersionConstraint(t *testing.T) {
+	// GIVEN a version_constraint and versions to check against it
+	tests := map[string]struct {
+		constraint string
+		satisfied  []string
+		unsatisfy  []string
+	}{
+		"empty": {
+			constraint: "",
+			satisfied:  []string{"0.0.0", "1.2.3", "99.0.0"}},
+		"any": {
+			constraint: "*",
+			satisfied:  []string{"0.0.0", "1.2.3"}},
+		"exact": {
+			constraint: "1.2.3",
+			satisfied:  []string{"1.2.3", "v1.2.3"},
+			unsatisfy:  []string{"1.2.4", "1.2.2", "1.2.3-beta"}},
+		"partial exact": {
+			constraint: "=1.2",
+			satisfied:  []string{"1.2.0", "1.2.99"},
+			unsatisfy:  []string{"1.1.9", "1.3.0"}},
+		"major wildcard": {
+			constraint: "1.x",
+			satisfied:  []string{"1.0.0", "1.99.99"},
+			unsatisfy:  []string{"0.9.9", "2.0.0"}},
+		"not equal": {
+			constraint: "!=1.2",
+			satisfied:  []string{"1.1.0", "1.3.0"},
+			unsatisfy:  []string{"1.2.0", "1.2.5"}},
+		"greater than": {
+			constraint: ">1.2.3",
+			satisfied:  []string{"1.2.4", "2.0.0"},
+			unsatisfy:  []string{"1.2.3", "1.0.0"}},
+		"greater than partial": {
+			constraint: ">1.2",
+			satisfied:  []string{"1.3.0"},
+			unsatisfy:  []string{"1.2.9"}},
+		"greater than or equal": {
+			constraint: ">=1.2",
+			satisfied:  []string{"1.2.0", "3.0.0"},
+			unsatisfy:  []string{"1.1.9"}},
+		"less than": {
+			constraint: "<1.2",
+			satisfied:  []string{"1.1.9"},
+			unsatisfy:  []string{"1.2.0"}},
+		"less than or equal partial": {
+			constraint: "<=1.2",
+			satisfied:  []string{"1.2.9"},
+			unsatisfy:  []string{"1.3.0"}},
+		"range": {
+			constraint: ">=2.3 <3",
+			satisfied:  []string{"2.3.0", "2.99.1"},
+			unsatisfy:  []string{"2.2.9", "3.0.0"}},
+		"or": {
+			constraint: "^1.2 || >=2.3 <3",
+			satisfied:  []string{"1.2.0", "1.9.0", "2.5.0"},
+			unsatisfy:  []string{"1.1.0", "2.0.0", "3.1.0"}},
+		"caret": {
+			constraint: "^1.2.3",
+			satisfied:  []string{"1.2.3", "1.9.0"},
+			unsatisfy:  []string{"1.2.2", "2.0.0"}},
+		"caret zero major": {
+			constraint: "^0.2.3",
+			satisfied:  []string{"0.2.3", "0.2.9"},
+			unsatisfy:  []string{"0.3.0"}},
+		"caret zero minor": {
+			constraint: "^0.0.3",
+			satisfied:  []string{"0.0.3"},
+			unsatisfy:  []string{"0.0.4"}},
+		"caret partial zero": {
+			constraint: "^0.0",
+			satisfied:  []string{"0.0.9"},
+			unsatisfy:  []string{"0.1.0"}},
+		"tilde": {
+			constraint: "~1.2.3",
+			satisfied:  []string{"1.2.3", "1.2.9"},
+			unsatisfy:  []string{"1.3.0", "1.2.2"}},
+		"tilde major": {
+			constraint: "~1",
+			satisfied:  []string{"1.9.9"},
+			unsatisfy:  []string{"2.0.0"}},
+		"hyphen range": {
+			constraint: "1.2 - 2.3",
+			satisfied:  []string{"1.2.0", "2.3.9"},
+			unsatisfy:  []string{"1.1.9", "2.4.0"}},
+		"hyphen range with full upper": {
+			constraint: "1.2 - 2.3.4",
+			satisfied:  []string{"2.3.4"},
+			unsatisfy:  []string{"2.3.5"}},
+		"prerelease": {
+			constraint: ">=1.2.3-beta.2",
+			satisfied:  []string{"1.2.3-beta.2", "1.2.3-rc.1", "1.2.3"},
+			unsatisfy:  []string{"1.2.3-beta.1", "1.2.2"}},
+		"partial versions checked": {
+			constraint: "^2",
+			satisfied:  []string{"2", "v2.1"},
+			unsatisfy:  []string{"1.9"}},
+		"prereleases of the next major": {
+			constraint: "^1",
+			satisfied:  []string{"1.9.0"},
+			unsatisfy:  []string{"2.0.0-rc.1", "1.5.0-rc.1"}},
+		"prereleases of the next major with a wildcard": {
+			constraint: "1.x",
+			unsatisfy:  []string{"2.0.0-rc.1"}},
+		"prereleases of the upper bound of a range": {
+			constraint: ">=2.3 <3",
+			unsatisfy:  []string{"3.0.0-alpha", "2.5.0-beta"}},
+		"prereleases of the next minor with a tilde": {
+			constraint: "~1.2",
+			unsatisfy:  []string{"1.3.0-rc.1"}},
+		"prereleases allowed by a prerelease in the constraint": {
+			constraint: ">=2.0.0-0 <3.0.0-0",
+			satisfied:  []string{"2.0.0-rc.1", "2.5.0-beta"},
+			unsatisfy:  []string{"3.0.0-alpha"}},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, want := range []bool{true, false} {
+				versions := tc.satisfied
+				if !want {
+					versions = tc.unsatisfy
+				}
+				for _, version := range versions {
+					// WHEN CheckVersionConstraint is called with the constraint and version
+					got, err := CheckVersionConstraint(tc.constraint, version)
+
+					// THEN the version satisfies the constraint when expected
+					if err != nil {
+						t.Fatalf("%q on %q: unexpected err: %v",
+							tc.constraint, version, err)
+					}
+					if got != want {
+						t.Errorf("%q on %q: want %t, got %t",
+							tc.constraint, version, want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckVersionConstraint_Errors(t *testing.T) {
+	// GIVEN a version_constraint/version that will fail
+	tests := map[string]struct {
+		constraint string
+		version    string
+		errRegex   string
+	}{
+		"invalid constraint": {
+			constraint: "^foo",
+			version:    "1.2.3",
+			errRegex:   `^improper constraint: \^foo$`},
+		"non-semantic version": {
+			constraint: "^1",
+			version:    "release-1",
+			errRegex:   `^"release-1" is not a semantic version$`},
+		"wildcard version": {
+			constraint: "^1",
+			version:    "*",
+			errRegex:   `^"\*" is not a semantic version$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN CheckVersionConstraint is called
+			got, err := CheckVersionConstraint(tc.constraint, tc.version)
+
+			// THEN it isn't satisfied
+			if got {
+				t.Errorf("want false, got %t", got)
+			}
+			// AND the err is what we expect
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
+func TestRequire_VersionConstraintCheck(t *testing.T) {
+	// GIVEN a Require
+	testLogging("WARN")
+	tests := map[string]struct {
+		require  *Require
+		version  string
+		errRegex string
+	}{
+		"nil require": {
+			require:  nil,
+			version:  "1.2.3",
+			errRegex: "^$"},
+		"empty version_constraint": {
+			require:  &Require{},
+			version:  "1.2.3",
+			errRegex: "^$"},
+		"satisfied": {
+			require:  &Require{VersionConstraint: ">=1.2 <2"},
+			version:  "1.2.3",
+			errRegex: "^$"},
+		"not satisfied": {
+			require:  &Require{VersionConstraint: "^2"},
+			version:  "1.2.3",
+			errRegex: `^version "1.2.3" doesn't satisfy the version_constraint "\^2"$`},
+		"non-semantic version": {
+			require:  &Require{VersionConstraint: "^2"},
+			version:  "latest",
+			errRegex: `^"latest" is not a semantic version$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.require != nil {
+				tc.require.Status = &svcstatus.Status{}
+			}
+
+			// WHEN VersionConstraintCheck is called on it
+			err := tc.require.VersionConstraintCheck(tc.version, &util.LogFrom{})
+
+			// THEN the err is what we expect
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND a miss is counted when the version is rejected
+			if tc.require != nil {
+				wantMisses := uint(0)
+				if err != nil {
+					wantMisses = 1
+				}
+				if got := tc.require.Status.RegexMissesVersion(); got != wantMisses {
+					t.Errorf("want %d version misses, got %d",
+						wantMisses, got)
+				}
+			}
+		})
+	}
+}
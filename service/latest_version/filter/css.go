@@ -0,0 +1,40 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import "github.com/andybalholm/cascadia"
+
+// compileCSSSelector compiles the group of CSS selectors of a css url_command, e.g.
+//
+//	div.release > h2
+//	a[href$=".tar.gz"]
+//	table#downloads tr:nth-child(2) td:first-child
+//	ul.versions li:not(.beta), span:contains("Latest")
+func compileCSSSelector(selector string) (cascadia.SelectorGroup, error) {
+	return cascadia.ParseGroup(selector) //nolint:wrapcheck
+}
+
+// cssMatches returns the text (or `attribute`) of the elements selected by `selector`
+// in document order, skipping those without the `attribute`.
+func (d *markupDocument) cssMatches(selector cascadia.SelectorGroup, attribute string) []string {
+	nodes := cascadia.QueryAll(d.html, selector)
+	texts := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if value, ok := htmlValue(node, attribute); ok {
+			texts = append(texts, value)
+		}
+	}
+	return texts
+}
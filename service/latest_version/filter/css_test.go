@@ -0,0 +1,178 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/release-argus/Argus/util"
+)
+
+func TestCompileCSSSelector(t *testing.T) {
+	// GIVEN a CSS selector
+	tests := map[string]struct {
+		selector string
+		errRegex string
+	}{
+		"tag":                  {selector: "span", errRegex: "^$"},
+		"universal":            {selector: "*", errRegex: "^$"},
+		"id and class":         {selector: "div#latest.release", errRegex: "^$"},
+		"attributes":           {selector: `a[href][href$=".tar.gz"][class~=download]`, errRegex: "^$"},
+		"combinators":          {selector: "ul > li + li ~ li span", errRegex: "^$"},
+		"group":                {selector: "h2 span , td", errRegex: "^$"},
+		"pseudo-classes":       {selector: "li:first-child, li:nth-child(2n+1):not(.beta), p:contains('v1')", errRegex: "^$"},
+		"escaped":              {selector: `#a\.b`, errRegex: "^$"},
+		"empty":                {selector: "", errRegex: "expected selector, found EOF"},
+		"trailing combinator":  {selector: "div >", errRegex: "expected selector, found EOF"},
+		"unclosed attribute":   {selector: "a[href", errRegex: "unexpected EOF"},
+		"invalid attribute op": {selector: "a[href%=x]", errRegex: "attribute operator"},
+		"unknown pseudo-class": {selector: "a:unknown", errRegex: "unknown pseudoclass"},
+		"invalid nth":          {selector: "li:nth-child(x)", errRegex: "expected"},
+		"unterminated string":  {selector: `a[href="x]`, errRegex: "EOF in string"},
+		"empty class":          {selector: "div.", errRegex: "expected identifier"},
+		"unexpected character": {selector: "div!", errRegex: "parsing"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN compileCSSSelector is called on it
+			_, err := compileCSSSelector(tc.selector)
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Errorf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
+func TestMarkupDocument_CSSMatches(t *testing.T) {
+	// GIVEN a HTML/XML document and a CSS selector
+	tests := map[string]struct {
+		document  string
+		selector  string
+		attribute string
+		want      []string
+	}{
+		"tag": {
+			selector: "h2",
+			want:     []string{"Version 1.2.0"}},
+		"class": {
+			selector: ".version",
+			want:     []string{"1.2.0", "1.3.0-beta.1", "1.1.0", "1.0.0"}},
+		"id": {
+			selector: "#latest .version",
+			want:     []string{"1.2.0"}},
+		"multiple classes": {
+			selector: "div.release.current h2",
+			want:     []string{"Version 1.2.0"}},
+		"attribute suffix": {
+			selector: `a[href$=".zip"]`,
+			want:     []string{"Windows"}},
+		"attribute prefix and contains": {
+			selector: `a[href^="/files/"][href*="tar"]`,
+			want:     []string{"Linux"}},
+		"attribute word": {
+			selector: `div[class~=current] a`,
+			want:     []string{"Linux", "Windows"}},
+		"attribute equals": {
+			selector: `meta[name=version]`,
+			want:     []string{""}},
+		"attribute of the elements": {
+			selector:  `a.download, meta`,
+			attribute: "href",
+			want:      []string{"/files/app-1.2.0.tar.gz", "/files/app-1.2.0.zip"}},
+		"child combinator": {
+			selector: "ul > span",
+			want:     nil},
+		"adjacent sibling": {
+			selector: "li.beta + li span",
+			want:     []string{"1.1.0"}},
+		"general sibling": {
+			selector: "li.beta ~ li",
+			want:     []string{"1.1.0", "1.0.0"}},
+		"first-child": {
+			selector: "ul li:first-child",
+			want:     []string{"1.3.0-beta.1"}},
+		"last-child": {
+			selector: "ul li:last-child",
+			want:     []string{"1.0.0"}},
+		"nth-child": {
+			selector: "table tr:nth-child(2) td:nth-child(1)",
+			want:     []string{"1.2.0"}},
+		"nth-child odd": {
+			selector: "ul li:nth-child(odd)",
+			want:     []string{"1.3.0-beta.1", "1.0.0"}},
+		"nth-last-child": {
+			selector: "ul li:nth-last-child(-n+2)",
+			want:     []string{"1.1.0", "1.0.0"}},
+		"first-of-type": {
+			selector: "tr:first-of-type th:last-of-type",
+			want:     []string{"Date"}},
+		"not": {
+			selector: "ul li:not(.beta)",
+			want:     []string{"1.1.0", "1.0.0"}},
+		"contains": {
+			selector: "td:contains('2023-01')",
+			want:     []string{"2023-01-15"}},
+		"group in document order without duplicates": {
+			selector: "td:first-child, .versions li, li:last-child",
+			want:     []string{"1.3.0-beta.1", "1.1.0", "1.0.0", "1.2.0", "1.1.0"}},
+		"xml": {
+			document: testMarkupXML,
+			selector: `version[stable="true"]`,
+			want:     []string{"1.2.0", "1.1.0"}},
+		"no matches": {
+			selector: "section",
+			want:     nil},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			selector, err := compileCSSSelector(tc.selector)
+			if err != nil {
+				t.Fatalf("compileCSSSelector(%q) failed: %s",
+					tc.selector, err)
+			}
+			document, err := parseMarkup(util.GetFirstNonDefault(tc.document, testMarkupHTML))
+			if err != nil {
+				t.Fatalf("parseMarkup failed: %s",
+					err)
+			}
+
+			// WHEN cssMatches is called on the document
+			got := document.cssMatches(selector, tc.attribute)
+
+			// THEN the text (or attribute) of the elements selected are returned
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+				t.Errorf("want %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
@@ -15,20 +15,44 @@
 package filter
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	net_url "net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/release-argus/Argus/util"
 	"gopkg.in/yaml.v3"
 )
 
+var (
+	// dockerCheckRegistries are the hosts of the Docker Registry of each DockerCheck Type.
+	dockerCheckRegistries = map[string]string{
+		"hub":  "registry-1.docker.io",
+		"ghcr": "ghcr.io",
+		"quay": "quay.io"}
+	// registryChallengeParamRegex matches the key="value" params of a WWW-Authenticate challenge.
+	registryChallengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	// registryTokens are the Bearer tokens of Docker Registries, shared by everything
+	// with the same credentials and scope (by a hash of them, see registryTokenKey).
+	registryTokens      = map[string]registryToken{}
+	registryTokensMutex sync.RWMutex
+)
+
+// registryToken is a Bearer token for a Docker Registry.
+type registryToken struct {
+	token      string
+	validUntil time.Time
+}
+
 // DockerCheck will verify that Tag exists for Image
 type DockerCheck struct {
 	Type       string    `yaml:"type" json:"type"`                             // Where to check, e.g. hub (DockerHub), GHCR, Quay
@@ -279,6 +303,130 @@ func (d *DockerCheck) refreshGHCRToken() (err error) {
 	return err
 }
 
+// Registry returns the host of the Docker Registry of this DockerCheck's Type.
+func (d *DockerCheck) Registry() string {
+	if d == nil {
+		return ""
+	}
+	return dockerCheckRegistries[d.Type]
+}
+
+// RegistryAuthorization returns the Authorization header value that answers the `challenge`
+// (WWW-Authenticate header) of a Docker Registry v2 API with the Username/Token of this DockerCheck.
+//
+// `scope` is used if the challenge doesn't have one, e.g. "repository:release-argus/argus:pull".
+func (d *DockerCheck) RegistryAuthorization(challenge string, scope string, client *http.Client) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if d.Username == "" && d.Token == "" {
+			return "", errors.New("registry requires a username and token")
+		}
+		return "Basic " + util.BasicAuth(d.Username, d.Token), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported registry authentication %q",
+			challenge)
+	}
+
+	values := map[string]string{}
+	for _, match := range registryChallengeParamRegex.FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(match[1])] = match[2]
+	}
+	if values["realm"] == "" {
+		return "", fmt.Errorf("no realm in the registry challenge %q",
+			challenge)
+	}
+
+	token, err := d.registryToken(
+		values["realm"], values["service"], util.GetFirstNonDefault(values["scope"], scope),
+		client)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
+
+// registryTokenKey returns the key of the token for `scope` from the token server at `realm`
+// with the Username/Token of this DockerCheck in registryTokens (hashed to keep the credentials out of memory).
+func (d *DockerCheck) registryTokenKey(realm string, service string, scope string) string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{realm, service, scope, d.Username, d.Token}, "\x00")))
+	return hex.EncodeToString(hash[:])
+}
+
+// registryToken returns a Bearer token for `scope` from the token server at `realm`,
+// reusing a cached one until it expires.
+//
+// https://docs.docker.com/registry/spec/auth/token/
+func (d *DockerCheck) registryToken(realm string, service string, scope string, client *http.Client) (string, error) {
+	key := d.registryTokenKey(realm, service, scope)
+	registryTokensMutex.RLock()
+	cached, ok := registryTokens[key]
+	registryTokensMutex.RUnlock()
+	if ok && time.Now().UTC().Before(cached.validUntil) {
+		return cached.token, nil
+	}
+
+	query := net_url.Values{}
+	if service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", scope)
+	req, err := http.NewRequest(http.MethodGet, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("registry token request, creation failed: %w", err)
+	}
+	req.Header.Set("Connection", "close")
+	if d.Username != "" || d.Token != "" {
+		req.SetBasicAuth(d.Username, d.Token)
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("registry token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token request failed: %s",
+			strings.TrimSpace(string(body)))
+	}
+
+	var tokenJSON struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.Unmarshal(body, &tokenJSON); err != nil {
+		return "", fmt.Errorf("unmarshal of registry token failed: %w", err)
+	}
+	token := util.GetFirstNonDefault(tokenJSON.Token, tokenJSON.AccessToken)
+	if token == "" {
+		return "", errors.New("registry didn't return a token")
+	}
+	// Tokens are valid for 60s unless the registry says otherwise.
+	expiresIn := 60
+	if tokenJSON.ExpiresIn > 0 {
+		expiresIn = tokenJSON.ExpiresIn
+	}
+	now := time.Now().UTC()
+	registryTokensMutex.Lock()
+	// Remove the expired tokens.
+	for cachedKey, cached := range registryTokens {
+		if !now.Before(cached.validUntil) {
+			delete(registryTokens, cachedKey)
+		}
+	}
+	registryTokens[key] = registryToken{
+		token:      token,
+		validUntil: now.Add(time.Duration(expiresIn-5) * time.Second)}
+	registryTokensMutex.Unlock()
+
+	return token, nil
+}
+
 // Print the DockerCheck.
 func (d *DockerCheck) Print(prefix string) {
 	if d == nil {
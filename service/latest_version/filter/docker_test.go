@@ -18,10 +18,14 @@ package filter
 
 import (
 	"encoding/base64"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -703,3 +707,175 @@ username: '>123'
 		})
 	}
 }
+
+func TestDockerCheck_Registry(t *testing.T) {
+	// GIVEN a DockerCheck
+	tests := map[string]struct {
+		dockerCheck *DockerCheck
+		want        string
+	}{
+		"nil": {
+			dockerCheck: nil,
+			want:        ""},
+		"hub": {
+			dockerCheck: &DockerCheck{Type: "hub"},
+			want:        "registry-1.docker.io"},
+		"ghcr": {
+			dockerCheck: &DockerCheck{Type: "ghcr"},
+			want:        "ghcr.io"},
+		"quay": {
+			dockerCheck: &DockerCheck{Type: "quay"},
+			want:        "quay.io"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN Registry is called on it
+			got := tc.dockerCheck.Registry()
+
+			// THEN the host of its registry is returned
+			if got != tc.want {
+				t.Errorf("want %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDockerCheck_RegistryTokenCache(t *testing.T) {
+	// GIVEN a Docker Registry token server, and an expired token in the cache
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"token":"token-for-%s","expires_in":300}`,
+			r.URL.Query().Get("scope"))
+	}))
+	defer server.Close()
+	dockerCheck := DockerCheck{Username: "cache-user", Token: "cache-secret"}
+	expiredKey := dockerCheck.registryTokenKey(server.URL, "registry.test", "repository:owner/expired:pull")
+	registryTokensMutex.Lock()
+	registryTokens[expiredKey] = registryToken{
+		token:      "expired",
+		validUntil: time.Now().UTC().Add(-time.Minute)}
+	registryTokensMutex.Unlock()
+
+	// WHEN a token is fetched with the credentials
+	token, err := dockerCheck.registryToken(server.URL, "registry.test", "repository:owner/cache:pull", nil)
+
+	// THEN the token is returned
+	if err != nil || token != "token-for-repository:owner/cache:pull" {
+		t.Fatalf("want token-for-repository:owner/cache:pull, got %q (err=%v)",
+			token, err)
+	}
+	registryTokensMutex.RLock()
+	defer registryTokensMutex.RUnlock()
+	// AND the expired token is evicted
+	if _, ok := registryTokens[expiredKey]; ok {
+		t.Errorf("want the expired token to be evicted, got %v",
+			registryTokens[expiredKey])
+	}
+	// AND the credentials aren't in the keys of the cache
+	for key := range registryTokens {
+		if strings.Contains(key, dockerCheck.Token) || strings.Contains(key, dockerCheck.Username) {
+			t.Errorf("want the credentials to be hashed in the key, got %q",
+				key)
+		}
+	}
+}
+
+func TestDockerCheck_RegistryAuthorization(t *testing.T) {
+	// GIVEN a Docker Registry token server
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if u, p, ok := r.BasicAuth(); ok && (u != "user" || p != "pass") {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"details":"incorrect username or password"}`))
+			return
+		}
+		fmt.Fprintf(w, `{"token":"token-for-%s","expires_in":300}`,
+			r.URL.Query().Get("scope"))
+	}))
+	defer server.Close()
+	tests := map[string]struct {
+		dockerCheck  DockerCheck
+		challenge    string
+		want         string
+		wantRequests int32
+		errRegex     string
+	}{
+		"bearer": {
+			dockerCheck: DockerCheck{Username: "user", Token: "pass"},
+			challenge:   `Bearer realm="%s",service="registry.test",scope="repository:owner/bearer:pull"`,
+			want:        "Bearer token-for-repository:owner/bearer:pull",
+			errRegex:    "^$"},
+		"bearer uses the scope given without one in the challenge": {
+			dockerCheck: DockerCheck{},
+			challenge:   `Bearer realm="%s",service="registry.test"`,
+			want:        "Bearer token-for-repository:owner/image:pull",
+			errRegex:    "^$"},
+		"bearer token is shared while valid": {
+			dockerCheck:  DockerCheck{Type: "ghcr", Image: "owner/shared", Username: "user", Token: "pass"},
+			challenge:    `Bearer realm="%s",service="registry.test",scope="repository:owner/shared:pull"`,
+			want:         "Bearer token-for-repository:owner/shared:pull",
+			wantRequests: 1,
+			errRegex:     "^$"},
+		"bearer with invalid credentials": {
+			dockerCheck: DockerCheck{Username: "user", Token: "wrong"},
+			challenge:   `Bearer realm="%s",service="registry.test",scope="repository:owner/wrong:pull"`,
+			errRegex:    "^registry token request failed: .*incorrect username or password"},
+		"bearer without a realm": {
+			dockerCheck: DockerCheck{},
+			challenge:   `Bearer service="registry.test"`,
+			errRegex:    `^no realm in the registry challenge`},
+		"basic": {
+			dockerCheck: DockerCheck{Username: "user", Token: "pass"},
+			challenge:   `Basic realm="registry"`,
+			want:        "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass")),
+			errRegex:    "^$"},
+		"basic without credentials": {
+			dockerCheck: DockerCheck{},
+			challenge:   `Basic realm="registry"`,
+			errRegex:    "^registry requires a username and token$"},
+		"unsupported": {
+			dockerCheck: DockerCheck{},
+			challenge:   `Negotiate`,
+			errRegex:    `^unsupported registry authentication "Negotiate"$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			challenge := tc.challenge
+			if strings.Contains(challenge, "%s") {
+				challenge = fmt.Sprintf(challenge, server.URL)
+			}
+
+			// WHEN RegistryAuthorization is called on it (twice, with a copy of the credentials)
+			got, err := tc.dockerCheck.RegistryAuthorization(challenge, "repository:owner/image:pull", nil)
+			before := atomic.LoadInt32(&requests)
+			other := DockerCheck{Username: tc.dockerCheck.Username, Token: tc.dockerCheck.Token}
+			again, _ := other.RegistryAuthorization(challenge, "repository:owner/image:pull", nil)
+
+			// THEN the err is what we expect
+			e := util.ErrorToString(err)
+			if !regexp.MustCompile(tc.errRegex).MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the Authorization is as expected
+			if got != tc.want {
+				t.Errorf("want %q, got %q",
+					tc.want, got)
+			}
+			// AND a valid token is reused by anything with the same credentials
+			if tc.wantRequests != 0 {
+				if again != got || atomic.LoadInt32(&requests) != before {
+					t.Errorf("want the cached token %q to be reused, got %q (%d requests)",
+						got, again, atomic.LoadInt32(&requests)-before)
+				}
+			}
+		})
+	}
+}
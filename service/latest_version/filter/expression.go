@@ -0,0 +1,224 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+// expressionEnv is the CEL environment of an expression, with these variables:
+//
+//	version                   string    - the version found
+//	major, minor, patch       int       - the numeric parts of the version (0 when missing)
+//	prerelease                bool      - the release is a prerelease (or the version has a prerelease part)
+//	draft                     bool      - the release is a draft
+//	tag, name                 string    - the tag and name of the release
+//	notes                     string    - the release notes (or commit message)
+//	assets                    list      - the assets of the release, e.g. {"name": "x.deb", "url": "https://..."}
+//	urls                      list      - the URLs of the release (Helm chart/package/feed entry)
+//	published, created        timestamp - when the release was published and its tag was created (null if unknown)
+//	now                       timestamp - the current time
+//	status                    map       - latest_version, latest_version_timestamp, deployed_version,
+//	                                      deployed_version_timestamp and approved_version of the Service
+//
+// major/minor/patch are dyn so that they can be compared with doubles (e.g. major == 2.0),
+// and published/created so that they can be null.
+var expressionEnv = func() *cel.Env {
+	env, err := cel.NewEnv(
+		cel.Variable("version", cel.StringType),
+		cel.Variable("major", cel.DynType),
+		cel.Variable("minor", cel.DynType),
+		cel.Variable("patch", cel.DynType),
+		cel.Variable("prerelease", cel.BoolType),
+		cel.Variable("draft", cel.BoolType),
+		cel.Variable("tag", cel.StringType),
+		cel.Variable("name", cel.StringType),
+		cel.Variable("notes", cel.StringType),
+		cel.Variable("assets", cel.ListType(cel.MapType(cel.StringType, cel.StringType))),
+		cel.Variable("urls", cel.ListType(cel.StringType)),
+		cel.Variable("published", cel.DynType),
+		cel.Variable("created", cel.DynType),
+		cel.Variable("now", cel.TimestampType),
+		cel.Variable("status", cel.MapType(cel.StringType, cel.DynType)),
+		ext.Strings(),
+		cel.CrossTypeNumericComparisons(true),
+		cel.DefaultUTCTimeZone(true))
+	if err != nil {
+		panic(err)
+	}
+	return env
+}()
+
+// Expression is a compiled CEL expression.
+type Expression struct {
+	source  string
+	program cel.Program
+}
+
+// ParseExpression compiles the CEL `expression`, which must give a bool.
+func ParseExpression(expression string) (*Expression, error) {
+	ast, issues := expressionEnv.Compile(expression)
+	if issues.Err() != nil {
+		messages := make([]string, len(issues.Errors()))
+		for i, issue := range issues.Errors() {
+			messages[i] = fmt.Sprintf("%s at %d:%d",
+				issue.Message, issue.Location.Line(), issue.Location.Column()+1)
+		}
+		return nil, fmt.Errorf("%s",
+			strings.Join(messages, ", "))
+	}
+	if outputType := ast.OutputType(); outputType != cel.BoolType && outputType != cel.DynType {
+		return nil, fmt.Errorf("gives %s, not a bool",
+			outputType)
+	}
+
+	program, err := expressionEnv.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{source: expression, program: program}, nil
+}
+
+// EvalBool evaluates the Expression with the `vars`, which must give a bool.
+func (e *Expression) EvalBool(vars map[string]interface{}) (bool, error) {
+	value, _, err := e.program.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("gave %s, not a bool",
+			value.Type().TypeName())
+	}
+	return result, nil
+}
+
+// versionPartsRegex matches the major.minor.patch numbers of a version.
+var versionPartsRegex = regexp.MustCompile(`^\D*(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// expressionVars returns the expressionEnv variables of `release` with `version`.
+func (r *Require) expressionVars(version string, release github_types.Release) map[string]interface{} {
+	vars := map[string]interface{}{
+		"version":    version,
+		"major":      int64(0),
+		"minor":      int64(0),
+		"patch":      int64(0),
+		"prerelease": release.PreRelease,
+		"draft":      release.Draft,
+		"tag":        release.TagName,
+		"name":       release.Name,
+		"notes":      util.GetFirstNonDefault(release.Body, release.Message),
+		"published":  expressionTimestamp(util.GetFirstNonDefault(release.PublishedAt, release.Created)),
+		"created":    expressionTimestamp(release.CreatedAt),
+		"now":        time.Now().UTC()}
+
+	// Version parts
+	if parts := versionPartsRegex.FindStringSubmatch(version); parts != nil {
+		for i, part := range []string{"major", "minor", "patch"} {
+			if number, err := strconv.ParseInt(parts[i+1], 10, 64); err == nil {
+				vars[part] = number
+			}
+		}
+	}
+	if release.SemanticVersion != nil && release.SemanticVersion.PreRelease != "" {
+		vars["prerelease"] = true
+	}
+
+	// Assets/URLs
+	assets := make([]interface{}, len(release.Assets))
+	for i, asset := range release.Assets {
+		assets[i] = map[string]interface{}{
+			"name": asset.Name,
+			"url":  util.GetFirstNonDefault(asset.BrowserDownloadURL, asset.URL)}
+	}
+	vars["assets"] = assets
+	urls := make([]interface{}, len(release.URLs))
+	for i, url := range release.URLs {
+		urls[i] = url
+	}
+	vars["urls"] = urls
+
+	// Status
+	status := map[string]interface{}{
+		"latest_version":             "",
+		"latest_version_timestamp":   nil,
+		"deployed_version":           "",
+		"deployed_version_timestamp": nil,
+		"approved_version":           ""}
+	if r.Status != nil {
+		status["latest_version"] = r.Status.GetLatestVersion()
+		status["latest_version_timestamp"] = expressionTimestamp(r.Status.GetLatestVersionTimestamp())
+		status["deployed_version"] = r.Status.GetDeployedVersion()
+		status["deployed_version_timestamp"] = expressionTimestamp(r.Status.GetDeployedVersionTimestamp())
+		status["approved_version"] = r.Status.GetApprovedVersion()
+	}
+	vars["status"] = status
+
+	return vars
+}
+
+// expressionTimestamp returns the RFC3339 `date` as a timestamp (nil if it isn't one).
+func expressionTimestamp(date string) interface{} {
+	parsed, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return nil
+	}
+	return parsed
+}
+
+// ExpressionCheck returns an error if `release` with `version` doesn't satisfy the Expression.
+func (r *Require) ExpressionCheck(
+	version string,
+	release github_types.Release,
+	logFrom *util.LogFrom,
+) error {
+	if r == nil || r.Expression == "" {
+		return nil
+	}
+
+	// Use the Expression compiled by CheckValues (if it's still current)
+	expression := r.expression
+	var err error
+	if expression == nil || expression.source != r.Expression {
+		expression, err = ParseExpression(r.Expression)
+	}
+	var satisfied bool
+	if err == nil {
+		satisfied, err = expression.EvalBool(r.expressionVars(version, release))
+	}
+	if err == nil && !satisfied {
+		err = fmt.Errorf("version %q doesn't satisfy the expression %q",
+			version, r.Expression)
+	} else if err != nil {
+		err = fmt.Errorf("version %q - expression %q failed: %w",
+			version, r.Expression, err)
+	}
+	if err != nil {
+		r.Status.RegexMissVersion()
+		jLog.Info(err, *logFrom, r.Status.RegexMissesVersion() == 1)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,303 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package filter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/coreos/go-semver/semver"
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	svcstatus "github.com/release-argus/Argus/service/status"
+	"github.com/release-argus/Argus/util"
+)
+
+func TestRequire_ExpressionCheck(t *testing.T) {
+	// GIVEN a Require and a release
+	testLogging("WARN")
+	release := github_types.Release{
+		TagName:     "v1.2.3",
+		Name:        "Argus 1.2.3",
+		Body:        "Fixes a bug.",
+		PublishedAt: "2023-01-01T00:00:00Z",
+		CreatedAt:   "2022-12-31T00:00:00Z",
+		Assets: []github_types.Asset{
+			{Name: "argus-1.2.3.linux-amd64.tar.gz", BrowserDownloadURL: "https://example.com/amd64"},
+			{Name: "argus-1.2.3.linux-arm64.tar.gz", URL: "https://api.example.com/arm64"}},
+		URLs: []string{"https://example.com/chart.tgz"}}
+	tests := map[string]struct {
+		require    *Require
+		version    string
+		release    *github_types.Release
+		prerelease string
+		errRegex   string
+	}{
+		"nil require": {
+			require:  nil,
+			errRegex: "^$"},
+		"empty expression": {
+			require:  &Require{},
+			errRegex: "^$"},
+		"satisfied": {
+			require: &Require{
+				Expression: `!prerelease && !draft && assets.exists(a, a.name.endsWith("linux-amd64.tar.gz")) && !notes.contains("DO NOT USE")`},
+			errRegex: "^$"},
+		"not satisfied": {
+			require: &Require{
+				Expression: `notes.contains("DO NOT USE")`},
+			errRegex: `^version "1.2.3" doesn't satisfy the expression "notes.contains\(\\"DO NOT USE\\"\)"$`},
+		"version parts": {
+			require: &Require{
+				Expression: `version == "1.2.3" && major == 1 && minor == 2 && patch == 3 && tag == "v1.2.3" && name == "Argus 1.2.3"`},
+			errRegex: "^$"},
+		"partial version": {
+			require: &Require{
+				Expression: `major == 2023 && minor == 10 && patch == 0`},
+			version:  "v2023.10",
+			errRegex: "^$"},
+		"assets and urls": {
+			require: &Require{
+				Expression: `assets.map(a, a.url) == ["https://example.com/amd64", "https://api.example.com/arm64"] && urls == ["https://example.com/chart.tgz"]`},
+			errRegex: "^$"},
+		"dates": {
+			require: &Require{
+				Expression: `published - created == duration("24h") && now - published > duration("24h")`},
+			errRegex: "^$"},
+		"no dates": {
+			require: &Require{
+				Expression: `published == null && created == null`},
+			release:  &github_types.Release{},
+			errRegex: "^$"},
+		"prerelease from the semantic version": {
+			require: &Require{
+				Expression: `!prerelease`},
+			prerelease: "rc.1",
+			errRegex:   `^version "1.2.3" doesn't satisfy the expression "!prerelease"$`},
+		"status": {
+			require: &Require{
+				Expression: `status.deployed_version == "1.2.2" && status.deployed_version_timestamp != null && status.latest_version == "" && status.latest_version_timestamp == null && status.approved_version == ""`},
+			errRegex: "^$"},
+		"double comparisons": {
+			require: &Require{
+				Expression: `major == 1.0 && minor < 2.5 && patch > 2.5`},
+			errRegex: "^$"},
+		"evaluation error": {
+			require: &Require{
+				Expression: `int(version) > 1`},
+			errRegex: `^version "1.2.3" - expression "int\(version\) > 1" failed: type conversion error from 'string' to 'int'$`},
+		"overflow": {
+			require: &Require{
+				Expression: `major + 9223372036854775807 > 0`},
+			errRegex: `^version "1.2.3" - expression "major \+ 9223372036854775807 > 0" failed: integer overflow$`},
+		"invalid expression": {
+			require: &Require{
+				Expression: `beta`},
+			errRegex: `^version "1.2.3" - expression "beta" failed: undeclared reference to 'beta' .*at 1:1$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.require != nil {
+				tc.require.Status = &svcstatus.Status{}
+				tc.require.Status.SetDeployedVersion("1.2.2", false)
+			}
+			if tc.version == "" {
+				tc.version = "1.2.3"
+			}
+			testRelease := release
+			if tc.release != nil {
+				testRelease = *tc.release
+			}
+			if tc.prerelease != "" {
+				testRelease.SemanticVersion = semver.New(tc.version + "-" + tc.prerelease)
+			}
+
+			// WHEN ExpressionCheck is called on it
+			err := tc.require.ExpressionCheck(tc.version, testRelease, &util.LogFrom{})
+
+			// THEN the err is what we expect
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND a miss is counted when the release is rejected
+			if tc.require != nil {
+				wantMisses := uint(0)
+				if err != nil {
+					wantMisses = 1
+				}
+				if got := tc.require.Status.RegexMissesVersion(); got != wantMisses {
+					t.Errorf("want %d version misses, got %d",
+						wantMisses, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	// GIVEN an expression
+	tests := map[string]struct {
+		expression string
+		errRegex   string
+	}{
+		"bool": {
+			expression: `!prerelease && version.startsWith("1.")`,
+			errRegex:   "^$"},
+		"macros and string extensions": {
+			expression: `assets.exists(a, a.name.lowerAscii().endsWith(".deb")) && has(status.deployed_version)`,
+			errRegex:   "^$"},
+		"dyn variable": {
+			expression: `status.deployed_version`,
+			errRegex:   "^$"},
+		"not a bool": {
+			expression: `size(assets)`,
+			errRegex:   `^gives int, not a bool$`},
+		"syntax error": {
+			expression: `major ==`,
+			errRegex:   `^Syntax error: .* at 1:9$`},
+		"unknown variable": {
+			expression: `beta`,
+			errRegex:   `^undeclared reference to 'beta' .*at 1:1$`},
+		"macro variable is scoped": {
+			expression: `assets.all(a, a.name != "") && a.name == ""`,
+			errRegex:   `^undeclared reference to 'a' .*at 1:32$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN ParseExpression is called on it
+			expression, err := ParseExpression(tc.expression)
+
+			// THEN the err is what we expect
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the Expression is only returned when there's no err
+			if (expression == nil) != (err != nil) {
+				t.Errorf("want Expression only without an err, got %v with err %v",
+					expression, err)
+			}
+		})
+	}
+}
+
+func TestExpression_EvalBool(t *testing.T) {
+	// GIVEN an Expression and variables
+	tests := map[string]struct {
+		expression string
+		vars       map[string]interface{}
+		want       bool
+		errRegex   string
+	}{
+		"true": {
+			expression: `status.deployed_version == "1.2.2"`,
+			vars: map[string]interface{}{
+				"status": map[string]interface{}{"deployed_version": "1.2.2"}},
+			want:     true,
+			errRegex: "^$"},
+		"false": {
+			expression: `major > 1`,
+			vars:       map[string]interface{}{"major": int64(1)},
+			want:       false,
+			errRegex:   "^$"},
+		"dyn gives a string": {
+			expression: `status.deployed_version`,
+			vars: map[string]interface{}{
+				"status": map[string]interface{}{"deployed_version": "1.2.2"}},
+			errRegex: `^gave string, not a bool$`},
+		"missing key": {
+			expression: `status.latest_version == ""`,
+			vars: map[string]interface{}{
+				"status": map[string]interface{}{}},
+			errRegex: `^no such key: latest_version$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			expression, err := ParseExpression(tc.expression)
+			if err != nil {
+				t.Fatalf("ParseExpression(%q) failed: %v",
+					tc.expression, err)
+			}
+
+			// WHEN EvalBool is called on it
+			got, err := expression.EvalBool(tc.vars)
+
+			// THEN the err is what we expect
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the result is what we expect
+			if got != tc.want {
+				t.Errorf("want %t, got %t",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRequire_ExpressionCheckUsesCheckValues(t *testing.T) {
+	// GIVEN a Require that has been through CheckValues
+	testLogging("WARN")
+	require := &Require{
+		Status:     &svcstatus.Status{},
+		Expression: `major == 1`}
+	if err := require.CheckValues(""); err != nil {
+		t.Fatalf("CheckValues failed: %v", err)
+	}
+
+	// WHEN CheckValues is called
+	// THEN the Expression is compiled
+	if require.expression == nil || require.expression.source != require.Expression {
+		t.Fatalf("want the Expression compiled by CheckValues, got %v",
+			require.expression)
+	}
+
+	// WHEN the Expression is changed without CheckValues
+	compiled := require.expression
+	require.Expression = `major == 2`
+	err := require.ExpressionCheck("1.2.3", github_types.Release{}, &util.LogFrom{})
+
+	// THEN the new Expression is used
+	wantErr := `version "1.2.3" doesn't satisfy the expression "major == 2"`
+	if util.ErrorToString(err) != wantErr {
+		t.Errorf("want err %q, got %q",
+			wantErr, util.ErrorToString(err))
+	}
+	// AND the compiled Expression is left for CheckValues to replace
+	if require.expression != compiled {
+		t.Errorf("ExpressionCheck shouldn't have replaced the compiled Expression")
+	}
+}
@@ -49,6 +49,34 @@ func testURLCommandReplace() URLCommand {
 	}
 }
 
+func testURLCommandJSONPath() URLCommand {
+	path := "$.releases[*].tag_name"
+	index := -1
+	return URLCommand{
+		Type:  "jsonpath",
+		Path:  &path,
+		Index: index,
+	}
+}
+
+func testURLCommandCSS() URLCommand {
+	selector := "a.download"
+	attribute := "href"
+	return URLCommand{
+		Type:      "css",
+		Selector:  &selector,
+		Attribute: &attribute,
+	}
+}
+
+func testURLCommandXPath() URLCommand {
+	path := "//span[@class='version']"
+	return URLCommand{
+		Type: "xpath",
+		Path: &path,
+	}
+}
+
 func testURLCommandSplit() URLCommand {
 	text := "this"
 	index := 1
@@ -0,0 +1,159 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+// compileJSONPath parses the JSONPath `path`, e.g.
+//
+//	$.releases[0].version
+//	$[*].tag_name
+//	$..assets[?(@.name =~ /linux/)].name
+//	$.versions[?(@.prerelease == false && @.major >= 2)].version
+func compileJSONPath(path string) (*yamlpath.Path, error) {
+	path = strings.TrimSpace(path)
+	// yamlpath also allows paths without the root (e.g. "tag_name").
+	if !strings.HasPrefix(path, "$") {
+		return nil, errors.New("must start with '$'")
+	}
+	return yamlpath.NewPath(path) //nolint:wrapcheck
+}
+
+// decodeJSON `text` to the YAML nodes that yamlpath searches,
+// keeping the order of object keys and the text of numbers.
+func decodeJSON(text string) (*yaml.Node, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	node, err := decodeJSONNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	// Nothing should follow the value.
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after top-level value")
+	}
+	return node, nil
+}
+
+// decodeJSONNode reads the next value from the decoder.
+func decodeJSONNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if value == '{' {
+			node.Kind = yaml.MappingNode
+			node.Tag = "!!map"
+		}
+		keys := map[string]int{}
+		for decoder.More() {
+			var key *yaml.Node
+			if node.Kind == yaml.MappingNode {
+				if key, err = decodeJSONNode(decoder); err != nil {
+					return nil, err
+				}
+			}
+			child, err := decodeJSONNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if key == nil {
+				node.Content = append(node.Content, child)
+				continue
+			}
+			// A repeated key keeps its place, with the last value.
+			if i, exists := keys[key.Value]; exists {
+				node.Content[i+1] = child
+				continue
+			}
+			keys[key.Value] = len(node.Content)
+			node.Content = append(node.Content, key, child)
+		}
+		// '}' or ']'
+		if _, err := decoder.Token(); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(value.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value.String()}, nil
+	case bool:
+		if value {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"}, nil
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"}, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+}
+
+// jsonValueString returns the value of `node` as a string
+// (strings as is, and everything else as JSON).
+func jsonValueString(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		return node.Value
+	}
+	var buf bytes.Buffer
+	writeJSON(&buf, node)
+	return buf.String()
+}
+
+// writeJSON of `node` to `buf`, in the order of its keys.
+func writeJSON(buf *bytes.Buffer, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, close := byte('['), byte(']')
+		if node.Kind == yaml.MappingNode {
+			open, close = '{', '}'
+		}
+		buf.WriteByte(open)
+		for i, child := range node.Content {
+			if i != 0 {
+				// Objects alternate key, value.
+				if node.Kind == yaml.MappingNode && i%2 == 1 {
+					buf.WriteByte(':')
+				} else {
+					buf.WriteByte(',')
+				}
+			}
+			writeJSON(buf, child)
+		}
+		buf.WriteByte(close)
+	default:
+		if node.Tag == "!!str" {
+			valueBytes, _ := json.Marshal(node.Value)
+			buf.Write(valueBytes)
+			return
+		}
+		buf.WriteString(node.Value)
+	}
+}
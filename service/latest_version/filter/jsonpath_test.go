@@ -0,0 +1,242 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/release-argus/Argus/util"
+)
+
+func TestCompileJSONPath(t *testing.T) {
+	// GIVEN a JSONPath
+	tests := map[string]struct {
+		path     string
+		errRegex string
+	}{
+		"root":              {path: "$", errRegex: "^$"},
+		"dot child":         {path: "$.tag_name", errRegex: "^$"},
+		"bracket child":     {path: "$['tag name']", errRegex: "^$"},
+		"index":             {path: "$[0].version", errRegex: "^$"},
+		"negative index":    {path: "$.versions[-1]", errRegex: "^$"},
+		"union":             {path: `$[0,-1]`, errRegex: "^$"},
+		"union of names":    {path: `$['a',"b"]`, errRegex: "^$"},
+		"slice":             {path: "$[1:3]", errRegex: "^$"},
+		"slice with step":   {path: "$[::-1]", errRegex: "^$"},
+		"wildcard":          {path: "$.*[*]", errRegex: "^$"},
+		"recursive descent": {path: "$..name", errRegex: "^$"},
+		"filter":            {path: "$[?(@.draft == false && (@.a > 1 || !@.b))]", errRegex: "^$"},
+		"filter regex":      {path: `$[?(@.name =~ /(?i)^v\/[0-9]/)]`, errRegex: "^$"},
+		"filter without paren": {
+			path: "$[?@.prerelease]", errRegex: "non-integer array index"},
+		"union of names and indices": {
+			path: `$[0,"a",-1]`, errRegex: "non-integer array index"},
+		"regex flags": {
+			path: `$[?(@.name =~ /^v/i)]`, errRegex: "invalid filter expression"},
+		"string ordering": {
+			path: "$[?(@.name < 'v1.2')]", errRegex: "strings cannot be compared using <"},
+		"no root": {
+			path: "tag_name", errRegex: "must start with '\\$'"},
+		"unclosed bracket": {
+			path: "$[0", errRegex: "unmatched \\["},
+		"trailing text": {
+			path: "$.a b", errRegex: `invalid character ' ' at position 3`},
+		"empty dot": {
+			path: "$.", errRegex: "child name missing"},
+		"zero slice step": {
+			path: "$[::0]", errRegex: "step value must be non-zero"},
+		"unterminated string": {
+			path: "$['a]", errRegex: `unmatched "'"`},
+		"invalid regex": {
+			path: "$[?(@.a =~ /[0-/)]", errRegex: "invalid regular expression"},
+		"unclosed filter": {
+			path: "$[?(@.a == 1]", errRegex: "invalid filter expression"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN compileJSONPath is called on it
+			_, err := compileJSONPath(tc.path)
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Errorf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
+func TestJSONPath_Find(t *testing.T) {
+	// GIVEN a JSON document and a JSONPath
+	document := `{
+		"name": "argus",
+		"latest": {"version": "1.2.0", "major": 1},
+		"releases": [
+			{"tag_name": "v2.0.0-beta", "prerelease": true, "downloads": 10, "assets": [{"name": "argus-linux"}]},
+			{"tag_name": "v1.2.0", "prerelease": false, "downloads": 250, "assets": [{"name": "argus-windows"}, {"name": "argus-linux"}]},
+			{"tag_name": "v1.1.0", "prerelease": false, "downloads": 1000, "assets": []},
+			{"tag_name": "v1.0.0", "prerelease": false, "downloads": 9.5e3}
+		],
+		"counts": [1, 2.50, true, null, {"b": 2, "a": 1}]
+	}`
+	tests := map[string]struct {
+		path string
+		want []string
+	}{
+		"root object keeps its key order": {
+			path: "$.counts[4]",
+			want: []string{`{"b":2,"a":1}`}},
+		"dot child": {
+			path: "$.latest.version",
+			want: []string{"1.2.0"}},
+		"bracket child": {
+			path: "$['latest'][\"version\"]",
+			want: []string{"1.2.0"}},
+		"missing child": {
+			path: "$.latest.unknown",
+			want: nil},
+		"index": {
+			path: "$.releases[1].tag_name",
+			want: []string{"v1.2.0"}},
+		"negative index": {
+			path: "$.releases[-1].tag_name",
+			want: []string{"v1.0.0"}},
+		"index out of range": {
+			path: "$.releases[4].tag_name",
+			want: nil},
+		"union": {
+			path: "$.releases[0,-1].tag_name",
+			want: []string{"v2.0.0-beta", "v1.0.0"}},
+		"slice": {
+			path: "$.releases[1:3].tag_name",
+			want: []string{"v1.2.0", "v1.1.0"}},
+		"slice reversed": {
+			path: "$.releases[::-2].tag_name",
+			want: []string{"v1.0.0", "v1.2.0"}},
+		"array wildcard": {
+			path: "$.releases[*].tag_name",
+			want: []string{"v2.0.0-beta", "v1.2.0", "v1.1.0", "v1.0.0"}},
+		"object wildcard in document order": {
+			path: "$.latest.*",
+			want: []string{"1.2.0", "1"}},
+		"values other than strings are JSON": {
+			path: "$.counts[*]",
+			want: []string{"1", "2.50", "true", "null", `{"b":2,"a":1}`}},
+		"recursive descent": {
+			path: "$..assets[*].name",
+			want: []string{"argus-linux", "argus-windows", "argus-linux"}},
+		"filter on bool": {
+			path: "$.releases[?(@.prerelease == false)].tag_name",
+			want: []string{"v1.2.0", "v1.1.0", "v1.0.0"}},
+		"filter on number": {
+			path: "$.releases[?(@.downloads >= 1000)].tag_name",
+			want: []string{"v1.1.0", "v1.0.0"}},
+		"filter on string": {
+			path: "$.releases[?(@.tag_name != 'v1.2.0')].tag_name",
+			want: []string{"v2.0.0-beta", "v1.1.0", "v1.0.0"}},
+		"filter on regex": {
+			path: `$.releases[?(@.tag_name =~ /(?i)^V1\.[12]/)].tag_name`,
+			want: []string{"v1.2.0", "v1.1.0"}},
+		"filter on existence": {
+			path: "$.releases[?(!@.assets)].tag_name",
+			want: []string{"v1.0.0"}},
+		"filter on every value of a path": {
+			path: "$.releases[?(@.assets[*].name == 'argus-linux')].tag_name",
+			want: []string{"v2.0.0-beta"}},
+		"filter with && and ||": {
+			path: "$.releases[?(@.prerelease == false && (@.downloads < 500 || @.tag_name == 'v1.0.0'))].tag_name",
+			want: []string{"v1.2.0", "v1.0.0"}},
+		"filter with path from root": {
+			path: "$.releases[?(@.downloads > $.latest.major)].tag_name",
+			want: []string{"v2.0.0-beta", "v1.2.0", "v1.1.0", "v1.0.0"}},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path, err := compileJSONPath(tc.path)
+			if err != nil {
+				t.Fatalf("compileJSONPath(%q) failed: %s",
+					tc.path, err)
+			}
+			root, err := decodeJSON(document)
+			if err != nil {
+				t.Fatalf("decodeJSON failed: %s",
+					err)
+			}
+
+			// WHEN Find is called on the document
+			nodes, err := path.Find(root)
+			if err != nil {
+				t.Fatalf("Find failed: %s",
+					err)
+			}
+
+			// THEN the values at the path are returned
+			got := make([]string, len(nodes))
+			for i, node := range nodes {
+				got[i] = jsonValueString(node)
+			}
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+				t.Errorf("want %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	// GIVEN some text
+	tests := map[string]struct {
+		text     string
+		errRegex string
+	}{
+		"object":            {text: `{"a": [1, "b"]}`, errRegex: "^$"},
+		"string":            {text: `"1.2.3"`, errRegex: "^$"},
+		"invalid":           {text: `{"a": }`, errRegex: "missing value"},
+		"not JSON":          {text: `<html></html>`, errRegex: "invalid character"},
+		"text after object": {text: `{"a": 1} {"b": 2}`, errRegex: "after top-level value"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN decodeJSON is called on it
+			_, err := decodeJSON(tc.text)
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Errorf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"golang.org/x/net/html"
+)
+
+// markupDocument is a parsed HTML/XML document, as used by the css and xpath url_commands.
+type markupDocument struct {
+	html *html.Node     // HTML document (or the XML document converted for css selectors)
+	xml  *xmlquery.Node // XML document (nil for HTML)
+}
+
+// parseMarkup parses `text` as XML if it starts with an XML declaration, and HTML otherwise.
+func parseMarkup(text string) (*markupDocument, error) {
+	if !strings.HasPrefix(strings.TrimSpace(text), "<?xml") {
+		document, err := html.Parse(strings.NewReader(text))
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		return &markupDocument{html: document}, nil
+	}
+
+	document, err := xmlquery.ParseWithOptions(strings.NewReader(text), xmlquery.ParserOptions{
+		Decoder: &xmlquery.DecoderOptions{
+			Strict:    false,
+			AutoClose: xml.HTMLAutoClose,
+			Entity:    xml.HTMLEntity}})
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return &markupDocument{
+			html: xmlToHTML(document),
+			xml:  document},
+		nil
+}
+
+// xmlToHTML converts the XML `node` (and its descendants) to HTML nodes,
+// with the local names of its elements and attributes (without namespace prefixes).
+func xmlToHTML(node *xmlquery.Node) *html.Node {
+	converted := &html.Node{Type: html.DocumentNode}
+	switch node.Type {
+	case xmlquery.ElementNode:
+		converted = &html.Node{Type: html.ElementNode, Data: node.Data}
+		for _, attr := range node.Attr {
+			// Skip the namespace declarations.
+			if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+				continue
+			}
+			converted.Attr = append(converted.Attr, html.Attribute{Key: attr.Name.Local, Val: attr.Value})
+		}
+	case xmlquery.TextNode, xmlquery.CharDataNode:
+		return &html.Node{Type: html.TextNode, Data: node.Data}
+	case xmlquery.CommentNode:
+		return &html.Node{Type: html.CommentNode, Data: node.Data}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == xmlquery.DeclarationNode || child.Type == xmlquery.AttributeNode {
+			continue
+		}
+		converted.AppendChild(xmlToHTML(child))
+	}
+	return converted
+}
+
+// htmlValue returns the `attribute` of the HTML node when given, otherwise its text.
+func htmlValue(node *html.Node, attribute string) (string, bool) {
+	if attribute == "" {
+		var text strings.Builder
+		writeHTMLText(node, &text)
+		return strings.TrimSpace(text.String()), true
+	}
+	if node.Type != html.ElementNode {
+		return "", false
+	}
+	for _, attr := range node.Attr {
+		if strings.EqualFold(attr.Key, attribute) {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// writeHTMLText writes the text of the HTML node and its descendants (excluding comments) to `text`.
+func writeHTMLText(node *html.Node, text *strings.Builder) {
+	switch node.Type {
+	case html.TextNode:
+		text.WriteString(node.Data)
+	case html.CommentNode:
+	default:
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			writeHTMLText(child, text)
+		}
+	}
+}
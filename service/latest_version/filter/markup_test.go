@@ -0,0 +1,150 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/release-argus/Argus/util"
+	"golang.org/x/net/html"
+)
+
+// testMarkupHTML is a download page to select from.
+var testMarkupHTML = `<!DOCTYPE html>
+<html>
+<head><meta name="version" content="1.2.0"><title>Downloads</title></head>
+<body>
+	<div id="latest" class="release current">
+		<h2>Version <span class="version">1.2.0</span></h2>
+		<a class="download" href="/files/app-1.2.0.tar.gz">Linux</a>
+		<a class="download" href="/files/app-1.2.0.zip">Windows</a>
+	</div>
+	<!-- old releases -->
+	<ul class="versions">
+		<li class="beta"><span class="version">1.3.0-beta.1</span></li>
+		<li><span class="version">1.1.0</span></li>
+		<li><span class="version">1.0.0</span></li>
+	</ul>
+	<table id="downloads">
+		<tr><th>Version</th><th>Date</th></tr>
+		<tr><td>1.2.0</td><td>2023-05-01</td></tr>
+		<tr><td>1.1.0</td><td>2023-01-15</td></tr>
+	</table>
+</body>
+</html>`
+
+// testMarkupXML is a feed to select from.
+var testMarkupXML = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:app="http://example.com/app">
+	<title>Releases</title>
+	<entry><title>v1.2.0</title><app:version stable="true">1.2.0</app:version><link href="https://example.com/1.2.0"/></entry>
+	<entry><title>v1.3.0-rc.1</title><app:version stable="false">1.3.0-rc.1</app:version><link href="https://example.com/1.3.0-rc.1"/></entry>
+	<entry><title>v1.1.0</title><app:version stable="true">1.1.0</app:version><link href="https://example.com/1.1.0"/></entry>
+</feed>`
+
+func TestParseMarkup(t *testing.T) {
+	// GIVEN some HTML/XML
+	tests := map[string]struct {
+		text         string
+		wantXML      bool
+		wantElements []string
+		wantText     string
+		errRegex     string
+	}{
+		"html": {
+			text:         `<p class="a">Hello <b>world</b><!-- comment --></p>`,
+			wantElements: []string{"html", "head", "body", "p", "b"},
+			wantText:     "Hello world",
+			errRegex:     "^$"},
+		"html fragment": {
+			text:         `1.2.3`,
+			wantElements: []string{"html", "head", "body"},
+			wantText:     "1.2.3",
+			errRegex:     "^$"},
+		"xml keeps the case of its elements, without namespace prefixes": {
+			text:         `<?xml version="1.0"?><Feed xmlns:app="https://example.com"><app:Version>1.2.3</app:Version><br/></Feed>`,
+			wantXML:      true,
+			wantElements: []string{"Feed", "Version", "br"},
+			wantText:     "1.2.3",
+			errRegex:     "^$"},
+		"xml with html entities": {
+			text:         `<?xml version="1.0"?><a>1.2.3&nbsp;&mdash;</a>`,
+			wantXML:      true,
+			wantElements: []string{"a"},
+			wantText:     "1.2.3\u00a0\u2014",
+			errRegex:     "^$"},
+		"xml without elements": {
+			text:     `<?xml version="1.0"?><!-- 1.2.3 -->`,
+			errRegex: "invalid XML document"},
+		"malformed xml is parsed leniently": {
+			text:     `<?xml version="1.0"?><a><b></a>`,
+			wantXML:  true,
+			errRegex: "^$"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN parseMarkup is called on it
+			document, err := parseMarkup(tc.text)
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			if err != nil {
+				return
+			}
+			// AND it's parsed as XML when expected
+			if gotXML := document.xml != nil; gotXML != tc.wantXML {
+				t.Errorf("want xml=%t, got %t",
+					tc.wantXML, gotXML)
+			}
+			if tc.wantElements == nil {
+				return
+			}
+			// AND the elements are in document order
+			var elements []string
+			var walk func(node *html.Node)
+			walk = func(node *html.Node) {
+				if node.Type == html.ElementNode {
+					elements = append(elements, node.Data)
+				}
+				for child := node.FirstChild; child != nil; child = child.NextSibling {
+					walk(child)
+				}
+			}
+			walk(document.html)
+			if strings.Join(elements, ",") != strings.Join(tc.wantElements, ",") {
+				t.Errorf("want elements %q, got %q",
+					tc.wantElements, elements)
+			}
+			// AND the text of the document is its text nodes
+			if got, _ := htmlValue(document.html, ""); got != tc.wantText {
+				t.Errorf("want text %q, got %q",
+					tc.wantText, got)
+			}
+		})
+	}
+}
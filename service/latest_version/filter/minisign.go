@@ -0,0 +1,80 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"aead.dev/minisign"
+)
+
+// maxMinisignLegacySize is the largest data that will be verified with a legacy (non-prehashed) signature,
+// as those are of the data itself, so it has to be held in memory.
+const maxMinisignLegacySize = 16 << 20
+
+// parseMinisignKey parses a minisign public key, either the base64 key alone,
+// or the contents of its .pub file (with the untrusted comment).
+func parseMinisignKey(publicKey string) (key minisign.PublicKey, err error) {
+	if err = key.UnmarshalText([]byte(strings.TrimSpace(publicKey))); err != nil {
+		err = errors.New("invalid minisign public key")
+	}
+	return
+}
+
+// verifyMinisign verifies the minisign `signature` of `data` was made by `publicKey`,
+// along with the global signature of its trusted comment.
+func verifyMinisign(publicKey string, signature []byte, data io.Reader) error {
+	key, err := parseMinisignKey(publicKey)
+	if err != nil {
+		return err
+	}
+	var sig minisign.Signature
+	if err := sig.UnmarshalText(signature); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if sig.KeyID != key.ID() {
+		return fmt.Errorf("signed by key %016X, not the public_key (%016X)",
+			sig.KeyID, key.ID())
+	}
+
+	var verified bool
+	switch sig.Algorithm {
+	// Prehashed, signature of the BLAKE2b-512 of the data.
+	case minisign.HashEdDSA:
+		reader := minisign.NewReader(data)
+		if _, err := io.Copy(io.Discard, reader); err != nil {
+			return fmt.Errorf("failed reading the signed data: %w", err)
+		}
+		verified = reader.Verify(key, signature)
+	// Legacy, signature of the data.
+	default:
+		message, err := io.ReadAll(io.LimitReader(data, maxMinisignLegacySize+1))
+		if err != nil {
+			return fmt.Errorf("failed reading the signed data: %w", err)
+		}
+		if len(message) > maxMinisignLegacySize {
+			return fmt.Errorf("legacy (non-prehashed) minisign signatures are only verified for files up to %dMiB, sign with `minisign -H`",
+				maxMinisignLegacySize>>20)
+		}
+		verified = minisign.Verify(key, message, signature)
+	}
+	if !verified {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package filter
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/release-argus/Argus/util"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	testMinisignSeed  = bytes.Repeat([]byte{7}, ed25519.SeedSize)
+	testMinisignKeyID = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+)
+
+// testMinisignKey returns the minisign public key (.pub file) of `seed`.
+func testMinisignKey(seed []byte) string {
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	key := append([]byte("Ed"), testMinisignKeyID...)
+	key = append(key, privateKey.Public().(ed25519.PublicKey)...)
+	return fmt.Sprintf("untrusted comment: minisign public key 0807060504030201\n%s\n",
+		base64.StdEncoding.EncodeToString(key))
+}
+
+// testMinisignSignature returns the minisign signature (.minisig file) of `data`,
+// prehashed with BLAKE2b-512 unless `legacy`.
+func testMinisignSignature(data string, legacy bool, trustedComment string) string {
+	privateKey := ed25519.NewKeyFromSeed(testMinisignSeed)
+
+	algorithm := "ED"
+	message := []byte(data)
+	if legacy {
+		algorithm = "Ed"
+	} else {
+		hash := blake2b.Sum512(message)
+		message = hash[:]
+	}
+	signature := ed25519.Sign(privateKey, message)
+	sig := append([]byte(algorithm), testMinisignKeyID...)
+	sig = append(sig, signature...)
+	globalSig := ed25519.Sign(privateKey, append(signature, trustedComment...))
+
+	return fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(sig), trustedComment, base64.StdEncoding.EncodeToString(globalSig))
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	// GIVEN a minisign public key, and a signature of some data
+	publicKey := testMinisignKey(testMinisignSeed)
+	signature := testMinisignSignature(testVerifyAsset, false, "timestamp:1700000000")
+	otherKeyID, _ := base64.StdEncoding.DecodeString(strings.Split(publicKey, "\n")[1])
+	otherKeyID[2]++
+	tests := map[string]struct {
+		publicKey string
+		signature string
+		data      string
+		errRegex  string
+	}{
+		"prehashed": {
+			publicKey: publicKey,
+			signature: signature,
+			data:      testVerifyAsset,
+			errRegex:  "^$"},
+		"legacy": {
+			publicKey: publicKey,
+			signature: testMinisignSignature(testVerifyAsset, true, "timestamp:1700000000"),
+			data:      testVerifyAsset,
+			errRegex:  "^$"},
+		"legacy, larger than maxMinisignLegacySize": {
+			publicKey: publicKey,
+			signature: testMinisignSignature(testVerifyAsset, true, "timestamp:1700000000"),
+			data:      strings.Repeat("a", maxMinisignLegacySize+1),
+			errRegex:  `^legacy \(non-prehashed\) minisign signatures are only verified for files up to 16MiB`},
+		"public key without its comment": {
+			publicKey: strings.Split(publicKey, "\n")[1],
+			signature: signature,
+			data:      testVerifyAsset,
+			errRegex:  "^$"},
+		"tampered data": {
+			publicKey: publicKey,
+			signature: signature,
+			data:      testVerifyAsset + "\n",
+			errRegex:  "^invalid signature$"},
+		"tampered trusted comment": {
+			publicKey: publicKey,
+			signature: strings.Replace(signature, "timestamp:1700000000", "timestamp:1800000000", 1),
+			data:      testVerifyAsset,
+			errRegex:  "^invalid signature$"},
+		"another key with the same ID": {
+			publicKey: testMinisignKey(bytes.Repeat([]byte{8}, ed25519.SeedSize)),
+			signature: signature,
+			data:      testVerifyAsset,
+			errRegex:  "^invalid signature$"},
+		"another key ID": {
+			publicKey: base64.StdEncoding.EncodeToString(otherKeyID),
+			signature: signature,
+			data:      testVerifyAsset,
+			errRegex:  `^signed by key 0807060504030201, not the public_key \(0807060504030202\)$`},
+		"invalid public key": {
+			publicKey: "RWQ",
+			signature: signature,
+			data:      testVerifyAsset,
+			errRegex:  "^invalid minisign public key$"},
+		"truncated signature": {
+			publicKey: publicKey,
+			signature: strings.Join(strings.Split(signature, "\n")[:2], "\n"),
+			data:      testVerifyAsset,
+			errRegex:  `^minisign: invalid signature$`},
+		"no trusted comment": {
+			publicKey: publicKey,
+			signature: strings.Replace(signature, "\ntrusted comment: ", "\ncomment: ", 1),
+			data:      testVerifyAsset,
+			errRegex:  `^minisign: invalid signature: invalid trusted comment$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN verifyMinisign is called
+			err := verifyMinisign(tc.publicKey, []byte(tc.signature), strings.NewReader(tc.data))
+
+			// THEN the signature is only verified when it's valid for the data
+			e := util.ErrorToString(err)
+			if !regexp.MustCompile(tc.errRegex).MatchString(e) {
+				t.Errorf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// verifyOpenPGP verifies the detached OpenPGP `signature` (armored or binary) of `data`
+// was made by a (valid, unexpired and unrevoked) key of the armored `publicKey` block.
+func verifyOpenPGP(publicKey string, signature []byte, data io.Reader) error {
+	keyring, err := parseOpenPGPKeys(publicKey)
+	if err != nil {
+		return err
+	}
+	if block, err := armor.Decode(bytes.NewReader(signature)); err == nil {
+		if signature, err = io.ReadAll(block.Body); err != nil {
+			return fmt.Errorf("invalid signature - %w", err)
+		}
+	}
+
+	_, err = openpgp.CheckDetachedSignature(keyring, data, bytes.NewReader(signature), nil)
+	if errors.Is(err, pgperrors.ErrUnknownIssuer) {
+		if issuer := openPGPIssuer(signature); issuer != 0 {
+			return fmt.Errorf("signed by key %016X, which isn't in the public_key",
+				issuer)
+		}
+	}
+	return err //nolint:wrapcheck
+}
+
+// parseOpenPGPKeys parses the armored public key block.
+func parseOpenPGPKeys(publicKey string) (openpgp.EntityList, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenPGP public key - %w", err)
+	}
+	return keyring, nil
+}
+
+// openPGPIssuer returns the ID of the key that made the first of the `signature` packets (0 if unknown).
+func openPGPIssuer(signature []byte) uint64 {
+	p, err := packet.NewReader(bytes.NewReader(signature)).Next()
+	if err != nil {
+		return 0
+	}
+	if sig, ok := p.(*packet.Signature); ok && sig.IssuerKeyId != nil {
+		return *sig.IssuerKeyId
+	}
+	return 0
+}
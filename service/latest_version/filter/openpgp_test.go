@@ -0,0 +1,369 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package filter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/release-argus/Argus/util"
+)
+
+// Signatures made with GnuPG of testVerifyChecksums (and testVerifyAsset).
+var (
+	testVerifyAsset     = "argus 1.2.3\n"
+	testVerifyChecksums = "11cc45ef8a05c4800f1354e09dda1537bcb0c13e42829e77571e8945e94f569a  argus-1.2.3.linux-amd64\n2d711642b726b04401627ca9fbac32f5c8530fb1903cc4db02258717921a4881  other-file\n"
+	testPGPKeyRSA       = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mQENBGrU0S8BCAC1ze1DNmk08Kni42XOwr4kz8dzJ6BbuAr7Wn8Ojk56AZWvghhB
+zLtsMyNMcMzdoGG69IS8mq8Xo5LTE6tqJQdP/fwk0fArudUhOTQx/LinslpUOp6W
+VGNAxFcwjyQpu4XEsLcrMGgX2OE9o/omrZNS55XVTUYv4bvaqXMHJ7Un/X9IB+PY
+yiEkubeA+lWvhgDEJk28DEiQTwqzoRkOkTzYe4Yep3u0Hbq52m/kb7hRtF/KWLg3
+7CAWqbI0VLdorlhUjHHVEOLDXt9vwYppyv7BEGUEjsZKgQ86GM9x17GcD+M3J8Ab
+M9a7T4mIIrFwoqIdIsSymWUZUd574EIK6xznABEBAAG0IEFyZ3VzIFRlc3QgUlNB
+IDxyc2FAZXhhbXBsZS5jb20+iQFOBBMBCgA4FiEERHrPZL7VtAeIHVxpMm0l5wsH
+0dcFAmrU0S8CGwMFCwkIBwIGFQoJCAsCBBYCAwECHgECF4AACgkQMm0l5wsH0dc5
+KAf/YZUnaiEbw+vwNabMxUY2Pri9CqclOq9y5JFDNQOGtiaUpSI7qkrff7PZzS3P
+ig/TKHCptrF6qZtn/tJ04cqKE/7nhGwpbmVWt//3qgsNshkCoqaqN5+vyDGDUpCM
+D+u04S9Iquab5FFGQXNSaMbbHzatm0nqV41vzOOWBl6mNs54QFNhtgzcg8kfPpwt
+tv53BDdUonNHjd2KscQFypjrChzCqwiYt92h7AHo/ga8Zijdo94RW9mKbor+gkcR
+Q/UrFOXLzL3TnENTLkoCPwT6Tc6qj3TRFm9+N+7+F1Fv0yJ7SfSHeqlXi+W26UAr
++lkBGJVgolr28rDegHgeb/QTXA==
+=CqKh
+-----END PGP PUBLIC KEY BLOCK-----`
+	testPGPKeyEd25519 = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatTRLxYJKwYBBAHaRw8BAQdAYp/YfWDPldPecwdmskq5wLi3HCTkpAerMH48
+Nphjs7m0I0FyZ3VzIFRlc3QgRWQyNTUxOSA8ZWRAZXhhbXBsZS5jb20+iJAEExYI
+ADgWIQRI6O+I10U5IzMFVEGSXSwtauHSqQUCatTRLwIbAwULCQgHAgYVCgkICwIE
+FgIDAQIeAQIXgAAKCRCSXSwtauHSqfWOAPsFRyQNvt6073k4M9iCNEGI9hxEHgsD
+nu9eo/2cx2tiPAD+JEyXK64VWNcgd/Jgn0H3KzTiAWrweohp3kcpqLYWtwu4MwRq
+1NEvFgkrBgEEAdpHDwEBB0BW/PCHLiBQq7k/SytiCPPmLtyPHtmutZdnQGbQzzeo
+JojvBBgWCAAgFiEESOjviNdFOSMzBVRBkl0sLWrh0qkFAmrU0S8CGwIAgQkQkl0s
+LWrh0ql2IAQZFggAHRYhBIAYli0l/dbz73opX7H0PoYZkdNOBQJq1NEvAAoJELH0
+PoYZkdNOZj0BAJG7t9RrPMMw5i3jyJZe5NfnRo6NZWGY5y/9jmlknOa6AQCCuPTn
+CkxEHYwxurvf/r6iQGkLCwMobzsQ46HMnDq3CFibAQC60wOmwzbdbpbqAeqHHBUs
+y03V/DP+wp844O0rfnpN8gD+LQQXS29PCP1UvKGwbNze9xPIPRgu4/cVCyLFOSRK
+JQU=
+=FaOR
+-----END PGP PUBLIC KEY BLOCK-----`
+	testPGPKeyP256 = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mFIEatTRLxMIKoZIzj0DAQcCAwQ1aEkj4uOgcV84NW6jERDeSx9cL4ygI4TWIl/H
+hVYHQmDOqZ/bC70J6GbcfYNbbhDFSv5QjULq3rCN3RaJYYeNtCJBcmd1cyBUZXN0
+IFAyNTYgPHAyNTZAZXhhbXBsZS5jb20+iJAEExMIADgWIQRwPmOSAnzcJ53SMKCR
+3v6d+Lt8awUCatTRLwIbAwULCQgHAgYVCgkICwIEFgIDAQIeAQIXgAAKCRCR3v6d
++Lt8a9NpAPwKyXim/rs7JzXXKMe5tWS1MQ9iL5JqbHcU6QhzCrpfPQEA3FPHhyAi
+6Ies29Ux+Rb/9f/hCeGTX8ZhlUKcDO+dMkA=
+=O4SI
+-----END PGP PUBLIC KEY BLOCK-----`
+	testPGPSigRSA = `-----BEGIN PGP SIGNATURE-----
+
+iQFEBAABCgAuFiEERHrPZL7VtAeIHVxpMm0l5wsH0dcFAmrU0ZgQHHJzYUBleGFt
+cGxlLmNvbQAKCRAybSXnCwfR19eBCACFCFZ/vkzp2+HSAZmd7mKjZ4wxIfyiHf7U
+u7N98vkjq3oDjxZkKumvdquXkQa4Up5HTnBVmCluNu1RFbx/ubedGtWjJ3uyqUOZ
+Yo6X7+m4gYa3rp60BAHu/aAYLEDr0hQnoNgoFmesjdGDrBzr/pHEcRKNxz1s1zyr
++jRlBSJWNj4BMrQflwm57thRzwEE897341+OrAT0/DZbldK52TZYPjNV7b2a3H8N
+S1k5n2h2lZ0ZSfBaD0ndcCt8W4yB6EY74WgxLY2GEnRU+QtMsl9cPVmzoaDqT1ex
+WQYTk/qaCYwu7JCMQy+0axLf9c1dPVEAo+T2Fuh9eEzdA42NDs9H
+=XGKX
+-----END PGP SIGNATURE-----`
+	testPGPSigEd25519 = `-----BEGIN PGP SIGNATURE-----
+
+iIUEABYIAC0WIQSAGJYtJf3W8+96KV+x9D6GGZHTTgUCatTRmA8cZWRAZXhhbXBs
+ZS5jb20ACgkQsfQ+hhmR004CLQD/RVE/zMa5k5SOpvvqpSQ4Y75rbhf5zWtHUPMa
+MKcpIE4BALwquCqWwVvftHyuGUXJxsAlKrA0brw1VR96D5ra2YAA
+=wMZz
+-----END PGP SIGNATURE-----`
+	testPGPSigP256 = `-----BEGIN PGP SIGNATURE-----
+
+iIcEABMIAC8WIQRwPmOSAnzcJ53SMKCR3v6d+Lt8awUCatTRmBEccDI1NkBleGFt
+cGxlLmNvbQAKCRCR3v6d+Lt8a6VcAP0RlGwXsqdQivCsMw0cxmp2g+x0GEpMLab9
+/hG4noUeIgEAze5w70p0frbtBJ+q1cxa4/Yy6j9O9Y2di8yUOd5P8wE=
+=qTM1
+-----END PGP SIGNATURE-----`
+	testPGPSigText = `-----BEGIN PGP SIGNATURE-----
+
+iIUEARYIAC0WIQSAGJYtJf3W8+96KV+x9D6GGZHTTgUCatTSdw8cZWRAZXhhbXBs
+ZS5jb20ACgkQsfQ+hhmR006CzgD+MLET7TtCiB4yyUoQbhQLjRWKA/UtqsFPhZiY
+bXYwOZEA/RaHg9do2dRclqeFvGYPoZ5m3B4r7JMhuoI4CdKaUcQJ
+=h9tT
+-----END PGP SIGNATURE-----` // --textmode of "line one\nline two\n"
+	testPGPSigRSAAsset = `-----BEGIN PGP SIGNATURE-----
+
+iQFEBAABCgAuFiEERHrPZL7VtAeIHVxpMm0l5wsH0dcFAmrU0ZgQHHJzYUBleGFt
+cGxlLmNvbQAKCRAybSXnCwfR1zEVCACvd04HtIf9JE4GKX0bwMIiFA1cet8qmdXm
+NApQG5VTovBz1Zl/z8j+0Vi7tjmloUTfNDHId2BxZKFfZ1K6sECYnYPT9nD/1M9f
+8x37hCWNZRL0NH1LlGvw5j6vT24t0dEeNGU3rwEL3HLK9+ewkbO/fMcuv+RQqk+X
+j/QHvaY+Ji++Y8FyyLYgkbSlSV/TGUHH4aF8FXY+pmwCK/RO91G2kgXGUssvpGg6
+yhqPiyh+uLZBhyj3Qr0VITsN+l3dQ45Jpv2ZsdYykEGt5Xo1AEB74CBudixvpjIG
+Awe6MkNBWAffTJm6gz5JSsbDQrEFB/5+NQAUpNgsitUEBNai3dgj
+=L9TZ
+-----END PGP SIGNATURE-----`
+)
+
+// testDearmor returns the binary of the armored `data`.
+func testDearmor(t *testing.T, data string) []byte {
+	lines := strings.Split(data, "\n")
+	// Skip the BEGIN line and blank line, and the checksum and END line.
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(lines[2:len(lines)-2], ""))
+	if err != nil {
+		t.Fatalf("failed to dearmor: %v",
+			err)
+	}
+	return decoded
+}
+
+// testArmor returns the armored `data` of `blockType`.
+func testArmor(t *testing.T, blockType string, data []byte) string {
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, blockType, nil)
+	if err != nil {
+		t.Fatalf("failed to armor: %v",
+			err)
+	}
+	w.Write(data)
+	w.Close()
+	return buf.String()
+}
+
+// testPGPKeyEd25519BadBinding returns testPGPKeyEd25519 (binary) with the signature binding its subkey corrupted.
+func testPGPKeyEd25519BadBinding(t *testing.T) []byte {
+	key := testDearmor(t, testPGPKeyEd25519)
+	// The binding signature is the last packet, ending with its signature MPIs.
+	key[len(key)-3] ^= 0xff
+	return key
+}
+
+// testPGPSignedKey is a generated public key and its detached signature of testVerifyChecksums.
+type testPGPSignedKey struct {
+	publicKey string
+	signature string
+}
+
+// testPGPSign generates a key with `config`, and returns it (revoked if `revoke`)
+// with its signature of testVerifyChecksums.
+func testPGPSign(t *testing.T, config *packet.Config, revoke bool) testPGPSignedKey {
+	entity, err := openpgp.NewEntity("Argus Test", "", "test@example.com", config)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v",
+			err)
+	}
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, entity, strings.NewReader(testVerifyChecksums), config); err != nil {
+		t.Fatalf("failed to sign: %v",
+			err)
+	}
+	if revoke {
+		if err := entity.RevokeKey(packet.KeyCompromised, "", config); err != nil {
+			t.Fatalf("failed to revoke the key: %v",
+				err)
+		}
+	}
+
+	var publicKey bytes.Buffer
+	if err := entity.Serialize(&publicKey); err != nil {
+		t.Fatalf("failed to serialize the key: %v",
+			err)
+	}
+	return testPGPSignedKey{
+		publicKey: testArmor(t, openpgp.PublicKeyType, publicKey.Bytes()),
+		signature: signature.String()}
+}
+
+func TestVerifyOpenPGP(t *testing.T) {
+	// GIVEN a public key, and a detached signature of some data
+	now := time.Now()
+	expiredKey := testPGPSign(t, &packet.Config{
+		Time:            func() time.Time { return now.Add(-2 * time.Hour) },
+		KeyLifetimeSecs: 3600}, false)
+	revokedKey := testPGPSign(t, nil, true)
+	expiredSignature := testPGPSign(t, &packet.Config{
+		Time:            func() time.Time { return now.Add(-2 * time.Hour) },
+		SigLifetimeSecs: 3600}, false)
+	tests := map[string]struct {
+		publicKey string
+		signature string
+		binary    bool
+		data      string
+		errRegex  string
+	}{
+		"RSA": {
+			publicKey: testPGPKeyRSA,
+			signature: testPGPSigRSA,
+			data:      testVerifyChecksums,
+			errRegex:  "^$"},
+		"RSA, binary signature": {
+			publicKey: testPGPKeyRSA,
+			signature: testPGPSigRSA,
+			binary:    true,
+			data:      testVerifyChecksums,
+			errRegex:  "^$"},
+		"Ed25519 subkey": {
+			publicKey: testPGPKeyEd25519,
+			signature: testPGPSigEd25519,
+			data:      testVerifyChecksums,
+			errRegex:  "^$"},
+		"ECDSA P-256": {
+			publicKey: testPGPKeyP256,
+			signature: testPGPSigP256,
+			data:      testVerifyChecksums,
+			errRegex:  "^$"},
+		"text signature": {
+			publicKey: testPGPKeyEd25519,
+			signature: testPGPSigText,
+			data:      "line one\nline two\n",
+			errRegex:  "^$"},
+		"text signature, CRLF line endings": {
+			publicKey: testPGPKeyEd25519,
+			signature: testPGPSigText,
+			data:      "line one\r\nline two\r\n",
+			errRegex:  "^$"},
+		"tampered data": {
+			publicKey: testPGPKeyRSA,
+			signature: testPGPSigRSA,
+			data:      testVerifyChecksums + "\n",
+			errRegex:  "^openpgp: invalid signature"},
+		"signature of other data": {
+			publicKey: testPGPKeyRSA,
+			signature: testPGPSigRSAAsset,
+			data:      testVerifyChecksums,
+			errRegex:  "^openpgp: invalid signature"},
+		"subkey binding signature invalid": {
+			publicKey: testArmor(t, openpgp.PublicKeyType, testPGPKeyEd25519BadBinding(t)),
+			signature: testPGPSigEd25519,
+			data:      testVerifyChecksums,
+			errRegex:  "^invalid OpenPGP public key - .*subkey signature invalid"},
+		"expired key": {
+			publicKey: expiredKey.publicKey,
+			signature: expiredKey.signature,
+			data:      testVerifyChecksums,
+			errRegex:  "^openpgp: key expired$"},
+		"revoked key": {
+			publicKey: revokedKey.publicKey,
+			signature: revokedKey.signature,
+			data:      testVerifyChecksums,
+			errRegex:  "^openpgp: signature made by revoked key$"},
+		"expired signature": {
+			publicKey: expiredSignature.publicKey,
+			signature: expiredSignature.signature,
+			data:      testVerifyChecksums,
+			errRegex:  "^openpgp: signature expired$"},
+		"signed by another key": {
+			publicKey: testPGPKeyRSA,
+			signature: testPGPSigEd25519,
+			data:      testVerifyChecksums,
+			errRegex:  "^signed by key B1F43E861991D34E, which isn't in the public_key$"},
+		"invalid public key": {
+			publicKey: "-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nabc\n-----END PGP PUBLIC KEY BLOCK-----",
+			signature: testPGPSigRSA,
+			data:      testVerifyChecksums,
+			errRegex:  "^invalid OpenPGP public key - "},
+		"not a signature": {
+			publicKey: testPGPKeyRSA,
+			signature: testPGPKeyRSA,
+			data:      testVerifyChecksums,
+			errRegex:  "non signature packet"},
+		"not OpenPGP": {
+			publicKey: testPGPKeyRSA,
+			signature: "untrusted comment: signature from minisign secret key",
+			data:      testVerifyChecksums,
+			errRegex:  "^openpgp: "},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			signature := []byte(tc.signature)
+			if tc.binary {
+				signature = testDearmor(t, tc.signature)
+			}
+
+			// WHEN verifyOpenPGP is called
+			err := verifyOpenPGP(tc.publicKey, signature, strings.NewReader(tc.data))
+
+			// THEN the signature is only verified when it's valid for the data
+			e := util.ErrorToString(err)
+			if !regexp.MustCompile(tc.errRegex).MatchString(e) {
+				t.Errorf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
+func TestParseOpenPGPKeys(t *testing.T) {
+	// GIVEN armored public key blocks
+	tests := map[string]struct {
+		publicKey string
+		wantIDs   []uint64
+		errRegex  string
+	}{
+		"RSA": {
+			publicKey: testPGPKeyRSA,
+			wantIDs:   []uint64{0x326D25E70B07D1D7},
+			errRegex:  "^$"},
+		"Ed25519 with a signing subkey": {
+			publicKey: testPGPKeyEd25519,
+			wantIDs:   []uint64{0x925D2C2D6AE1D2A9, 0xB1F43E861991D34E},
+			errRegex:  "^$"},
+		"not armored": {
+			publicKey: "RWQ...",
+			errRegex:  "^invalid OpenPGP public key - "},
+		"no END": {
+			publicKey: strings.Split(testPGPKeyRSA, "=")[0],
+			errRegex:  "^invalid OpenPGP public key - "},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN parseOpenPGPKeys is called
+			keys, err := parseOpenPGPKeys(tc.publicKey)
+
+			// THEN the key and its subkeys are returned
+			e := util.ErrorToString(err)
+			if !regexp.MustCompile(tc.errRegex).MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			var ids []uint64
+			for _, entity := range keys {
+				ids = append(ids, entity.PrimaryKey.KeyId)
+				for _, subkey := range entity.Subkeys {
+					ids = append(ids, subkey.PublicKey.KeyId)
+				}
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tc.wantIDs) {
+				t.Errorf("want key IDs %016X, got %016X",
+					tc.wantIDs, ids)
+			}
+		})
+	}
+}
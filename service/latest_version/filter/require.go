@@ -18,7 +18,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	command "github.com/release-argus/Argus/commands"
 	svcstatus "github.com/release-argus/Argus/service/status"
@@ -38,11 +40,16 @@ func LogInit(log *util.JLog) {
 
 // Require for version to be considered valid.
 type Require struct {
-	Status       *svcstatus.Status `yaml:"-" json:"-"`                                             // Service Status
-	RegexContent string            `yaml:"regex_content,omitempty" json:"regex_content,omitempty"` // "abc-[a-z]+-{{ version }}_amd64.deb" This regex must exist in the body of the URL to trigger new version actions
-	RegexVersion string            `yaml:"regex_version,omitempty" json:"regex_version,omitempty"` // "v*[0-9.]+" The version found must match this release to trigger new version actions
-	Command      command.Command   `yaml:"command,omitempty" json:"command,omitempty"`             // Require Command to pass
-	Docker       *DockerCheck      `yaml:"docker,omitempty" json:"docker,omitempty"`               // Docker image tag requirements
+	Status            *svcstatus.Status `yaml:"-" json:"-"`                                                       // Service Status
+	RegexContent      string            `yaml:"regex_content,omitempty" json:"regex_content,omitempty"`           // "abc-[a-z]+-{{ version }}_amd64.deb" This regex must exist in the body of the URL to trigger new version actions
+	RegexVersion      string            `yaml:"regex_version,omitempty" json:"regex_version,omitempty"`           // "v*[0-9.]+" The version found must match this release to trigger new version actions
+	VersionConstraint string            `yaml:"version_constraint,omitempty" json:"version_constraint,omitempty"` // ">=2.3 <3 || ^4" The version found must satisfy this semantic version range
+	Expression        string            `yaml:"expression,omitempty" json:"expression,omitempty"`                 // "!prerelease && assets.exists(a, a.name.endsWith('.deb'))" The release found must satisfy this expression
+	MinAge            string            `yaml:"min_age,omitempty" json:"min_age,omitempty"`                       // "48h" The version found must have been published (or first seen) this long ago to trigger new version actions
+	Command           command.Command   `yaml:"command,omitempty" json:"command,omitempty"`                       // Require Command to pass
+	Docker            *DockerCheck      `yaml:"docker,omitempty" json:"docker,omitempty"`                         // Docker image tag requirements
+	Verify            *VerifyCheck      `yaml:"verify,omitempty" json:"verify,omitempty"`                         // Asset checksum/signature requirements
+	expression        *Expression       // Expression compiled by CheckValues
 }
 
 // String returns a string representation of the Require.
@@ -63,6 +70,15 @@ func (r *Require) Init(status *svcstatus.Status) {
 	r.Status = status
 }
 
+// GetMinAge returns the MinAge as a duration (0 if there's none).
+func (r *Require) GetMinAge() time.Duration {
+	if r == nil {
+		return 0
+	}
+	d, _ := time.ParseDuration(r.MinAge)
+	return d
+}
+
 // Print the Require.
 func (r *Require) Print(prefix string) {
 	if r == nil {
@@ -73,10 +89,17 @@ func (r *Require) Print(prefix string) {
 		fmt.Sprintf("%s  regex_content: %q", prefix, r.RegexContent))
 	util.PrintlnIfNotDefault(r.RegexVersion,
 		fmt.Sprintf("%s  regex_version: %q", prefix, r.RegexVersion))
+	util.PrintlnIfNotDefault(r.VersionConstraint,
+		fmt.Sprintf("%s  version_constraint: %q", prefix, r.VersionConstraint))
+	util.PrintlnIfNotDefault(r.Expression,
+		fmt.Sprintf("%s  expression: %q", prefix, r.Expression))
+	util.PrintlnIfNotDefault(r.MinAge,
+		fmt.Sprintf("%s  min_age: %s", prefix, r.MinAge))
 	if len(r.Command) != 0 {
 		fmt.Printf("%s  command: %s\n", prefix, r.Command.FormattedString())
 	}
 	r.Docker.Print(prefix + "  ")
+	r.Verify.Print(prefix + "  ")
 }
 
 // CheckValues of the Require option.
@@ -108,6 +131,36 @@ func (r *Require) CheckValues(prefix string) (errs error) {
 		}
 	}
 
+	// Version Constraint
+	if r.VersionConstraint != "" {
+		if _, err := parseVersionConstraint(r.VersionConstraint); err != nil {
+			errs = fmt.Errorf("%s%s  version_constraint: %q <invalid> (%s)\\",
+				util.ErrorToString(errs), prefix, r.VersionConstraint, err)
+		}
+	}
+
+	// Expression
+	if r.Expression != "" {
+		expression, err := ParseExpression(r.Expression)
+		if err != nil {
+			errs = fmt.Errorf("%s%s  expression: %q <invalid> (%s)\\",
+				util.ErrorToString(errs), prefix, r.Expression, err)
+		}
+		r.expression = expression
+	}
+
+	// Min Age
+	if r.MinAge != "" {
+		// Default to seconds when an integer is provided
+		if _, err := strconv.Atoi(r.MinAge); err == nil {
+			r.MinAge += "s"
+		}
+		if minAge, err := time.ParseDuration(r.MinAge); err != nil || minAge < 0 {
+			errs = fmt.Errorf("%s%s  min_age: %q <invalid> (Use 'AhBmCs' duration format)\\",
+				util.ErrorToString(errs), prefix, r.MinAge)
+		}
+	}
+
 	for i := range r.Command {
 		if !util.CheckTemplate(r.Command[i]) {
 			errs = fmt.Errorf("%s%s  command: %v (%q) <invalid> (didn't pass templating)\\",
@@ -121,6 +174,11 @@ func (r *Require) CheckValues(prefix string) (errs error) {
 			util.ErrorToString(errs), prefix, err)
 	}
 
+	if err := r.Verify.CheckValues(prefix + "    "); err != nil {
+		errs = fmt.Errorf("%s%s  verify:\\%w",
+			util.ErrorToString(errs), prefix, err)
+	}
+
 	if errs != nil {
 		errs = fmt.Errorf("%srequire:\\%s",
 			prefix, util.ErrorToString(errs))
@@ -158,6 +216,15 @@ func RequireFromStr(jsonStr *string, previous *Require, logFrom *util.LogFrom) (
 		if !util.Contains(jsonKeys, "regex_version") {
 			require.RegexVersion = previous.RegexVersion
 		}
+		if !util.Contains(jsonKeys, "version_constraint") {
+			require.VersionConstraint = previous.VersionConstraint
+		}
+		if !util.Contains(jsonKeys, "expression") {
+			require.Expression = previous.Expression
+		}
+		if !util.Contains(jsonKeys, "min_age") {
+			require.MinAge = previous.MinAge
+		}
 
 		if !util.Contains(jsonKeys, "command") {
 			require.Command = previous.Command
@@ -188,6 +255,10 @@ func RequireFromStr(jsonStr *string, previous *Require, logFrom *util.LogFrom) (
 				require.Docker = previous.Docker
 			}
 		}
+
+		if !util.Contains(jsonKeys, "verify") {
+			require.Verify = previous.Verify
+		}
 	}
 	if require.Docker != nil {
 		// nil Docker if only Type
@@ -23,6 +23,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	command "github.com/release-argus/Argus/commands"
 	svcstatus "github.com/release-argus/Argus/service/status"
@@ -91,6 +92,21 @@ func TestRequire_Print(t *testing.T) {
 				RegexVersion: "version"},
 			lines: 2,
 		},
+		"only version_constraint": {
+			require: &Require{
+				VersionConstraint: "^1.2"},
+			lines: 2,
+		},
+		"only expression": {
+			require: &Require{
+				Expression: "!prerelease"},
+			lines: 2,
+		},
+		"only min_age": {
+			require: &Require{
+				MinAge: "48h"},
+			lines: 2,
+		},
 		"only command": {
 			require: &Require{
 				Command: []string{"bash", "update.sh"}},
@@ -101,6 +117,13 @@ func TestRequire_Print(t *testing.T) {
 				Docker: &DockerCheck{Type: "ghcr"}},
 			lines: 3,
 		},
+		"only verify": {
+			require: &Require{
+				Verify: &VerifyCheck{
+					Asset:     "argus",
+					Checksums: "SHA256SUMS"}},
+			lines: 4,
+		},
 		"full require": {
 			require: &Require{
 				RegexContent: "content",
@@ -134,6 +157,40 @@ func TestRequire_Print(t *testing.T) {
 	}
 }
 
+func TestRequire_GetMinAge(t *testing.T) {
+	// GIVEN a Require
+	tests := map[string]struct {
+		require *Require
+		want    time.Duration
+	}{
+		"nil require": {
+			require: nil,
+			want:    0},
+		"no min_age": {
+			require: &Require{},
+			want:    0},
+		"min_age": {
+			require: &Require{MinAge: "1h30m"},
+			want:    90 * time.Minute},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN GetMinAge is called on it
+			got := tc.require.GetMinAge()
+
+			// THEN the min_age is returned as a duration
+			if got != tc.want {
+				t.Errorf("want %s, got %s",
+					tc.want, got)
+			}
+		})
+	}
+}
+
 func TestRequire_CheckValues(t *testing.T) {
 	// GIVEN a Require
 	tests := map[string]struct {
@@ -179,6 +236,56 @@ func TestRequire_CheckValues(t *testing.T) {
 				`^require:$`,
 				`^  regex_version: .* <invalid>`},
 		},
+		"valid version_constraint": {
+			require: &Require{
+				VersionConstraint: ">=2.3 <3 || ^4.1"},
+			errRegex: []string{`^$`},
+		},
+		"invalid version_constraint": {
+			require: &Require{
+				VersionConstraint: ">=2.3.4.5"}, errRegex: []string{
+				`^require:$`,
+				`^  version_constraint: ">=2.3.4.5" <invalid> \(improper constraint: >=2\.3\.4\.5\)$`},
+		},
+		"valid expression": {
+			require: &Require{
+				Expression: `!prerelease && assets.exists(a, a.name.endsWith("linux-amd64.tar.gz"))`},
+			errRegex: []string{`^$`},
+		},
+		"invalid expression": {
+			require: &Require{
+				Expression: "!prerelease &&"}, errRegex: []string{
+				`^require:$`,
+				`^  expression: "!prerelease &&" <invalid> \(Syntax error: mismatched input '<EOF>' .* at 1:15\)$`},
+		},
+		"expression with an unknown variable": {
+			require: &Require{
+				Expression: "beta"}, errRegex: []string{
+				`^require:$`,
+				`^  expression: "beta" <invalid> \(undeclared reference to 'beta' .*at 1:1\)$`},
+		},
+		"valid min_age": {
+			require: &Require{
+				MinAge: "48h"},
+			errRegex: []string{`^$`},
+		},
+		"min_age of seconds": {
+			require: &Require{
+				MinAge: "3600"},
+			errRegex: []string{`^$`},
+		},
+		"invalid min_age": {
+			require: &Require{
+				MinAge: "2d"}, errRegex: []string{
+				`^require:$`,
+				`^  min_age: "2d" <invalid> \(Use 'AhBmCs' duration format\)$`},
+		},
+		"negative min_age": {
+			require: &Require{
+				MinAge: "-1h"}, errRegex: []string{
+				`^require:$`,
+				`^  min_age: "-1h" <invalid>`},
+		},
 		"valid command": {
 			require: &Require{
 				Command: []string{
@@ -209,6 +316,22 @@ func TestRequire_CheckValues(t *testing.T) {
 				`^  docker:$`,
 				`^    type: .* <invalid>`},
 		},
+		"valid verify": {
+			require: &Require{
+				Verify: &VerifyCheck{
+					Asset:     "argus-{{ version }}.linux-amd64",
+					Checksums: "SHA256SUMS"}},
+			errRegex: []string{`^$`},
+		},
+		"invalid verify": {
+			require: &Require{
+				Verify: &VerifyCheck{
+					Asset: "argus"}},
+			errRegex: []string{
+				`^require:$`,
+				`^  verify:$`,
+				`^    checksums: <required>`},
+		},
 		"all possible errors": {
 			require: &Require{
 				RegexContent: "[0-",
@@ -333,6 +456,78 @@ func TestRequire_FromStr(t *testing.T) {
 				RegexContent: "foo",
 				RegexVersion: "bar"},
 		},
+		"VersionConstraint from str, RegexVersion from default": {
+			jsonStr: stringPtr(`{
+"version_constraint": "^1"}`),
+			dflt: &Require{
+				RegexVersion:      "bar",
+				VersionConstraint: "~2"},
+			want: &Require{
+				RegexVersion:      "bar",
+				VersionConstraint: "^1"},
+		},
+		"VersionConstraint from default": {
+			jsonStr: stringPtr(`{
+"regex_version": "bar"}`),
+			dflt: &Require{
+				VersionConstraint: "~2"},
+			want: &Require{
+				RegexVersion:      "bar",
+				VersionConstraint: "~2"},
+		},
+		"Expression from str": {
+			jsonStr: stringPtr(`{
+"expression": "!draft"}`),
+			dflt: &Require{
+				RegexVersion: "bar",
+				Expression:   "!prerelease"},
+			want: &Require{
+				RegexVersion: "bar",
+				Expression:   "!draft"},
+		},
+		"Expression from default": {
+			jsonStr: stringPtr(`{
+"regex_version": "bar"}`),
+			dflt: &Require{
+				Expression: "!prerelease"},
+			want: &Require{
+				RegexVersion: "bar",
+				Expression:   "!prerelease"},
+		},
+		"MinAge from str": {
+			jsonStr: stringPtr(`{
+"min_age": "48h"}`),
+			dflt: &Require{
+				RegexVersion: "bar",
+				MinAge:       "1h"},
+			want: &Require{
+				RegexVersion: "bar",
+				MinAge:       "48h"},
+		},
+		"MinAge from default": {
+			jsonStr: stringPtr(`{
+"regex_version": "bar"}`),
+			dflt: &Require{
+				MinAge: "1h"},
+			want: &Require{
+				RegexVersion: "bar",
+				MinAge:       "1h"},
+		},
+		"invalid MinAge": {
+			jsonStr: stringPtr(`{
+"min_age": "soon"}`),
+			errRegex: "min_age: .* <invalid>",
+		},
+		"invalid Expression": {
+			jsonStr: stringPtr(`{
+"expression": "size(assets) >"}`),
+			errRegex: "expression: .* <invalid>",
+		},
+		"invalid VersionConstraint": {
+			jsonStr: stringPtr(`{
+"version_constraint": ">>1"}`),
+			errRegex: "version_constraint: .* <invalid>",
+		},
 		"Command defined": {
 			jsonStr: stringPtr(`{
 "command":[
@@ -527,6 +722,35 @@ func TestRequire_FromStr(t *testing.T) {
 					Image: "releaseargus/argus",
 					Tag:   "latest"}},
 		},
+		"Verify defined": {
+			jsonStr: stringPtr(`{
+"verify": {
+	"asset": "argus-{{ version }}",
+	"checksums": "SHA256SUMS"}}`),
+			want: &Require{
+				Verify: &VerifyCheck{
+					Asset:     "argus-{{ version }}",
+					Checksums: "SHA256SUMS"}},
+		},
+		"Verify from default": {
+			jsonStr: stringPtr(`{
+"regex_version": "foo"}`),
+			dflt: &Require{
+				Verify: &VerifyCheck{
+					Asset:     "argus",
+					Checksums: "SHA256SUMS"}},
+			want: &Require{
+				RegexVersion: "foo",
+				Verify: &VerifyCheck{
+					Asset:     "argus",
+					Checksums: "SHA256SUMS"}},
+		},
+		"Invalid Verify (no checksums/signature)": {
+			jsonStr: stringPtr(`{
+"verify": {
+	"asset": "argus"}}`),
+			errRegex: `verify:.*checksums: <required>`,
+		},
 		"Invalid Docker (no tag)": {
 			jsonStr: stringPtr(`{
 "docker": {
@@ -564,6 +788,15 @@ func TestRequire_FromStr(t *testing.T) {
 			}
 			// AND got is expected
 			if !tc.pointerToDefault {
+				// (with its Expression compiled)
+				if got != nil {
+					if got.Expression != "" &&
+						(got.expression == nil || got.expression.source != got.Expression) {
+						t.Errorf("want the Expression %q compiled, got %v",
+							got.Expression, got.expression)
+					}
+					got.expression = nil
+				}
 				if !reflect.DeepEqual(got, tc.want) {
 					if tc.want.Docker == nil || got.Docker == nil {
 						t.Errorf("\nwant: %v\ngot:  %v",
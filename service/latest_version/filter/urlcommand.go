@@ -20,6 +20,8 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/xpath"
 	"github.com/release-argus/Argus/util"
 	"gopkg.in/yaml.v3"
 )
@@ -39,12 +41,17 @@ func (s *URLCommandSlice) String() string {
 
 // URLCommand is a command to be ran to filter version from the URL body.
 type URLCommand struct {
-	Type  string  `yaml:"type" json:"type"`                       // regex/replace/split
-	Regex *string `yaml:"regex,omitempty" json:"regex,omitempty"` // regex: regexp.MustCompile(Regex)
-	Index int     `yaml:"index,omitempty" json:"index,omitempty"` // regex/split: re.FindAllString(URL_content, -1)[Index]  /  strings.Split("text")[Index]
-	Text  *string `yaml:"text,omitempty" json:"text,omitempty"`   // split: strings.Split(tgtString, "Text")
-	New   *string `yaml:"new,omitempty" json:"new,omitempty"`     // replace: strings.ReplaceAll(tgtString, "Old", "New")
-	Old   *string `yaml:"old,omitempty" json:"old,omitempty"`     // replace: strings.ReplaceAll(tgtString, "Old", "New")
+	Type       string  `yaml:"type" json:"type"`                                   // regex/replace/split/jsonpath/css/xpath
+	Regex      *string `yaml:"regex,omitempty" json:"regex,omitempty"`             // regex: regexp.MustCompile(Regex)
+	Template   *string `yaml:"template,omitempty" json:"template,omitempty"`       // regex: "{{ major }}.{{ minor }}-{{ build }}", rendered with the named capture groups of the match
+	Path       *string `yaml:"path,omitempty" json:"path,omitempty"`               // jsonpath: "$.releases[?(@.draft == false)].version", xpath: "//a[contains(@href, '.tar.gz')]/@href"
+	Selector   *string `yaml:"selector,omitempty" json:"selector,omitempty"`       // css: "div.release > h2"
+	Attribute  *string `yaml:"attribute,omitempty" json:"attribute,omitempty"`     // css/xpath: attribute of the selected elements to use (default their text)
+	Index      int     `yaml:"index,omitempty" json:"index,omitempty"`             // regex/split/jsonpath/css/xpath: re.FindAllString(URL_content, -1)[Index]  /  strings.Split("text")[Index]  /  matches(URL_content)[Index]
+	AllMatches bool    `yaml:"all_matches,omitempty" json:"all_matches,omitempty"` // regex/jsonpath/css/xpath: every match is a candidate version (type:url), rather than the one at Index
+	Text       *string `yaml:"text,omitempty" json:"text,omitempty"`               // split: strings.Split(tgtString, "Text")
+	New        *string `yaml:"new,omitempty" json:"new,omitempty"`                 // replace: strings.ReplaceAll(tgtString, "Old", "New")
+	Old        *string `yaml:"old,omitempty" json:"old,omitempty"`                 // replace: strings.ReplaceAll(tgtString, "Old", "New")
 }
 
 // String returns a string representation of the URLCommand.
@@ -98,8 +105,26 @@ func (c *URLCommand) Print(prefix string) {
 	switch c.Type {
 	case "regex":
 		fmt.Printf("%s  regex: %q\n", prefix, *c.Regex)
+		if c.Template != nil {
+			fmt.Printf("%s  template: %q\n", prefix, *c.Template)
+		}
+		util.PrintlnIfNotDefault(c.Index,
+			fmt.Sprintf("%s  index: %d", prefix, c.Index))
+		util.PrintlnIfNotDefault(c.AllMatches,
+			fmt.Sprintf("%s  all_matches: %t", prefix, c.AllMatches))
+	case "jsonpath", "xpath", "css":
+		if c.Type == "css" {
+			fmt.Printf("%s  selector: %q\n", prefix, *c.Selector)
+		} else {
+			fmt.Printf("%s  path: %q\n", prefix, *c.Path)
+		}
+		if c.Attribute != nil {
+			fmt.Printf("%s  attribute: %q\n", prefix, *c.Attribute)
+		}
 		util.PrintlnIfNotDefault(c.Index,
 			fmt.Sprintf("%s  index: %d", prefix, c.Index))
+		util.PrintlnIfNotDefault(c.AllMatches,
+			fmt.Sprintf("%s  all_matches: %t", prefix, c.AllMatches))
 	case "replace":
 		fmt.Printf("%s  new: %q\n", prefix, *c.New)
 		fmt.Printf("%s  old: %q\n", prefix, *c.Old)
@@ -127,6 +152,69 @@ func (s *URLCommandSlice) Run(text string, logFrom util.LogFrom) (string, error)
 	return text, nil
 }
 
+// HasAllMatches returns whether any of the URLCommand(s) in this URLCommandSlice give every match.
+func (s *URLCommandSlice) HasAllMatches() bool {
+	if s == nil {
+		return false
+	}
+
+	for commandIndex := range *s {
+		if (*s)[commandIndex].AllMatches {
+			return true
+		}
+	}
+	return false
+}
+
+// RunAll of the URLCommand(s) in this URLCommandSlice, returning every candidate version.
+//
+// A regex/jsonpath/css/xpath with all_matches gives a candidate for each of its matches, that the following
+// URLCommand(s) are ran on separately (dropping the candidates they fail on).
+func (s *URLCommandSlice) RunAll(text string, logFrom util.LogFrom) ([]string, error) {
+	if s == nil {
+		return []string{text}, nil
+	}
+
+	logFrom.Secondary = "url_commands"
+	texts := []string{text}
+	var err error
+	for commandIndex := range *s {
+		command := &(*s)[commandIndex]
+		var nextTexts []string
+		for _, text := range texts {
+			var results []string
+			if command.AllMatches {
+				results, err = command.runAll(text, &logFrom)
+			} else {
+				var result string
+				result, err = command.run(text, &logFrom)
+				results = []string{result}
+			}
+			// Drop this candidate.
+			if err != nil {
+				continue
+			}
+			nextTexts = append(nextTexts, results...)
+		}
+		// No candidates left.
+		if len(nextTexts) == 0 {
+			return nil, err
+		}
+		texts = nextTexts
+	}
+
+	// Remove duplicate candidates, keeping the first.
+	candidates := make([]string, 0, len(texts))
+	seen := make(map[string]bool, len(texts))
+	for _, text := range texts {
+		if !seen[text] {
+			seen[text] = true
+			candidates = append(candidates, text)
+		}
+	}
+	return candidates, nil
+}
+
 // run this URLCommand on `text`
 func (c *URLCommand) run(text string, logFrom *util.LogFrom) (string, error) {
 	var err error
@@ -149,6 +237,9 @@ func (c *URLCommand) run(text string, logFrom *util.LogFrom) (string, error) {
 	case "regex":
 		msg = fmt.Sprintf("Regexing %q", *c.Regex)
 		text, err = c.regex(text, logFrom)
+	case "jsonpath", "css", "xpath":
+		msg = fmt.Sprintf("Selecting %s %q with index %d", c.Type, c.expression(), c.Index)
+		text, err = c.selectMatch(text, logFrom)
 	}
 	if err != nil {
 		return textBak, err
@@ -192,7 +283,196 @@ func (c *URLCommand) regex(text string, logFrom *util.LogFrom) (string, error) {
 		return text, err
 	}
 
-	return texts[index][len(texts[index])-1], nil
+	result, err := c.regexResult(re, texts[index])
+	if err != nil {
+		jLog.Warn(err, *logFrom, true)
+
+		return text, err
+	}
+	return result, nil
+}
+
+// regexResult returns the URLCommand's template rendered with the named groups of the `match`,
+// or the last group of the `match` if there's no template.
+func (c *URLCommand) regexResult(re *regexp.Regexp, match []string) (string, error) {
+	if c.Template == nil {
+		return match[len(match)-1], nil
+	}
+
+	text, err := util.TemplateRegexGroups(*c.Template, re, match)
+	if err != nil {
+		return "", fmt.Errorf("%s template %q failed: %w",
+			c.Type, *c.Template, err)
+	}
+	return text, nil
+}
+
+// runAll returns every match of this (all_matches) URLCommand on `text`.
+func (c *URLCommand) runAll(text string, logFrom *util.LogFrom) ([]string, error) {
+	if c.Type == "regex" {
+		return c.regexAll(text, logFrom)
+	}
+	return c.selectAll(text, logFrom)
+}
+
+// regexAll returns every match of the URLCommand's regex in `text`.
+func (c *URLCommand) regexAll(text string, logFrom *util.LogFrom) ([]string, error) {
+	re := regexp.MustCompile(*c.Regex)
+
+	matches := re.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		err := fmt.Errorf("%s %q didn't return any matches",
+			c.Type, *c.Regex)
+		if len(text) < 20 {
+			err = fmt.Errorf("%w on %q",
+				err, text)
+		}
+		jLog.Warn(err, *logFrom, true)
+
+		return nil, err
+	}
+
+	texts := make([]string, len(matches))
+	for i, match := range matches {
+		var err error
+		if texts[i], err = c.regexResult(re, match); err != nil {
+			jLog.Warn(err, *logFrom, true)
+			return nil, err
+		}
+	}
+	if jLog.IsLevel("DEBUG") {
+		jLog.Debug(
+			fmt.Sprintf("Regexing %q for all matches\nResolved to %q", *c.Regex, texts),
+			*logFrom, true)
+	}
+	return texts, nil
+}
+
+// expression returns the path/selector of this jsonpath/css/xpath URLCommand.
+func (c *URLCommand) expression() string {
+	if c.Type == "css" {
+		return util.DefaultIfNil(c.Selector)
+	}
+	return util.DefaultIfNil(c.Path)
+}
+
+// selectMatches returns every match of this jsonpath/css/xpath URLCommand on `text`.
+func (c *URLCommand) selectMatches(text string, logFrom *util.LogFrom) (texts []string, err error) {
+	if c.Type == "jsonpath" {
+		texts, err = c.jsonPathMatches(text)
+	} else {
+		texts, err = c.markupMatches(text)
+	}
+	if err == nil && len(texts) == 0 {
+		err = fmt.Errorf("%s %q didn't return any matches",
+			c.Type, c.expression())
+	}
+	if err != nil {
+		jLog.Warn(err, *logFrom, true)
+		return nil, err
+	}
+	return texts, nil
+}
+
+// jsonPathMatches returns the values at the URLCommand's path in the JSON `text`.
+func (c *URLCommand) jsonPathMatches(text string) ([]string, error) {
+	path, err := compileJSONPath(*c.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q is invalid: %w",
+			c.Type, *c.Path, err)
+	}
+
+	document, err := decodeJSON(text)
+	if err != nil {
+		err = fmt.Errorf("%s %q failed as the text isn't JSON: %w",
+			c.Type, *c.Path, err)
+		if len(text) < 20 {
+			err = fmt.Errorf("%w (%q)",
+				err, text)
+		}
+		return nil, err
+	}
+
+	nodes, err := path.Find(document)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q failed: %w",
+			c.Type, *c.Path, err)
+	}
+	texts := make([]string, len(nodes))
+	for i, node := range nodes {
+		texts[i] = jsonValueString(node)
+	}
+	return texts, nil
+}
+
+// markupMatches returns the text (or attribute) of the nodes selected by the URLCommand's
+// css selector/xpath in the HTML/XML `text`.
+func (c *URLCommand) markupMatches(text string) ([]string, error) {
+	var (
+		selector cascadia.SelectorGroup
+		path     *xpath.Expr
+		err      error
+	)
+	if c.Type == "css" {
+		selector, err = compileCSSSelector(*c.Selector)
+	} else {
+		path, err = compileXPath(*c.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s %q is invalid: %w",
+			c.Type, c.expression(), err)
+	}
+
+	document, err := parseMarkup(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q failed as the text couldn't be parsed: %w",
+			c.Type, c.expression(), err)
+	}
+
+	attribute := util.DefaultIfNil(c.Attribute)
+	if c.Type == "css" {
+		return document.cssMatches(selector, attribute), nil
+	}
+	return document.xpathMatches(path, attribute), nil
+}
+
+// selectMatch `text` with the URLCommand's path/selector and return the index specified.
+func (c *URLCommand) selectMatch(text string, logFrom *util.LogFrom) (string, error) {
+	texts, err := c.selectMatches(text, logFrom)
+	if err != nil {
+		return text, err
+	}
+
+	index := c.Index
+	// Handle negative indices.
+	if index < 0 {
+		index = len(texts) + index
+	}
+
+	if index < 0 || (len(texts)-index) < 1 {
+		err := fmt.Errorf("%s (%s) returned %d elements but the index wants element number %d",
+			c.Type, c.expression(), len(texts), (index + 1))
+		jLog.Warn(err, *logFrom, true)
+
+		return text, err
+	}
+
+	return texts[index], nil
+}
+
+// selectAll returns every match of the URLCommand's path/selector on `text`.
+func (c *URLCommand) selectAll(text string, logFrom *util.LogFrom) ([]string, error) {
+	texts, err := c.selectMatches(text, logFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	if jLog.IsLevel("DEBUG") {
+		jLog.Debug(
+			fmt.Sprintf("Selecting %s %q for all matches\nResolved to %q", c.Type, c.expression(), texts),
+			*logFrom, true)
+	}
+	return texts, nil
 }
 
 // split `text` with the URLCommand's text amd return the index specified.
@@ -258,9 +538,48 @@ func (c *URLCommand) CheckValues(prefix string) (errs error) {
 			if err != nil {
 				errs = fmt.Errorf("%s%sregex: %q <invalid> (Invalid RegEx)\\",
 					util.ErrorToString(errs), prefix, *c.Regex)
+			} else if c.Template != nil && !util.RegexHasNamedGroups(*c.Regex) {
+				errs = fmt.Errorf("%s%sregex: %q <invalid> (template requires named capture groups, e.g. (?P<major>[0-9]+))\\",
+					util.ErrorToString(errs), prefix, *c.Regex)
 			}
 		}
+		if c.Template != nil && !util.CheckTemplate(*c.Template) {
+			errs = fmt.Errorf("%s%stemplate: %q <invalid> (didn't pass templating)\\",
+				util.ErrorToString(errs), prefix, *c.Template)
+		}
+	case "jsonpath":
+		if c.Attribute != nil {
+			errs = fmt.Errorf("%s%sattribute: <invalid> (only for the css/xpath types)\\",
+				util.ErrorToString(errs), prefix)
+		}
+		if c.Path == nil {
+			errs = fmt.Errorf("%s%spath: <required> (JSONPath to the version, e.g. '$.tag_name')\\",
+				util.ErrorToString(errs), prefix)
+		} else if _, err := compileJSONPath(*c.Path); err != nil {
+			errs = fmt.Errorf("%s%spath: %q <invalid> (%s)\\",
+				util.ErrorToString(errs), prefix, *c.Path, err)
+		}
+	case "css":
+		if c.Selector == nil {
+			errs = fmt.Errorf("%s%sselector: <required> (CSS selector of the element, e.g. 'span.version')\\",
+				util.ErrorToString(errs), prefix)
+		} else if _, err := compileCSSSelector(*c.Selector); err != nil {
+			errs = fmt.Errorf("%s%sselector: %q <invalid> (%s)\\",
+				util.ErrorToString(errs), prefix, *c.Selector, err)
+		}
+	case "xpath":
+		if c.Path == nil {
+			errs = fmt.Errorf("%s%spath: <required> (XPath of the node, e.g. '//span[@class=\"version\"]')\\",
+				util.ErrorToString(errs), prefix)
+		} else if _, err := compileXPath(*c.Path); err != nil {
+			errs = fmt.Errorf("%s%spath: %q <invalid> (%s)\\",
+				util.ErrorToString(errs), prefix, *c.Path, err)
+		}
 	case "replace":
+		if c.AllMatches {
+			errs = fmt.Errorf("%s%sall_matches: <invalid> (only for the regex/jsonpath/css/xpath types)\\",
+				util.ErrorToString(errs), prefix)
+		}
 		if c.New == nil {
 			errs = fmt.Errorf("%s%snew: <required> (text you want to replace with)\\",
 				util.ErrorToString(errs), prefix)
@@ -270,16 +589,25 @@ func (c *URLCommand) CheckValues(prefix string) (errs error) {
 				util.ErrorToString(errs), prefix)
 		}
 	case "split":
+		if c.AllMatches {
+			errs = fmt.Errorf("%s%sall_matches: <invalid> (only for the regex/jsonpath/css/xpath types)\\",
+				util.ErrorToString(errs), prefix)
+		}
 		if c.Text == nil {
 			errs = fmt.Errorf("%s%stext: <required> (text to split on)\\",
 				util.ErrorToString(errs), prefix)
 		}
 	default:
 		validType = false
-		errs = fmt.Errorf("%s%stype: %q <invalid> is not a valid url_command (regex/replace/split)\\",
+		errs = fmt.Errorf("%s%stype: %q <invalid> is not a valid url_command (regex/replace/split/jsonpath/css/xpath)\\",
 			util.ErrorToString(errs), prefix, c.Type)
 	}
 
+	if validType && c.Type != "regex" && c.Template != nil {
+		errs = fmt.Errorf("%s%stemplate: <invalid> (only for the regex type)\\",
+			util.ErrorToString(errs), prefix)
+	}
+
 	if errs != nil && validType {
 		errs = fmt.Errorf("%stype: %s\\%w",
 			prefix, c.Type, errs)
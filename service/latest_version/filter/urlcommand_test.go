@@ -207,12 +207,25 @@ func TestURLCommandSlice_Print(t *testing.T) {
 		"regex": {
 			slice: &URLCommandSlice{testURLCommandRegex()},
 			lines: 3},
+		"regex with template": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr("(?P<major>[0-9]+)"), Template: stringPtr("{{ major }}.0.0")}},
+			lines: 4},
 		"replace": {
 			slice: &URLCommandSlice{testURLCommandReplace()},
 			lines: 4},
 		"split": {
 			slice: &URLCommandSlice{testURLCommandSplit()},
 			lines: 4},
+		"jsonpath": {
+			slice: &URLCommandSlice{testURLCommandJSONPath()},
+			lines: 4},
+		"css": {
+			slice: &URLCommandSlice{testURLCommandCSS()},
+			lines: 4},
+		"xpath": {
+			slice: &URLCommandSlice{testURLCommandXPath()},
+			lines: 3},
 		"all types": {
 			slice: &URLCommandSlice{
 				testURLCommandRegex(),
@@ -273,6 +286,20 @@ func TestURLCommandSlice_Run(t *testing.T) {
 			errRegex: "^$",
 			want:     "def",
 		},
+		"regex with template": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr("(?P<name>[a-z]+)(?P<number>[0-9]+)"), Index: 1,
+					Template: stringPtr("{{ number }}-{{ name }}")}},
+			errRegex: "^$",
+			want:     "456-def",
+		},
+		"regex with template across the text": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr("(?P<major>[0-9])[0-9]+-.*(?P<minor>[0-9])$"),
+					Template: stringPtr("{{ major }}.{{ minor }}.0")}},
+			errRegex: "^$",
+			want:     "1.6.0",
+		},
 		"regex doesn't match (gives text that didn't match)": {
 			slice: &URLCommandSlice{
 				{Type: "regex", Regex: stringPtr("([h-z]+)[0-9]+"), Index: 1}},
@@ -322,6 +349,47 @@ func TestURLCommandSlice_Run(t *testing.T) {
 			errRegex: `split .* returned \d elements but the index wants element number \d`,
 			want:     testText,
 		},
+		"jsonpath": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$.releases[?(@.draft == false)].version")}},
+			text:     `{"releases": [{"version": "1.3.0", "draft": true}, {"version": "1.2.0", "draft": false}]}`,
+			errRegex: "^$",
+			want:     "1.2.0",
+		},
+		"jsonpath with negative index": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$[*].tag_name"), Index: -1}},
+			text:     `[{"tag_name": "v1.3.0"}, {"tag_name": "v1.2.0"}]`,
+			errRegex: "^$",
+			want:     "v1.2.0",
+		},
+		"jsonpath of a number": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$.version")}},
+			text:     `{"version": 1.10}`,
+			errRegex: "^$",
+			want:     "1.10",
+		},
+		"jsonpath on text that isn't JSON": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$.version")}},
+			errRegex: `jsonpath .* failed as the text isn't JSON: invalid character .*` + testText,
+			want:     testText,
+		},
+		"jsonpath doesn't match": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$.version")}},
+			text:     `{"tag_name": "v1.2.0"}`,
+			errRegex: "jsonpath .* didn't return any matches$",
+			want:     `{"tag_name": "v1.2.0"}`,
+		},
+		"jsonpath index out of bounds": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$[*]"), Index: 2}},
+			text:     `["1.2.0", "1.3.0"]`,
+			errRegex: `jsonpath .* returned \d elements but the index wants element number \d`,
+			want:     `["1.2.0", "1.3.0"]`,
+		},
 		"all types": {
 			slice: &URLCommandSlice{
 				{Type: "regex", Regex: stringPtr("([a-z]+)[0-9]+"), Index: 1},
@@ -330,6 +398,106 @@ func TestURLCommandSlice_Run(t *testing.T) {
 			errRegex: "^$",
 			want:     "f",
 		},
+		"css": {
+			slice: &URLCommandSlice{
+				{Type: "css", Selector: stringPtr("ul.versions li:not(.beta) span")}},
+			text:     testMarkupHTML,
+			errRegex: "^$",
+			want:     "1.1.0",
+		},
+		"css attribute with negative index": {
+			slice: &URLCommandSlice{
+				{Type: "css", Selector: stringPtr("a.download"), Attribute: stringPtr("href"), Index: -1}},
+			text:     testMarkupHTML,
+			errRegex: "^$",
+			want:     "/files/app-1.2.0.zip",
+		},
+		"css attribute skips elements without it": {
+			slice: &URLCommandSlice{
+				{Type: "css", Selector: stringPtr("div, a"), Attribute: stringPtr("href")}},
+			text:     testMarkupHTML,
+			errRegex: "^$",
+			want:     "/files/app-1.2.0.tar.gz",
+		},
+		"css doesn't match": {
+			slice: &URLCommandSlice{
+				{Type: "css", Selector: stringPtr("section")}},
+			text:     testMarkupHTML,
+			errRegex: `css "section" didn't return any matches$`,
+			want:     testMarkupHTML,
+		},
+		"css attribute doesn't match": {
+			slice: &URLCommandSlice{
+				{Type: "css", Selector: stringPtr("span"), Attribute: stringPtr("href")}},
+			text:     testMarkupHTML,
+			errRegex: `css "span" didn't return any matches$`,
+			want:     testMarkupHTML,
+		},
+		"css index out of bounds": {
+			slice: &URLCommandSlice{
+				{Type: "css", Selector: stringPtr("a"), Index: 2}},
+			text:     testMarkupHTML,
+			errRegex: `css \(a\) returned 2 elements but the index wants element number 3`,
+			want:     testMarkupHTML,
+		},
+		"xpath": {
+			slice: &URLCommandSlice{
+				{Type: "xpath", Path: stringPtr("//table//tr[2]/td[1]")}},
+			text:     testMarkupHTML,
+			errRegex: "^$",
+			want:     "1.2.0",
+		},
+		"xpath attribute": {
+			slice: &URLCommandSlice{
+				{Type: "xpath", Path: stringPtr("//meta[@name='version']"), Attribute: stringPtr("content")}},
+			text:     testMarkupHTML,
+			errRegex: "^$",
+			want:     "1.2.0",
+		},
+		"xpath value": {
+			slice: &URLCommandSlice{
+				{Type: "xpath", Path: stringPtr("substring-after(//a[1]/@href, 'app-')")}},
+			text:     testMarkupHTML,
+			errRegex: "^$",
+			want:     "1.2.0.tar.gz",
+		},
+		"xpath on xml": {
+			slice: &URLCommandSlice{
+				{Type: "xpath", Path: stringPtr("//entry/app:version[@stable='true']"), Index: -1}},
+			text:     testMarkupXML,
+			errRegex: "^$",
+			want:     "1.1.0",
+		},
+		"xpath doesn't match": {
+			slice: &URLCommandSlice{
+				{Type: "xpath", Path: stringPtr("//section")}},
+			text:     testMarkupHTML,
+			errRegex: `xpath "//section" didn't return any matches$`,
+			want:     testMarkupHTML,
+		},
+		"xpath on xml that can't be parsed": {
+			slice: &URLCommandSlice{
+				{Type: "xpath", Path: stringPtr("//a")}},
+			text:     `<?xml version="1.0"?>`,
+			errRegex: `xpath "//a" failed as the text couldn't be parsed: .*invalid XML document`,
+			want:     `<?xml version="1.0"?>`,
+		},
+		"css then regex": {
+			slice: &URLCommandSlice{
+				{Type: "css", Selector: stringPtr("#latest h2")},
+				{Type: "regex", Regex: stringPtr(`([0-9.]+)$`)}},
+			text:     testMarkupHTML,
+			errRegex: "^$",
+			want:     "1.2.0",
+		},
+		"jsonpath then regex": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$.assets[0].name")},
+				{Type: "regex", Regex: stringPtr(`-([0-9.]+)\.tar`)}},
+			text:     `{"assets": [{"name": "argus-0.11.2.tar.gz"}]}`,
+			errRegex: "^$",
+			want:     "0.11.2",
+		},
 	}
 
 	for name, tc := range tests {
@@ -358,6 +526,110 @@ func TestURLCommandSlice_Run(t *testing.T) {
 	}
 }
 
+func TestURLCommandSlice_RunAll(t *testing.T) {
+	// GIVEN a URLCommandSlice
+	testLogging("WARN")
+	testText := "v1.2.0 v1.10.0 v1.3.0 v1.10.0 - latest v1.10.0"
+	tests := map[string]struct {
+		slice    *URLCommandSlice
+		text     string
+		want     []string
+		errRegex string
+	}{
+		"nil slice": {
+			slice:    nil,
+			want:     []string{testText},
+			errRegex: "^$"},
+		"no all_matches gives a single candidate": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`v([0-9.]+)`), Index: -1}},
+			want:     []string{"1.10.0"},
+			errRegex: "^$"},
+		"all_matches gives every match, once": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`v([0-9.]+)`), AllMatches: true}},
+			want:     []string{"1.2.0", "1.10.0", "1.3.0"},
+			errRegex: "^$"},
+		"commands after all_matches run on each candidate": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`v[0-9.]+`), AllMatches: true},
+				{Type: "replace", Old: stringPtr("v"), New: stringPtr("")}},
+			want:     []string{"1.2.0", "1.10.0", "1.3.0"},
+			errRegex: "^$"},
+		"candidates that fail a later command are dropped": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`v([0-9.]+)`), AllMatches: true},
+				{Type: "regex", Regex: stringPtr(`^1\.[0-9]\.[0-9]+$`)}},
+			want:     []string{"1.2.0", "1.3.0"},
+			errRegex: "^$"},
+		"all_matches with template renders each match": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`v(?P<major>[0-9]+)\.(?P<minor>[0-9]+)`), AllMatches: true,
+					Template: stringPtr("{{ major }}-{{ minor }}")}},
+			want:     []string{"1-2", "1-10", "1-3"},
+			errRegex: "^$"},
+		"jsonpath all_matches gives every value": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$[*].tag_name"), AllMatches: true},
+				{Type: "replace", Old: stringPtr("v"), New: stringPtr("")}},
+			text:     `[{"tag_name": "v1.2.0"}, {"tag_name": "v1.10.0"}, {"tag_name": "v1.2.0"}]`,
+			want:     []string{"1.2.0", "1.10.0"},
+			errRegex: "^$"},
+		"css all_matches gives every element": {
+			slice: &URLCommandSlice{
+				{Type: "css", Selector: stringPtr(".version"), AllMatches: true}},
+			text:     testMarkupHTML,
+			want:     []string{"1.2.0", "1.3.0-beta.1", "1.1.0", "1.0.0"},
+			errRegex: "^$"},
+		"xpath all_matches gives every node": {
+			slice: &URLCommandSlice{
+				{Type: "xpath", Path: stringPtr("//tr/td[1]"), AllMatches: true}},
+			text:     testMarkupHTML,
+			want:     []string{"1.2.0", "1.1.0"},
+			errRegex: "^$"},
+		"jsonpath all_matches on text that isn't JSON": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$[*]"), AllMatches: true}},
+			errRegex: "isn't JSON"},
+		"no matches": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`x([0-9.]+)`), AllMatches: true}},
+			errRegex: "regex .* didn't return any matches$"},
+		"every candidate fails a later command": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`v([0-9.]+)`), AllMatches: true},
+				{Type: "split", Text: stringPtr("-")}},
+			errRegex: "split didn't find any .* to split on"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN RunAll is called on it
+			text := testText
+			if tc.text != "" {
+				text = tc.text
+			}
+			got, err := tc.slice.RunAll(text, util.LogFrom{})
+
+			// THEN the expected candidates were returned
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want %q, got %q",
+					tc.want, got)
+			}
+			// AND any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
 func TestURLCommand_String(t *testing.T) {
 	// GIVEN a URLCommand
 	regex := testURLCommandRegex()
@@ -456,6 +728,81 @@ func TestURLCommandSlice_CheckValues(t *testing.T) {
 				{Type: "split"}},
 			errRegex: []string{`^    text: <required>`},
 		},
+		"valid jsonpath": {
+			slice: &URLCommandSlice{
+				testURLCommandJSONPath()},
+			errRegex: []string{`^$`},
+		},
+		"undefined jsonpath path": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath"}},
+			errRegex: []string{`^  item_0:$`, `^    type: jsonpath$`, `^    path: <required>`},
+		},
+		"invalid jsonpath path": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$.releases[0")}},
+			errRegex: []string{`^    path: "\$.releases\[0" <invalid> \(unmatched \[ at position 12, following "\.releases\[0"\)`},
+		},
+		"valid css": {
+			slice: &URLCommandSlice{
+				testURLCommandCSS()},
+			errRegex: []string{`^$`},
+		},
+		"undefined css selector": {
+			slice: &URLCommandSlice{
+				{Type: "css"}},
+			errRegex: []string{`^    type: css$`, `^    selector: <required>`},
+		},
+		"invalid css selector": {
+			slice: &URLCommandSlice{
+				{Type: "css", Selector: stringPtr("a:unknown")}},
+			errRegex: []string{`^    selector: "a:unknown" <invalid> \(.*unknown pseudoclass.*\)`},
+		},
+		"valid xpath": {
+			slice: &URLCommandSlice{
+				testURLCommandXPath()},
+			errRegex: []string{`^$`},
+		},
+		"undefined xpath path": {
+			slice: &URLCommandSlice{
+				{Type: "xpath"}},
+			errRegex: []string{`^    type: xpath$`, `^    path: <required>`},
+		},
+		"invalid xpath path": {
+			slice: &URLCommandSlice{
+				{Type: "xpath", Path: stringPtr("//a[")}},
+			errRegex: []string{`^    path: "//a\[" <invalid> \(.+\)`},
+		},
+		"attribute on a jsonpath": {
+			slice: &URLCommandSlice{
+				{Type: "jsonpath", Path: stringPtr("$.version"), Attribute: stringPtr("href")}},
+			errRegex: []string{`^    attribute: <invalid> \(only for the css/xpath types\)`},
+		},
+		"valid regex with template": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr("(?P<major>[0-9]+)"), Template: stringPtr("{{ major }}.0.0")}},
+			errRegex: []string{`^$`},
+		},
+		"regex without named groups for template": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr("([0-9]+)"), Template: stringPtr("{{ major }}.0.0")}},
+			errRegex: []string{`^    regex: "\(\[0-9\]\+\)" <invalid> \(template requires named capture groups`},
+		},
+		"invalid template": {
+			slice: &URLCommandSlice{
+				{Type: "regex", Regex: stringPtr("(?P<major>[0-9]+)"), Template: stringPtr("{{ major }.0.0")}},
+			errRegex: []string{`^    template: "{{ major }.0.0" <invalid> \(didn't pass templating\)`},
+		},
+		"template on a split": {
+			slice: &URLCommandSlice{
+				{Type: "split", Text: stringPtr("-"), Template: stringPtr("{{ major }}")}},
+			errRegex: []string{`^    template: <invalid> \(only for the regex type\)`},
+		},
+		"all_matches on a split": {
+			slice: &URLCommandSlice{
+				{Type: "split", Text: stringPtr("-"), AllMatches: true}},
+			errRegex: []string{`^    all_matches: <invalid> \(only for the regex/jsonpath/css/xpath types\)`},
+		},
 		"invalid type": {
 			slice: &URLCommandSlice{
 				{Type: "something"}},
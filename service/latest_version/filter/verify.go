@@ -0,0 +1,478 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	net_url "net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxVerifyFileSize is the largest checksums/signature file that will be downloaded.
+	maxVerifyFileSize = 1 << 20
+	// maxVerifyAssetSize is the largest asset that will be downloaded to verify.
+	maxVerifyAssetSize = 1 << 30
+	// maxVerifyResults is the most verification results that will be cached.
+	maxVerifyResults = 32
+	// verifyDownloadTimeout is the longest a download of a file to verify can take.
+	verifyDownloadTimeout = 10 * time.Minute
+)
+
+// VerifyCheck will verify an asset of the release against its published checksums and/or detached signature.
+type VerifyCheck struct {
+	Asset         string `yaml:"asset" json:"asset"`                                       // "argus-{{ version }}.linux-amd64" Name (or URL) of the asset to verify
+	Checksums     string `yaml:"checksums,omitempty" json:"checksums,omitempty"`           // "checksums.txt" Name (or URL) of the SHA256SUMS-style file with the checksum of the Asset
+	Signature     string `yaml:"signature,omitempty" json:"signature,omitempty"`           // "checksums.txt.asc" Name (or URL) of the detached signature of the Checksums (or the Asset if there are none)
+	SignatureType string `yaml:"signature_type,omitempty" json:"signature_type,omitempty"` // Type of the Signature, pgp/minisign
+	PublicKey     string `yaml:"public_key,omitempty" json:"public_key,omitempty"`         // Key to verify the Signature with (armored OpenPGP key block, or minisign public key)
+
+	mutex        sync.Mutex               `yaml:"-" json:"-"` // Lock for the results and inFlight
+	results      map[string]error         `yaml:"-" json:"-"` // Results of verifying the Asset of a version, by version and Asset URL
+	resultsOrder []string                 `yaml:"-" json:"-"` // Keys of the results, oldest first
+	inFlight     map[string]chan struct{} `yaml:"-" json:"-"` // Verifications in progress, closed when they finish
+}
+
+// VerifyDownloader downloads the files of a VerifyCheck with the HTTP client and credentials of its lookup.
+type VerifyDownloader struct {
+	Client    *http.Client                  // HTTP Client of the lookup (e.g. allowing invalid certs)
+	Authorize func(req *http.Request) error // Sets the credentials of the lookup on a request (if it's for its host)
+}
+
+// retryableError is an error that may not happen when retried,
+// e.g. a failed download, or a file that hasn't been uploaded to the release yet.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// downloadReader returns the errors reading a download as retryableErrors,
+// and errors if it's larger than maxSize.
+type downloadReader struct {
+	reader  io.Reader
+	read    int64
+	maxSize int64
+}
+
+func (r *downloadReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.maxSize {
+		return n, &retryableError{err: fmt.Errorf("larger than %d bytes",
+			r.maxSize)}
+	}
+	if err != nil && err != io.EOF {
+		err = &retryableError{err: err}
+	}
+	return n, err
+}
+
+// String returns a string representation of the VerifyCheck.
+func (v *VerifyCheck) String() string {
+	if v == nil {
+		return "<nil>"
+	}
+	yamlBytes, _ := yaml.Marshal(v)
+	return string(yamlBytes)
+}
+
+// VerifyAssetCheck will verify the Asset of `version` against its Checksums/Signature,
+// and return an error if it's missing or fails verification.
+//
+// `assets` are those of the release, which the Asset/Checksums/Signature are looked up in (unless they're URLs),
+// and are downloaded with `downloader`.
+func (r *Require) VerifyAssetCheck(
+	version string,
+	assets []github_types.Asset,
+	downloader *VerifyDownloader,
+) error {
+	if r == nil || r.Verify == nil {
+		return nil
+	}
+	v := r.Verify
+
+	assetName, assetURL, err := findVerifyAsset(v.Asset, version, assets)
+	if err != nil {
+		return err
+	}
+	key := version + " " + assetURL
+	for {
+		v.mutex.Lock()
+		// Verified (or failed verification) on an earlier query.
+		if err, ok := v.results[key]; ok {
+			v.mutex.Unlock()
+			return err
+		}
+		// Not being verified by another query.
+		done, ok := v.inFlight[key]
+		if !ok {
+			break
+		}
+		v.mutex.Unlock()
+		<-done
+	}
+	done := make(chan struct{})
+	if v.inFlight == nil {
+		v.inFlight = make(map[string]chan struct{}, 1)
+	}
+	v.inFlight[key] = done
+	v.mutex.Unlock()
+
+	if v.Checksums != "" {
+		err = v.verifyChecksum(downloader, assetName, assetURL, version, assets)
+	} else {
+		err = downloader.download(assetURL, maxVerifyAssetSize, func(body io.Reader) error {
+			return v.verifySignature(downloader, body, version, assets)
+		})
+	}
+	if err != nil {
+		err = fmt.Errorf("%s - %w",
+			assetName, err)
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	delete(v.inFlight, key)
+	close(done)
+	// Failed downloads are retried on the next query (e.g. the signature may not have been uploaded yet).
+	var retryErr *retryableError
+	if !errors.As(err, &retryErr) {
+		v.cacheResult(key, err)
+	}
+	return err
+}
+
+// cacheResult of verifying `key`, evicting the oldest result if there are maxVerifyResults.
+func (v *VerifyCheck) cacheResult(key string, err error) {
+	if v.results == nil {
+		v.results = make(map[string]error, 1)
+	}
+	if len(v.resultsOrder) >= maxVerifyResults {
+		delete(v.results, v.resultsOrder[0])
+		v.resultsOrder = v.resultsOrder[1:]
+	}
+	v.results[key] = err
+	v.resultsOrder = append(v.resultsOrder, key)
+}
+
+// verifyChecksum of the asset against the one in the Checksums (after verifying their Signature).
+func (v *VerifyCheck) verifyChecksum(
+	downloader *VerifyDownloader,
+	assetName string,
+	assetURL string,
+	version string,
+	assets []github_types.Asset,
+) error {
+	checksumsName, checksumsURL, err := findVerifyAsset(v.Checksums, version, assets)
+	if err != nil {
+		return err
+	}
+	var checksums []byte
+	err = downloader.download(checksumsURL, maxVerifyFileSize, func(body io.Reader) (err error) {
+		checksums, err = io.ReadAll(body)
+		return //nolint:wrapcheck
+	})
+	if err != nil {
+		return err
+	}
+	if err = v.verifySignature(downloader, bytes.NewReader(checksums), version, assets); err != nil {
+		return fmt.Errorf("%s - %w",
+			checksumsName, err)
+	}
+
+	want, hash, err := findChecksum(checksums, assetName)
+	if err != nil {
+		return fmt.Errorf("%w in %s",
+			err, checksumsName)
+	}
+	h := hash.New()
+	err = downloader.download(assetURL, maxVerifyAssetSize, func(body io.Reader) (err error) {
+		_, err = io.Copy(h, body)
+		return //nolint:wrapcheck
+	})
+	if err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch (want %s, got %s)",
+			want, got)
+	}
+	return nil
+}
+
+// verifySignature of `data` with the PublicKey (if there's a Signature).
+func (v *VerifyCheck) verifySignature(
+	downloader *VerifyDownloader,
+	data io.Reader,
+	version string,
+	assets []github_types.Asset,
+) error {
+	if v.Signature == "" {
+		return nil
+	}
+
+	signatureName, signatureURL, err := findVerifyAsset(v.Signature, version, assets)
+	if err != nil {
+		return err
+	}
+	var signature []byte
+	err = downloader.download(signatureURL, maxVerifyFileSize, func(body io.Reader) (err error) {
+		signature, err = io.ReadAll(body)
+		return //nolint:wrapcheck
+	})
+	if err != nil {
+		return err
+	}
+
+	switch v.SignatureType {
+	case "minisign":
+		err = verifyMinisign(v.PublicKey, signature, data)
+	default:
+		err = verifyOpenPGP(v.PublicKey, signature, data)
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s - %w",
+			v.SignatureType, signatureName, err)
+	}
+	return nil
+}
+
+// findVerifyAsset returns the name and download URL of the templated `asset`,
+// which is either a URL, or the name of one of the `assets` of the release.
+func findVerifyAsset(asset string, version string, assets []github_types.Asset) (name string, url string, err error) {
+	name = util.TemplateString(asset, util.ServiceInfo{LatestVersion: version})
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+		url = name
+		if parsed, err := net_url.Parse(url); err == nil {
+			name = path.Base(parsed.Path)
+		}
+		return
+	}
+
+	for _, releaseAsset := range assets {
+		if releaseAsset.Name == name {
+			url = releaseAsset.BrowserDownloadURL
+			if url == "" {
+				url = releaseAsset.URL
+			}
+			return
+		}
+	}
+	err = &retryableError{err: fmt.Errorf("asset %q not found in the release",
+		name)}
+	return
+}
+
+// download `url` and read (at most `maxSize` bytes of) its body with `read`.
+func (d *VerifyDownloader) download(url string, maxSize int64, read func(body io.Reader) error) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("%s - %w",
+			url, err)
+	}
+	req.Header.Set("Connection", "close")
+	// Copy the client of the lookup (if any) to give it a timeout.
+	client := &http.Client{}
+	if d != nil {
+		if d.Authorize != nil {
+			if err := d.Authorize(req); err != nil {
+				return &retryableError{err: fmt.Errorf("%s - %w",
+					url, err)}
+			}
+		}
+		if d.Client != nil {
+			*client = *d.Client
+		}
+	}
+	if client.Timeout == 0 {
+		client.Timeout = verifyDownloadTimeout
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &retryableError{err: fmt.Errorf("%s - %w",
+			url, err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &retryableError{err: fmt.Errorf("%s - %s",
+			url, resp.Status)}
+	}
+	if resp.ContentLength > maxSize {
+		return &retryableError{err: fmt.Errorf("%s - larger than %d bytes",
+			url, maxSize)}
+	}
+	body := &downloadReader{
+		reader:  io.LimitReader(resp.Body, maxSize+1),
+		maxSize: maxSize}
+	if err := read(body); err != nil {
+		return fmt.Errorf("%s - %w",
+			url, err)
+	}
+	return nil
+}
+
+var (
+	// bsdChecksumRegex matches BSD-style checksum lines, e.g. "SHA256 (argus) = abc...".
+	bsdChecksumRegex = regexp.MustCompile(`^[A-Za-z0-9-]+ ?\((.+)\) ?= ?([0-9A-Fa-f]+)$`)
+	// checksumHashes are the hashes of the checksums by the length of their hex.
+	checksumHashes = map[int]crypto.Hash{
+		64:  crypto.SHA256,
+		96:  crypto.SHA384,
+		128: crypto.SHA512}
+)
+
+// findChecksum returns the checksum of the file `name` in `checksums` (SHA256SUMS/BSD style),
+// along with its hash (SHA-256/384/512 by the length of the checksum).
+//
+// A file of a single checksum without a name (e.g. argus.sha256) is used for any `name`.
+func findChecksum(checksums []byte, name string) (string, crypto.Hash, error) {
+	lines := strings.Split(strings.TrimSpace(string(checksums)), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		var checksum, file string
+		if match := bsdChecksumRegex.FindStringSubmatch(line); match != nil {
+			file, checksum = match[1], match[2]
+		} else {
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 1 && len(lines) == 1:
+				checksum, file = fields[0], name
+			case len(fields) >= 2:
+				// "<checksum>  <file>" (text) or "<checksum> *<file>" (binary).
+				checksum = fields[0]
+				file = strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(line, checksum)), "*")
+			default:
+				continue
+			}
+		}
+
+		if file != name && path.Base(file) != name {
+			continue
+		}
+		hash, ok := checksumHashes[len(checksum)]
+		if _, err := hex.DecodeString(checksum); err != nil || !ok {
+			return "", 0, fmt.Errorf("unsupported checksum %q for %q",
+				checksum, name)
+		}
+		return strings.ToLower(checksum), hash, nil
+	}
+	return "", 0, fmt.Errorf("no checksum for %q",
+		name)
+}
+
+// CheckValues of the VerifyCheck.
+func (v *VerifyCheck) CheckValues(prefix string) (errs error) {
+	if v == nil {
+		return
+	}
+
+	if v.Asset == "" {
+		errs = fmt.Errorf("%s%sasset: <required> (name or URL of the asset to verify)\\",
+			util.ErrorToString(errs), prefix)
+	} else if !util.CheckTemplate(v.Asset) {
+		errs = fmt.Errorf("%s%sasset: %q <invalid> (didn't pass templating)\\",
+			util.ErrorToString(errs), prefix, v.Asset)
+	}
+
+	if v.Checksums == "" && v.Signature == "" {
+		errs = fmt.Errorf("%s%schecksums: <required> (checksums and/or signature to verify the asset with)\\",
+			util.ErrorToString(errs), prefix)
+	} else if v.Checksums != "" && !util.CheckTemplate(v.Checksums) {
+		errs = fmt.Errorf("%s%schecksums: %q <invalid> (didn't pass templating)\\",
+			util.ErrorToString(errs), prefix, v.Checksums)
+	}
+
+	if v.Signature == "" {
+		return
+	}
+	if !util.CheckTemplate(v.Signature) {
+		errs = fmt.Errorf("%s%ssignature: %q <invalid> (didn't pass templating)\\",
+			util.ErrorToString(errs), prefix, v.Signature)
+	}
+	// Default the SignatureType from the PublicKey.
+	if v.SignatureType == "" {
+		v.SignatureType = "minisign"
+		if strings.Contains(v.PublicKey, "-----BEGIN PGP ") {
+			v.SignatureType = "pgp"
+		}
+	}
+	validTypes := []string{"pgp", "minisign"}
+	if !util.Contains(validTypes, v.SignatureType) {
+		errs = fmt.Errorf("%s%ssignature_type: %q <invalid> (should be pgp/minisign)\\",
+			util.ErrorToString(errs), prefix, v.SignatureType)
+	} else if v.PublicKey == "" {
+		errs = fmt.Errorf("%s%spublic_key: <required> (%s public key to verify the signature with)\\",
+			util.ErrorToString(errs), prefix, v.SignatureType)
+	} else if err := v.checkPublicKey(); err != nil {
+		errs = fmt.Errorf("%s%spublic_key: <invalid> (%s)\\",
+			util.ErrorToString(errs), prefix, err)
+	}
+
+	return
+}
+
+// checkPublicKey can be parsed as a key of the SignatureType.
+func (v *VerifyCheck) checkPublicKey() (err error) {
+	switch v.SignatureType {
+	case "minisign":
+		_, err = parseMinisignKey(v.PublicKey)
+	case "pgp":
+		_, err = parseOpenPGPKeys(v.PublicKey)
+	default:
+		err = errors.New("unknown signature_type")
+	}
+	return
+}
+
+// Print the VerifyCheck.
+func (v *VerifyCheck) Print(prefix string) {
+	if v == nil {
+		return
+	}
+
+	fmt.Printf("%sverify:\n", prefix)
+	util.PrintlnIfNotDefault(v.Asset,
+		fmt.Sprintf("%s  asset: %q", prefix, v.Asset))
+	util.PrintlnIfNotDefault(v.Checksums,
+		fmt.Sprintf("%s  checksums: %q", prefix, v.Checksums))
+	util.PrintlnIfNotDefault(v.Signature,
+		fmt.Sprintf("%s  signature: %q", prefix, v.Signature))
+	util.PrintlnIfNotDefault(v.SignatureType,
+		fmt.Sprintf("%s  signature_type: %s", prefix, v.SignatureType))
+	util.PrintlnIfNotDefault(v.PublicKey,
+		fmt.Sprintf("%s  public_key: %q", prefix, v.PublicKey))
+}
@@ -0,0 +1,808 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package filter
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+// testVerifyServer serves the `files` by their path, counting the requests for them.
+func testVerifyServer(t *testing.T, files map[string]string) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		body, ok := files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestRequire_VerifyAssetCheck(t *testing.T) {
+	// GIVEN a Require with a VerifyCheck, and a release with assets
+	testLogging("WARN")
+	minisignSig := testMinisignSignature(testVerifyAsset, false, "timestamp:1700000000")
+	tests := map[string]struct {
+		verify     *VerifyCheck
+		nilRequire bool
+		files      map[string]string
+		assets     []string // Names of the files that are assets of the release.
+		errRegex   string
+	}{
+		"nil Require": {
+			nilRequire: true,
+			errRegex:   "^$"},
+		"no VerifyCheck": {
+			errRegex: "^$"},
+		"checksums": {
+			verify: &VerifyCheck{
+				Asset:     "argus-{{ version }}.linux-amd64",
+				Checksums: "SHA256SUMS"},
+			files: map[string]string{
+				"/argus-1.2.3.linux-amd64": testVerifyAsset,
+				"/SHA256SUMS":              testVerifyChecksums},
+			assets:   []string{"argus-1.2.3.linux-amd64", "SHA256SUMS"},
+			errRegex: "^$"},
+		"checksums from URLs": {
+			verify: &VerifyCheck{
+				Asset:     "{{ server }}/download/argus-{{ version }}.linux-amd64",
+				Checksums: "{{ server }}/download/SHA256SUMS"},
+			files: map[string]string{
+				"/download/argus-1.2.3.linux-amd64": testVerifyAsset,
+				"/download/SHA256SUMS":              testVerifyChecksums},
+			errRegex: "^$"},
+		"checksum mismatch": {
+			verify: &VerifyCheck{
+				Asset:     "argus-{{ version }}.linux-amd64",
+				Checksums: "SHA256SUMS"},
+			files: map[string]string{
+				"/argus-1.2.3.linux-amd64": "tampered",
+				"/SHA256SUMS":              testVerifyChecksums},
+			assets:   []string{"argus-1.2.3.linux-amd64", "SHA256SUMS"},
+			errRegex: `^argus-1.2.3.linux-amd64 - checksum mismatch \(want 11cc45ef.*, got .*\)$`},
+		"no checksum for the asset": {
+			verify: &VerifyCheck{
+				Asset:     "argus-{{ version }}.linux-arm64",
+				Checksums: "SHA256SUMS"},
+			files: map[string]string{
+				"/argus-1.2.3.linux-arm64": testVerifyAsset,
+				"/SHA256SUMS":              testVerifyChecksums},
+			assets:   []string{"argus-1.2.3.linux-arm64", "SHA256SUMS"},
+			errRegex: `^argus-1.2.3.linux-arm64 - no checksum for "argus-1.2.3.linux-arm64" in SHA256SUMS$`},
+		"asset not in the release": {
+			verify: &VerifyCheck{
+				Asset:     "argus-{{ version }}.linux-amd64",
+				Checksums: "SHA256SUMS"},
+			assets:   []string{"SHA256SUMS"},
+			errRegex: `^asset "argus-1.2.3.linux-amd64" not found in the release$`},
+		"checksums not in the release": {
+			verify: &VerifyCheck{
+				Asset:     "argus-{{ version }}.linux-amd64",
+				Checksums: "SHA256SUMS"},
+			assets:   []string{"argus-1.2.3.linux-amd64"},
+			errRegex: `^argus-1.2.3.linux-amd64 - asset "SHA256SUMS" not found in the release$`},
+		"checksums not downloadable": {
+			verify: &VerifyCheck{
+				Asset:     "argus-{{ version }}.linux-amd64",
+				Checksums: "SHA256SUMS"},
+			assets:   []string{"argus-1.2.3.linux-amd64", "SHA256SUMS"},
+			errRegex: `^argus-1.2.3.linux-amd64 - http://.*/SHA256SUMS - 404 Not Found$`},
+		"checksums too large": {
+			verify: &VerifyCheck{
+				Asset:     "argus-{{ version }}.linux-amd64",
+				Checksums: "SHA256SUMS"},
+			files: map[string]string{
+				"/argus-1.2.3.linux-amd64": testVerifyAsset,
+				"/SHA256SUMS":              testVerifyChecksums + strings.Repeat(" ", maxVerifyFileSize)},
+			assets:   []string{"argus-1.2.3.linux-amd64", "SHA256SUMS"},
+			errRegex: `^argus-1.2.3.linux-amd64 - http://.*/SHA256SUMS - larger than 1048576 bytes$`},
+		"signed checksums": {
+			verify: &VerifyCheck{
+				Asset:         "argus-{{ version }}.linux-amd64",
+				Checksums:     "SHA256SUMS",
+				Signature:     "SHA256SUMS.asc",
+				SignatureType: "pgp",
+				PublicKey:     testPGPKeyEd25519},
+			files: map[string]string{
+				"/argus-1.2.3.linux-amd64": testVerifyAsset,
+				"/SHA256SUMS":              testVerifyChecksums,
+				"/SHA256SUMS.asc":          testPGPSigEd25519},
+			assets:   []string{"argus-1.2.3.linux-amd64", "SHA256SUMS", "SHA256SUMS.asc"},
+			errRegex: "^$"},
+		"signed checksums, signed by another key": {
+			verify: &VerifyCheck{
+				Asset:         "argus-{{ version }}.linux-amd64",
+				Checksums:     "SHA256SUMS",
+				Signature:     "SHA256SUMS.asc",
+				SignatureType: "pgp",
+				PublicKey:     testPGPKeyRSA},
+			files: map[string]string{
+				"/argus-1.2.3.linux-amd64": testVerifyAsset,
+				"/SHA256SUMS":              testVerifyChecksums,
+				"/SHA256SUMS.asc":          testPGPSigEd25519},
+			assets:   []string{"argus-1.2.3.linux-amd64", "SHA256SUMS", "SHA256SUMS.asc"},
+			errRegex: `^argus-1.2.3.linux-amd64 - SHA256SUMS - pgp SHA256SUMS.asc - signed by key B1F43E861991D34E`},
+		"signed checksums, signature missing": {
+			verify: &VerifyCheck{
+				Asset:         "argus-{{ version }}.linux-amd64",
+				Checksums:     "SHA256SUMS",
+				Signature:     "SHA256SUMS.asc",
+				SignatureType: "pgp",
+				PublicKey:     testPGPKeyRSA},
+			files: map[string]string{
+				"/argus-1.2.3.linux-amd64": testVerifyAsset,
+				"/SHA256SUMS":              testVerifyChecksums},
+			assets:   []string{"argus-1.2.3.linux-amd64", "SHA256SUMS"},
+			errRegex: `^argus-1.2.3.linux-amd64 - SHA256SUMS - asset "SHA256SUMS.asc" not found in the release$`},
+		"signed asset (pgp)": {
+			verify: &VerifyCheck{
+				Asset:         "argus-{{ version }}.linux-amd64",
+				Signature:     "argus-{{ version }}.linux-amd64.asc",
+				SignatureType: "pgp",
+				PublicKey:     testPGPKeyRSA},
+			files: map[string]string{
+				"/argus-1.2.3.linux-amd64":     testVerifyAsset,
+				"/argus-1.2.3.linux-amd64.asc": testPGPSigRSAAsset},
+			assets:   []string{"argus-1.2.3.linux-amd64", "argus-1.2.3.linux-amd64.asc"},
+			errRegex: "^$"},
+		"signed asset (minisign)": {
+			verify: &VerifyCheck{
+				Asset:         "argus-{{ version }}.linux-amd64",
+				Signature:     "argus-{{ version }}.linux-amd64.minisig",
+				SignatureType: "minisign",
+				PublicKey:     testMinisignKey(testMinisignSeed)},
+			files: map[string]string{
+				"/argus-1.2.3.linux-amd64":         testVerifyAsset,
+				"/argus-1.2.3.linux-amd64.minisig": minisignSig},
+			assets:   []string{"argus-1.2.3.linux-amd64", "argus-1.2.3.linux-amd64.minisig"},
+			errRegex: "^$"},
+		"signed asset (minisign), tampered": {
+			verify: &VerifyCheck{
+				Asset:         "argus-{{ version }}.linux-amd64",
+				Signature:     "argus-{{ version }}.linux-amd64.minisig",
+				SignatureType: "minisign",
+				PublicKey:     testMinisignKey(testMinisignSeed)},
+			files: map[string]string{
+				"/argus-1.2.3.linux-amd64":         "tampered",
+				"/argus-1.2.3.linux-amd64.minisig": minisignSig},
+			assets:   []string{"argus-1.2.3.linux-amd64", "argus-1.2.3.linux-amd64.minisig"},
+			errRegex: `^argus-1.2.3.linux-amd64 - http://.* - minisign argus-1.2.3.linux-amd64.minisig - invalid signature$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server, _ := testVerifyServer(t, tc.files)
+			assets := make([]github_types.Asset, len(tc.assets))
+			for i, asset := range tc.assets {
+				assets[i] = github_types.Asset{
+					Name:               asset,
+					BrowserDownloadURL: server.URL + "/" + asset}
+			}
+			if tc.verify != nil {
+				tc.verify.Asset = strings.ReplaceAll(tc.verify.Asset, "{{ server }}", server.URL)
+				tc.verify.Checksums = strings.ReplaceAll(tc.verify.Checksums, "{{ server }}", server.URL)
+			}
+			require := &Require{Verify: tc.verify}
+			if tc.nilRequire {
+				require = nil
+			}
+
+			// WHEN VerifyAssetCheck is called on it
+			err := require.VerifyAssetCheck("1.2.3", assets, nil)
+
+			// THEN the asset is only verified when it matches its checksums/signature
+			e := util.ErrorToString(err)
+			if !regexp.MustCompile(tc.errRegex).MatchString(e) {
+				t.Errorf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
+func TestRequire_VerifyAssetCheckCached(t *testing.T) {
+	// GIVEN a Require with a VerifyCheck of a release's assets
+	server, requests := testVerifyServer(t, map[string]string{
+		"/argus-1.2.3.linux-amd64": testVerifyAsset,
+		"/SHA256SUMS":              testVerifyChecksums})
+	require := &Require{Verify: &VerifyCheck{
+		Asset:     server.URL + "/argus-{{ version }}.linux-amd64",
+		Checksums: server.URL + "/SHA256SUMS"}}
+
+	// WHEN VerifyAssetCheck is called for the same version twice
+	for i := 0; i < 2; i++ {
+		if err := require.VerifyAssetCheck("1.2.3", nil, nil); err != nil {
+			t.Fatalf("unexpected err: %v",
+				err)
+		}
+	}
+	// THEN the asset is only downloaded and verified once
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("want 2 requests (checksums+asset), got %d",
+			got)
+	}
+
+	// WHEN VerifyAssetCheck is called for another version
+	err := require.VerifyAssetCheck("1.2.4", nil, nil)
+
+	// THEN that version's asset is verified
+	if err == nil {
+		t.Errorf("want an err for the missing asset of 1.2.4, got nil")
+	}
+}
+
+func TestRequire_VerifyAssetCheckCachedFailure(t *testing.T) {
+	// GIVEN a Require with a VerifyCheck of a release's assets, where the asset doesn't match its checksum
+	server, requests := testVerifyServer(t, map[string]string{
+		"/argus-1.2.3.linux-amd64": "tampered",
+		"/SHA256SUMS":              testVerifyChecksums + strings.Repeat("0", 64) + "  argus-1.2.4.linux-amd64\n"})
+	require := &Require{Verify: &VerifyCheck{
+		Asset:     server.URL + "/argus-{{ version }}.linux-amd64",
+		Checksums: server.URL + "/SHA256SUMS"}}
+
+	// WHEN VerifyAssetCheck is called for the same version twice
+	var errs [2]string
+	for i := range errs {
+		errs[i] = util.ErrorToString(require.VerifyAssetCheck("1.2.3", nil, nil))
+	}
+
+	// THEN the asset is only downloaded and fails verification once
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("want 2 requests (checksums+asset), got %d",
+			got)
+	}
+	wantErr := "checksum mismatch"
+	if !strings.Contains(errs[0], wantErr) || errs[1] != errs[0] {
+		t.Errorf("want the same %q err both times, got %q",
+			wantErr, errs)
+	}
+
+	// WHEN VerifyAssetCheck is called for a version whose asset can't be downloaded (yet)
+	for i := 0; i < 2; i++ {
+		require.VerifyAssetCheck("1.2.4", nil, nil)
+	}
+
+	// THEN the download is retried each time
+	if got := atomic.LoadInt32(requests); got != 6 {
+		t.Errorf("want 4 more requests (checksums+asset twice), got %d",
+			got-2)
+	}
+}
+
+func TestRequire_VerifyAssetCheckRetriesMissingAsset(t *testing.T) {
+	// GIVEN a Require with a VerifyCheck, and a release whose checksums haven't been uploaded yet
+	server, _ := testVerifyServer(t, map[string]string{
+		"/argus-1.2.3.linux-amd64": testVerifyAsset,
+		"/SHA256SUMS":              testVerifyChecksums})
+	require := &Require{Verify: &VerifyCheck{
+		Asset:     "argus-{{ version }}.linux-amd64",
+		Checksums: "SHA256SUMS"}}
+	assets := []github_types.Asset{
+		{Name: "argus-1.2.3.linux-amd64", BrowserDownloadURL: server.URL + "/argus-1.2.3.linux-amd64"}}
+
+	// WHEN VerifyAssetCheck is called before the checksums are uploaded
+	err := require.VerifyAssetCheck("1.2.3", assets, nil)
+
+	// THEN it fails
+	wantErr := `asset "SHA256SUMS" not found in the release`
+	if e := util.ErrorToString(err); !strings.Contains(e, wantErr) {
+		t.Errorf("want err containing %q, got %q",
+			wantErr, e)
+	}
+
+	// WHEN VerifyAssetCheck is called after they're uploaded
+	assets = append(assets,
+		github_types.Asset{Name: "SHA256SUMS", BrowserDownloadURL: server.URL + "/SHA256SUMS"})
+	err = require.VerifyAssetCheck("1.2.3", assets, nil)
+
+	// THEN the failure wasn't cached, and the asset is verified
+	if err != nil {
+		t.Errorf("want the asset to be verified, got %v",
+			err)
+	}
+}
+
+func TestRequire_VerifyAssetCheckConcurrent(t *testing.T) {
+	// GIVEN a Require with a VerifyCheck of a release's assets
+	server, requests := testVerifyServer(t, map[string]string{
+		"/argus-1.2.3.linux-amd64": testVerifyAsset,
+		"/SHA256SUMS":              testVerifyChecksums})
+	require := &Require{Verify: &VerifyCheck{
+		Asset:     server.URL + "/argus-{{ version }}.linux-amd64",
+		Checksums: server.URL + "/SHA256SUMS"}}
+
+	// WHEN VerifyAssetCheck is called for the same version concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := require.VerifyAssetCheck("1.2.3", nil, nil); err != nil {
+				t.Errorf("unexpected err: %v",
+					err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// THEN the asset is only downloaded and verified once
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("want 2 requests (checksums+asset), got %d",
+			got)
+	}
+}
+
+func TestVerifyCheck_CacheResult(t *testing.T) {
+	// GIVEN a VerifyCheck with maxVerifyResults cached
+	verify := &VerifyCheck{}
+	for i := 0; i < maxVerifyResults; i++ {
+		verify.cacheResult(fmt.Sprint(i), nil)
+	}
+
+	// WHEN another result is cached
+	verify.cacheResult("new", errors.New("failed"))
+
+	// THEN only the oldest result is evicted
+	if len(verify.results) != maxVerifyResults {
+		t.Errorf("want %d results, got %d",
+			maxVerifyResults, len(verify.results))
+	}
+	if _, ok := verify.results["0"]; ok {
+		t.Errorf("want the oldest result to be evicted, got %v",
+			verify.results)
+	}
+	for _, key := range []string{"1", fmt.Sprint(maxVerifyResults - 1), "new"} {
+		if _, ok := verify.results[key]; !ok {
+			t.Errorf("want result %q to be kept, got %v",
+				key, verify.results)
+		}
+	}
+}
+
+func TestVerifyDownloader_Download(t *testing.T) {
+	// GIVEN a server requiring a token, and VerifyDownloaders
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testVerifyAsset))
+	}))
+	t.Cleanup(server.Close)
+	authorize := func(req *http.Request) error {
+		req.Header.Set("PRIVATE-TOKEN", "secret")
+		return nil
+	}
+	tests := map[string]struct {
+		downloader *VerifyDownloader
+		maxSize    int64
+		want       string
+		errRegex   string
+	}{
+		"nil downloader": {
+			errRegex: `x509`},
+		"client, no credentials": {
+			downloader: &VerifyDownloader{
+				Client: server.Client()},
+			errRegex: `^https://.* - 401 Unauthorized$`},
+		"client and credentials": {
+			downloader: &VerifyDownloader{
+				Client:    server.Client(),
+				Authorize: authorize},
+			want:     testVerifyAsset,
+			errRegex: `^$`},
+		"failed to authorize": {
+			downloader: &VerifyDownloader{
+				Client: server.Client(),
+				Authorize: func(req *http.Request) error {
+					return errors.New("no token")
+				}},
+			errRegex: `^https://.* - no token$`},
+		"larger than maxSize": {
+			downloader: &VerifyDownloader{
+				Client:    server.Client(),
+				Authorize: authorize},
+			maxSize:  int64(len(testVerifyAsset) - 1),
+			errRegex: `^https://.* - larger than 11 bytes$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.maxSize == 0 {
+				tc.maxSize = maxVerifyFileSize
+			}
+
+			// WHEN download is called with it
+			var got []byte
+			err := tc.downloader.download(server.URL, tc.maxSize, func(body io.Reader) (err error) {
+				got, err = io.ReadAll(body)
+				return
+			})
+
+			// THEN the body is downloaded with the client and credentials of the downloader
+			e := util.ErrorToString(err)
+			if !regexp.MustCompile(tc.errRegex).MatchString(e) {
+				t.Errorf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			if string(got) != tc.want {
+				t.Errorf("want body %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFindChecksum(t *testing.T) {
+	// GIVEN a checksums file and the name of a file to find
+	sha512 := strings.Repeat("ab", 64)
+	tests := map[string]struct {
+		checksums string
+		name      string
+		want      string
+		wantHash  crypto.Hash
+		errRegex  string
+	}{
+		"SHA256SUMS": {
+			checksums: testVerifyChecksums,
+			name:      "argus-1.2.3.linux-amd64",
+			want:      "11cc45ef8a05c4800f1354e09dda1537bcb0c13e42829e77571e8945e94f569a",
+			wantHash:  crypto.SHA256,
+			errRegex:  "^$"},
+		"binary mode, uppercase": {
+			checksums: "11CC45EF8A05C4800F1354E09DDA1537BCB0C13E42829E77571E8945E94F569A *argus",
+			name:      "argus",
+			want:      "11cc45ef8a05c4800f1354e09dda1537bcb0c13e42829e77571e8945e94f569a",
+			wantHash:  crypto.SHA256,
+			errRegex:  "^$"},
+		"path in the checksums": {
+			checksums: sha512 + "  ./dist/argus",
+			name:      "argus",
+			want:      sha512,
+			wantHash:  crypto.SHA512,
+			errRegex:  "^$"},
+		"BSD style": {
+			checksums: "SHA512 (foo) = 00\r\nSHA512 (argus) = " + sha512 + "\r\n",
+			name:      "argus",
+			want:      sha512,
+			wantHash:  crypto.SHA512,
+			errRegex:  "^$"},
+		"only a checksum": {
+			checksums: sha512 + "\n",
+			name:      "argus",
+			want:      sha512,
+			wantHash:  crypto.SHA512,
+			errRegex:  "^$"},
+		"no checksum for the file": {
+			checksums: testVerifyChecksums,
+			name:      "argus",
+			errRegex:  `^no checksum for "argus"$`},
+		"unsupported checksum": {
+			checksums: "d41d8cd98f00b204e9800998ecf8427e  argus",
+			name:      "argus",
+			errRegex:  `^unsupported checksum "d41d8cd98f00b204e9800998ecf8427e" for "argus"$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN findChecksum is called
+			got, gotHash, err := findChecksum([]byte(tc.checksums), tc.name)
+
+			// THEN the checksum of the file is found
+			e := util.ErrorToString(err)
+			if !regexp.MustCompile(tc.errRegex).MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			if got != tc.want || gotHash != tc.wantHash {
+				t.Errorf("want %s (%v), got %s (%v)",
+					tc.want, tc.wantHash, got, gotHash)
+			}
+		})
+	}
+}
+
+func TestFindVerifyAsset(t *testing.T) {
+	// GIVEN the assets of a release
+	assets := []github_types.Asset{
+		{Name: "argus-1.2.3.linux-amd64", URL: "https://api.example.com/assets/1",
+			BrowserDownloadURL: "https://example.com/download/argus-1.2.3.linux-amd64"},
+		{Name: "SHA256SUMS", URL: "https://example.com/SHA256SUMS"}}
+	tests := map[string]struct {
+		asset    string
+		wantName string
+		wantURL  string
+		errRegex string
+	}{
+		"templated asset": {
+			asset:    "argus-{{ version }}.linux-amd64",
+			wantName: "argus-1.2.3.linux-amd64",
+			wantURL:  "https://example.com/download/argus-1.2.3.linux-amd64",
+			errRegex: "^$"},
+		"asset without a browser_download_url": {
+			asset:    "SHA256SUMS",
+			wantName: "SHA256SUMS",
+			wantURL:  "https://example.com/SHA256SUMS",
+			errRegex: "^$"},
+		"URL": {
+			asset:    "https://mirror.example.com/{{ version }}/argus%2Blinux?raw=1",
+			wantName: "argus+linux",
+			wantURL:  "https://mirror.example.com/1.2.3/argus%2Blinux?raw=1",
+			errRegex: "^$"},
+		"unknown asset": {
+			asset:    "argus-{{ version }}.darwin",
+			wantName: "argus-1.2.3.darwin",
+			errRegex: `^asset "argus-1.2.3.darwin" not found in the release$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN findVerifyAsset is called
+			gotName, gotURL, err := findVerifyAsset(tc.asset, "1.2.3", assets)
+
+			// THEN the name and URL of the asset are returned
+			e := util.ErrorToString(err)
+			if !regexp.MustCompile(tc.errRegex).MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			if gotName != tc.wantName || gotURL != tc.wantURL {
+				t.Errorf("want %q (%q), got %q (%q)",
+					tc.wantName, tc.wantURL, gotName, gotURL)
+			}
+		})
+	}
+}
+
+func TestVerifyCheck_CheckValues(t *testing.T) {
+	// GIVEN a VerifyCheck
+	tests := map[string]struct {
+		verify            *VerifyCheck
+		wantSignatureType string
+		errRegex          string
+	}{
+		"nil VerifyCheck": {
+			errRegex: "^$"},
+		"checksums": {
+			verify: &VerifyCheck{
+				Asset:     "argus-{{ version }}.linux-amd64",
+				Checksums: "SHA256SUMS"},
+			errRegex: "^$"},
+		"no asset": {
+			verify: &VerifyCheck{
+				Checksums: "SHA256SUMS"},
+			errRegex: `^-asset: <required>`},
+		"invalid asset template": {
+			verify: &VerifyCheck{
+				Asset:     "argus-{{ version }",
+				Checksums: "SHA256SUMS"},
+			errRegex: `^-asset: "argus-{{ version }" <invalid> \(didn't pass templating\)`},
+		"no checksums or signature": {
+			verify: &VerifyCheck{
+				Asset: "argus"},
+			errRegex: `^-checksums: <required> \(checksums and/or signature`},
+		"invalid checksums template": {
+			verify: &VerifyCheck{
+				Asset:     "argus",
+				Checksums: "{% if %}"},
+			errRegex: `^-checksums: .* <invalid> \(didn't pass templating\)`},
+		"pgp signature": {
+			verify: &VerifyCheck{
+				Asset:         "argus",
+				Signature:     "argus.asc",
+				SignatureType: "pgp",
+				PublicKey:     testPGPKeyRSA},
+			wantSignatureType: "pgp",
+			errRegex:          "^$"},
+		"signature_type defaulted to pgp": {
+			verify: &VerifyCheck{
+				Asset:     "argus",
+				Signature: "argus.asc",
+				PublicKey: testPGPKeyRSA},
+			wantSignatureType: "pgp",
+			errRegex:          "^$"},
+		"signature_type defaulted to minisign": {
+			verify: &VerifyCheck{
+				Asset:     "argus",
+				Signature: "argus.minisig",
+				PublicKey: testMinisignKey(testMinisignSeed)},
+			wantSignatureType: "minisign",
+			errRegex:          "^$"},
+		"invalid signature template": {
+			verify: &VerifyCheck{
+				Asset:         "argus",
+				Signature:     "{{ version }",
+				SignatureType: "minisign",
+				PublicKey:     testMinisignKey(testMinisignSeed)},
+			wantSignatureType: "minisign",
+			errRegex:          `^-signature: .* <invalid> \(didn't pass templating\)`},
+		"invalid signature_type": {
+			verify: &VerifyCheck{
+				Asset:         "argus",
+				Signature:     "argus.sig",
+				SignatureType: "x509",
+				PublicKey:     testPGPKeyRSA},
+			wantSignatureType: "x509",
+			errRegex:          `^-signature_type: "x509" <invalid> \(should be pgp/minisign\)`},
+		"no public_key": {
+			verify: &VerifyCheck{
+				Asset:         "argus",
+				Signature:     "argus.sig",
+				SignatureType: "pgp"},
+			wantSignatureType: "pgp",
+			errRegex:          `^-public_key: <required> \(pgp public key`},
+		"public_key of the wrong type": {
+			verify: &VerifyCheck{
+				Asset:         "argus",
+				Signature:     "argus.sig",
+				SignatureType: "minisign",
+				PublicKey:     testPGPKeyRSA},
+			wantSignatureType: "minisign",
+			errRegex:          `^-public_key: <invalid> \(invalid minisign public key\)`},
+		"all errors": {
+			verify: &VerifyCheck{
+				Signature:     "{{ version }",
+				SignatureType: "pgp",
+				PublicKey:     "foo"},
+			wantSignatureType: "pgp",
+			errRegex:          `^-asset: <required>.*\\-signature: .* <invalid>.*\\-public_key: <invalid>`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN CheckValues is called on it
+			err := tc.verify.CheckValues("-")
+
+			// THEN the err is what we expect
+			e := util.ErrorToString(err)
+			if !regexp.MustCompile(tc.errRegex).MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the signature_type is defaulted from the public_key
+			if tc.verify != nil && tc.verify.SignatureType != tc.wantSignatureType {
+				t.Errorf("want signature_type %q, got %q",
+					tc.wantSignatureType, tc.verify.SignatureType)
+			}
+		})
+	}
+}
+
+func TestVerifyCheck_Print(t *testing.T) {
+	// GIVEN a VerifyCheck
+	tests := map[string]struct {
+		verify *VerifyCheck
+		lines  int
+	}{
+		"nil VerifyCheck": {
+			lines: 0},
+		"empty VerifyCheck": {
+			verify: &VerifyCheck{},
+			lines:  1},
+		"checksums": {
+			verify: &VerifyCheck{
+				Asset:     "argus",
+				Checksums: "SHA256SUMS"},
+			lines: 3},
+		"all fields": {
+			verify: &VerifyCheck{
+				Asset:         "argus",
+				Checksums:     "SHA256SUMS",
+				Signature:     "SHA256SUMS.asc",
+				SignatureType: "pgp",
+				PublicKey:     testPGPKeyRSA},
+			lines: 6},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			stdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			// WHEN Print is called
+			tc.verify.Print("")
+
+			// THEN it prints the expected number of lines
+			w.Close()
+			out, _ := io.ReadAll(r)
+			os.Stdout = stdout
+			got := strings.Count(string(out), "\n")
+			if got != tc.lines {
+				t.Errorf("Print should have given %d lines, but gave %d\n%s",
+					tc.lines, got, string(out))
+			}
+		})
+	}
+}
+
+func TestVerifyCheck_String(t *testing.T) {
+	// GIVEN a VerifyCheck
+	tests := map[string]struct {
+		verify *VerifyCheck
+		want   string
+	}{
+		"nil": {
+			verify: nil,
+			want:   "<nil>"},
+		"filled": {
+			verify: &VerifyCheck{
+				Asset:         "argus",
+				Checksums:     "SHA256SUMS",
+				Signature:     "SHA256SUMS.minisig",
+				SignatureType: "minisign",
+				PublicKey:     "RWQ..."},
+			want: `
+asset: argus
+checksums: SHA256SUMS
+signature: SHA256SUMS.minisig
+signature_type: minisign
+public_key: RWQ...
+`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN the VerifyCheck is stringified with String
+			got := tc.verify.String()
+
+			// THEN the result is as expected
+			tc.want = strings.TrimPrefix(tc.want, "\n")
+			if got != tc.want {
+				t.Errorf("got:\n%q\nwant:\n%q",
+					got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// compileXPath compiles the XPath 1.0 expression of an xpath url_command, e.g.
+//
+//	//div[@class='release']/h2
+//	//a[contains(@href, '.tar.gz')]/@href
+//	(//table[@id='downloads']//tr)[2]/td[1]/text()
+//	string(//meta[@name='version']/@content)
+func compileXPath(path string) (*xpath.Expr, error) {
+	return xpath.Compile(path) //nolint:wrapcheck
+}
+
+// xpathMatches returns the text (or `attribute`) of the nodes selected by `path` in document order,
+// skipping those without the `attribute`, or the value of `path` when it isn't a node-set (e.g. count(...)).
+func (d *markupDocument) xpathMatches(path *xpath.Expr, attribute string) []string {
+	var navigator xpath.NodeNavigator = htmlquery.CreateXPathNavigator(d.html)
+	if d.xml != nil {
+		navigator = xmlquery.CreateXPathNavigator(d.xml)
+	}
+
+	var texts []string
+	switch value := path.Evaluate(navigator).(type) {
+	case *xpath.NodeIterator:
+		for value.MoveNext() {
+			if text, ok := xpathValue(value.Current(), attribute); ok {
+				texts = append(texts, text)
+			}
+		}
+	case float64:
+		texts = []string{strconv.FormatFloat(value, 'f', -1, 64)}
+	case bool:
+		texts = []string{strconv.FormatBool(value)}
+	case string:
+		texts = []string{value}
+	}
+	return texts
+}
+
+// xpathValue returns the `attribute` of the element at `node` when given, otherwise its text.
+func xpathValue(node xpath.NodeNavigator, attribute string) (string, bool) {
+	if attribute == "" {
+		return strings.TrimSpace(node.Value()), true
+	}
+	if node.NodeType() != xpath.ElementNode {
+		return "", false
+	}
+
+	switch navigator := node.(type) {
+	case *htmlquery.NodeNavigator:
+		return htmlValue(navigator.Current(), attribute)
+	case *xmlquery.NodeNavigator:
+		for _, attr := range navigator.Current().Attr {
+			if strings.EqualFold(attr.Name.Local, attribute) {
+				return attr.Value, true
+			}
+		}
+	}
+	return "", false
+}
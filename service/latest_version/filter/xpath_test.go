@@ -0,0 +1,203 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package filter
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/release-argus/Argus/util"
+)
+
+func TestCompileXPath(t *testing.T) {
+	// GIVEN an XPath
+	tests := map[string]struct {
+		path     string
+		errRegex string
+	}{
+		"root":                 {path: "/", errRegex: "^$"},
+		"absolute":             {path: "/html/body/div", errRegex: "^$"},
+		"descendants":          {path: "//span[@class='version']", errRegex: "^$"},
+		"attribute":            {path: "//a/@href", errRegex: "^$"},
+		"axes":                 {path: "//li/following-sibling::li/ancestor::ul/child::*", errRegex: "^$"},
+		"node tests":           {path: "//h2/text() | //comment() | //node()", errRegex: "^$"},
+		"functions":            {path: "//a[contains(@href, '.zip') and not(starts-with(., 'L'))]", errRegex: "^$"},
+		"filter expression":    {path: "(//tr)[last()]/td[1]", errRegex: "^$"},
+		"arithmetic":           {path: "//li[position() mod 2 = 1 and position() * 2 div 2 > -1]", errRegex: "^$"},
+		"value":                {path: "count(//li) + 1", errRegex: "^$"},
+		"prefixed names":       {path: "//app:version", errRegex: "^$"},
+		"empty":                {path: "", errRegex: ".+"},
+		"unclosed predicate":   {path: "//li[1", errRegex: ".+"},
+		"unterminated string":  {path: "//li[@a='x]", errRegex: ".+"},
+		"unknown function":     {path: "//li[foo(., 'x')]", errRegex: `function foo\(\)`},
+		"wrong argument count": {path: "//li[contains(.)]", errRegex: ".+"},
+		"trailing slash":       {path: "//li/", errRegex: ".+"},
+		"unexpected character": {path: "//li#", errRegex: ".+"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN compileXPath is called on it
+			_, err := compileXPath(tc.path)
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Errorf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
+func TestMarkupDocument_XPathMatches(t *testing.T) {
+	// GIVEN a HTML/XML document and an XPath
+	tests := map[string]struct {
+		document  string
+		path      string
+		attribute string
+		want      []string
+	}{
+		"element": {
+			path: "//h2",
+			want: []string{"Version 1.2.0"}},
+		"attribute equals": {
+			path: "//span[@class='version']",
+			want: []string{"1.2.0", "1.3.0-beta.1", "1.1.0", "1.0.0"}},
+		"attribute": {
+			path: "//a[contains(@href, '.zip')]/@href",
+			want: []string{"/files/app-1.2.0.zip"}},
+		"attribute wildcard": {
+			path: "/html/head/meta/@*",
+			want: []string{"version", "1.2.0"}},
+		"text node": {
+			path: "//h2/text()",
+			want: []string{"Version"}},
+		"comment": {
+			path: "//comment()",
+			want: []string{"old releases"}},
+		"position": {
+			path: "//ul/li[2]/span",
+			want: []string{"1.1.0"}},
+		"last": {
+			path: "//ul/li[last()]",
+			want: []string{"1.0.0"}},
+		"position of a filter expression": {
+			path: "(//td)[3]",
+			want: []string{"1.1.0"}},
+		"position is per parent": {
+			path: "//tr/td[1]",
+			want: []string{"1.2.0", "1.1.0"}},
+		"not": {
+			path: "//li[not(@class)]",
+			want: []string{"1.1.0", "1.0.0"}},
+		"and/or": {
+			path: "//td[starts-with(., '2023') and (contains(., '-05-') or ends-with(., '-15'))]",
+			want: []string{"2023-05-01", "2023-01-15"}},
+		"following-sibling": {
+			path: "//li[@class='beta']/following-sibling::li[1]",
+			want: []string{"1.1.0"}},
+		"preceding-sibling is in reverse": {
+			path: "//li[last()]/preceding-sibling::li[1]",
+			want: []string{"1.1.0"}},
+		"parent": {
+			path: "//span[. = '1.1.0']/../following-sibling::*/span",
+			want: []string{"1.0.0"}},
+		"ancestor": {
+			path: "//span[. = '1.2.0']/ancestor::div/@id",
+			want: []string{"latest"}},
+		"following": {
+			path: "//ul/following::td[1]",
+			want: []string{"1.2.0"}},
+		"descendant axis": {
+			path: "//div/descendant::span",
+			want: []string{"1.2.0"}},
+		"union": {
+			path: "//h2/span | //td[1]",
+			want: []string{"1.2.0", "1.2.0", "1.1.0"}},
+		"comparing a node-set": {
+			path: "//tr[td = '1.1.0']/td[2]",
+			want: []string{"2023-01-15"}},
+		"comparing numbers": {
+			path: "//li[position() >= 2]",
+			want: []string{"1.1.0", "1.0.0"}},
+		"string value": {
+			path: "string(//meta[@name='version']/@content)",
+			want: []string{"1.2.0"}},
+		"number value": {
+			path: "count(//li) * 2 - 1",
+			want: []string{"5"}},
+		"boolean value": {
+			path: "boolean(//li[@class='beta'])",
+			want: []string{"true"}},
+		"string functions": {
+			path: "concat(substring-before(//td[2], '-'), '.', substring(substring-after(//td[2], '-'), 1, 2), '.', translate(normalize-space('  0 '), '0', '1'))",
+			want: []string{"2023.05.1"}},
+		"attribute of the elements": {
+			path:      "//a | //h2",
+			attribute: "href",
+			want:      []string{"/files/app-1.2.0.tar.gz", "/files/app-1.2.0.zip"}},
+		"attribute of an attribute": {
+			path:      "//a/@href",
+			attribute: "href",
+			want:      nil},
+		"xml namespace prefixes": {
+			document: testMarkupXML,
+			path:     "//entry[app:version/@stable='true']/link/@href",
+			want:     []string{"https://example.com/1.2.0", "https://example.com/1.1.0"}},
+		"xml attribute of the elements": {
+			document:  testMarkupXML,
+			path:      "//app:version",
+			attribute: "stable",
+			want:      []string{"true", "false", "true"}},
+		"no matches": {
+			path: "//section",
+			want: nil},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path, err := compileXPath(tc.path)
+			if err != nil {
+				t.Fatalf("compileXPath(%q) failed: %s",
+					tc.path, err)
+			}
+			document, err := parseMarkup(util.GetFirstNonDefault(tc.document, testMarkupHTML))
+			if err != nil {
+				t.Fatalf("parseMarkup failed: %s",
+					err)
+			}
+
+			// WHEN xpathMatches is called on the document
+			got := document.xpathMatches(path, tc.attribute)
+
+			// THEN the text (or attribute) of the nodes selected (or the value) are returned
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+				t.Errorf("want %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"github.com/release-argus/Argus/util"
+	"github.com/release-argus/Argus/util/vercmp"
 )
 
 func (l *Lookup) GetAccessToken() *string {
@@ -36,7 +37,7 @@ func (l *Lookup) GetAllowInvalidCerts() bool {
 }
 
 // GetServiceURL returns the service's URL (handles the github type where the URL
-// may be `owner/repo`, adding the github.com prefix in that case).
+// may be `owner/repo`, adding the github.com (or base_url) prefix in that case).
 func (l *Lookup) GetServiceURL(ignoreWebURL bool) string {
 	if !ignoreWebURL && *l.Status.WebURL != "" {
 		// Don't use this template if `LatestVersion` hasn't been found and is used in `WebURL`.
@@ -49,16 +50,46 @@ func (l *Lookup) GetServiceURL(ignoreWebURL bool) string {
 	}
 
 	serviceURL := l.URL
+	switch l.Type {
 	// GitHub service. Get the non-API URL.
-	if l.Type == "github" {
+	case "github":
 		// If it's "owner/repo" rather than a full path.
 		if strings.Count(serviceURL, "/") == 1 {
-			serviceURL = fmt.Sprintf("https://github.com/%s", serviceURL)
+			serviceURL = fmt.Sprintf("%s/%s", l.gitHubBaseURL(), serviceURL)
 		}
+		// Branch being tracked.
+		if l.trackBranch() {
+			serviceURL = fmt.Sprintf("%s/tree/%s", serviceURL, l.Branch)
+		}
+	// Docker service. Get the image URL.
+	case "docker":
+		image := parseDockerImage(serviceURL)
+		serviceURL = image.webURL()
+	// Package registry service. Get the package URL.
+	case "crates", "go", "npm", "pypi":
+		serviceURL = l.packageWebURL()
+	// Gitea service. Get the repo URL.
+	case "gitea":
+		serviceURL = l.giteaWebURL()
+	// GitLab service. Get the project URL.
+	case "gitlab":
+		serviceURL = l.gitLabWebURL()
 	}
 	return serviceURL
 }
 
+// versionScheme returns the scheme that the versions are validated and ordered with (nil for none).
+// Docker digests and git commits never have one, and OS packages always use that of their distro.
+func (l *Lookup) versionScheme() *vercmp.Scheme {
+	if l.trackDigest() || l.trackBranch() {
+		return nil
+	}
+	if scheme := osPackageVersionScheme[l.Type]; scheme != "" {
+		return vercmp.GetScheme(scheme)
+	}
+	return vercmp.GetScheme(l.Options.GetVersionScheme())
+}
+
 // Get UsePreRelease will return whether GitHub PreReleases are considered valid for new versions.
 func (l *Lookup) GetUsePreRelease() bool {
 	return *util.GetFirstNonDefault(
@@ -77,3 +108,25 @@ func GetURL(url string, urlType string) string {
 	}
 	return url
 }
+
+// queryURL returns the URL to query for the releases of this Lookup.
+func (l *Lookup) queryURL() string {
+	switch l.Type {
+	case "github":
+		if l.trackBranch() {
+			return l.gitHubCommitURL()
+		}
+		return l.gitHubReleasesURL()
+	case "gitea":
+		return l.giteaAPIURL()
+	case "gitlab":
+		return l.gitLabAPIURL()
+	case "git":
+		return l.gitInfoRefsURL()
+	case "helm":
+		return l.helmIndexURL()
+	case "crates", "go", "npm", "pypi":
+		return l.packageAPIURL()
+	}
+	return GetURL(l.URL, l.Type)
+}
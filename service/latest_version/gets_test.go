@@ -124,6 +124,7 @@ func TestLookup_GetServiceURL(t *testing.T) {
 	tests := map[string]struct {
 		serviceType   string
 		url           string
+		branch        string
 		webURL        string
 		ignoreWebURL  bool
 		latestVersion string
@@ -159,6 +160,14 @@ func TestLookup_GetServiceURL(t *testing.T) {
 			latestVersion: "",
 			ignoreWebURL:  false,
 		},
+		"github - want branch url address": {
+			want:         "https://github.com/release-argus/Argus/tree/main",
+			serviceType:  "github",
+			url:          "release-argus/Argus",
+			branch:       "main",
+			webURL:       "foo",
+			ignoreWebURL: true,
+		},
 		"url - want query url": {
 			want:         "https://release-argus.io",
 			serviceType:  "url",
@@ -202,7 +211,7 @@ func TestLookup_GetServiceURL(t *testing.T) {
 				stringPtr("http://example.com"))
 			status.SetLatestVersion(tc.latestVersion, false)
 			status.WebURL = &tc.webURL
-			lookup := Lookup{Type: tc.serviceType, URL: tc.url, Status: &status}
+			lookup := Lookup{Type: tc.serviceType, URL: tc.url, Branch: tc.branch, Status: &status}
 
 			// WHEN GetAllowInvalidCerts is called
 			got := lookup.GetServiceURL(tc.ignoreWebURL)
@@ -0,0 +1,152 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+	"github.com/release-argus/Argus/util/vercmp"
+)
+
+// gitRef is a ref advertised by a git server.
+type gitRef struct {
+	Name   string // e.g. refs/tags/v1.2.3
+	Commit string // SHA of the commit (peeled for annotated tags)
+}
+
+// gitInfoRefsURL returns the URL of the smart HTTP ref advertisement of the repository at URL.
+func (l *Lookup) gitInfoRefsURL() string {
+	return strings.TrimSuffix(l.URL, "/") + "/info/refs?service=git-upload-pack"
+}
+
+// trackBranch returns whether this Lookup tracks the head commit of a branch
+// rather than the tags of a git repository (or the releases of a GitHub repository).
+func (l *Lookup) trackBranch() bool {
+	return (l.Type == "git" || l.Type == "github") && l.Branch != ""
+}
+
+// parseGitRefs parses the refs of the smart HTTP ref advertisement (git ls-remote) in `body`.
+func parseGitRefs(body []byte) (refs []gitRef, err error) {
+	// e.g. "001e# service=git-upload-pack\n"
+	if !bytes.HasPrefix(body, []byte("001e# service=git-upload-pack")) {
+		response := strings.TrimSpace(string(body))
+		if len(response) > 100 {
+			response = response[:100] + "..."
+		}
+		return nil, fmt.Errorf("not a git smart HTTP response (is the url the repository, and does the server support smart HTTP?)\n%s",
+			response)
+	}
+
+	peeled := map[string]string{}
+	for len(body) != 0 {
+		// pkt-line - 4 hex digits of length (including themselves) then the data.
+		if len(body) < 4 {
+			return nil, errors.New("truncated pkt-line")
+		}
+		length, parseErr := strconv.ParseUint(string(body[:4]), 16, 16)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid pkt-line length %q",
+				body[:4])
+		}
+		// flush-pkt.
+		if length == 0 {
+			body = body[4:]
+			continue
+		}
+		if length < 4 || int(length) > len(body) {
+			return nil, errors.New("truncated pkt-line")
+		}
+		line := string(bytes.TrimSuffix(body[4:length], []byte("\n")))
+		body = body[length:]
+
+		if strings.HasPrefix(line, "# service=") {
+			continue
+		}
+		// The capabilities follow the first ref.
+		line, _, _ = strings.Cut(line, "\x00")
+		commit, name, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		if strings.HasSuffix(name, "^{}") {
+			peeled[strings.TrimSuffix(name, "^{}")] = commit
+			continue
+		}
+		refs = append(refs, gitRef{Name: name, Commit: commit})
+	}
+	// Annotated tags point at a tag object, so use the commit they peel to.
+	for i := range refs {
+		if commit, ok := peeled[refs[i].Name]; ok {
+			refs[i].Commit = commit
+		}
+	}
+	return
+}
+
+// checkGitRefsBody will convert the tags (or the head of Branch) in the ref advertisement body to releases.
+func (l *Lookup) checkGitRefsBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	refs, err := parseGitRefs(*body)
+	if err != nil {
+		err = fmt.Errorf("failed to list the refs of %s\n%w",
+			l.URL, err)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	// Head commit of a branch.
+	if l.trackBranch() {
+		want := "refs/heads/" + l.Branch
+		if l.Branch == "HEAD" {
+			want = "HEAD"
+		}
+		for _, ref := range refs {
+			if ref.Name == want {
+				releases = []github_types.Release{{
+					TagName: ref.Commit,
+					Digest:  ref.Commit}}
+				return
+			}
+		}
+		err = fmt.Errorf("branch %q not found in %s",
+			l.Branch, l.URL)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	releases = make([]github_types.Release, 0, len(refs))
+	for _, ref := range refs {
+		tag := strings.TrimPrefix(ref.Name, "refs/tags/")
+		if tag == ref.Name {
+			continue
+		}
+		releases = append(releases, github_types.Release{
+			TagName:    tag,
+			PreRelease: isSemVerPreRelease(tag),
+			Digest:     ref.Commit})
+	}
+	// Refs are advertised in lexical order (v1.10 before v1.9), so sort them newest first
+	// by comparing the numbers in the tags.
+	sort.SliceStable(releases, func(i, j int) bool {
+		return vercmp.RPM(releases[i].TagName, releases[j].TagName) > 0
+	})
+	return
+}
@@ -0,0 +1,222 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/release-argus/Argus/service/latest_version/filter"
+	"github.com/release-argus/Argus/util"
+)
+
+// testGitAdvertisement returns the smart HTTP ref advertisement of `refs` ("<sha> <ref>").
+func testGitAdvertisement(refs ...string) string {
+	pktLine := func(line string) string {
+		return fmt.Sprintf("%04x%s", len(line)+4, line)
+	}
+	advertisement := pktLine("# service=git-upload-pack\n") + "0000"
+	for i, ref := range refs {
+		if i == 0 {
+			ref += "\x00multi_ack thin-pack side-band symref=HEAD:refs/heads/main"
+		}
+		advertisement += pktLine(ref + "\n")
+	}
+	return advertisement + "0000"
+}
+
+var testGitRefs = testGitAdvertisement(
+	"1111111111111111111111111111111111111111 HEAD",
+	"2222222222222222222222222222222222222222 refs/heads/develop",
+	"1111111111111111111111111111111111111111 refs/heads/main",
+	"3333333333333333333333333333333333333333 refs/pull/1/head",
+	"4444444444444444444444444444444444444444 refs/tags/v1.10.0",
+	"5555555555555555555555555555555555555555 refs/tags/v1.10.0^{}",
+	"6666666666666666666666666666666666666666 refs/tags/v1.2.0",
+	"7777777777777777777777777777777777777777 refs/tags/v2.0.0-rc.1")
+
+func TestParseGitRefs(t *testing.T) {
+	// GIVEN a ref advertisement
+	tests := map[string]struct {
+		body     string
+		want     []string
+		errRegex string
+	}{
+		"refs, with annotated tags peeled": {
+			body: testGitRefs,
+			want: []string{
+				"HEAD=1111111111111111111111111111111111111111",
+				"refs/heads/develop=2222222222222222222222222222222222222222",
+				"refs/heads/main=1111111111111111111111111111111111111111",
+				"refs/pull/1/head=3333333333333333333333333333333333333333",
+				"refs/tags/v1.10.0=5555555555555555555555555555555555555555",
+				"refs/tags/v1.2.0=6666666666666666666666666666666666666666",
+				"refs/tags/v2.0.0-rc.1=7777777777777777777777777777777777777777"},
+			errRegex: "^$"},
+		"empty repository": {
+			body:     testGitAdvertisement(),
+			errRegex: "^$"},
+		"dumb HTTP/not a repository": {
+			body:     "1111111111111111111111111111111111111111\trefs/heads/main\n",
+			errRegex: "not a git smart HTTP response"},
+		"truncated": {
+			body:     testGitRefs[:60],
+			errRegex: "truncated pkt-line"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN parseGitRefs is called on it
+			refs, err := parseGitRefs([]byte(tc.body))
+
+			// THEN it err's when expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the refs are returned
+			got := make([]string, len(refs))
+			for i := range refs {
+				got[i] = refs[i].Name + "=" + refs[i].Commit
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want: %v\ngot:  %v",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryGit(t *testing.T) {
+	// GIVEN a git repository served over smart HTTP
+	testLogging("ERROR")
+	tests := map[string]struct {
+		branch             string
+		usePreRelease      bool
+		semanticVersioning bool
+		username           string
+		want               string
+		wantDigest         string
+		errRegex           string
+	}{
+		"newest semantic version tag": {
+			semanticVersioning: true,
+			want:               "1.10.0",
+			wantDigest:         "5555555555555555555555555555555555555555",
+			errRegex:           "^$"},
+		"use_prerelease": {
+			semanticVersioning: true,
+			usePreRelease:      true,
+			want:               "2.0.0-rc.1",
+			wantDigest:         "7777777777777777777777777777777777777777",
+			errRegex:           "^$"},
+		"no semantic versioning, tags in natural order": {
+			want:       "v1.10.0",
+			wantDigest: "5555555555555555555555555555555555555555",
+			errRegex:   "^$"},
+		"branch head commit": {
+			semanticVersioning: true,
+			branch:             "develop",
+			want:               "2222222222222222222222222222222222222222",
+			wantDigest:         "2222222222222222222222222222222222222222",
+			errRegex:           "^$"},
+		"default branch (HEAD)": {
+			branch:     "HEAD",
+			want:       "1111111111111111111111111111111111111111",
+			wantDigest: "1111111111111111111111111111111111111111",
+			errRegex:   "^$"},
+		"unknown branch": {
+			branch:   "unknown",
+			errRegex: `branch "unknown" not found`},
+		"basic auth": {
+			semanticVersioning: true,
+			username:           "user",
+			want:               "1.10.0",
+			wantDigest:         "5555555555555555555555555555555555555555",
+			errRegex:           "^$"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repo.git/info/refs" || r.URL.Query().Get("service") != "git-upload-pack" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if u, p, ok := r.BasicAuth(); tc.username != "" && (!ok || u != tc.username || p != "pass") {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+				w.Write([]byte(testGitRefs))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "git"
+			lookup.URL = server.URL + "/repo.git/"
+			lookup.Branch = tc.branch
+			lookup.Username = tc.username
+			if tc.username != "" {
+				lookup.AccessToken = stringPtr("pass")
+			}
+			lookup.GitHubData = nil
+			lookup.URLCommands = nil
+			// Tags are prefixed with a 'v', so strip it for semantic versioning (like GitHub tags).
+			if tc.semanticVersioning && tc.branch == "" {
+				regex := `^v?([0-9.]+(?:-.+)?)$`
+				lookup.URLCommands = filter.URLCommandSlice{{Type: "regex", Regex: &regex}}
+			}
+			lookup.UsePreRelease = &tc.usePreRelease
+			lookup.Options.SemanticVersioning = &tc.semanticVersioning
+			lookup.Require = nil
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			// AND the commit is the digest
+			if got := lookup.Status.GetLatestVersionDigest(); got != tc.wantDigest {
+				t.Errorf("want latest_version_digest %q, got %q",
+					tc.wantDigest, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+// giteaReleasesPerPage is the number of releases to query in each page of the Gitea API
+// (the default maximum of Gitea/Forgejo instances).
+const giteaReleasesPerPage = 50
+
+// giteaAPIURL returns the Gitea/Forgejo API URL of the releases for this Lookup's "owner/repo".
+func (l *Lookup) giteaAPIURL() string {
+	return fmt.Sprintf("%s/api/v1/repos/%s/releases?limit=%d",
+		strings.TrimSuffix(l.BaseURL, "/"), l.URL, giteaReleasesPerPage)
+}
+
+// giteaWebURL returns the URL of this Lookup's repo on the Gitea/Forgejo web UI.
+func (l *Lookup) giteaWebURL() string {
+	return fmt.Sprintf("%s/%s",
+		strings.TrimSuffix(l.BaseURL, "/"), l.URL)
+}
+
+// checkGiteaReleasesBody will check that the body is of the expected API format for a successful query
+func (l *Lookup) checkGiteaReleasesBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	// Error responses are an object rather than a list, e.g.
+	// {"errors":null,"message":"The target couldn't be found.","url":"https://gitea.example.com/api/swagger"}
+	if !strings.HasPrefix(strings.TrimSpace(string(*body)), "[") {
+		var apiError struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(*body, &apiError)
+		msg := util.GetFirstNonDefault(apiError.Message, string(*body))
+
+		switch lowerMsg := strings.ToLower(msg); {
+		case strings.Contains(lowerMsg, "token"),
+			strings.Contains(lowerMsg, "unauthorized"),
+			strings.Contains(lowerMsg, "user does not exist"):
+			err = errors.New("gitea access token is invalid")
+		case strings.Contains(lowerMsg, "rate limit"):
+			err = errors.New("rate limit reached for Gitea")
+		default:
+			err = fmt.Errorf("releases not found at %s\n%s",
+				l.URL, msg)
+		}
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	// The release format is the same as GitHub's.
+	if err = json.Unmarshal(*body, &releases); err != nil {
+		releases = nil
+		jLog.Error(err, *logFrom, true)
+		err = fmt.Errorf("unmarshal of Gitea API data failed\n%w",
+			err)
+		jLog.Error(err, *logFrom, true)
+	}
+	return
+}
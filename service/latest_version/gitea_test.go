@@ -0,0 +1,292 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/release-argus/Argus/service/latest_version/filter"
+	"github.com/release-argus/Argus/util"
+)
+
+func TestLookup_GiteaURLs(t *testing.T) {
+	// GIVEN a Gitea Lookup
+	lookup := Lookup{
+		Type:    "gitea",
+		URL:     "owner/repo",
+		BaseURL: "https://codeberg.org/"}
+
+	// WHEN giteaAPIURL/giteaWebURL are called
+	apiURL := lookup.giteaAPIURL()
+	webURL := lookup.giteaWebURL()
+
+	// THEN the URLs are as expected
+	wantAPIURL := "https://codeberg.org/api/v1/repos/owner/repo/releases?limit=50"
+	if apiURL != wantAPIURL {
+		t.Errorf("API URL - want: %q\ngot:  %q",
+			wantAPIURL, apiURL)
+	}
+	wantWebURL := "https://codeberg.org/owner/repo"
+	if webURL != wantWebURL {
+		t.Errorf("Web URL - want: %q\ngot:  %q",
+			wantWebURL, webURL)
+	}
+}
+
+func TestLookup_CheckGiteaReleasesBody(t *testing.T) {
+	// GIVEN a body
+	testLogging("WARN")
+	tests := map[string]struct {
+		body     string
+		want     int
+		errRegex string
+	}{
+		"repo not found": {
+			body:     `{"errors":null,"message":"The target couldn't be found.","url":"https://gitea.example.com/api/swagger"}`,
+			errRegex: "releases not found at .*\nThe target couldn't be found."},
+		"bad credentials": {
+			body:     `{"message":"user does not exist [uid: 0, name: ]","url":"https://gitea.example.com/api/swagger"}`,
+			errRegex: "gitea access token is invalid"},
+		"invalid json": {
+			body:     `[{"tag_name":1}]`,
+			errRegex: "unmarshal .* failed"},
+		"releases": {
+			body:     `[{"tag_name":"v1.2.3","draft":true},{"tag_name":"v1.2.2","prerelease":true}]`,
+			want:     2,
+			errRegex: "^$"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			body := []byte(tc.body)
+			lookup := Lookup{Type: "gitea", URL: "owner/repo"}
+
+			// WHEN checkGiteaReleasesBody is called on this body
+			releases, err := lookup.checkGiteaReleasesBody(&body, &util.LogFrom{})
+
+			// THEN it err's when expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the releases are returned
+			if len(releases) != tc.want {
+				t.Errorf("want %d releases, got %d\n%v",
+					tc.want, len(releases), releases)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryGitea(t *testing.T) {
+	// GIVEN a Gitea API
+	testLogging("WARN")
+	body := `[
+		{"tag_name":"v3.0.0","draft":true},
+		{"tag_name":"v2.0.0-rc.1","prerelease":true,"assets":[{"id":3,"name":"checksums.txt","browser_download_url":"https://gitea.example.com/owner/repo/releases/download/v2.0.0-rc.1/checksums.txt"}]},
+		{"tag_name":"v1.10.0","assets":[{"id":1,"name":"app_1.10.0_linux_amd64.tar.gz","browser_download_url":"https://gitea.example.com/owner/repo/releases/download/v1.10.0/app_1.10.0_linux_amd64.tar.gz"}]},
+		{"tag_name":"v1.9.0"}]`
+	tests := map[string]struct {
+		accessToken         *string
+		usePreRelease       bool
+		requireRegexContent string
+		want                string
+		errRegex            string
+	}{
+		"drafts are ignored": {
+			want:     "1.10.0",
+			errRegex: "^$"},
+		"use_prerelease": {
+			usePreRelease: true,
+			want:          "2.0.0-rc.1",
+			errRegex:      "^$"},
+		"require.regex_content checks the assets": {
+			requireRegexContent: `app_{{ version }}_linux_amd64\.tar\.gz$`,
+			usePreRelease:       true,
+			want:                "1.10.0",
+			errRegex:            "^$"},
+		"access token is sent": {
+			accessToken: stringPtr("secret"),
+			want:        "1.10.0",
+			errRegex:    "^$"},
+		"access token is invalid": {
+			accessToken: stringPtr("invalid"),
+			errRegex:    "gitea access token is invalid"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/repos/owner/repo/releases" {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"errors":null,"message":"The target couldn't be found."}`))
+					return
+				}
+				if auth := r.Header.Get("Authorization"); auth != "" && auth != "token secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte(`{"message":"user does not exist [uid: 0, name: ]"}`))
+					return
+				}
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "gitea"
+			lookup.URL = "owner/repo"
+			lookup.BaseURL = server.URL
+			lookup.AccessToken = tc.accessToken
+			lookup.GitHubData = &GitHubData{}
+			lookup.URLCommands = filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`^v(.+)$`)}}
+			lookup.UsePreRelease = &tc.usePreRelease
+			lookup.Require.RegexContent = tc.requireRegexContent
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryGiteaPages(t *testing.T) {
+	// GIVEN a Gitea API with 2 pages of releases
+	testLogging("ERROR")
+	// page 1 - v1.0.0...v1.0.49, page 2 - v1.1.0...v1.1.24
+	testPages := make([]string, 2)
+	for page, count := range []int{giteaReleasesPerPage, giteaReleasesPerPage / 2} {
+		releases := make([]string, count)
+		for i := range releases {
+			releases[i] = fmt.Sprintf(`{"tag_name": "v1.%d.%d"}`, page, i)
+		}
+		testPages[page] = "[" + strings.Join(releases, ", ") + "]"
+	}
+	tests := map[string]struct {
+		maxPages        *uint
+		queries         int
+		wantRequests    int32
+		wantNotModified int32
+		want            string
+	}{
+		"default of 1 page": {
+			queries:      1,
+			wantRequests: 1,
+			want:         "1.0.49"},
+		"releases on the 2nd page are found": {
+			maxPages:     uintPtr(2),
+			queries:      1,
+			wantRequests: 2,
+			want:         "1.1.24"},
+		"unchanged pages are conditional requests": {
+			maxPages:        uintPtr(2),
+			queries:         2,
+			wantRequests:    4,
+			wantNotModified: 2,
+			want:            "1.1.24"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests, notModified int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/repos/owner/repo/releases" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				atomic.AddInt32(&requests, 1)
+				page := 1
+				fmt.Sscan(r.URL.Query().Get("page"), &page)
+				etag := fmt.Sprintf(`"page-%d"`, page)
+				if r.Header.Get("If-None-Match") == etag {
+					atomic.AddInt32(&notModified, 1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", "W/"+etag)
+				if page < len(testPages) {
+					w.Header().Set("Link", fmt.Sprintf(`<%s?limit=%d&page=%d>; rel="next"`,
+						r.URL.Path, giteaReleasesPerPage, page+1))
+				}
+				w.Write([]byte(testPages[page-1]))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "gitea"
+			lookup.URL = "owner/repo"
+			lookup.BaseURL = server.URL
+			lookup.AccessToken = nil
+			lookup.GitHubData = &GitHubData{}
+			lookup.MaxPages = tc.maxPages
+			lookup.URLCommands = filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`^v(.+)$`)}}
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it `queries` times
+			for i := 0; i < tc.queries; i++ {
+				if _, err := lookup.Query(false, &util.LogFrom{}); err != nil {
+					t.Fatalf("unexpected err on query %d: %v",
+						i+1, err)
+				}
+			}
+
+			// THEN the latest version is found on the expected page
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			// AND only the needed pages were requested
+			if got := atomic.LoadInt32(&requests); got != tc.wantRequests {
+				t.Errorf("want %d requests, got %d",
+					tc.wantRequests, got)
+			}
+			// AND unchanged pages were conditional requests
+			if got := atomic.LoadInt32(&notModified); got != tc.wantNotModified {
+				t.Errorf("want %d Not Modified responses, got %d",
+					tc.wantNotModified, got)
+			}
+		})
+	}
+}
@@ -18,27 +18,37 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	net_url "net/url"
 	"sort"
 	"strings"
 
 	"github.com/coreos/go-semver/semver"
 	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
 	"github.com/release-argus/Argus/util"
+	"github.com/release-argus/Argus/util/vercmp"
 )
 
-// filterGitHubReleases will filter releases that fail the URLCommands, aren't semantic (if wanted),
-// or are pre_release's (when they're not wanted). This list will be returned and be sorted descending.
+// filterGitHubReleases will filter releases that fail the URLCommands, don't follow the version_scheme (if any),
+// are drafts, or are pre_release's (when they're not wanted). This list will be returned and be sorted descending
+// (by the order_by).
 func (l *Lookup) filterGitHubReleases(
 	releases []github_types.Release,
 	logFrom *util.LogFrom,
 ) (filteredReleases []github_types.Release) {
-	semanticVerioning := l.Options.GetSemanticVersioning()
+	scheme := l.versionScheme()
+	orderBySemVer := l.GetOrderBy() == "semver"
 	usePreReleases := l.GetUsePreRelease()
 
 	// Make a slice with the same capacity as releases
 	filteredReleases = make([]github_types.Release, 0, len(releases))
 
 	for i := range releases {
+		// If it's a draft, skip (only visible with write access)
+		if releases[i].Draft {
+			continue
+		}
 		// If it's a prerelease, and they're not wanted, skip
 		if releases[i].PreRelease && !usePreReleases {
 			continue
@@ -49,33 +59,41 @@ func (l *Lookup) filterGitHubReleases(
 
 		// Check that TagName matches URLCommands
 		if tagName, err = l.URLCommands.Run(releases[i].TagName, *logFrom); err != nil {
-			continue
+			// Feed entries may only have the version in their link.
+			if l.Type != "feed" || releases[i].URL == "" {
+				continue
+			}
+			if tagName, err = l.URLCommands.Run(releases[i].URL, *logFrom); err != nil {
+				continue
+			}
 		}
 
 		// Copy the release with the filtered TagName
 		release := releases[i]
 		release.TagName = tagName
 
-		// If SemVer isn't wanted, add without any sorting
-		if !semanticVerioning {
+		// If there's no version scheme, add without any sorting
+		if scheme == nil {
 			filteredReleases = append(filteredReleases, release)
 			continue
 		}
 
 		// Else, sort the versions
-		semVer, err := semver.NewVersion(tagName)
-		if err != nil {
+		if !scheme.Valid(tagName) {
 			continue
 		}
-		release.SemanticVersion = semVer
-		// If there's no other versions, just add it without insertion sort
-		if len(filteredReleases) == 0 {
+		if scheme.Name == "semver" {
+			release.SemanticVersion, _ = semver.NewVersion(tagName)
+		}
+		// If there's no other versions, or they're not ordered by version, just add it without insertion sort
+		if len(filteredReleases) == 0 || !orderBySemVer {
 			filteredReleases = append(filteredReleases, release)
 			continue
 		}
 		// Insertion Sort
-		insertionSort(release, &filteredReleases)
+		insertionSortScheme(release, &filteredReleases, scheme)
 	}
+	l.orderReleases(filteredReleases)
 	return
 }
 
@@ -83,10 +101,35 @@ func (l *Lookup) filterGitHubReleases(
 //
 // Every GitHubRelease must be follow SemanticVersioning for this insertion
 func insertionSort(release github_types.Release, filteredReleases *[]github_types.Release) {
+	insertionSortFunc(release, filteredReleases, func(a, b github_types.Release) bool {
+		return a.SemanticVersion.LessThan(*b.SemanticVersion)
+	})
+}
+
+// insertionSortScheme will do an insertion sort of release on filteredReleases,
+// ordering their TagName's with the version `scheme`.
+func insertionSortScheme(release github_types.Release, filteredReleases *[]github_types.Release, scheme *vercmp.Scheme) {
+	// SemanticVersion's are already parsed.
+	if scheme.Name == "semver" {
+		insertionSort(release, filteredReleases)
+		return
+	}
+	insertionSortFunc(release, filteredReleases, func(a, b github_types.Release) bool {
+		return scheme.Compare(a.TagName, b.TagName) < 0
+	})
+}
+
+// insertionSortFunc will do an insertion sort of release on the (descending) filteredReleases,
+// where `less` returns whether release `a` is older than `b`.
+func insertionSortFunc(
+	release github_types.Release,
+	filteredReleases *[]github_types.Release,
+	less func(a, b github_types.Release) bool,
+) {
 	n := len(*filteredReleases)
 	// find the insertion point
 	i := sort.Search(n, func(index int) bool {
-		return (*filteredReleases)[index].SemanticVersion.LessThan(*release.SemanticVersion)
+		return less((*filteredReleases)[index], release)
 	})
 
 	// append an empty release to the end of the slice
@@ -104,6 +147,290 @@ func insertionSort(release github_types.Release, filteredReleases *[]github_type
 	}
 }
 
+// gitHubDefaultBaseURL is the base URL used for `github` types without a `base_url`.
+const gitHubDefaultBaseURL = "https://github.com"
+
+// gitHubHosts are the hosts of github.com, which are never a GitHub Enterprise Server.
+var gitHubHosts = map[string]bool{
+	"github.com":     true,
+	"www.github.com": true,
+	"api.github.com": true}
+
+// gitHubEnterpriseRepo returns the base URL and "owner/repo" of the URL if it's a repository
+// on a GitHub Enterprise Server, or "", "" if it's not.
+//
+// The URL is only a repository on a GitHub Enterprise Server when it's the web URL of one
+// (e.g. "https://github.example.com/owner/repo"), or is on the host of a `base_url`.
+// Other full URLs (e.g. a custom/proxied API URL) are used as-is.
+func (l *Lookup) gitHubEnterpriseRepo() (baseURL string, repo string) {
+	if strings.Count(l.URL, "/") < 2 {
+		return
+	}
+	parsedURL, err := net_url.Parse(l.URL)
+	if err != nil || parsedURL.Host == "" || gitHubHosts[strings.ToLower(parsedURL.Host)] {
+		return
+	}
+	parts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
+	// Not "owner/repo[/...]", or an API URL.
+	if len(parts) < 2 || parts[0] == "api" {
+		return
+	}
+	// Not the web URL of a repository, so only if it's on the host of a base_url.
+	if (len(parts) != 2 || parsedURL.RawQuery != "") && !l.onGitHubBaseURLHost(parsedURL.Host) {
+		return
+	}
+	return fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host),
+		parts[0] + "/" + parts[1]
+}
+
+// onGitHubBaseURLHost returns whether `host` is the host of the `base_url` of this Lookup
+// (or of its defaults).
+func (l *Lookup) onGitHubBaseURLHost(host string) bool {
+	baseURL := l.BaseURL
+	if baseURL == "" && l.Defaults != nil {
+		baseURL = l.Defaults.BaseURL
+	}
+	if baseURL == "" && l.HardDefaults != nil {
+		baseURL = l.HardDefaults.BaseURL
+	}
+	if baseURL == "" {
+		return false
+	}
+	parsedBaseURL, err := net_url.Parse(baseURL)
+	return err == nil && strings.EqualFold(parsedBaseURL.Host, host)
+}
+
+// gitHubRepo returns the "owner/repo" of the URL
+// (the URL itself if it's not a repository on a GitHub Enterprise Server).
+func (l *Lookup) gitHubRepo() string {
+	if _, repo := l.gitHubEnterpriseRepo(); repo != "" {
+		return repo
+	}
+	return l.URL
+}
+
+// gitHubBaseURL returns the base URL of the GitHub instance (e.g. a GitHub Enterprise Server),
+// falling back to the host of the URL and then the `base_url` of the defaults.
+func (l *Lookup) gitHubBaseURL() string {
+	baseURL := l.BaseURL
+	if baseURL == "" {
+		baseURL, _ = l.gitHubEnterpriseRepo()
+	}
+	if baseURL == "" && l.Defaults != nil {
+		baseURL = l.Defaults.BaseURL
+	}
+	if baseURL == "" && l.HardDefaults != nil {
+		baseURL = l.HardDefaults.BaseURL
+	}
+	return strings.TrimSuffix(util.GetFirstNonDefault(baseURL, gitHubDefaultBaseURL), "/")
+}
+
+// gitHubAPIURL returns the base URL of the GitHub REST API
+// (api.github.com, or /api/v3 on a GitHub Enterprise Server).
+func (l *Lookup) gitHubAPIURL() string {
+	baseURL := l.gitHubBaseURL()
+	if baseURL == gitHubDefaultBaseURL {
+		return "https://api.github.com"
+	}
+	return baseURL + "/api/v3"
+}
+
+// gitHubReleasesURL returns the API URL of the releases (or the latest release) of this Lookup's "owner/repo".
+func (l *Lookup) gitHubReleasesURL() string {
+	url := l.gitHubRepo()
+	// "owner/repo" rather than a full URL.
+	if strings.Count(url, "/") == 1 {
+		url = fmt.Sprintf("%s/repos/%s/releases",
+			l.gitHubAPIURL(), url)
+	}
+	if l.GetUseLatestRelease() {
+		url = strings.TrimSuffix(url, "/") + "/latest"
+	}
+	return url
+}
+
+// GetMaxPages returns the maximum number of pages of GitHub/GitLab releases to query.
+func (l *Lookup) GetMaxPages() uint {
+	maxPages := util.GetFirstNonNilPtr(
+		l.MaxPages,
+		l.Defaults.MaxPages,
+		l.HardDefaults.MaxPages)
+	if maxPages == nil || *maxPages == 0 {
+		return 1
+	}
+	return *maxPages
+}
+
+// GetUseLatestRelease returns whether the release GitHub has marked as the latest should be used.
+func (l *Lookup) GetUseLatestRelease() bool {
+	return util.DefaultIfNil(util.GetFirstNonNilPtr(
+		l.UseLatestRelease,
+		l.Defaults.UseLatestRelease,
+		l.HardDefaults.UseLatestRelease))
+}
+
+// releasesPerPage is the number of releases to query in each page of the GitHub/GitLab APIs.
+const releasesPerPage = 100
+
+// releasesPagesRequest will query up to max_pages pages of GitHub/GitLab/Gitea releases from `url`,
+// with conditional requests for each page. A page of fewer than `perPage` releases is the last.
+//
+// Returns the releases of all pages, or (for GitHub) an empty body if none of the pages have changed.
+func (l *Lookup) releasesPagesRequest(url string, perPage int, logFrom *util.LogFrom) (rawBody []byte, err error) {
+	client := l.httpClient()
+
+	maxPages := int(l.GetMaxPages())
+	changed := false
+	pages := make([]GitHubPage, 0, maxPages)
+	var releases []json.RawMessage
+	for page := 0; page < maxPages && url != ""; page++ {
+		req, reqErr := http.NewRequest(http.MethodGet, url, nil)
+		if reqErr != nil {
+			err = reqErr
+			jLog.Error(err, *logFrom, true)
+			return
+		}
+		req.Header.Set("Connection", "close")
+		if err = l.setAuth(req); err != nil {
+			jLog.Error(err, *logFrom, true)
+			return
+		}
+		// Conditional request of this page.
+		conditional := page < len(l.GitHubData.Pages) && l.GitHubData.Pages[page].ETag != ""
+		if conditional {
+			req.Header.Set("If-None-Match", l.GitHubData.Pages[page].ETag)
+		}
+
+		resp, doErr := client.Do(req)
+		if doErr != nil {
+			err = doErr
+			// Don't crash on invalid certs.
+			if strings.Contains(err.Error(), "x509") {
+				err = fmt.Errorf("x509 (certificate invalid)")
+				jLog.Warn(err, *logFrom, true)
+				return
+			}
+			jLog.Error(err, *logFrom, true)
+			return
+		}
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			err = readErr
+			jLog.Error(err, *logFrom, true)
+			return
+		}
+		switch resp.StatusCode {
+		// Unchanged, so use the cached page.
+		case http.StatusNotModified:
+			// Not a conditional request (e.g. a caching proxy), so there's no cached page to use.
+			if !conditional {
+				err = fmt.Errorf("%s - 304 Not Modified for a page that isn't cached",
+					url)
+				jLog.Error(err, *logFrom, true)
+				return
+			}
+			pages = append(pages, l.GitHubData.Pages[page])
+		case http.StatusOK:
+			changed = true
+			pages = append(pages, GitHubPage{
+				ETag: strings.TrimPrefix(resp.Header.Get("etag"), "W/"),
+				Next: nextPageURL(resp, url),
+				Body: body})
+		// Error (e.g. rate limit), so give the body to checkGitHubReleasesBody/checkGitLabReleasesBody/checkGiteaReleasesBody.
+		default:
+			if app := l.GetGitHubApp(); l.Type == "github" && app != nil && resp.StatusCode == http.StatusUnauthorized {
+				l.forgetGitHubAppAccessToken(app)
+			}
+			rawBody = body
+			return
+		}
+
+		var pageReleases []json.RawMessage
+		if jsonErr := json.Unmarshal(pages[page].Body, &pageReleases); jsonErr != nil {
+			// Not a list of releases, so give the body to checkGitHubReleasesBody/checkGitLabReleasesBody/checkGiteaReleasesBody.
+			rawBody = pages[page].Body
+			return
+		}
+		releases = append(releases, pageReleases...)
+		// Last page.
+		if len(pageReleases) < perPage {
+			break
+		}
+		url = pages[page].Next
+	}
+
+	// Fewer pages than before.
+	if len(pages) != len(l.GitHubData.Pages) {
+		changed = true
+	}
+	l.GitHubData.Pages = pages
+	l.GitHubData.ETag = pages[0].ETag
+	if changed {
+		jLog.Verbose("Potentially found new releases (ETag changed)", *logFrom, true)
+	} else if l.Type == "github" {
+		// GitHub releases are cached in GitHubData.Releases.
+		return
+	}
+	rawBody, _ = json.Marshal(releases)
+	return
+}
+
+// checkGitHubLatestReleaseBody will convert the release GitHub has marked as the latest in the body
+// to a list of releases.
+func (l *Lookup) checkGitHubLatestReleaseBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	// Wrap the release in a list, so it can be checked like a list of releases.
+	trimmedBody := strings.TrimSpace(string(*body))
+	if strings.HasPrefix(trimmedBody, "{") && strings.Contains(trimmedBody, `"tag_name"`) {
+		listBody := []byte("[" + trimmedBody + "]")
+		return l.checkGitHubReleasesBody(&listBody, logFrom)
+	}
+	return l.checkGitHubReleasesBody(body, logFrom)
+}
+
+// gitHubCommitURL returns the API URL of the head commit of Branch.
+func (l *Lookup) gitHubCommitURL() string {
+	return fmt.Sprintf("%s/repos/%s/commits/%s",
+		l.gitHubAPIURL(), l.gitHubRepo(), net_url.PathEscape(l.Branch))
+}
+
+// checkGitHubCommitBody will convert the head commit of Branch in the body to a release of its SHA.
+func (l *Lookup) checkGitHubCommitBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	// Check for rate limit.
+	if len(string(*body)) < 500 && strings.Contains(string(*body), "rate limit") {
+		err = errors.New("rate limit reached for GitHub")
+		jLog.Warn(err, *logFrom, true)
+		return
+	}
+
+	var commit github_types.Commit
+	if err = json.Unmarshal(*body, &commit); err != nil {
+		jLog.Error(err, *logFrom, true)
+		err = fmt.Errorf("unmarshal of GitHub API data failed\n%w",
+			err)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+	if commit.SHA == "" {
+		jLog.Error("github access token is invalid", *logFrom, strings.Contains(string(*body), "Bad credentials"))
+		err = fmt.Errorf("branch %q not found at %s\n%s",
+			l.Branch, l.URL, string(*body))
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	release := github_types.Release{
+		TagName: commit.SHA,
+		Digest:  commit.SHA,
+		Created: commit.Commit.Committer.Date,
+		Message: commit.Commit.Message}
+	if commit.HTMLURL != "" {
+		release.URLs = []string{commit.HTMLURL}
+	}
+	releases = []github_types.Release{release}
+	return
+}
+
 // checkGitHubReleasesBody will check that the body is of the expected API format for a successful query
 func (l *Lookup) checkGitHubReleasesBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
 	// Check for rate lirmRDrit.
@@ -0,0 +1,340 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/release-argus/Argus/util"
+)
+
+// GitHubApp to authenticate `github` lookups as, rather than with an access_token.
+// (`github` WebHooks only simulate GitHub's push events, so don't call the GitHub API.)
+type GitHubApp struct {
+	AppID          string `yaml:"app_id,omitempty" json:"app_id,omitempty"`                   // ID of the GitHub App
+	PrivateKey     string `yaml:"private_key,omitempty" json:"private_key,omitempty"`         // Path to the PEM private key of the GitHub App
+	InstallationID string `yaml:"installation_id,omitempty" json:"installation_id,omitempty"` // ID of the installation of the GitHub App
+}
+
+// gitHubAppToken is an installation access token of a GitHub App.
+type gitHubAppToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// gitHubAppInstallation holds the installation access token of a GitHub App installation,
+// locked while the token is being refreshed so that each installation only has one request at a time.
+type gitHubAppInstallation struct {
+	mutex sync.Mutex
+	token gitHubAppToken
+}
+
+var (
+	// gitHubAppTokens are the installations with their access tokens, shared by all lookups using the same installation.
+	gitHubAppTokens = map[string]*gitHubAppInstallation{}
+	// gitHubAppTokensMutex only locks the map, never a token request.
+	gitHubAppTokensMutex sync.Mutex
+	// gitHubAppTokenRefresh is how long before expiry an installation access token is refreshed.
+	gitHubAppTokenRefresh = 5 * time.Minute
+	// gitHubAppTokenTimeout is the timeout of an installation access token request.
+	gitHubAppTokenTimeout = 30 * time.Second
+)
+
+// String returns a string representation of the GitHubApp.
+func (a *GitHubApp) String() string {
+	if a == nil {
+		return "<nil>"
+	}
+	jsonBytes, _ := json.Marshal(a)
+	return string(jsonBytes)
+}
+
+// Print the struct.
+func (a *GitHubApp) Print(prefix string) {
+	if a == nil {
+		return
+	}
+	fmt.Printf("%sgithub_app:\n", prefix)
+	util.PrintlnIfNotDefault(a.AppID,
+		fmt.Sprintf("%s  app_id: %s", prefix, a.AppID))
+	util.PrintlnIfNotDefault(a.PrivateKey,
+		fmt.Sprintf("%s  private_key: %s", prefix, a.PrivateKey))
+	util.PrintlnIfNotDefault(a.InstallationID,
+		fmt.Sprintf("%s  installation_id: %s", prefix, a.InstallationID))
+}
+
+// CheckValues of the GitHubApp.
+func (a *GitHubApp) CheckValues(prefix string) (errs error) {
+	if a == nil {
+		return
+	}
+
+	if a.AppID == "" {
+		errs = fmt.Errorf("%s%sapp_id: <required> e.g. '123456'\\",
+			util.ErrorToString(errs), prefix)
+	} else if _, err := strconv.ParseUint(a.AppID, 10, 64); err != nil {
+		errs = fmt.Errorf("%s%sapp_id: %q <invalid> (expected a number)\\",
+			util.ErrorToString(errs), prefix, a.AppID)
+	}
+	if a.InstallationID == "" {
+		errs = fmt.Errorf("%s%sinstallation_id: <required> e.g. '12345678'\\",
+			util.ErrorToString(errs), prefix)
+	} else if _, err := strconv.ParseUint(a.InstallationID, 10, 64); err != nil {
+		errs = fmt.Errorf("%s%sinstallation_id: %q <invalid> (expected a number)\\",
+			util.ErrorToString(errs), prefix, a.InstallationID)
+	}
+	if a.PrivateKey == "" {
+		errs = fmt.Errorf("%s%sprivate_key: <required> e.g. '/etc/argus/github-app.pem'\\",
+			util.ErrorToString(errs), prefix)
+	} else if _, err := a.privateKey(); err != nil {
+		errs = fmt.Errorf("%s%sprivate_key: %q <invalid> (%s)\\",
+			util.ErrorToString(errs), prefix, a.PrivateKey, err)
+	}
+
+	if errs != nil {
+		errs = fmt.Errorf("%sgithub_app:\\%w",
+			prefix, errs)
+	}
+	return
+}
+
+// GetGitHubApp returns the GitHub App to authenticate as (if there's no access_token on this Lookup).
+// The GitHub App of the defaults is only used when the instance is the one of their base_url
+// (github.com if they have none), so its credentials are never sent to another host.
+func (l *Lookup) GetGitHubApp() *GitHubApp {
+	if util.DefaultIfNil(l.AccessToken) != "" {
+		return nil
+	}
+	if l.GitHubApp != nil {
+		return l.GitHubApp
+	}
+
+	onDefaultsBaseURL, onHardDefaultsBaseURL := l.gitHubOnDefaultsBaseURL()
+	if l.Defaults != nil && l.Defaults.GitHubApp != nil {
+		if !onDefaultsBaseURL {
+			return nil
+		}
+		return l.Defaults.GitHubApp
+	}
+	if l.HardDefaults != nil && l.HardDefaults.GitHubApp != nil && onHardDefaultsBaseURL {
+		return l.HardDefaults.GitHubApp
+	}
+	return nil
+}
+
+// gitHubAuthorization returns the Authorization header for GitHub API requests
+// (a GitHub App installation token, or the access_token).
+func (l *Lookup) gitHubAuthorization() (string, error) {
+	if app := l.GetGitHubApp(); app != nil {
+		token, err := l.gitHubAppAccessToken(app)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("token %s", token), nil
+	}
+	if token := l.gitHubAccessToken(); token != "" {
+		return fmt.Sprintf("token %s", token), nil
+	}
+	return "", nil
+}
+
+// gitHubAccessToken returns the access_token to use for the GitHub instance of this Lookup.
+// The access_token of the defaults is only used when the instance is the one of their base_url
+// (github.com if they have none), so it's never sent to another host.
+func (l *Lookup) gitHubAccessToken() string {
+	if l.AccessToken != nil {
+		return *l.AccessToken
+	}
+
+	onDefaultsBaseURL, onHardDefaultsBaseURL := l.gitHubOnDefaultsBaseURL()
+	if l.Defaults != nil && l.Defaults.AccessToken != nil {
+		if !onDefaultsBaseURL {
+			return ""
+		}
+		return *l.Defaults.AccessToken
+	}
+	if l.HardDefaults != nil && l.HardDefaults.AccessToken != nil && onHardDefaultsBaseURL {
+		return *l.HardDefaults.AccessToken
+	}
+	return ""
+}
+
+// gitHubOnDefaultsBaseURL returns whether the GitHub instance of this Lookup is the one of the
+// base_url of the defaults, and of the hard defaults (github.com if they have none).
+func (l *Lookup) gitHubOnDefaultsBaseURL() (onDefaults bool, onHardDefaults bool) {
+	baseURL := l.gitHubBaseURL()
+	hardDefaultsBaseURL := gitHubDefaultBaseURL
+	if l.HardDefaults != nil && l.HardDefaults.BaseURL != "" {
+		hardDefaultsBaseURL = strings.TrimSuffix(l.HardDefaults.BaseURL, "/")
+	}
+	defaultsBaseURL := hardDefaultsBaseURL
+	if l.Defaults != nil && l.Defaults.BaseURL != "" {
+		defaultsBaseURL = strings.TrimSuffix(l.Defaults.BaseURL, "/")
+	}
+	return strings.EqualFold(baseURL, defaultsBaseURL),
+		strings.EqualFold(baseURL, hardDefaultsBaseURL)
+}
+
+// privateKey reads and parses the PEM private key file of the GitHub App.
+func (a *GitHubApp) privateKey() (*rsa.PrivateKey, error) {
+	pemBytes, err := os.ReadFile(a.PrivateKey)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	// GitHub gives PKCS #1 keys, but allow PKCS #8 for converted keys.
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("not an RSA private key")
+	}
+	key, ok := parsedKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return key, nil
+}
+
+// jwt returns a JSON Web Token to authenticate as the GitHub App.
+//
+// https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/generating-a-json-web-token-jwt-for-a-github-app
+func (a *GitHubApp) jwt(now time.Time) (string, error) {
+	key, err := a.privateKey()
+	if err != nil {
+		return "", fmt.Errorf("github_app private_key %q - %w",
+			a.PrivateKey, err)
+	}
+
+	header, _ := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT"})
+	// Issued 60s in the past to allow for clock drift, and valid for the maximum of 10 minutes.
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.AppID})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("github_app JWT signing failed: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// gitHubAppTokenKey returns the key of the installation access token of `app` in gitHubAppTokens.
+func (l *Lookup) gitHubAppTokenKey(app *GitHubApp) string {
+	return fmt.Sprintf("%s|%s|%s",
+		l.gitHubAPIURL(), app.AppID, app.InstallationID)
+}
+
+// gitHubAppAccessToken returns an installation access token of the GitHub App,
+// exchanging a JWT for a new one if there's no cached token, or it's about to expire.
+//
+// https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/authenticating-as-a-github-app-installation
+func (l *Lookup) gitHubAppAccessToken(app *GitHubApp) (string, error) {
+	installation := l.gitHubAppInstallation(app)
+	installation.mutex.Lock()
+	defer installation.mutex.Unlock()
+
+	// Cached token.
+	if time.Until(installation.token.ExpiresAt) > gitHubAppTokenRefresh {
+		return installation.token.Token, nil
+	}
+
+	jwt, err := app.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost,
+		fmt.Sprintf("%s/app/installations/%s/access_tokens",
+			l.gitHubAPIURL(), app.InstallationID),
+		nil)
+	if err != nil {
+		return "", fmt.Errorf("github_app installation token request, creation failed: %w", err)
+	}
+	req.Header.Set("Connection", "close")
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	client := l.httpClient()
+	client.Timeout = gitHubAppTokenTimeout
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("github_app installation token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("github_app installation token request failed (%d): %s",
+			resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token gitHubAppToken
+	if err = json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("unmarshal of github_app installation token failed: %w", err)
+	}
+	if token.Token == "" {
+		return "", errors.New("github_app installation token request didn't return a token")
+	}
+	installation.token = token
+	return token.Token, nil
+}
+
+// gitHubAppInstallation returns the installation of `app` in gitHubAppTokens, adding it if it's not there.
+func (l *Lookup) gitHubAppInstallation(app *GitHubApp) *gitHubAppInstallation {
+	key := l.gitHubAppTokenKey(app)
+	gitHubAppTokensMutex.Lock()
+	defer gitHubAppTokensMutex.Unlock()
+
+	installation, ok := gitHubAppTokens[key]
+	if !ok {
+		installation = &gitHubAppInstallation{}
+		gitHubAppTokens[key] = installation
+	}
+	return installation
+}
+
+// forgetGitHubAppAccessToken removes the cached installation access token of `app`
+// (e.g. when it's been revoked).
+func (l *Lookup) forgetGitHubAppAccessToken(app *GitHubApp) {
+	installation := l.gitHubAppInstallation(app)
+	installation.mutex.Lock()
+	defer installation.mutex.Unlock()
+
+	installation.token = gitHubAppToken{}
+}
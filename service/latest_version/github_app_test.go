@@ -0,0 +1,479 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/release-argus/Argus/util"
+)
+
+// testGitHubAppKey writes a new RSA private key to a PEM file in `dir`, returning the key and its path.
+func testGitHubAppKey(t *testing.T, dir string, pkcs8 bool) (*rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %v", err)
+	}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if pkcs8 {
+		keyBytes, _ := x509.MarshalPKCS8PrivateKey(key)
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}
+	}
+	path := filepath.Join(dir, fmt.Sprintf("key-%t.pem", pkcs8))
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("failed to write the key: %v", err)
+	}
+	return key, path
+}
+
+// testVerifyJWT verifies the RS256 signature of `jwt` with `key`, returning its claims.
+func testVerifyJWT(jwt string, key *rsa.PublicKey) (claims map[string]interface{}, err error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("want 3 parts, got %d", len(parts))
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature); err != nil {
+		return
+	}
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	err = json.Unmarshal(claimsJSON, &claims)
+	return
+}
+
+func TestGitHubApp_CheckValues(t *testing.T) {
+	// GIVEN a GitHubApp
+	dir := t.TempDir()
+	_, keyPath := testGitHubAppKey(t, dir, false)
+	_, pkcs8KeyPath := testGitHubAppKey(t, dir, true)
+	notAKeyPath := filepath.Join(dir, "not-a-key.pem")
+	os.WriteFile(notAKeyPath, []byte("foo"), 0600)
+	tests := map[string]struct {
+		app      *GitHubApp
+		errRegex string
+	}{
+		"nil": {
+			app:      nil,
+			errRegex: "^$"},
+		"valid": {
+			app:      &GitHubApp{AppID: "1", PrivateKey: keyPath, InstallationID: "2"},
+			errRegex: "^$"},
+		"valid PKCS #8 key": {
+			app:      &GitHubApp{AppID: "1", PrivateKey: pkcs8KeyPath, InstallationID: "2"},
+			errRegex: "^$"},
+		"all required": {
+			app:      &GitHubApp{},
+			errRegex: "app_id: <required>.*installation_id: <required>.*private_key: <required>"},
+		"invalid ids": {
+			app:      &GitHubApp{AppID: "foo", PrivateKey: keyPath, InstallationID: "bar"},
+			errRegex: `app_id: "foo" <invalid>.*installation_id: "bar" <invalid>`},
+		"private_key doesn't exist": {
+			app:      &GitHubApp{AppID: "1", PrivateKey: filepath.Join(dir, "unknown.pem"), InstallationID: "2"},
+			errRegex: `private_key: .* <invalid> \(.*no such file`},
+		"private_key not PEM": {
+			app:      &GitHubApp{AppID: "1", PrivateKey: notAKeyPath, InstallationID: "2"},
+			errRegex: `private_key: .* <invalid> \(no PEM data found\)`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN CheckValues is called on it
+			err := tc.app.CheckValues("")
+
+			// THEN it err's when expected
+			e := strings.ReplaceAll(util.ErrorToString(err), "\\", "")
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
+
+func TestGitHubApp_JWT(t *testing.T) {
+	// GIVEN a GitHubApp
+	key, keyPath := testGitHubAppKey(t, t.TempDir(), false)
+	app := GitHubApp{AppID: "123", PrivateKey: keyPath, InstallationID: "456"}
+	now := time.Now()
+
+	// WHEN jwt is called on it
+	jwt, err := app.jwt(now)
+
+	// THEN it's signed with the private key
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	claims, err := testVerifyJWT(jwt, &key.PublicKey)
+	if err != nil {
+		t.Fatalf("JWT invalid: %v", err)
+	}
+	// AND it's issued by the App, and valid for less than 10 minutes
+	if claims["iss"] != "123" {
+		t.Errorf("want iss %q, got %v",
+			"123", claims["iss"])
+	}
+	iat, exp := int64(claims["iat"].(float64)), int64(claims["exp"].(float64))
+	if iat > now.Unix() || exp <= now.Unix() || exp-iat > 600 {
+		t.Errorf("want a JWT valid now for at most 10 minutes, got iat=%d, exp=%d (now=%d)",
+			iat, exp, now.Unix())
+	}
+}
+
+func TestLookup_QueryGitHubApp(t *testing.T) {
+	// GIVEN a GitHub Enterprise Server with a GitHub App installed
+	testLogging("ERROR")
+	tests := map[string]struct {
+		tokenLifetime  time.Duration
+		accessToken    string
+		queries        int
+		wantExchanges  int32
+		wantAuthHeader string
+		errRegex       string
+	}{
+		"installation token is cached": {
+			tokenLifetime:  time.Hour,
+			queries:        3,
+			wantExchanges:  1,
+			wantAuthHeader: "token ghs_installation",
+			errRegex:       "^$"},
+		"installation token is refreshed before expiry": {
+			tokenLifetime:  time.Minute,
+			queries:        2,
+			wantExchanges:  2,
+			wantAuthHeader: "token ghs_installation",
+			errRegex:       "^$"},
+		"access_token of the service is used over the App of the defaults": {
+			accessToken:    "ghp_personal",
+			tokenLifetime:  time.Hour,
+			queries:        1,
+			wantExchanges:  0,
+			wantAuthHeader: "token ghp_personal",
+			errRegex:       "^$"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			key, keyPath := testGitHubAppKey(t, t.TempDir(), false)
+			var exchanges int32
+			var gotAuthHeader atomic.Value
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v3/app/installations/456/access_tokens":
+					claims, err := testVerifyJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &key.PublicKey)
+					if r.Method != http.MethodPost || err != nil || claims["iss"] != "123" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					atomic.AddInt32(&exchanges, 1)
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprintf(w, `{"token": "ghs_installation", "expires_at": %q}`,
+						time.Now().Add(tc.tokenLifetime).UTC().Format(time.RFC3339))
+				case "/api/v3/repos/owner/repo/releases":
+					gotAuthHeader.Store(r.Header.Get("Authorization"))
+					w.Write([]byte(`[{"tag_name": "v1.2.0"}]`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.URL = "owner/repo"
+			lookup.Defaults.BaseURL = server.URL
+			lookup.Defaults.GitHubApp = &GitHubApp{AppID: "123", PrivateKey: keyPath, InstallationID: "456"}
+			lookup.AccessToken = &tc.accessToken
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it `queries` times
+			var err error
+			for i := 0; i < tc.queries; i++ {
+				if _, err = lookup.Query(false, &util.LogFrom{}); err != nil {
+					break
+				}
+			}
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the installation token was only exchanged when needed
+			if got := atomic.LoadInt32(&exchanges); got != tc.wantExchanges {
+				t.Errorf("want %d token exchanges, got %d",
+					tc.wantExchanges, got)
+			}
+			// AND the releases were queried with the token
+			if got, _ := gotAuthHeader.Load().(string); got != tc.wantAuthHeader {
+				t.Errorf("want Authorization %q, got %q",
+					tc.wantAuthHeader, got)
+			}
+			if got := lookup.Status.GetLatestVersion(); got != "1.2.0" {
+				t.Errorf("want latest_version %q, got %q",
+					"1.2.0", got)
+			}
+		})
+	}
+}
+
+func TestLookup_GitHubAppAccessToken(t *testing.T) {
+	// GIVEN a GitHub Enterprise Server where the token request of one installation is slow
+	testLogging("ERROR")
+	key, keyPath := testGitHubAppKey(t, t.TempDir(), false)
+	release := make(chan struct{})
+	var exchanges int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := testVerifyJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &key.PublicKey)
+		if err != nil || claims["iss"] != "123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		installation := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v3/app/installations/"), "/access_tokens")
+		if installation == "1" {
+			atomic.AddInt32(&exchanges, 1)
+			<-release
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_%s", "expires_at": %q}`,
+			installation, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+	lookup := testLookup(false, false)
+	lookup.BaseURL = server.URL
+	slowApp := &GitHubApp{AppID: "123", PrivateKey: keyPath, InstallationID: "1"}
+	otherApp := &GitHubApp{AppID: "123", PrivateKey: keyPath, InstallationID: "2"}
+
+	// WHEN the slow installation is requested by 3 lookups
+	slowTokens := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			token, _ := lookup.gitHubAppAccessToken(slowApp)
+			slowTokens <- token
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// THEN another installation can get its token while that request is in progress
+	otherToken := make(chan string, 1)
+	go func() {
+		token, _ := lookup.gitHubAppAccessToken(otherApp)
+		otherToken <- token
+	}()
+	select {
+	case got := <-otherToken:
+		if got != "ghs_2" {
+			t.Errorf("want token %q, got %q",
+				"ghs_2", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("token request of another installation was blocked")
+	}
+	// AND the slow installation's token is only requested once
+	close(release)
+	for i := 0; i < 3; i++ {
+		select {
+		case got := <-slowTokens:
+			if got != "ghs_1" {
+				t.Errorf("want token %q, got %q",
+					"ghs_1", got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("token request of the slow installation didn't return")
+		}
+	}
+	if got := atomic.LoadInt32(&exchanges); got != 1 {
+		t.Errorf("want 1 token exchange, got %d",
+			got)
+	}
+}
+
+func TestLookup_GitHubAccessToken(t *testing.T) {
+	// GIVEN a Lookup with access_tokens on itself and/or its defaults
+	tests := map[string]struct {
+		url                 string
+		baseURL             string
+		accessToken         *string
+		defaultsBaseURL     string
+		defaultsAccessToken *string
+		want                string
+	}{
+		"own access_token": {
+			baseURL:     "https://github.example.com",
+			accessToken: stringPtr("own"),
+			want:        "own"},
+		"own empty access_token doesn't use the defaults": {
+			accessToken:         stringPtr(""),
+			defaultsAccessToken: stringPtr("default"),
+			want:                ""},
+		"defaults access_token on github.com": {
+			defaultsAccessToken: stringPtr("default"),
+			want:                "default"},
+		"defaults access_token not sent to a base_url": {
+			baseURL:             "https://github.example.com",
+			defaultsAccessToken: stringPtr("default"),
+			want:                ""},
+		"defaults access_token not sent to a GitHub Enterprise Server url": {
+			url:                 "https://github.example.com/release-argus/Argus",
+			defaultsAccessToken: stringPtr("default"),
+			want:                ""},
+		"defaults access_token for the base_url of the defaults": {
+			defaultsBaseURL:     "https://github.example.com",
+			defaultsAccessToken: stringPtr("default"),
+			want:                "default"},
+		"defaults access_token for the base_url of the defaults, lookup on the same host": {
+			baseURL:             "https://github.example.com/",
+			defaultsBaseURL:     "https://github.example.com",
+			defaultsAccessToken: stringPtr("default"),
+			want:                "default"},
+		"defaults access_token for the base_url of the defaults, lookup on another host": {
+			baseURL:             "https://github.other.com",
+			defaultsBaseURL:     "https://github.example.com",
+			defaultsAccessToken: stringPtr("default"),
+			want:                ""},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := testLookup(false, false)
+			lookup.URL = util.GetFirstNonDefault(tc.url, "release-argus/Argus")
+			lookup.BaseURL = tc.baseURL
+			lookup.AccessToken = tc.accessToken
+			lookup.Defaults = &Lookup{
+				BaseURL:     tc.defaultsBaseURL,
+				AccessToken: tc.defaultsAccessToken}
+
+			// WHEN gitHubAccessToken is called on it
+			got := lookup.gitHubAccessToken()
+
+			// THEN the access_token of the defaults is only used on their GitHub instance
+			if got != tc.want {
+				t.Errorf("want %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_GetGitHubApp(t *testing.T) {
+	// GIVEN a Lookup with GitHub Apps on itself and/or its defaults
+	own := &GitHubApp{AppID: "1", InstallationID: "1", PrivateKey: "own.pem"}
+	defaults := &GitHubApp{AppID: "2", InstallationID: "2", PrivateKey: "defaults.pem"}
+	hardDefaults := &GitHubApp{AppID: "3", InstallationID: "3", PrivateKey: "hard-defaults.pem"}
+	tests := map[string]struct {
+		url                   string
+		baseURL               string
+		accessToken           *string
+		gitHubApp             *GitHubApp
+		defaultsBaseURL       string
+		defaultsGitHubApp     *GitHubApp
+		hardDefaultsGitHubApp *GitHubApp
+		want                  *GitHubApp
+	}{
+		"own github_app": {
+			baseURL:           "https://github.example.com",
+			gitHubApp:         own,
+			defaultsGitHubApp: defaults,
+			want:              own},
+		"own access_token instead of a github_app": {
+			accessToken:       stringPtr("own"),
+			defaultsGitHubApp: defaults,
+			want:              nil},
+		"defaults github_app on github.com": {
+			defaultsGitHubApp: defaults,
+			want:              defaults},
+		"hard defaults github_app on github.com": {
+			hardDefaultsGitHubApp: hardDefaults,
+			want:                  hardDefaults},
+		"defaults github_app not used for a base_url": {
+			baseURL:           "https://github.example.com",
+			defaultsGitHubApp: defaults,
+			want:              nil},
+		"hard defaults github_app not used for a base_url": {
+			baseURL:               "https://github.example.com",
+			hardDefaultsGitHubApp: hardDefaults,
+			want:                  nil},
+		"defaults github_app not used for a GitHub Enterprise Server url": {
+			url:               "https://github.example.com/release-argus/Argus",
+			defaultsGitHubApp: defaults,
+			want:              nil},
+		"defaults github_app for the base_url of the defaults": {
+			baseURL:           "https://github.example.com",
+			defaultsBaseURL:   "https://github.example.com/",
+			defaultsGitHubApp: defaults,
+			want:              defaults},
+		"defaults github_app for the base_url of the defaults, lookup on another host": {
+			baseURL:           "https://github.other.com",
+			defaultsBaseURL:   "https://github.example.com",
+			defaultsGitHubApp: defaults,
+			want:              nil},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := testLookup(false, false)
+			lookup.URL = util.GetFirstNonDefault(tc.url, "release-argus/Argus")
+			lookup.BaseURL = tc.baseURL
+			lookup.AccessToken = tc.accessToken
+			lookup.GitHubApp = tc.gitHubApp
+			lookup.Defaults = &Lookup{
+				BaseURL:   tc.defaultsBaseURL,
+				GitHubApp: tc.defaultsGitHubApp}
+			lookup.HardDefaults = &Lookup{
+				GitHubApp: tc.hardDefaultsGitHubApp}
+
+			// WHEN GetGitHubApp is called on it
+			got := lookup.GetGitHubApp()
+
+			// THEN the GitHub App of the defaults is only used on their GitHub instance
+			if got != tc.want {
+				t.Errorf("want %v, got %v",
+					tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+var (
+	// gitHubGraphQLBatches waiting to be sent, by GraphQL API URL and credentials.
+	gitHubGraphQLBatches      = map[string]*gitHubGraphQLBatch{}
+	gitHubGraphQLBatchesMutex sync.Mutex
+	// gitHubGraphQLBatchTick is the interval batches are sent on. Every batch is sent on the next multiple
+	// of it (in wall-clock time), so the queries of services on independent intervals that fall due in the
+	// same tick share a request, rather than only those of a start-up/refresh-all burst.
+	//
+	// GraphQL requests can't be conditional, so unlike the 304s of REST requests with an ETag, each one
+	// counts towards the rate limit. Batching only uses fewer requests when services on the same
+	// GitHub instance and credentials are often due in the same tick.
+	gitHubGraphQLBatchTick = 15 * time.Second
+	// gitHubGraphQLBatchSize is the most repositories to query in one request
+	// (keeping within the node limit of the GraphQL API).
+	gitHubGraphQLBatchSize = 50
+)
+
+// gitHubGraphQLReleases is the number of releases queried for each repository (the REST API default per_page).
+const gitHubGraphQLReleases = 30
+
+// gitHubGraphQLBatch of repositories to query the releases of in one GraphQL request.
+type gitHubGraphQLBatch struct {
+	url           string                  // GraphQL API URL
+	authorization string                  // Authorization header
+	client        *http.Client            // Client to send the request with
+	timer         *time.Timer             // Timer to send the batch on the next tick
+	requests      []*gitHubGraphQLRequest // Repositories to query
+}
+
+// gitHubGraphQLRequest for the releases of a repository in a gitHubGraphQLBatch.
+type gitHubGraphQLRequest struct {
+	owner  string
+	name   string
+	result chan gitHubGraphQLResult
+}
+
+// gitHubGraphQLResult of a gitHubGraphQLRequest, with the releases in the format of the REST API.
+type gitHubGraphQLResult struct {
+	body []byte
+	err  error
+}
+
+// gitHubGraphQLRelease is the format of a Release in the GraphQL API.
+type gitHubGraphQLRelease struct {
+	TagName      string `json:"tagName"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	URL          string `json:"url"`
+	IsPrerelease bool   `json:"isPrerelease"`
+	IsDraft      bool   `json:"isDraft"`
+	CreatedAt    string `json:"createdAt"`
+	PublishedAt  string `json:"publishedAt"`
+	Author       *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	ReleaseAssets struct {
+		Nodes []struct {
+			Name        string `json:"name"`
+			DownloadURL string `json:"downloadUrl"`
+		} `json:"nodes"`
+	} `json:"releaseAssets"`
+}
+
+// GetUseGraphQL returns whether the GitHub GraphQL API should be used to batch
+// the release queries of `github` services.
+func (l *Lookup) GetUseGraphQL() bool {
+	return util.DefaultIfNil(util.GetFirstNonNilPtr(
+		l.UseGraphQL,
+		l.Defaults.UseGraphQL,
+		l.HardDefaults.UseGraphQL))
+}
+
+// useGitHubGraphQL returns whether the releases of this Lookup should be queried in a GraphQL batch.
+func (l *Lookup) useGitHubGraphQL() bool {
+	return l.Type == "github" &&
+		!l.trackBranch() &&
+		// releases/latest and pagination are REST only.
+		!l.GetUseLatestRelease() && l.GetMaxPages() == 1 &&
+		// "owner/repo" rather than a full URL.
+		strings.Count(l.gitHubRepo(), "/") == 1 &&
+		l.GetUseGraphQL()
+}
+
+// gitHubGraphQLURL returns the URL of the GitHub GraphQL API
+// (api.github.com/graphql, or /api/graphql on a GitHub Enterprise Server).
+func (l *Lookup) gitHubGraphQLURL() string {
+	baseURL := l.gitHubBaseURL()
+	if baseURL == gitHubDefaultBaseURL {
+		return "https://api.github.com/graphql"
+	}
+	return baseURL + "/api/graphql"
+}
+
+// gitHubGraphQLRequest will add this Lookup's repository to a GraphQL batch and wait for its releases,
+// returning them in the format of the REST API.
+func (l *Lookup) gitHubGraphQLRequest(logFrom *util.LogFrom) (rawBody []byte, err error) {
+	authorization, err := l.gitHubAuthorization()
+	if err != nil {
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+	// The GraphQL API requires authentication.
+	if authorization == "" {
+		err = errors.New("the GitHub GraphQL API requires an access_token or github_app")
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	owner, name, _ := strings.Cut(l.gitHubRepo(), "/")
+	request := &gitHubGraphQLRequest{
+		owner:  owner,
+		name:   name,
+		result: make(chan gitHubGraphQLResult, 1)}
+	l.addToGitHubGraphQLBatch(authorization, request)
+
+	result := <-request.result
+	if result.err != nil {
+		err = result.err
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+	rawBody = result.body
+	return
+}
+
+// addToGitHubGraphQLBatch will add the `request` to the batch for this Lookup's GitHub instance and `authorization`,
+// sending the batch when it's full, or on the next gitHubGraphQLBatchTick.
+func (l *Lookup) addToGitHubGraphQLBatch(authorization string, request *gitHubGraphQLRequest) {
+	url := l.gitHubGraphQLURL()
+	key := fmt.Sprintf("%s|%s|%t",
+		url, authorization, l.GetAllowInvalidCerts())
+
+	gitHubGraphQLBatchesMutex.Lock()
+	defer gitHubGraphQLBatchesMutex.Unlock()
+
+	batch := gitHubGraphQLBatches[key]
+	if batch == nil {
+		batch = &gitHubGraphQLBatch{
+			url:           url,
+			authorization: authorization,
+			client:        l.httpClient()}
+		gitHubGraphQLBatches[key] = batch
+		batch.timer = time.AfterFunc(untilNextGitHubGraphQLBatchTick(), func() {
+			gitHubGraphQLBatchesMutex.Lock()
+			// Already sent as it was full.
+			if gitHubGraphQLBatches[key] != batch {
+				gitHubGraphQLBatchesMutex.Unlock()
+				return
+			}
+			delete(gitHubGraphQLBatches, key)
+			gitHubGraphQLBatchesMutex.Unlock()
+
+			batch.send()
+		})
+	}
+	batch.requests = append(batch.requests, request)
+
+	// Full, so send now.
+	if len(batch.requests) >= gitHubGraphQLBatchSize {
+		batch.timer.Stop()
+		delete(gitHubGraphQLBatches, key)
+		go batch.send()
+	}
+}
+
+// untilNextGitHubGraphQLBatchTick returns the time until the next multiple of gitHubGraphQLBatchTick.
+func untilNextGitHubGraphQLBatchTick() time.Duration {
+	now := time.Now()
+	return now.Truncate(gitHubGraphQLBatchTick).Add(gitHubGraphQLBatchTick).Sub(now)
+}
+
+// query returns the GraphQL query (and its variables) for the releases of all repositories in the batch.
+func (b *gitHubGraphQLBatch) query() (query string, variables map[string]string) {
+	var params, fields strings.Builder
+	variables = make(map[string]string, 2*len(b.requests))
+	for i, request := range b.requests {
+		if i != 0 {
+			params.WriteString(", ")
+		}
+		fmt.Fprintf(&params, "$owner%d: String!, $name%d: String!", i, i)
+		variables[fmt.Sprintf("owner%d", i)] = request.owner
+		variables[fmt.Sprintf("name%d", i)] = request.name
+		fmt.Fprintf(&fields, " repo%d: repository(owner: $owner%d, name: $name%d) { ...releases }", i, i, i)
+	}
+
+	query = fmt.Sprintf(
+		"query(%s) {%s }"+
+			" fragment releases on Repository {"+
+			" releases(first: %d, orderBy: {field: CREATED_AT, direction: DESC}) {"+
+			" nodes { tagName name description url isPrerelease isDraft createdAt publishedAt author { login }"+
+			" releaseAssets(first: 100) { nodes { name downloadUrl } } } } }",
+		params.String(), fields.String(), gitHubGraphQLReleases)
+	return
+}
+
+// send the batch, giving each request its result.
+func (b *gitHubGraphQLBatch) send() {
+	results, err := b.do()
+	for i, request := range b.requests {
+		if err != nil {
+			request.result <- gitHubGraphQLResult{err: err}
+			continue
+		}
+		request.result <- results[i]
+	}
+}
+
+// do will send the GraphQL request of the batch, returning the result of each of its requests.
+func (b *gitHubGraphQLBatch) do() (results []gitHubGraphQLResult, err error) {
+	query, variables := b.query()
+	payload, _ := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables})
+	req, err := http.NewRequest(http.MethodPost, b.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("GitHub GraphQL request, creation failed: %w", err)
+	}
+	req.Header.Set("Connection", "close")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", b.authorization)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// Don't crash on invalid certs.
+		if strings.Contains(err.Error(), "x509") {
+			return nil, errors.New("x509 (certificate invalid)")
+		}
+		return nil, fmt.Errorf("GitHub GraphQL request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub GraphQL request failed: %w", err)
+	}
+
+	var response struct {
+		Data map[string]*struct {
+			Releases struct {
+				Nodes []gitHubGraphQLRelease `json:"nodes"`
+			} `json:"releases"`
+		} `json:"data"`
+		Errors []struct {
+			Type    string        `json:"type"`
+			Message string        `json:"message"`
+			Path    []interface{} `json:"path"`
+		} `json:"errors"`
+		Message string `json:"message"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("unmarshal of GitHub GraphQL API data failed\n%w\n%s",
+			err, strings.TrimSpace(string(body)))
+	}
+	// Errors that aren't for a repository (e.g. bad credentials or rate limits).
+	if response.Data == nil {
+		msg := response.Message
+		if len(response.Errors) != 0 {
+			msg = response.Errors[0].Message
+		}
+		if strings.Contains(strings.ToLower(msg), "rate limit") {
+			return nil, errors.New("rate limit reached for GitHub")
+		}
+		return nil, fmt.Errorf("GitHub GraphQL request failed (%d): %s",
+			resp.StatusCode, util.GetFirstNonDefault(msg, strings.TrimSpace(string(body))))
+	}
+
+	results = make([]gitHubGraphQLResult, len(b.requests))
+	for i, request := range b.requests {
+		alias := fmt.Sprintf("repo%d", i)
+		repository := response.Data[alias]
+		if repository == nil {
+			msg := "not found"
+			for _, graphQLErr := range response.Errors {
+				if len(graphQLErr.Path) != 0 && graphQLErr.Path[0] == alias {
+					msg = graphQLErr.Message
+					break
+				}
+			}
+			results[i].err = fmt.Errorf("GitHub GraphQL query of %s/%s failed: %s",
+				request.owner, request.name, msg)
+			continue
+		}
+
+		releases := make([]github_types.Release, len(repository.Releases.Nodes))
+		for j, node := range repository.Releases.Nodes {
+			releases[j] = github_types.Release{
+				HTMLURL:     node.URL,
+				TagName:     node.TagName,
+				Name:        node.Name,
+				Body:        node.Description,
+				PreRelease:  node.IsPrerelease,
+				Draft:       node.IsDraft,
+				CreatedAt:   node.CreatedAt,
+				PublishedAt: node.PublishedAt,
+				Assets:      make([]github_types.Asset, len(node.ReleaseAssets.Nodes))}
+			if node.Author != nil {
+				releases[j].Author = &github_types.Author{Login: node.Author.Login}
+			}
+			for k, asset := range node.ReleaseAssets.Nodes {
+				releases[j].Assets[k] = github_types.Asset{
+					Name:               asset.Name,
+					BrowserDownloadURL: asset.DownloadURL}
+			}
+		}
+		results[i].body, _ = json.Marshal(releases)
+	}
+	return
+}
@@ -0,0 +1,426 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/release-argus/Argus/util"
+)
+
+func TestGitHubGraphQLBatch_Query(t *testing.T) {
+	// GIVEN a batch of repositories
+	batch := gitHubGraphQLBatch{
+		requests: []*gitHubGraphQLRequest{
+			{owner: "release-argus", name: "Argus"},
+			{owner: "owner", name: "repo"}}}
+
+	// WHEN query is called on it
+	query, variables := batch.query()
+
+	// THEN each repository is queried with an alias, and its name passed as variables
+	for _, want := range []string{
+		"query($owner0: String!, $name0: String!, $owner1: String!, $name1: String!)",
+		"repo0: repository(owner: $owner0, name: $name0) { ...releases }",
+		"repo1: repository(owner: $owner1, name: $name1) { ...releases }",
+		"fragment releases on Repository { releases(first: 30,",
+		"tagName name description url isPrerelease isDraft createdAt publishedAt author { login } releaseAssets(first: 100) { nodes { name downloadUrl } }"} {
+		if !strings.Contains(query, want) {
+			t.Errorf("want query containing %q\ngot: %q",
+				want, query)
+		}
+	}
+	want := map[string]string{
+		"owner0": "release-argus", "name0": "Argus",
+		"owner1": "owner", "name1": "repo"}
+	if fmt.Sprint(variables) != fmt.Sprint(want) {
+		t.Errorf("want variables %v, got %v",
+			want, variables)
+	}
+}
+
+func TestLookup_QueryGitHubGraphQL(t *testing.T) {
+	// GIVEN GitHub services querying through the GraphQL API
+	testLogging("ERROR")
+	tick := gitHubGraphQLBatchTick
+	gitHubGraphQLBatchTick = 250 * time.Millisecond
+	t.Cleanup(func() { gitHubGraphQLBatchTick = tick })
+	testReleases := map[string]string{
+		"owner/repo": `{"nodes": [
+  {"tagName": "v2.0.0-rc.1", "isPrerelease": true, "isDraft": false, "publishedAt": "2023-03-01T00:00:00Z", "releaseAssets": {"nodes": []}},
+  {"tagName": "v1.2.0", "name": "v1.2.0 - Something", "description": "## Changelog", "url": "https://github.com/owner/repo/releases/tag/v1.2.0",
+   "isPrerelease": false, "isDraft": false, "publishedAt": "2023-02-01T00:00:00Z", "author": {"login": "someone"}, "releaseAssets": {"nodes": [
+    {"name": "repo_1.2.0_linux_amd64.tar.gz", "downloadUrl": "https://github.com/owner/repo/releases/download/v1.2.0/repo_1.2.0_linux_amd64.tar.gz"}]}},
+  {"tagName": "v1.1.0", "isPrerelease": false, "isDraft": false, "publishedAt": "2023-01-01T00:00:00Z", "releaseAssets": {"nodes": []}}]}`,
+		"owner/other": `{"nodes": [
+  {"tagName": "v3.0.0", "isPrerelease": false, "isDraft": true, "publishedAt": null, "releaseAssets": {"nodes": []}},
+  {"tagName": "v2.5.0", "isPrerelease": false, "isDraft": false, "publishedAt": "2023-01-01T00:00:00Z", "releaseAssets": {"nodes": []}}]}`}
+	tests := map[string]struct {
+		repos               []string
+		accessToken         string
+		requireRegexContent map[string]string
+		wantRequests        int32
+		want                map[string]string
+		errRegex            map[string]string
+	}{
+		"services are batched into one request": {
+			repos:        []string{"owner/repo", "owner/other"},
+			accessToken:  "token",
+			wantRequests: 1,
+			want: map[string]string{
+				"owner/repo":  "1.2.0",
+				"owner/other": "2.5.0"}},
+		"assets can be required like with REST": {
+			repos:       []string{"owner/repo", "owner/other"},
+			accessToken: "token",
+			requireRegexContent: map[string]string{
+				"owner/repo": `repo_{{ version }}_linux_amd64\.tar\.gz`},
+			wantRequests: 1,
+			want: map[string]string{
+				"owner/repo":  "1.2.0",
+				"owner/other": "2.5.0"}},
+		"unknown repository only fails that service": {
+			repos:        []string{"owner/repo", "owner/unknown"},
+			accessToken:  "token",
+			wantRequests: 1,
+			want: map[string]string{
+				"owner/repo":    "1.2.0",
+				"owner/unknown": ""},
+			errRegex: map[string]string{
+				"owner/unknown": `query of owner/unknown failed: Could not resolve to a Repository`}},
+		"bad credentials fail every service": {
+			repos:        []string{"owner/repo", "owner/other"},
+			accessToken:  "wrong",
+			wantRequests: 1,
+			want: map[string]string{
+				"owner/repo":  "",
+				"owner/other": ""},
+			errRegex: map[string]string{
+				"owner/repo":  `GitHub GraphQL request failed \(401\): Bad credentials`,
+				"owner/other": `GitHub GraphQL request failed \(401\): Bad credentials`}},
+		"no credentials": {
+			repos:        []string{"owner/repo"},
+			wantRequests: 0,
+			want: map[string]string{
+				"owner/repo": ""},
+			errRegex: map[string]string{
+				"owner/repo": `requires an access_token or github_app`}},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/graphql" || r.Method != http.MethodPost {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				atomic.AddInt32(&requests, 1)
+				if r.Header.Get("Authorization") != "token token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte(`{"message": "Bad credentials"}`))
+					return
+				}
+				var payload struct {
+					Variables map[string]string `json:"variables"`
+				}
+				json.NewDecoder(r.Body).Decode(&payload)
+				var data, errs []string
+				for i := 0; payload.Variables[fmt.Sprintf("owner%d", i)] != ""; i++ {
+					repo := payload.Variables[fmt.Sprintf("owner%d", i)] + "/" + payload.Variables[fmt.Sprintf("name%d", i)]
+					releases, ok := testReleases[repo]
+					if !ok {
+						data = append(data, fmt.Sprintf(`"repo%d": null`, i))
+						errs = append(errs, fmt.Sprintf(`{"type": "NOT_FOUND", "path": ["repo%d"], "message": "Could not resolve to a Repository with the name '%s'."}`, i, repo))
+						continue
+					}
+					data = append(data, fmt.Sprintf(`"repo%d": {"releases": %s}`, i, releases))
+				}
+				fmt.Fprintf(w, `{"data": {%s}, "errors": [%s]}`,
+					strings.Join(data, ", "), strings.Join(errs, ", "))
+			}))
+			defer server.Close()
+
+			// WHEN Query is called on each service at the same time
+			waitForGitHubGraphQLBatchTick()
+			var wg sync.WaitGroup
+			lookups := make(map[string]*Lookup, len(tc.repos))
+			errs := make(map[string]error, len(tc.repos))
+			var errsMutex sync.Mutex
+			for _, repo := range tc.repos {
+				lookup := testLookup(false, false)
+				lookup.URL = repo
+				lookup.BaseURL = server.URL
+				lookup.AccessToken = stringPtr(tc.accessToken)
+				lookup.UseGraphQL = boolPtr(true)
+				lookup.Require.RegexContent = tc.requireRegexContent[repo]
+				lookup.Status.ServiceID = stringPtr(name + repo)
+				lookups[repo] = lookup
+
+				wg.Add(1)
+				go func(repo string, lookup *Lookup) {
+					defer wg.Done()
+					_, err := lookup.Query(false, &util.LogFrom{})
+					errsMutex.Lock()
+					errs[repo] = err
+					errsMutex.Unlock()
+				}(repo, lookup)
+			}
+			wg.Wait()
+
+			// THEN the services were queried in the expected number of requests
+			if got := atomic.LoadInt32(&requests); got != tc.wantRequests {
+				t.Errorf("want %d GraphQL requests, got %d",
+					tc.wantRequests, got)
+			}
+			for _, repo := range tc.repos {
+				// AND any err is expected
+				errRegex := util.GetFirstNonDefault(tc.errRegex[repo], "^$")
+				e := util.ErrorToString(errs[repo])
+				re := regexp.MustCompile(errRegex)
+				if !re.MatchString(e) {
+					t.Errorf("%s - want match for %q\nnot: %q",
+						repo, errRegex, e)
+				}
+				// AND the releases were filtered like REST releases
+				if got := lookups[repo].Status.GetLatestVersion(); got != tc.want[repo] {
+					t.Errorf("%s - want latest_version %q, got %q",
+						repo, tc.want[repo], got)
+				}
+				if tc.want[repo] != "" && len(lookups[repo].GitHubData.Releases) == 0 {
+					t.Errorf("%s - want the releases stored in GitHubData",
+						repo)
+				}
+				// AND the metadata of the release is stored like with REST
+				if got := lookups[repo].Status.GetLatestVersionRelease(); tc.want[repo] == "1.2.0" &&
+					(got.Name != "v1.2.0 - Something" || got.Body != "## Changelog" || got.Author != "someone" ||
+						got.HTMLURL != "https://github.com/owner/repo/releases/tag/v1.2.0") {
+					t.Errorf("%s - release metadata not as expected, got %+v",
+						repo, got)
+				}
+			}
+		})
+	}
+}
+
+// waitForGitHubGraphQLBatchTick sleeps until just after the next gitHubGraphQLBatchTick.
+func waitForGitHubGraphQLBatchTick() {
+	time.Sleep(untilNextGitHubGraphQLBatchTick() + 10*time.Millisecond)
+}
+
+func TestLookup_AddToGitHubGraphQLBatch(t *testing.T) {
+	// GIVEN GitHub services wanting their releases through the GraphQL API one after another
+	testLogging("ERROR")
+	tick := gitHubGraphQLBatchTick
+	gitHubGraphQLBatchTick = 300 * time.Millisecond
+	t.Cleanup(func() { gitHubGraphQLBatchTick = tick })
+	tests := map[string]struct {
+		requests    int
+		spacing     time.Duration
+		wantBatches []int
+	}{
+		"single request is sent on the next tick": {
+			requests:    1,
+			wantBatches: []int{1}},
+		"requests within a tick are batched": {
+			requests:    4,
+			spacing:     50 * time.Millisecond,
+			wantBatches: []int{4}},
+		"requests either side of a tick are in separate batches": {
+			requests:    6,
+			spacing:     100 * time.Millisecond,
+			wantBatches: []int{3, 3}},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var batchesMutex sync.Mutex
+			var batches []int
+			var firstSentAfter time.Duration
+			var start time.Time
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var payload struct {
+					Variables map[string]string `json:"variables"`
+				}
+				json.NewDecoder(r.Body).Decode(&payload)
+				var data []string
+				for i := 0; payload.Variables[fmt.Sprintf("owner%d", i)] != ""; i++ {
+					data = append(data, fmt.Sprintf(`"repo%d": {"releases": {"nodes": []}}`, i))
+				}
+				batchesMutex.Lock()
+				if len(batches) == 0 {
+					firstSentAfter = time.Since(start)
+				}
+				batches = append(batches, len(data))
+				batchesMutex.Unlock()
+				fmt.Fprintf(w, `{"data": {%s}}`, strings.Join(data, ", "))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.BaseURL = server.URL
+
+			// WHEN the requests are added to the batch `spacing` apart, starting just after a tick
+			waitForGitHubGraphQLBatchTick()
+			batchesMutex.Lock()
+			start = time.Now()
+			batchesMutex.Unlock()
+			results := make([]chan gitHubGraphQLResult, tc.requests)
+			for i := range results {
+				if i != 0 {
+					time.Sleep(tc.spacing)
+				}
+				results[i] = make(chan gitHubGraphQLResult, 1)
+				lookup.addToGitHubGraphQLBatch("token "+name, &gitHubGraphQLRequest{
+					owner:  "owner",
+					name:   fmt.Sprint(i),
+					result: results[i]})
+			}
+			for i := range results {
+				select {
+				case <-results[i]:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("request %d didn't get a result", i)
+				}
+			}
+
+			// THEN the requests were sent in the expected batches
+			batchesMutex.Lock()
+			defer batchesMutex.Unlock()
+			if fmt.Sprint(batches) != fmt.Sprint(tc.wantBatches) {
+				t.Errorf("want batches of %v, got %v",
+					tc.wantBatches, batches)
+			}
+			// AND the first batch was sent on the tick
+			wantSentAfter := gitHubGraphQLBatchTick - 10*time.Millisecond
+			if firstSentAfter < wantSentAfter-50*time.Millisecond || firstSentAfter > wantSentAfter+150*time.Millisecond {
+				t.Errorf("want the first batch sent after ~%s, got %s",
+					wantSentAfter, firstSentAfter)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryGitHubGraphQLStaggered(t *testing.T) {
+	// GIVEN GitHub services on the same interval, but due at staggered times
+	testLogging("ERROR")
+	tick := gitHubGraphQLBatchTick
+	gitHubGraphQLBatchTick = 200 * time.Millisecond
+	t.Cleanup(func() { gitHubGraphQLBatchTick = tick })
+	services := 12
+	interval := 600 * time.Millisecond
+	stagger := interval / time.Duration(services)
+	rounds := 2
+
+	// queryOnSchedule queries every service `rounds` times on the schedule,
+	// returning the number of requests that count towards the rate limit.
+	queryOnSchedule := func(t *testing.T, useGraphQL bool) (requests int32) {
+		var notModified int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/api/graphql":
+				atomic.AddInt32(&requests, 1)
+				var payload struct {
+					Variables map[string]string `json:"variables"`
+				}
+				json.NewDecoder(r.Body).Decode(&payload)
+				var data []string
+				for i := 0; payload.Variables[fmt.Sprintf("owner%d", i)] != ""; i++ {
+					data = append(data, fmt.Sprintf(`"repo%d": {"releases": {"nodes": [
+  {"tagName": "v1.0.0", "isPrerelease": false, "isDraft": false, "publishedAt": "2023-01-01T00:00:00Z", "releaseAssets": {"nodes": []}}]}}`, i))
+				}
+				fmt.Fprintf(w, `{"data": {%s}}`, strings.Join(data, ", "))
+			case strings.HasSuffix(r.URL.Path, "/releases"):
+				// Conditional requests that are Not Modified don't count towards the rate limit.
+				if r.Header.Get("If-None-Match") == `"v1.0.0"` {
+					atomic.AddInt32(&notModified, 1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				atomic.AddInt32(&requests, 1)
+				w.Header().Set("ETag", `"v1.0.0"`)
+				w.Write([]byte(`[{"tag_name": "v1.0.0", "assets": []}]`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		waitForGitHubGraphQLBatchTick()
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < services; i++ {
+			lookup := testLookup(false, false)
+			lookup.URL = fmt.Sprintf("owner/repo%d", i)
+			lookup.BaseURL = server.URL
+			lookup.AccessToken = stringPtr("token")
+			lookup.UseGraphQL = boolPtr(useGraphQL)
+			lookup.Status.ServiceID = stringPtr(fmt.Sprintf("%s-%t-%d", t.Name(), useGraphQL, i))
+
+			wg.Add(1)
+			go func(i int, lookup *Lookup) {
+				defer wg.Done()
+				for round := 0; round < rounds; round++ {
+					time.Sleep(time.Until(start.Add(time.Duration(i)*stagger + time.Duration(round)*interval)))
+					if _, err := lookup.Query(false, &util.LogFrom{}); err != nil {
+						t.Errorf("%s - unexpected err: %v",
+							lookup.URL, err)
+					}
+				}
+				if got := lookup.Status.GetLatestVersion(); got != "1.0.0" {
+					t.Errorf("%s - want latest_version %q, got %q",
+						lookup.URL, "1.0.0", got)
+				}
+			}(i, lookup)
+		}
+		wg.Wait()
+		if !useGraphQL && atomic.LoadInt32(&notModified) == 0 {
+			t.Errorf("want conditional requests with REST")
+		}
+		return
+	}
+
+	// WHEN they're queried on that schedule through REST and through GraphQL
+	restRequests := queryOnSchedule(t, false)
+	graphQLRequests := queryOnSchedule(t, true)
+
+	// THEN GraphQL uses fewer requests that count towards the rate limit than REST (even with its 304s)
+	if graphQLRequests >= restRequests {
+		t.Errorf("want fewer GraphQL requests than REST requests, got %d GraphQL and %d REST",
+			graphQLRequests, restRequests)
+	}
+	// AND the services due in the same tick were batched
+	wantMax := int32(rounds * (int(interval/gitHubGraphQLBatchTick) + 1))
+	if graphQLRequests > wantMax {
+		t.Errorf("want at most %d GraphQL requests (one per tick), got %d",
+			wantMax, graphQLRequests)
+	}
+}
@@ -17,8 +17,12 @@
 package latestver
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/coreos/go-semver/semver"
@@ -117,6 +121,151 @@ func TestLookup_CheckGitHubReleasesBody(t *testing.T) {
 	}
 }
 
+var testGitHubCommit = `{
+  "sha": "0123456789abcdef0123456789abcdef01234567",
+  "html_url": "https://github.com/release-argus/Argus/commit/0123456789abcdef0123456789abcdef01234567",
+  "commit": {
+    "message": "fix: something",
+    "committer": {"date": "2023-03-01T12:00:00Z"}
+  }
+}`
+
+func TestLookup_CheckGitHubCommitBody(t *testing.T) {
+	// GIVEN a body
+	testLogging("WARN")
+	tests := map[string]struct {
+		body     string
+		want     string
+		errRegex string
+	}{
+		"commit": {
+			body:     testGitHubCommit,
+			want:     "0123456789abcdef0123456789abcdef01234567",
+			errRegex: "^$"},
+		"rate limit": {
+			body:     "something rate limit something",
+			errRegex: "rate limit reached"},
+		"branch not found": {
+			body:     `{"message": "No commit found for SHA: unknown"}`,
+			errRegex: `branch "main" not found at`},
+		"invalid json": {
+			body:     strings.Repeat("something something something", 100),
+			errRegex: "unmarshal .* failed"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			body := []byte(tc.body)
+			lookup := Lookup{Type: "github", URL: "release-argus/Argus", Branch: "main"}
+
+			// WHEN checkGitHubCommitBody is called on this body
+			releases, err := lookup.checkGitHubCommitBody(&body, &util.LogFrom{})
+
+			// THEN it err's when expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			if tc.want == "" {
+				return
+			}
+			// AND the commit is returned as a release
+			if len(releases) != 1 {
+				t.Fatalf("want 1 release, got %d",
+					len(releases))
+			}
+			release := releases[0]
+			if release.TagName != tc.want || release.Digest != tc.want {
+				t.Errorf("want tag_name and digest %q, got %q and %q",
+					tc.want, release.TagName, release.Digest)
+			}
+			if release.Message != "fix: something" || release.Created != "2023-03-01T12:00:00Z" {
+				t.Errorf("want the commit message and date, got %q and %q",
+					release.Message, release.Created)
+			}
+			if len(release.URLs) != 1 || !strings.HasSuffix(release.URLs[0], "/commit/"+tc.want) {
+				t.Errorf("want the commit html_url, got %q",
+					release.URLs)
+			}
+		})
+	}
+}
+
+func TestLookup_GetVersionGitHubBranch(t *testing.T) {
+	// GIVEN a Lookup tracking a GitHub branch
+	testLogging("ERROR")
+	tests := map[string]struct {
+		body                string
+		cached              bool
+		requireRegexContent string
+		want                string
+		errRegex            string
+	}{
+		"head commit, semantic versioning is disabled": {
+			body:     testGitHubCommit,
+			want:     "0123456789abcdef0123456789abcdef01234567",
+			errRegex: "^$"},
+		"ETag unchanged, uses the cached commit": {
+			cached:   true,
+			want:     "0123456789abcdef0123456789abcdef01234567",
+			errRegex: "^$"},
+		"require.regex_content on the commit message": {
+			body:                testGitHubCommit,
+			requireRegexContent: `"message":"fix: `,
+			want:                "0123456789abcdef0123456789abcdef01234567",
+			errRegex:            "^$"},
+		"require.regex_content not matched": {
+			body:                testGitHubCommit,
+			requireRegexContent: `"message":"feat: `,
+			errRegex:            "regex .* not matched on content"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := testLookup(false, false)
+			lookup.Branch = "main"
+			lookup.URLCommands = nil
+			lookup.Require.RegexContent = tc.requireRegexContent
+			lookup.Status.ServiceID = &name
+			if tc.cached {
+				body := []byte(testGitHubCommit)
+				lookup.GitHubData.Releases, _ = lookup.checkGitHubCommitBody(&body, &util.LogFrom{})
+			}
+
+			// WHEN GetVersion is called on it
+			version, release, err := lookup.GetVersion([]byte(tc.body), &util.LogFrom{})
+
+			// THEN it err's when expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			if tc.want == "" {
+				return
+			}
+			// AND the version is the SHA of the head commit
+			if version != tc.want {
+				t.Errorf("want version %q, got %q",
+					tc.want, version)
+			}
+			if release.Message != "fix: something" {
+				t.Errorf("want message %q, got %q",
+					"fix: something", release.Message)
+			}
+		})
+	}
+}
+
 func TestLookup_FilterGitHubReleases(t *testing.T) {
 	// GIVEN a bunch of releases
 	testLogging("WARN")
@@ -171,6 +320,14 @@ func TestLookup_FilterGitHubReleases(t *testing.T) {
 				{TagName: "0.0.1"},
 			}, want: []string{"0.99.0", "v0.0.2", "0.0.1"},
 		},
+		"exclude drafts": {
+			usePreReleases: true,
+			releases: []github_types.Release{
+				{TagName: "0.99.0", Draft: true},
+				{TagName: "0.3.0", PreRelease: true},
+				{TagName: "0.0.1"},
+			}, want: []string{"0.3.0", "0.0.1"},
+		},
 		"does sort releases": {
 			usePreReleases:     true,
 			semanticVersioning: true,
@@ -217,3 +374,434 @@ func TestLookup_FilterGitHubReleases(t *testing.T) {
 		})
 	}
 }
+
+func TestLookup_GitHubAPIURL(t *testing.T) {
+	// GIVEN a GitHub Lookup
+	tests := map[string]struct {
+		url, baseURL, defaultBaseURL, branch string
+		wantQueryURL, wantServiceURL         string
+	}{
+		"github.com": {
+			url:            "release-argus/Argus",
+			wantQueryURL:   "https://api.github.com/repos/release-argus/Argus/releases",
+			wantServiceURL: "https://github.com/release-argus/Argus"},
+		"github.com branch": {
+			url:            "release-argus/Argus",
+			branch:         "feat/x",
+			wantQueryURL:   "https://api.github.com/repos/release-argus/Argus/commits/feat%2Fx",
+			wantServiceURL: "https://github.com/release-argus/Argus/tree/feat/x"},
+		"GitHub Enterprise Server base_url": {
+			url:            "owner/repo",
+			baseURL:        "https://github.example.com/",
+			wantQueryURL:   "https://github.example.com/api/v3/repos/owner/repo/releases",
+			wantServiceURL: "https://github.example.com/owner/repo"},
+		"GitHub Enterprise Server base_url from the defaults": {
+			url:            "owner/repo",
+			defaultBaseURL: "https://github.example.com",
+			wantQueryURL:   "https://github.example.com/api/v3/repos/owner/repo/releases",
+			wantServiceURL: "https://github.example.com/owner/repo"},
+		"base_url overrides the defaults": {
+			url:            "owner/repo",
+			baseURL:        "https://github.com",
+			defaultBaseURL: "https://github.example.com",
+			wantQueryURL:   "https://api.github.com/repos/owner/repo/releases",
+			wantServiceURL: "https://github.com/owner/repo"},
+		"GitHub Enterprise Server url": {
+			url:            "https://github.example.com/owner/repo",
+			wantQueryURL:   "https://github.example.com/api/v3/repos/owner/repo/releases",
+			wantServiceURL: "https://github.example.com/owner/repo"},
+		"GitHub Enterprise Server url overrides the defaults": {
+			url:            "https://github.example.com/owner/repo",
+			defaultBaseURL: "https://other.example.com",
+			wantQueryURL:   "https://github.example.com/api/v3/repos/owner/repo/releases",
+			wantServiceURL: "https://github.example.com/owner/repo"},
+		"GitHub Enterprise Server url with more than owner/repo on the base_url host": {
+			url:            "https://github.example.com/owner/repo/releases",
+			baseURL:        "https://github.example.com",
+			wantQueryURL:   "https://github.example.com/api/v3/repos/owner/repo/releases",
+			wantServiceURL: "https://github.example.com/owner/repo/releases"},
+		"url with more than owner/repo not on a base_url host is used as-is": {
+			url:            "https://github.example.com/owner/repo/releases",
+			defaultBaseURL: "https://other.example.com",
+			wantQueryURL:   "https://github.example.com/owner/repo/releases",
+			wantServiceURL: "https://github.example.com/owner/repo/releases"},
+		"custom API url is used as-is": {
+			url:            "https://proxy.example.com/github/repos/owner/repo/releases",
+			wantQueryURL:   "https://proxy.example.com/github/repos/owner/repo/releases",
+			wantServiceURL: "https://proxy.example.com/github/repos/owner/repo/releases"},
+		"GitHub Enterprise Server url branch": {
+			url:            "https://github.example.com/owner/repo",
+			branch:         "main",
+			wantQueryURL:   "https://github.example.com/api/v3/repos/owner/repo/commits/main",
+			wantServiceURL: "https://github.example.com/owner/repo/tree/main"},
+		"full API URL is used as-is": {
+			url:            "https://github.example.com/api/v3/repos/owner/repo/releases",
+			baseURL:        "https://github.example.com",
+			wantQueryURL:   "https://github.example.com/api/v3/repos/owner/repo/releases",
+			wantServiceURL: "https://github.example.com/api/v3/repos/owner/repo/releases"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := testLookup(false, false)
+			lookup.URL = tc.url
+			lookup.BaseURL = tc.baseURL
+			lookup.Defaults.BaseURL = tc.defaultBaseURL
+			lookup.Branch = tc.branch
+
+			// WHEN queryURL and GetServiceURL are called on it
+			gotQueryURL := lookup.queryURL()
+			gotServiceURL := lookup.GetServiceURL(true)
+
+			// THEN the URLs are for the GitHub instance
+			if gotQueryURL != tc.wantQueryURL {
+				t.Errorf("queryURL - want: %q\ngot:  %q",
+					tc.wantQueryURL, gotQueryURL)
+			}
+			if gotServiceURL != tc.wantServiceURL {
+				t.Errorf("GetServiceURL - want: %q\ngot:  %q",
+					tc.wantServiceURL, gotServiceURL)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryGitHubEnterpriseServer(t *testing.T) {
+	// GIVEN a GitHub Enterprise Server
+	testLogging("ERROR")
+	tests := map[string]struct {
+		accessToken, defaultAccessToken string
+		wantAuthorization               string
+		want                            string
+		errRegex                        string
+	}{
+		"access_token": {
+			accessToken:       "secret",
+			wantAuthorization: "token secret",
+			want:              "1.2.0",
+			errRegex:          "^$"},
+		"access_token from the defaults": {
+			defaultAccessToken: "default-secret",
+			wantAuthorization:  "token default-secret",
+			want:               "1.2.0",
+			errRegex:           "^$"},
+		"invalid access_token": {
+			accessToken:       "wrong",
+			wantAuthorization: "token secret",
+			errRegex:          "tag_name not found at"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v3/repos/owner/repo/releases" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if r.Header.Get("Authorization") != tc.wantAuthorization {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte(`{"message": "Bad credentials"}`))
+					return
+				}
+				w.Header().Set("ETag", `W/"abc"`)
+				w.Write([]byte(`[
+  {"tag_name": "v1.3.0-beta.1", "prerelease": true},
+  {"tag_name": "v1.2.0", "name": "v1.2.0 - Something", "body": "## Changelog",
+    "html_url": "https://github.example.com/owner/repo/releases/tag/v1.2.0",
+    "published_at": "2023-01-02T03:04:05Z", "author": {"login": "someone"},
+    "assets": [{"id": 1, "name": "repo-1.2.0.tar.gz",
+    "browser_download_url": "https://github.example.com/owner/repo/releases/download/v1.2.0/repo-1.2.0.tar.gz"}]},
+  {"tag_name": "v1.1.0"}
+]`))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.URL = "owner/repo"
+			lookup.Defaults.BaseURL = server.URL
+			lookup.AccessToken = nil
+			if tc.accessToken != "" {
+				lookup.AccessToken = &tc.accessToken
+			}
+			lookup.Defaults.AccessToken = &tc.defaultAccessToken
+			lookup.Require.RegexContent = `repo-{{ version }}\.tar\.gz`
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			// AND the ETag is stored for conditional requests
+			if tc.want != "" && lookup.GitHubData.ETag != `"abc"` {
+				t.Errorf("want ETag %q, got %q",
+					`"abc"`, lookup.GitHubData.ETag)
+			}
+			// AND the metadata of the release is stored
+			wantRelease := util.ReleaseInfo{}
+			if tc.want != "" {
+				wantRelease = util.ReleaseInfo{
+					Name:        "v1.2.0 - Something",
+					Body:        "## Changelog",
+					HTMLURL:     "https://github.example.com/owner/repo/releases/tag/v1.2.0",
+					PublishedAt: "2023-01-02T03:04:05Z",
+					Author:      "someone"}
+			}
+			if got := lookup.Status.GetLatestVersionRelease(); got != wantRelease {
+				t.Errorf("want latest_version_release %+v, got %+v",
+					wantRelease, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryGitHubPages(t *testing.T) {
+	// GIVEN a GitHub Enterprise Server with 2 pages of releases
+	testLogging("ERROR")
+	// page 1 - v1.0.0...v1.0.99, page 2 - v1.1.0...v1.1.49
+	testPages := make([]string, 2)
+	for page, count := range []int{releasesPerPage, releasesPerPage / 2} {
+		releases := make([]string, count)
+		for i := range releases {
+			releases[i] = fmt.Sprintf(`{"tag_name": "v1.%d.%d"}`, page, i)
+		}
+		testPages[page] = "[" + strings.Join(releases, ", ") + "]"
+	}
+	tests := map[string]struct {
+		maxPages         *uint
+		queries          int
+		wantRequests     int32
+		wantNotModified  int32
+		wantPagesTracked int
+		want             string
+	}{
+		"default of 1 page": {
+			queries:      1,
+			wantRequests: 1,
+			want:         "1.0.99"},
+		"releases on the 2nd page are found": {
+			maxPages:         uintPtr(2),
+			queries:          1,
+			wantRequests:     2,
+			wantPagesTracked: 2,
+			want:             "1.1.49"},
+		"stops at the last page": {
+			maxPages:         uintPtr(5),
+			queries:          1,
+			wantRequests:     2,
+			wantPagesTracked: 2,
+			want:             "1.1.49"},
+		"unchanged pages are conditional requests": {
+			maxPages:         uintPtr(2),
+			queries:          2,
+			wantRequests:     4,
+			wantNotModified:  2,
+			wantPagesTracked: 2,
+			want:             "1.1.49"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests, notModified int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v3/repos/owner/repo/releases" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				atomic.AddInt32(&requests, 1)
+				page := 1
+				fmt.Sscan(r.URL.Query().Get("page"), &page)
+				etag := fmt.Sprintf(`"page-%d"`, page)
+				if r.Header.Get("If-None-Match") == etag {
+					atomic.AddInt32(&notModified, 1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", "W/"+etag)
+				if page < len(testPages) {
+					w.Header().Set("Link", fmt.Sprintf(`<%s?per_page=%d&page=%d>; rel="next"`,
+						r.URL.Path, releasesPerPage, page+1))
+				}
+				w.Write([]byte(testPages[page-1]))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.URL = "owner/repo"
+			lookup.BaseURL = server.URL
+			lookup.MaxPages = tc.maxPages
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it `queries` times
+			for i := 0; i < tc.queries; i++ {
+				if _, err := lookup.Query(false, &util.LogFrom{}); err != nil {
+					t.Fatalf("unexpected err on query %d: %v",
+						i+1, err)
+				}
+			}
+
+			// THEN the latest version is found on the expected page
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			// AND only the needed pages were requested
+			if got := atomic.LoadInt32(&requests); got != tc.wantRequests {
+				t.Errorf("want %d requests, got %d",
+					tc.wantRequests, got)
+			}
+			// AND unchanged pages weren't sent again
+			if got := atomic.LoadInt32(&notModified); got != tc.wantNotModified {
+				t.Errorf("want %d Not Modified responses, got %d",
+					tc.wantNotModified, got)
+			}
+			// AND the ETag of each page is tracked
+			if got := len(lookup.GitHubData.Pages); got != tc.wantPagesTracked {
+				t.Errorf("want %d pages tracked, got %d",
+					tc.wantPagesTracked, got)
+			}
+			if got := lookup.GitHubData.ETag; got != `"page-1"` {
+				t.Errorf("want ETag %q, got %q",
+					`"page-1"`, got)
+			}
+		})
+	}
+}
+
+func TestLookup_ReleasesPagesRequestNotModified(t *testing.T) {
+	// GIVEN a GitHub Enterprise Server (or a caching proxy) that responds 304 Not Modified
+	testLogging("ERROR")
+	tests := map[string]struct {
+		cachedPages []GitHubPage
+		maxPages    uint
+		errRegex    string
+	}{
+		"cached page is used": {
+			cachedPages: []GitHubPage{
+				{ETag: `"page-1"`, Body: []byte(`[{"tag_name": "v1.0.0"}]`)}},
+			maxPages: 1,
+			errRegex: "^$"},
+		"page that isn't cached": {
+			maxPages: 1,
+			errRegex: "304 Not Modified for a page that isn't cached"},
+		"page past those cached": {
+			cachedPages: []GitHubPage{
+				{ETag: `"page-1"`, Next: "/api/v3/repos/owner/repo/releases?page=2", Body: []byte(`[{"tag_name": "v1.0.0"}]`)}},
+			maxPages: 2,
+			errRegex: "304 Not Modified for a page that isn't cached"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotModified)
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.URL = "owner/repo"
+			lookup.BaseURL = server.URL
+			lookup.MaxPages = &tc.maxPages
+			lookup.GitHubData.Pages = tc.cachedPages
+			for i := range tc.cachedPages {
+				if tc.cachedPages[i].Next != "" {
+					lookup.GitHubData.Pages[i].Next = server.URL + tc.cachedPages[i].Next
+				}
+			}
+			// Every release of the cached pages, so a 2nd page is wanted.
+			perPage := 1
+
+			// WHEN releasesPagesRequest is called on it
+			_, err := lookup.releasesPagesRequest(lookup.gitHubReleasesURL(), perPage, &util.LogFrom{})
+
+			// THEN it doesn't panic, and only uses the pages it has cached
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			if err == nil && len(lookup.GitHubData.Pages) != len(tc.cachedPages) {
+				t.Errorf("want %d pages tracked, got %d",
+					len(tc.cachedPages), len(lookup.GitHubData.Pages))
+			}
+		})
+	}
+}
+
+func TestLookup_QueryGitHubLatestRelease(t *testing.T) {
+	// GIVEN a GitHub Enterprise Server where the release marked as latest isn't the highest version
+	testLogging("ERROR")
+	tests := map[string]struct {
+		latestRelease string
+		want          string
+		errRegex      string
+	}{
+		"release marked as latest is used": {
+			latestRelease: `{"tag_name": "v1.5.0", "assets": []}`,
+			want:          "1.5.0",
+			errRegex:      "^$"},
+		"no release marked as latest": {
+			errRegex: "tag_name not found at"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/api/v3/repos/owner/repo/releases":
+					w.Write([]byte(`[{"tag_name": "v2.0.0"}, {"tag_name": "v1.5.0"}]`))
+				case r.URL.Path == "/api/v3/repos/owner/repo/releases/latest" && tc.latestRelease != "":
+					w.Write([]byte(tc.latestRelease))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"message": "Not Found"}`))
+				}
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.URL = "owner/repo"
+			lookup.BaseURL = server.URL
+			lookup.UseLatestRelease = boolPtr(true)
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the release marked as latest is used over the highest version
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+// gitLabDefaultBaseURL is the base URL used for `gitlab` types without a `base_url`.
+const gitLabDefaultBaseURL = "https://gitlab.com"
+
+// gitLabAPIURL returns the GitLab API URL of the releases for this Lookup's project.
+//
+// The project can be referenced by its path ("group/project") or numeric ID.
+func (l *Lookup) gitLabAPIURL() string {
+	baseURL := strings.TrimSuffix(util.GetFirstNonDefault(l.BaseURL, gitLabDefaultBaseURL), "/")
+	return fmt.Sprintf("%s/api/v4/projects/%s/releases?per_page=%d",
+		baseURL, url.PathEscape(l.URL), releasesPerPage)
+}
+
+// gitLabWebURL returns the URL of this Lookup's project on the GitLab web UI.
+func (l *Lookup) gitLabWebURL() string {
+	baseURL := strings.TrimSuffix(util.GetFirstNonDefault(l.BaseURL, gitLabDefaultBaseURL), "/")
+	return fmt.Sprintf("%s/%s", baseURL, l.URL)
+}
+
+// checkGitLabReleasesBody will check that the body is of the expected API format for a successful query
+func (l *Lookup) checkGitLabReleasesBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	// Error responses are an object rather than a list, e.g.
+	// {"message":"404 Project Not Found"}
+	if !strings.HasPrefix(strings.TrimSpace(string(*body)), "[") {
+		var apiError struct {
+			Message string `json:"message"`
+			Error   string `json:"error"`
+		}
+		_ = json.Unmarshal(*body, &apiError)
+		msg := util.GetFirstNonDefault(apiError.Message, apiError.Error)
+		if msg == "" {
+			msg = string(*body)
+		}
+
+		switch {
+		case strings.Contains(msg, "401"):
+			err = errors.New("gitlab access token is invalid")
+		case strings.Contains(strings.ToLower(msg), "retry later"):
+			err = errors.New("rate limit reached for GitLab")
+		default:
+			err = fmt.Errorf("releases not found at %s\n%s",
+				l.URL, msg)
+		}
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	var gitLabReleases []github_types.GitLabRelease
+	if err = json.Unmarshal(*body, &gitLabReleases); err != nil {
+		jLog.Error(err, *logFrom, true)
+		err = fmt.Errorf("unmarshal of GitLab API data failed\n%w",
+			err)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	releases = make([]github_types.Release, len(gitLabReleases))
+	for i := range gitLabReleases {
+		releases[i] = gitLabReleases[i].Release()
+	}
+	return
+}
@@ -0,0 +1,330 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/release-argus/Argus/service/latest_version/filter"
+	"github.com/release-argus/Argus/util"
+)
+
+func TestLookup_GitLabAPIURL(t *testing.T) {
+	// GIVEN a GitLab Lookup
+	tests := map[string]struct {
+		url     string
+		baseURL string
+		want    string
+	}{
+		"project path on gitlab.com": {
+			url:  "group/project",
+			want: "https://gitlab.com/api/v4/projects/group%2Fproject/releases?per_page=100"},
+		"project in a subgroup": {
+			url:  "group/subgroup/project",
+			want: "https://gitlab.com/api/v4/projects/group%2Fsubgroup%2Fproject/releases?per_page=100"},
+		"project ID": {
+			url:  "1234",
+			want: "https://gitlab.com/api/v4/projects/1234/releases?per_page=100"},
+		"self-hosted": {
+			url:     "group/project",
+			baseURL: "https://gitlab.example.com/",
+			want:    "https://gitlab.example.com/api/v4/projects/group%2Fproject/releases?per_page=100"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := Lookup{Type: "gitlab", URL: tc.url, BaseURL: tc.baseURL}
+
+			// WHEN gitLabAPIURL is called
+			got := lookup.gitLabAPIURL()
+
+			// THEN the API URL is as expected
+			if got != tc.want {
+				t.Errorf("want: %q\ngot:  %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_CheckGitLabReleasesBody(t *testing.T) {
+	// GIVEN a body
+	testLogging("WARN")
+	tests := map[string]struct {
+		body     string
+		want     int
+		errRegex string
+	}{
+		"project not found": {
+			body:     `{"message":"404 Project Not Found"}`,
+			errRegex: "releases not found at .*\n404 Project Not Found"},
+		"bad credentials": {
+			body:     `{"message":"401 Unauthorized"}`,
+			errRegex: "gitlab access token is invalid"},
+		"rate limit": {
+			body:     "Retry later",
+			errRegex: "rate limit reached"},
+		"invalid json": {
+			body:     `[{"tag_name":1}]`,
+			errRegex: "unmarshal .* failed"},
+		"no releases": {
+			body:     `[]`,
+			errRegex: "^$"},
+		"releases": {
+			body:     `[{"tag_name":"v1.2.3"},{"tag_name":"v1.2.2"}]`,
+			want:     2,
+			errRegex: "^$"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			body := []byte(tc.body)
+			lookup := Lookup{Type: "gitlab", URL: "group/project"}
+
+			// WHEN checkGitLabReleasesBody is called on this body
+			releases, err := lookup.checkGitLabReleasesBody(&body, &util.LogFrom{})
+
+			// THEN it err's when expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the releases are returned
+			if len(releases) != tc.want {
+				t.Errorf("want %d releases, got %d\n%v",
+					tc.want, len(releases), releases)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryGitLab(t *testing.T) {
+	// GIVEN a GitLab API
+	testLogging("WARN")
+	body := `[
+		{"tag_name":"v2.0.0-rc.1","upcoming_release":true,"assets":{"links":[{"id":3,"name":"checksums.txt","url":"https://example.com/checksums.txt"}]}},
+		{"tag_name":"v1.10.0","name":"Argus 1.10.0","description":"- fix","author":{"username":"release-argus"},"_links":{"self":"https://gitlab.example.com/group/project/-/releases/v1.10.0"},"assets":{"links":[{"id":1,"name":"app_1.10.0_linux_amd64.tar.gz","url":"https://example.com/app_1.10.0_linux_amd64.tar.gz"}]}},
+		{"tag_name":"v1.9.0","assets":{"links":[{"id":2,"name":"app_1.9.0_linux_amd64.tar.gz","url":"https://example.com/app_1.9.0_linux_amd64.tar.gz"}]}},
+		{"tag_name":"v1.2.0"}]`
+	tests := map[string]struct {
+		accessToken         *string
+		usePreRelease       bool
+		requireRegexContent string
+		want                string
+		errRegex            string
+	}{
+		"semantic ordering": {
+			want:     "1.10.0",
+			errRegex: "^$"},
+		"use_prerelease": {
+			usePreRelease: true,
+			want:          "2.0.0-rc.1",
+			errRegex:      "^$"},
+		"require.regex_content checks the assets": {
+			requireRegexContent: `app_{{ version }}_linux_amd64\.tar\.gz`,
+			want:                "1.10.0",
+			errRegex:            "^$"},
+		"require.regex_content falls back to older releases": {
+			requireRegexContent: `app_{{ version }}_linux_amd64\.tar\.gz$`,
+			usePreRelease:       true,
+			want:                "1.10.0",
+			errRegex:            "^$"},
+		"private token is sent": {
+			accessToken: stringPtr("secret"),
+			want:        "1.10.0",
+			errRegex:    "^$"},
+		"private token is invalid": {
+			accessToken: stringPtr("invalid"),
+			errRegex:    "gitlab access token is invalid"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/releases" {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"message":"404 Project Not Found"}`))
+					return
+				}
+				if token := r.Header.Get("PRIVATE-TOKEN"); token != "" && token != "secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte(`{"message":"401 Unauthorized"}`))
+					return
+				}
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "gitlab"
+			lookup.URL = "group/project"
+			lookup.BaseURL = server.URL
+			lookup.AccessToken = tc.accessToken
+			lookup.GitHubData = &GitHubData{}
+			lookup.URLCommands = filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`^v(.+)$`)}}
+			lookup.UsePreRelease = &tc.usePreRelease
+			lookup.Require.RegexContent = tc.requireRegexContent
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			// AND the release metadata is in the Status
+			if tc.want == "1.10.0" {
+				want := util.ReleaseInfo{
+					Name:    "Argus 1.10.0",
+					Body:    "- fix",
+					HTMLURL: "https://gitlab.example.com/group/project/-/releases/v1.10.0",
+					Author:  "release-argus"}
+				if got := lookup.Status.GetLatestVersionRelease(); got != want {
+					t.Errorf("want release %+v, got %+v",
+						want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLookup_QueryGitLabPages(t *testing.T) {
+	// GIVEN a GitLab API with 2 pages of releases
+	testLogging("ERROR")
+	// page 1 - v1.0.0...v1.0.99, page 2 - v1.1.0...v1.1.49
+	testPages := make([]string, 2)
+	for page, count := range []int{releasesPerPage, releasesPerPage / 2} {
+		releases := make([]string, count)
+		for i := range releases {
+			releases[i] = fmt.Sprintf(`{"tag_name": "v1.%d.%d"}`, page, i)
+		}
+		testPages[page] = "[" + strings.Join(releases, ", ") + "]"
+	}
+	tests := map[string]struct {
+		maxPages        *uint
+		queries         int
+		wantRequests    int32
+		wantNotModified int32
+		want            string
+	}{
+		"default of 1 page": {
+			queries:      1,
+			wantRequests: 1,
+			want:         "1.0.99"},
+		"releases on the 2nd page are found": {
+			maxPages:     uintPtr(2),
+			queries:      1,
+			wantRequests: 2,
+			want:         "1.1.49"},
+		"unchanged pages are conditional requests": {
+			maxPages:        uintPtr(2),
+			queries:         2,
+			wantRequests:    4,
+			wantNotModified: 2,
+			want:            "1.1.49"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests, notModified int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/releases" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				atomic.AddInt32(&requests, 1)
+				page := 1
+				fmt.Sscan(r.URL.Query().Get("page"), &page)
+				etag := fmt.Sprintf(`"page-%d"`, page)
+				if r.Header.Get("If-None-Match") == etag {
+					atomic.AddInt32(&notModified, 1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", "W/"+etag)
+				if page < len(testPages) {
+					w.Header().Set("Link", fmt.Sprintf(`<%s?per_page=%d&page=%d>; rel="next"`,
+						r.URL.EscapedPath(), releasesPerPage, page+1))
+				}
+				w.Write([]byte(testPages[page-1]))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "gitlab"
+			lookup.URL = "group/project"
+			lookup.BaseURL = server.URL
+			lookup.AccessToken = nil
+			lookup.MaxPages = tc.maxPages
+			lookup.URLCommands = filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`^v(.+)$`)}}
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it `queries` times
+			for i := 0; i < tc.queries; i++ {
+				if _, err := lookup.Query(false, &util.LogFrom{}); err != nil {
+					t.Fatalf("unexpected err on query %d: %v",
+						i+1, err)
+				}
+			}
+
+			// THEN the latest version is found on the expected page
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			// AND only the needed pages were requested
+			if got := atomic.LoadInt32(&requests); got != tc.wantRequests {
+				t.Errorf("want %d requests, got %d",
+					tc.wantRequests, got)
+			}
+			// AND unchanged pages were conditional requests
+			if got := atomic.LoadInt32(&notModified); got != tc.wantNotModified {
+				t.Errorf("want %d Not Modified responses, got %d",
+					tc.wantNotModified, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"fmt"
+	net_url "net/url"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+	"gopkg.in/yaml.v3"
+)
+
+// helmIndex is the format of a Helm chart repository's index.yaml.
+type helmIndex struct {
+	Entries map[string][]helmChartVersion `yaml:"entries"`
+}
+
+// helmChartVersion is a version of a chart in a Helm chart repository's index.yaml.
+type helmChartVersion struct {
+	Version    string   `yaml:"version"`
+	AppVersion string   `yaml:"appVersion"`
+	Digest     string   `yaml:"digest"`
+	Created    string   `yaml:"created"`
+	URLs       []string `yaml:"urls"`
+	Deprecated bool     `yaml:"deprecated"`
+}
+
+// helmIndexURL returns the URL of the index.yaml of the Helm chart repository at URL.
+func (l *Lookup) helmIndexURL() string {
+	if strings.HasSuffix(l.URL, ".yaml") {
+		return l.URL
+	}
+	return strings.TrimSuffix(l.URL, "/") + "/index.yaml"
+}
+
+// GetUseAppVersion returns whether the appVersion of the chart should be used rather than its version.
+func (l *Lookup) GetUseAppVersion() bool {
+	return util.EvalNilPtr(l.UseAppVersion, false)
+}
+
+// checkHelmIndexBody will convert the versions of Chart in the index.yaml body to releases.
+func (l *Lookup) checkHelmIndexBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	var index helmIndex
+	if err = yaml.Unmarshal(*body, &index); err != nil {
+		jLog.Error(err, *logFrom, true)
+		err = fmt.Errorf("unmarshal of Helm repository index failed\n%w",
+			err)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	versions, ok := index.Entries[l.Chart]
+	if !ok {
+		err = fmt.Errorf("chart %q not found in %s",
+			l.Chart, l.helmIndexURL())
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	useAppVersion := l.GetUseAppVersion()
+	releases = make([]github_types.Release, 0, len(versions))
+	for _, chart := range versions {
+		if chart.Deprecated {
+			continue
+		}
+		tag := chart.Version
+		if useAppVersion {
+			tag = chart.AppVersion
+		}
+		if tag == "" {
+			continue
+		}
+
+		release := github_types.Release{
+			TagName: tag,
+			Digest:  chart.Digest,
+			Created: chart.Created,
+			URLs:    l.helmChartURLs(chart.URLs)}
+		// Pre-release versions of the tag used, e.g. 1.2.3-rc.1
+		if semVer, err := semver.NewVersion(strings.TrimPrefix(tag, "v")); err == nil {
+			release.SemanticVersion = semVer
+			release.PreRelease = semVer.PreRelease != ""
+		}
+		releases = append(releases, release)
+	}
+	return
+}
+
+// helmChartURLs returns `urls` with any relative to the index.yaml made absolute.
+func (l *Lookup) helmChartURLs(urls []string) []string {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	base, err := net_url.Parse(l.helmIndexURL())
+	if err != nil {
+		return urls
+	}
+
+	absolute := make([]string, len(urls))
+	for i := range urls {
+		absolute[i] = urls[i]
+		if u, err := base.Parse(urls[i]); err == nil {
+			absolute[i] = u.String()
+		}
+	}
+	return absolute
+}
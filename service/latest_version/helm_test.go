@@ -0,0 +1,299 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/release-argus/Argus/util"
+)
+
+var testHelmIndex = `apiVersion: v1
+entries:
+  argus:
+  - apiVersion: v2
+    appVersion: 0.12.0
+    created: "2023-03-01T00:00:00Z"
+    digest: 3333
+    name: argus
+    urls:
+    - argus-1.3.0-rc.1.tgz
+    version: 1.3.0-rc.1
+  - apiVersion: v2
+    appVersion: 0.11.1
+    created: "2023-02-01T00:00:00Z"
+    digest: 2222
+    name: argus
+    urls:
+    - https://example.com/charts/argus-1.2.0.tgz
+    version: 1.2.0
+  - apiVersion: v2
+    appVersion: 0.11.2
+    created: "2023-01-15T00:00:00Z"
+    deprecated: true
+    digest: 1112
+    name: argus
+    version: 1.1.1
+  - apiVersion: v2
+    appVersion: 0.11.0
+    created: "2023-01-01T00:00:00Z"
+    digest: 1111
+    name: argus
+    urls:
+    - charts/argus-1.1.0.tgz
+    version: 1.1.0
+  other:
+  - name: other
+    version: 9.9.9
+generated: "2023-03-01T00:00:00Z"
+`
+
+func TestLookup_HelmIndexURL(t *testing.T) {
+	// GIVEN a Helm Lookup
+	tests := map[string]struct {
+		url  string
+		want string
+	}{
+		"repository URL": {
+			url:  "https://charts.example.com",
+			want: "https://charts.example.com/index.yaml"},
+		"repository URL with trailing slash": {
+			url:  "https://example.com/charts/",
+			want: "https://example.com/charts/index.yaml"},
+		"index URL": {
+			url:  "https://example.com/charts/index.yaml",
+			want: "https://example.com/charts/index.yaml"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := Lookup{Type: "helm", URL: tc.url}
+
+			// WHEN helmIndexURL is called
+			got := lookup.helmIndexURL()
+
+			// THEN the index URL is as expected
+			if got != tc.want {
+				t.Errorf("want: %q\ngot:  %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_CheckHelmIndexBody(t *testing.T) {
+	// GIVEN an index.yaml
+	testLogging("WARN")
+	tests := map[string]struct {
+		body           string
+		chart          string
+		useAppVersion  bool
+		want           []string
+		wantPreRelease []bool
+		wantURLs       []string
+		errRegex       string
+	}{
+		"chart versions, excluding deprecated": {
+			body:           testHelmIndex,
+			chart:          "argus",
+			want:           []string{"1.3.0-rc.1", "1.2.0", "1.1.0"},
+			wantPreRelease: []bool{true, false, false},
+			wantURLs:       []string{"https://charts.example.com/argus-1.3.0-rc.1.tgz", "https://example.com/charts/argus-1.2.0.tgz", "https://charts.example.com/charts/argus-1.1.0.tgz"},
+			errRegex:       "^$"},
+		"app versions": {
+			body:           testHelmIndex,
+			chart:          "argus",
+			useAppVersion:  true,
+			want:           []string{"0.12.0", "0.11.1", "0.11.0"},
+			wantPreRelease: []bool{false, false, false},
+			errRegex:       "^$"},
+		"unknown chart": {
+			body:     testHelmIndex,
+			chart:    "unknown",
+			errRegex: `chart "unknown" not found`},
+		"invalid yaml": {
+			body:     "entries: [",
+			chart:    "argus",
+			errRegex: "unmarshal of Helm repository index failed"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			body := []byte(tc.body)
+			lookup := Lookup{
+				Type:          "helm",
+				URL:           "https://charts.example.com",
+				Chart:         tc.chart,
+				UseAppVersion: &tc.useAppVersion}
+
+			// WHEN checkHelmIndexBody is called on this body
+			releases, err := lookup.checkHelmIndexBody(&body, &util.LogFrom{})
+
+			// THEN it err's when expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the releases are returned
+			got := make([]string, len(releases))
+			var gotURLs []string
+			for i := range releases {
+				got[i] = releases[i].TagName
+				gotURLs = append(gotURLs, releases[i].URLs...)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want releases %v, got %v",
+					tc.want, got)
+			}
+			if tc.wantURLs != nil && strings.Join(gotURLs, ",") != strings.Join(tc.wantURLs, ",") {
+				t.Errorf("want urls %v, got %v",
+					tc.wantURLs, gotURLs)
+			}
+			// AND prereleases are flagged from the tag used
+			for i := range tc.wantPreRelease {
+				if releases[i].PreRelease != tc.wantPreRelease[i] {
+					t.Errorf("want %q to have PreRelease=%t, got %t",
+						releases[i].TagName, tc.wantPreRelease[i], releases[i].PreRelease)
+				}
+				if releases[i].SemanticVersion == nil || releases[i].SemanticVersion.String() != releases[i].TagName {
+					t.Errorf("want %q to have SemanticVersion %q, got %v",
+						releases[i].TagName, releases[i].TagName, releases[i].SemanticVersion)
+				}
+			}
+		})
+	}
+}
+
+func TestLookup_QueryHelm(t *testing.T) {
+	// GIVEN a Helm chart repository
+	testLogging("WARN")
+	tests := map[string]struct {
+		useAppVersion       bool
+		usePreRelease       bool
+		requireRegexContent string
+		username            string
+		want                string
+		wantDigest          string
+		wantCreated         string
+		errRegex            string
+	}{
+		"newest chart version": {
+			want:        "1.2.0",
+			wantDigest:  "2222",
+			wantCreated: "2023-02-01T00:00:00Z",
+			errRegex:    "^$"},
+		"use_prerelease": {
+			usePreRelease: true,
+			want:          "1.3.0-rc.1",
+			wantDigest:    "3333",
+			wantCreated:   "2023-03-01T00:00:00Z",
+			errRegex:      "^$"},
+		"use_app_version, prerelease from the appVersion": {
+			useAppVersion: true,
+			want:          "0.12.0",
+			wantDigest:    "3333",
+			wantCreated:   "2023-03-01T00:00:00Z",
+			errRegex:      "^$"},
+		"require.regex_content checks the chart metadata": {
+			requireRegexContent: `"digest":"1111"`,
+			want:                "1.1.0",
+			wantDigest:          "1111",
+			wantCreated:         "2023-01-01T00:00:00Z",
+			errRegex:            "^$"},
+		"basic auth": {
+			username:    "user",
+			want:        "1.2.0",
+			wantDigest:  "2222",
+			wantCreated: "2023-02-01T00:00:00Z",
+			errRegex:    "^$"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/index.yaml" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if u, p, ok := r.BasicAuth(); tc.username != "" && (!ok || u != tc.username || p != "pass") {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(testHelmIndex))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "helm"
+			lookup.URL = server.URL
+			lookup.Chart = "argus"
+			lookup.UseAppVersion = &tc.useAppVersion
+			lookup.Username = tc.username
+			if tc.username != "" {
+				lookup.AccessToken = stringPtr("pass")
+			}
+			lookup.GitHubData = nil
+			lookup.URLCommands = nil
+			lookup.UsePreRelease = &tc.usePreRelease
+			lookup.Require.RegexContent = tc.requireRegexContent
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			// AND the chart metadata is in the Status
+			if got := lookup.Status.GetLatestVersionDigest(); got != tc.wantDigest {
+				t.Errorf("want latest_version_digest %q, got %q",
+					tc.wantDigest, got)
+			}
+			if got := lookup.Status.GetLatestVersionCreated(); got != tc.wantCreated {
+				t.Errorf("want latest_version_created %q, got %q",
+					tc.wantCreated, got)
+			}
+			if got := lookup.Status.GetLatestVersionURLs(); len(got) != 1 {
+				t.Errorf("want 1 url, got %v",
+					got)
+			}
+		})
+	}
+}
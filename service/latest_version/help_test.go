@@ -26,6 +26,9 @@ import (
 	"github.com/release-argus/Argus/util"
 )
 
+func uintPtr(val uint) *uint {
+	return &val
+}
 func boolPtr(val bool) *bool {
 	return &val
 }
@@ -42,7 +42,7 @@ func (l *Lookup) Init(
 	status *svcstatus.Status,
 	options *opt.Options,
 ) {
-	if l.Type == "github" {
+	if l.Type == "github" || l.Type == "gitlab" || l.Type == "gitea" {
 		l.GitHubData = &GitHubData{}
 	}
 
@@ -50,6 +50,7 @@ func (l *Lookup) Init(
 	l.HardDefaults = hardDefaults
 	l.Status = status
 	l.Options = options
+	l.Require.Init(status)
 }
 
 // initMetrics for this Lookup.
@@ -89,4 +89,9 @@ func TestLookup_Init(t *testing.T) {
 		t.Errorf("Options were not handed to the Lookup correctly\n want: %v\ngot:  %v",
 			&options, lookup.Options)
 	}
+	// require
+	if lookup.Require.Status != &status {
+		t.Errorf("Status was not handed to the Require correctly\n want: %v\ngot:  %v",
+			&status, lookup.Require.Status)
+	}
 }
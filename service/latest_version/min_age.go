@@ -0,0 +1,61 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"fmt"
+	"time"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+// holdForMinAge returns whether `version` should be held as the pending version,
+// as it's younger than the require.min_age.
+//
+// Its age is from when `release` was published, or when the version was first seen
+// if the release doesn't have a date.
+func (l *Lookup) holdForMinAge(version string, release github_types.Release, logFrom *util.LogFrom) bool {
+	minAge := l.Require.GetMinAge()
+	if minAge == 0 {
+		l.Status.SetPendingVersion("", "", true)
+		return false
+	}
+
+	since := releaseDate(release, "published_date")
+	if since.IsZero() {
+		since = time.Now().UTC()
+		// Seen on an earlier query.
+		if l.Status.GetPendingVersion() == version {
+			if firstSeen, err := time.Parse(time.RFC3339, l.Status.GetPendingVersionTimestamp()); err == nil {
+				since = firstSeen
+			}
+		}
+	}
+
+	// Old enough.
+	if time.Since(since) >= minAge {
+		l.Status.SetPendingVersion("", "", true)
+		return false
+	}
+
+	if l.Status.GetPendingVersion() != version {
+		msg := fmt.Sprintf("Pending Release - %q (waiting until %s for the min_age of %s)",
+			version, since.Add(minAge).UTC().Format(time.RFC3339), minAge)
+		jLog.Info(msg, *logFrom, true)
+	}
+	l.Status.SetPendingVersion(version, since.UTC().Format(time.RFC3339), true)
+	return true
+}
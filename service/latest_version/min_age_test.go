@@ -0,0 +1,221 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+func TestLookup_HoldForMinAge(t *testing.T) {
+	// GIVEN a Lookup that may have a min_age and a release
+	testLogging("ERROR")
+	now := time.Now().UTC()
+	tests := map[string]struct {
+		minAge                  string
+		release                 github_types.Release
+		pendingVersion          string
+		pendingVersionTimestamp string
+		want                    bool
+		wantPendingVersion      string
+		wantPendingTimestamp    string // "now" for the time of the call
+	}{
+		"no min_age": {
+			release: github_types.Release{
+				PublishedAt: now.Format(time.RFC3339)},
+			want: false},
+		"no min_age clears a pending version": {
+			pendingVersion:          "1.2.3",
+			pendingVersionTimestamp: now.Format(time.RFC3339),
+			want:                    false},
+		"published before the min_age": {
+			minAge: "48h",
+			release: github_types.Release{
+				PublishedAt: now.Add(-72 * time.Hour).Format(time.RFC3339)},
+			want: false},
+		"published within the min_age": {
+			minAge: "48h",
+			release: github_types.Release{
+				PublishedAt: now.Add(-time.Hour).Format(time.RFC3339)},
+			want:                 true,
+			wantPendingVersion:   "1.2.3",
+			wantPendingTimestamp: now.Add(-time.Hour).Format(time.RFC3339)},
+		"created within the min_age (image/chart/package)": {
+			minAge: "48h",
+			release: github_types.Release{
+				Created: now.Add(-time.Hour).Format(time.RFC3339)},
+			want:                 true,
+			wantPendingVersion:   "1.2.3",
+			wantPendingTimestamp: now.Add(-time.Hour).Format(time.RFC3339)},
+		"no release date, first seen now": {
+			minAge:               "48h",
+			want:                 true,
+			wantPendingVersion:   "1.2.3",
+			wantPendingTimestamp: "now"},
+		"no release date, seen within the min_age": {
+			minAge:                  "48h",
+			pendingVersion:          "1.2.3",
+			pendingVersionTimestamp: now.Add(-time.Hour).Format(time.RFC3339),
+			want:                    true,
+			wantPendingVersion:      "1.2.3",
+			wantPendingTimestamp:    now.Add(-time.Hour).Format(time.RFC3339)},
+		"no release date, seen before the min_age": {
+			minAge:                  "48h",
+			pendingVersion:          "1.2.3",
+			pendingVersionTimestamp: now.Add(-49 * time.Hour).Format(time.RFC3339),
+			want:                    false},
+		"no release date, another version seen before the min_age": {
+			minAge:                  "48h",
+			pendingVersion:          "1.2.2",
+			pendingVersionTimestamp: now.Add(-49 * time.Hour).Format(time.RFC3339),
+			want:                    true,
+			wantPendingVersion:      "1.2.3",
+			wantPendingTimestamp:    "now"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := testLookup(true, false)
+			lookup.Require.MinAge = tc.minAge
+			lookup.Status.SetPendingVersion(tc.pendingVersion, tc.pendingVersionTimestamp, false)
+
+			// WHEN holdForMinAge is called on it
+			before := time.Now().UTC().Truncate(time.Second)
+			got := lookup.holdForMinAge("1.2.3", tc.release, &util.LogFrom{Primary: name})
+			after := time.Now().UTC()
+
+			// THEN the version is held when it's too young
+			if got != tc.want {
+				t.Errorf("want %t, got %t",
+					tc.want, got)
+			}
+			// AND the pending version is what we expect
+			if gotVersion := lookup.Status.GetPendingVersion(); gotVersion != tc.wantPendingVersion {
+				t.Errorf("want pending version %q, got %q",
+					tc.wantPendingVersion, gotVersion)
+			}
+			// AND its timestamp is when it was published/first seen
+			gotTimestamp := lookup.Status.GetPendingVersionTimestamp()
+			if tc.wantPendingTimestamp == "now" {
+				seen, err := time.Parse(time.RFC3339, gotTimestamp)
+				if err != nil || seen.Before(before) || seen.After(after) {
+					t.Errorf("want pending version timestamp between %s and %s, got %q",
+						before, after, gotTimestamp)
+				}
+			} else if gotTimestamp != tc.wantPendingTimestamp {
+				t.Errorf("want pending version timestamp %q, got %q",
+					tc.wantPendingTimestamp, gotTimestamp)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryMinAge(t *testing.T) {
+	// GIVEN a url service with a min_age
+	testLogging("ERROR")
+	now := time.Now().UTC()
+	tests := map[string]struct {
+		minAge                  string
+		latestVersion           string
+		pendingVersion          string
+		pendingVersionTimestamp string
+		wantNewVersion          bool
+		wantLatestVersion       string
+		wantPendingVersion      string
+	}{
+		"no min_age": {
+			latestVersion:     "1.2.2",
+			wantNewVersion:    true,
+			wantLatestVersion: "1.2.3"},
+		"new version held as pending": {
+			minAge:             "48h",
+			latestVersion:      "1.2.2",
+			wantNewVersion:     false,
+			wantLatestVersion:  "1.2.2",
+			wantPendingVersion: "1.2.3"},
+		"first version held as pending": {
+			minAge:             "48h",
+			wantNewVersion:     false,
+			wantLatestVersion:  "",
+			wantPendingVersion: "1.2.3"},
+		"pending version old enough becomes the latest version": {
+			minAge:                  "48h",
+			latestVersion:           "1.2.2",
+			pendingVersion:          "1.2.3",
+			pendingVersionTimestamp: now.Add(-49 * time.Hour).Format(time.RFC3339),
+			wantNewVersion:          true,
+			wantLatestVersion:       "1.2.3"},
+		"pending version no longer found": {
+			minAge:                  "48h",
+			latestVersion:           "1.2.3",
+			pendingVersion:          "1.2.4",
+			pendingVersionTimestamp: now.Add(-time.Hour).Format(time.RFC3339),
+			wantNewVersion:          false,
+			wantLatestVersion:       "1.2.3"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("v1.2.3"))
+			}))
+			defer server.Close()
+			lookup := testLookup(true, false)
+			lookup.URL = server.URL
+			lookup.Require.MinAge = tc.minAge
+			lookup.Status.ServiceID = &name
+			lookup.Status.SetLatestVersion(tc.latestVersion, false)
+			lookup.Status.SetDeployedVersion(tc.latestVersion, false)
+			lookup.Status.SetPendingVersion(tc.pendingVersion, tc.pendingVersionTimestamp, false)
+
+			// WHEN Query is called on it
+			newVersion, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN it doesn't err
+			if err != nil {
+				t.Fatalf("unexpected err: %v",
+					err)
+			}
+			// AND a new version is only reported once it's old enough
+			if newVersion != tc.wantNewVersion {
+				t.Errorf("want newVersion=%t, got %t",
+					tc.wantNewVersion, newVersion)
+			}
+			// AND the latest version is what we expect
+			if got := lookup.Status.GetLatestVersion(); got != tc.wantLatestVersion {
+				t.Errorf("want latest_version %q, got %q",
+					tc.wantLatestVersion, got)
+			}
+			// AND the pending version is what we expect
+			if got := lookup.Status.GetPendingVersion(); got != tc.wantPendingVersion {
+				t.Errorf("want pending_version %q, got %q",
+					tc.wantPendingVersion, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"sort"
+	"time"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+// orderByValues are the ways releases can be ordered to choose the latest.
+var orderByValues = []string{"semver", "published_date", "api_order", "tag_date"}
+
+// GetOrderBy returns how the releases are ordered to choose the latest.
+//
+// Defaults to semver (ordered by the version_scheme) with a version_scheme, and the order
+// of the API without (which semver also falls back to, as the versions can't be compared).
+func (l *Lookup) GetOrderBy() string {
+	orderBy := util.GetFirstNonDefault(
+		l.OrderBy,
+		l.Defaults.OrderBy,
+		l.HardDefaults.OrderBy)
+	if orderBy == "" || orderBy == "semver" {
+		if l.versionScheme() != nil {
+			return "semver"
+		}
+		return "api_order"
+	}
+	return orderBy
+}
+
+// orderReleases will sort `releases` newest first by their date, if ordering by a date.
+//
+// Releases without a date are kept in the order of the API, after those with one.
+func (l *Lookup) orderReleases(releases []github_types.Release) {
+	orderBy := l.GetOrderBy()
+	if orderBy != "published_date" && orderBy != "tag_date" {
+		return
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releaseDate(releases[i], orderBy).After(releaseDate(releases[j], orderBy))
+	})
+}
+
+// releaseDate returns the date of `release` to order by (zero if it doesn't have one).
+//
+// published_date - when the release was published (or when the image/chart/package/feed entry was created).
+//
+// tag_date - when the commit of the tag was made (or when the image/chart/package/feed entry was created).
+func releaseDate(release github_types.Release, orderBy string) time.Time {
+	date := release.PublishedAt
+	if orderBy == "tag_date" {
+		date = release.CreatedAt
+	}
+	date = util.GetFirstNonDefault(date, release.Created)
+
+	parsed, _ := time.Parse(time.RFC3339, date)
+	return parsed
+}
@@ -0,0 +1,191 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+func TestLookup_GetOrderBy(t *testing.T) {
+	// GIVEN a Lookup
+	tests := map[string]struct {
+		orderBy, defaultOrderBy, hardDefaultOrderBy string
+		semanticVersioning                          bool
+		want                                        string
+	}{
+		"default with semantic versioning": {
+			semanticVersioning: true,
+			want:               "semver"},
+		"default without semantic versioning": {
+			semanticVersioning: false,
+			want:               "api_order"},
+		"semver without semantic versioning": {
+			orderBy:            "semver",
+			semanticVersioning: false,
+			want:               "api_order"},
+		"order_by": {
+			orderBy:            "published_date",
+			defaultOrderBy:     "tag_date",
+			semanticVersioning: true,
+			want:               "published_date"},
+		"order_by from the defaults": {
+			defaultOrderBy:     "tag_date",
+			hardDefaultOrderBy: "api_order",
+			semanticVersioning: true,
+			want:               "tag_date"},
+		"order_by from the hard defaults": {
+			hardDefaultOrderBy: "api_order",
+			semanticVersioning: true,
+			want:               "api_order"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := testLookup(false, false)
+			lookup.OrderBy = tc.orderBy
+			lookup.Defaults.OrderBy = tc.defaultOrderBy
+			lookup.HardDefaults.OrderBy = tc.hardDefaultOrderBy
+			lookup.Options.SemanticVersioning = &tc.semanticVersioning
+
+			// WHEN GetOrderBy is called on it
+			got := lookup.GetOrderBy()
+
+			// THEN the result is as expected
+			if got != tc.want {
+				t.Errorf("want %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_FilterGitHubReleasesOrderBy(t *testing.T) {
+	// GIVEN releases where a v1 maintenance release was published after v2
+	releases := []github_types.Release{
+		{TagName: "v2.0.0", CreatedAt: "2023-01-01T00:00:00Z", PublishedAt: "2023-01-05T00:00:00Z"},
+		{TagName: "v1.9.1", CreatedAt: "2023-01-03T00:00:00Z", PublishedAt: "2023-01-04T00:00:00Z"},
+		{TagName: "v2.0.1", CreatedAt: "2023-01-02T00:00:00Z", PublishedAt: "2023-01-06T00:00:00Z"},
+		{TagName: "v1.9.0"},
+		{TagName: "v1.8.0", Created: "2022-12-01T00:00:00Z"}}
+	tests := map[string]struct {
+		orderBy string
+		want    []string
+	}{
+		"semver": {
+			orderBy: "semver",
+			want:    []string{"2.0.1", "2.0.0", "1.9.1", "1.9.0", "1.8.0"}},
+		"api_order": {
+			orderBy: "api_order",
+			want:    []string{"2.0.0", "1.9.1", "2.0.1", "1.9.0", "1.8.0"}},
+		"published_date, falling back to the created date, and undated releases last": {
+			orderBy: "published_date",
+			want:    []string{"2.0.1", "2.0.0", "1.9.1", "1.8.0", "1.9.0"}},
+		"tag_date": {
+			orderBy: "tag_date",
+			want:    []string{"1.9.1", "2.0.1", "2.0.0", "1.8.0", "1.9.0"}},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := testLookup(false, false)
+			lookup.OrderBy = tc.orderBy
+
+			// WHEN filterGitHubReleases is called on them
+			filteredReleases := lookup.filterGitHubReleases(releases, &util.LogFrom{})
+
+			// THEN they're in the expected order
+			got := make([]string, len(filteredReleases))
+			for i := range filteredReleases {
+				got[i] = filteredReleases[i].SemanticVersion.String()
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want %v, got %v",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryOrderByDate(t *testing.T) {
+	// GIVEN a GitHub Enterprise Server where a v1 maintenance release was published after v2
+	testLogging("ERROR")
+	tests := map[string]struct {
+		orderBy  string
+		want     string
+		errRegex string
+	}{
+		"newest published release is used, even though it's a lower version": {
+			orderBy:  "published_date",
+			want:     "1.9.1",
+			errRegex: "^$"},
+		"highest version with semver": {
+			orderBy:  "semver",
+			want:     "2.0.0",
+			errRegex: "^$"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`[
+  {"tag_name": "v1.9.1", "published_at": "2023-01-04T00:00:00Z"},
+  {"tag_name": "v2.0.0", "published_at": "2023-01-03T00:00:00Z"}
+]`))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.URL = "owner/repo"
+			lookup.BaseURL = server.URL
+			lookup.OrderBy = tc.orderBy
+			lookup.Status.ServiceID = &name
+			lookup.Status.SetLatestVersion("2.0.0", false)
+			lookup.Status.SetDeployedVersion("2.0.0", false)
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is the newest by the order_by
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
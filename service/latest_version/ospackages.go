@@ -0,0 +1,558 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	net_url "net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+	"github.com/release-argus/Argus/util/vercmp"
+)
+
+// osPackageVersionScheme is the version scheme of each OS package repository type.
+var osPackageVersionScheme = map[string]string{
+	"apk": "apk",
+	"apt": "debian",
+	"rpm": "rpm"}
+
+// osPackageMaxIndexSize is the most of a (decompressed) repository index that will be read.
+const osPackageMaxIndexSize = 512 << 20
+
+// apkPreReleaseRegex matches the pre-release suffixes of an APK version, e.g. 1.2.3_rc1.
+var apkPreReleaseRegex = regexp.MustCompile(`_(alpha|beta|pre|rc)[0-9]*`)
+
+// osPackageRequest will fetch the repository index at URL, returning the versions
+// of Package in it as JSON releases.
+func (l *Lookup) osPackageRequest(logFrom *util.LogFrom) (rawBody []byte, err error) {
+	var releases []github_types.Release
+	switch l.Type {
+	case "apk":
+		releases, err = l.apkIndexReleases(logFrom)
+	case "apt":
+		releases, err = l.aptReleases(logFrom)
+	case "rpm":
+		releases, err = l.rpmReleases(logFrom)
+	}
+	if err != nil {
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	// Only the first of each version (e.g. the first architecture it's listed under).
+	seen := make(map[string]bool, len(releases))
+	unique := make([]github_types.Release, 0, len(releases))
+	for _, release := range releases {
+		if !seen[release.TagName] {
+			seen[release.TagName] = true
+			unique = append(unique, release)
+		}
+	}
+	rawBody, err = json.Marshal(unique)
+	return
+}
+
+// osPackageGet will GET `url` with any credentials of the repository,
+// returning the (decompressed) body.
+func (l *Lookup) osPackageGet(url string, logFrom *util.LogFrom) (body []byte, err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Connection", "close")
+	// Basic Auth (don't use the defaults as those are GitHub tokens)
+	if l.Username != "" || util.DefaultIfNil(l.AccessToken) != "" {
+		req.SetBasicAuth(l.Username, util.DefaultIfNil(l.AccessToken))
+	}
+
+	resp, err := l.httpClient().Do(req)
+	if err != nil {
+		// Don't crash on invalid certs.
+		if strings.Contains(err.Error(), "x509") {
+			err = fmt.Errorf("x509 (certificate invalid)")
+		}
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s - %s",
+			url, resp.Status)
+		return
+	}
+	jLog.Verbose(fmt.Sprintf("Fetched %s", url), *logFrom, true)
+
+	var reader io.Reader = resp.Body
+	bufReader := bufio.NewReader(resp.Body)
+	// gzip magic number.
+	if magic, _ := bufReader.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		var gzipReader *gzip.Reader
+		if gzipReader, err = gzip.NewReader(bufReader); err != nil {
+			err = fmt.Errorf("%s - %w",
+				url, err)
+			return
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	} else {
+		switch path.Ext(req.URL.Path) {
+		case ".bz2", ".lz4", ".lzma", ".xz", ".zck", ".zst":
+			err = fmt.Errorf("%s - unsupported compression %q (only gzip and uncompressed indexes are supported)",
+				url, path.Ext(req.URL.Path))
+			return
+		}
+		reader = bufReader
+	}
+
+	body, err = io.ReadAll(io.LimitReader(reader, osPackageMaxIndexSize))
+	if err != nil {
+		err = fmt.Errorf("%s - %w",
+			url, err)
+	}
+	return
+}
+
+// osPackageNotFound returns the error for a package that wasn't found in the repository.
+func (l *Lookup) osPackageNotFound() error {
+	return fmt.Errorf("package %q not found in %s",
+		l.Package, l.URL)
+}
+
+// debianControlFields parses the stanzas of a Debian control file (Packages/Release),
+// calling `stanza` with the fields of each until it returns false.
+func debianControlFields(body []byte, stanza func(fields map[string]string) bool) {
+	fields := map[string]string{}
+	lastField := ""
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// End of the clearsigned data of an InRelease.
+		if strings.HasPrefix(line, "-----BEGIN PGP SIGNATURE") {
+			break
+		}
+		switch {
+		case strings.TrimSpace(line) == "":
+			if len(fields) != 0 && !stanza(fields) {
+				return
+			}
+			fields = map[string]string{}
+			lastField = ""
+		// Continuation line.
+		case line[0] == ' ' || line[0] == '\t':
+			if lastField != "" {
+				fields[lastField] += "\n" + strings.TrimSpace(line)
+			}
+		default:
+			if key, value, found := strings.Cut(line, ":"); found {
+				lastField = key
+				fields[key] = strings.TrimSpace(value)
+			}
+		}
+	}
+	if len(fields) != 0 {
+		stanza(fields)
+	}
+}
+
+// aptArchiveRoot returns the root of the archive of the Packages/Release at `indexURL`,
+// which the Filename of packages are relative to.
+func aptArchiveRoot(indexURL string) string {
+	if index := strings.Index(indexURL, "/dists/"); index != -1 {
+		return indexURL[:index+1]
+	}
+	// Flat repository.
+	return indexURL[:strings.LastIndex(indexURL, "/")+1]
+}
+
+// aptReleases will return the versions of Package in the Debian repository at URL,
+// which is either a Packages(.gz) index, or a Release/InRelease file listing them.
+func (l *Lookup) aptReleases(logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	indexURLs := []string{l.URL}
+	switch path.Base(l.URL) {
+	case "Release", "InRelease":
+		var body []byte
+		if body, err = l.osPackageGet(l.URL, logFrom); err != nil {
+			return
+		}
+		if indexURLs, err = aptReleasePackagesURLs(l.URL, body, l.Architecture); err != nil {
+			return
+		}
+	}
+
+	// Check the indexes in order until one has the package.
+	var indexErr error
+	read := 0
+	for _, indexURL := range indexURLs {
+		body, getErr := l.osPackageGet(indexURL, logFrom)
+		if getErr != nil {
+			// e.g. a component/architecture missing from a mirror.
+			jLog.Verbose(fmt.Sprintf("Skipping index %s - %s", indexURL, getErr), *logFrom, true)
+			indexErr = getErr
+			continue
+		}
+		read++
+		archiveRoot, _ := net_url.Parse(aptArchiveRoot(indexURL))
+		debianControlFields(body, func(fields map[string]string) bool {
+			if fields["Package"] != l.Package || fields["Version"] == "" ||
+				!osPackageArchitecture(l.Architecture, fields["Architecture"], "all") {
+				return true
+			}
+			release := github_types.Release{
+				TagName:    fields["Version"],
+				PreRelease: debianPreRelease(fields["Version"])}
+			if fields["SHA256"] != "" {
+				release.Digest = "sha256:" + fields["SHA256"]
+			}
+			if fields["Filename"] != "" && archiveRoot != nil {
+				if fileURL, err := archiveRoot.Parse(fields["Filename"]); err == nil {
+					release.URLs = []string{fileURL.String()}
+				}
+			}
+			releases = append(releases, release)
+			return true
+		})
+		if len(releases) != 0 {
+			return
+		}
+	}
+	// None of the indexes could be read.
+	if read == 0 && indexErr != nil {
+		err = indexErr
+		return
+	}
+	err = l.osPackageNotFound()
+	return
+}
+
+// osPackageArchitecture returns whether a package of `packageArch` is for the `architecture` wanted
+// (any if there's no `architecture`, or it's the architecture-independent `anyArch`).
+func osPackageArchitecture(architecture string, packageArch string, anyArch string) bool {
+	return architecture == "" || packageArch == "" ||
+		packageArch == architecture || packageArch == anyArch
+}
+
+// debianPreRelease returns whether the Debian `version` is a prerelease (has a tilde in its upstream version).
+//
+// A tilde in the Debian revision sorts a backport/security update before the release it's based on
+// (e.g. 3.0.15-1~deb12u1 and 2.4-1~bpo12+1), so it isn't a prerelease.
+func debianPreRelease(version string) bool {
+	upstream := version
+	if i := strings.LastIndex(upstream, "-"); i != -1 {
+		upstream = upstream[:i]
+	}
+	return strings.Contains(upstream, "~")
+}
+
+// aptReleasePackagesURLs returns the URLs of the Packages indexes listed in the Release
+// file at `releaseURL`, preferring the gzipped index of each component/architecture
+// (only those of `architecture` and binary-all if there is one).
+func aptReleasePackagesURLs(releaseURL string, body []byte, architecture string) (indexURLs []string, err error) {
+	var dirs []string
+	best := map[string]string{}
+	debianControlFields(body, func(fields map[string]string) bool {
+		for _, checksums := range []string{fields["SHA256"], fields["MD5Sum"]} {
+			for _, line := range strings.Split(checksums, "\n") {
+				// "<checksum> <size> <path>"
+				parts := strings.Fields(line)
+				if len(parts) != 3 || strings.Contains(parts[2], "debian-installer") {
+					continue
+				}
+				dir, file := path.Split(parts[2])
+				if file != "Packages" && file != "Packages.gz" {
+					continue
+				}
+				// "main/binary-amd64/"
+				if dirArch, found := strings.CutPrefix(path.Base(dir), "binary-"); found &&
+					!osPackageArchitecture(architecture, dirArch, "all") {
+					continue
+				}
+				if _, found := best[dir]; !found {
+					dirs = append(dirs, dir)
+				}
+				if best[dir] != "Packages.gz" {
+					best[dir] = file
+				}
+			}
+		}
+		// Skip the headers of an InRelease.
+		return len(dirs) == 0
+	})
+	if len(dirs) == 0 {
+		err = fmt.Errorf("no Packages indexes found in %s",
+			releaseURL)
+		return
+	}
+
+	base, err := net_url.Parse(releaseURL)
+	if err != nil {
+		return
+	}
+	indexURLs = make([]string, len(dirs))
+	for i, dir := range dirs {
+		indexURL, _ := base.Parse(dir + best[dir])
+		indexURLs[i] = indexURL.String()
+	}
+	return
+}
+
+// apkIndexURL returns the URL of the APKINDEX.tar.gz of the Alpine repository at URL.
+func (l *Lookup) apkIndexURL() string {
+	if strings.HasSuffix(l.URL, ".tar.gz") {
+		return l.URL
+	}
+	return strings.TrimSuffix(l.URL, "/") + "/APKINDEX.tar.gz"
+}
+
+// apkIndexReleases will return the versions of Package in the APKINDEX of the Alpine repository at URL.
+func (l *Lookup) apkIndexReleases(logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	indexURL := l.apkIndexURL()
+	body, err := l.osPackageGet(indexURL, logFrom)
+	if err != nil {
+		return
+	}
+
+	// The index is a (signed) tarball containing APKINDEX.
+	var index []byte
+	tarReader := tar.NewReader(bytes.NewReader(body))
+	for {
+		header, tarErr := tarReader.Next()
+		if tarErr != nil {
+			break
+		}
+		if header.Name == "APKINDEX" {
+			if index, err = io.ReadAll(tarReader); err != nil {
+				return
+			}
+			break
+		}
+	}
+	if index == nil {
+		err = fmt.Errorf("APKINDEX not found in %s",
+			indexURL)
+		return
+	}
+
+	packageDir := indexURL[:strings.LastIndex(indexURL, "/")+1]
+	// "P:name\nV:version\n..." stanzas, the same layout as Debian control files.
+	debianControlFields(index, func(fields map[string]string) bool {
+		if fields["P"] != l.Package || fields["V"] == "" {
+			return true
+		}
+		release := github_types.Release{
+			TagName:    fields["V"],
+			PreRelease: apkPreReleaseRegex.MatchString(fields["V"]),
+			Digest:     apkChecksumDigest(fields["C"]),
+			URLs:       []string{fmt.Sprintf("%s%s-%s.apk", packageDir, fields["P"], fields["V"])}}
+		if buildTime, err := strconv.ParseInt(fields["t"], 10, 64); err == nil {
+			release.Created = time.Unix(buildTime, 0).UTC().Format(time.RFC3339)
+		}
+		releases = append(releases, release)
+		return true
+	})
+	if len(releases) == 0 {
+		err = l.osPackageNotFound()
+	}
+	return
+}
+
+// apkChecksumDigest converts the checksum of an APKINDEX entry ("Q1" + base64 SHA-1) to a digest.
+func apkChecksumDigest(checksum string) string {
+	if sum, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(checksum, "Q1")); err == nil && strings.HasPrefix(checksum, "Q1") {
+		return "sha1:" + hex.EncodeToString(sum)
+	}
+	return checksum
+}
+
+// rpmRepoMD is the format of the repodata/repomd.xml of an RPM repository.
+type rpmRepoMD struct {
+	Data []struct {
+		Type     string `xml:"type,attr"`
+		Location struct {
+			Href string `xml:"href,attr"`
+		} `xml:"location"`
+	} `xml:"data"`
+}
+
+// rpmPackage is a package in the primary.xml of an RPM repository.
+type rpmPackage struct {
+	Name    string `xml:"name"`
+	Arch    string `xml:"arch"`
+	Version struct {
+		Epoch string `xml:"epoch,attr"`
+		Ver   string `xml:"ver,attr"`
+		Rel   string `xml:"rel,attr"`
+	} `xml:"version"`
+	Checksum struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"checksum"`
+	Time struct {
+		Build int64 `xml:"build,attr"`
+	} `xml:"time"`
+	Location struct {
+		Href string `xml:"href,attr"`
+	} `xml:"location"`
+}
+
+// rpmRepoURL returns the base URL of the RPM repository at URL (which may be its repomd.xml).
+func (l *Lookup) rpmRepoURL() string {
+	return strings.TrimSuffix(
+		strings.TrimSuffix(l.URL, "repodata/repomd.xml"),
+		"/") + "/"
+}
+
+// rpmReleases will return the versions of Package in the primary metadata of the RPM repository at URL.
+func (l *Lookup) rpmReleases(logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	repoURL, err := net_url.Parse(l.rpmRepoURL())
+	if err != nil {
+		return
+	}
+	repoMDURL, _ := repoURL.Parse("repodata/repomd.xml")
+	body, err := l.osPackageGet(repoMDURL.String(), logFrom)
+	if err != nil {
+		return
+	}
+	var repoMD rpmRepoMD
+	if err = xml.Unmarshal(body, &repoMD); err != nil {
+		err = fmt.Errorf("unmarshal of %s failed\n%w",
+			repoMDURL, err)
+		return
+	}
+	primaryHref := ""
+	for _, data := range repoMD.Data {
+		if data.Type == "primary" {
+			primaryHref = data.Location.Href
+			break
+		}
+	}
+	if primaryHref == "" {
+		err = fmt.Errorf("no primary metadata found in %s",
+			repoMDURL)
+		return
+	}
+
+	primaryURL, err := repoURL.Parse(primaryHref)
+	if err != nil {
+		return
+	}
+	if body, err = l.osPackageGet(primaryURL.String(), logFrom); err != nil {
+		return
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, tokenErr := decoder.Token()
+		if tokenErr != nil {
+			if !errors.Is(tokenErr, io.EOF) {
+				err = fmt.Errorf("unmarshal of %s failed\n%w",
+					primaryURL, tokenErr)
+				return
+			}
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "package" {
+			continue
+		}
+		var pkg rpmPackage
+		if err = decoder.DecodeElement(&pkg, &start); err != nil {
+			err = fmt.Errorf("unmarshal of %s failed\n%w",
+				primaryURL, err)
+			return
+		}
+		if pkg.Name != l.Package || pkg.Arch == "src" ||
+			!osPackageArchitecture(l.Architecture, pkg.Arch, "noarch") {
+			continue
+		}
+
+		version := pkg.Version.Ver
+		if pkg.Version.Rel != "" {
+			version += "-" + pkg.Version.Rel
+		}
+		if pkg.Version.Epoch != "" && pkg.Version.Epoch != "0" {
+			version = pkg.Version.Epoch + ":" + version
+		}
+		release := github_types.Release{
+			TagName: version,
+			// A tilde in the release is a packaging change of the version (e.g. 1~bp1), not a prerelease.
+			PreRelease: strings.Contains(pkg.Version.Ver, "~")}
+		if pkg.Checksum.Value != "" {
+			release.Digest = pkg.Checksum.Type + ":" + strings.TrimSpace(pkg.Checksum.Value)
+		}
+		if pkg.Time.Build != 0 {
+			release.Created = time.Unix(pkg.Time.Build, 0).UTC().Format(time.RFC3339)
+		}
+		if fileURL, err := repoURL.Parse(pkg.Location.Href); err == nil && pkg.Location.Href != "" {
+			release.URLs = []string{fileURL.String()}
+		}
+		releases = append(releases, release)
+	}
+	if len(releases) == 0 {
+		err = l.osPackageNotFound()
+	}
+	return
+}
+
+// checkOSPackageBody will convert the versions of Package in the body to releases,
+// sorted newest first with the version ordering of the distro.
+func (l *Lookup) checkOSPackageBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	if err = json.Unmarshal(*body, &releases); err != nil {
+		jLog.Error(err, *logFrom, true)
+		err = fmt.Errorf("unmarshal of %s package versions failed\n%w",
+			l.Type, err)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+	if len(releases) == 0 {
+		err = l.osPackageNotFound()
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	compare := vercmp.GetScheme(osPackageVersionScheme[l.Type]).Compare
+	sort.SliceStable(releases, func(i, j int) bool {
+		return compare(releases[i].TagName, releases[j].TagName) > 0
+	})
+	return
+}
+
+// checkOSPackageURL will check that the URL is of a repository index of this type.
+func (l *Lookup) checkOSPackageURL() error {
+	if !strings.HasPrefix(l.URL, "http://") && !strings.HasPrefix(l.URL, "https://") {
+		return fmt.Errorf("url: %q <invalid> (e.g. %s)",
+			l.URL, map[string]string{
+				"apk": "https://dl-cdn.alpinelinux.org/alpine/v3.18/main/x86_64",
+				"apt": "https://deb.debian.org/debian/dists/bookworm/InRelease",
+				"rpm": "https://dl.rockylinux.org/pub/rocky/9/BaseOS/x86_64/os"}[l.Type])
+	}
+	return nil
+}
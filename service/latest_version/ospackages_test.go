@@ -0,0 +1,581 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/release-argus/Argus/util"
+)
+
+var testAPTPackages = `Package: curl
+Version: 7.88.1-10+deb12u1
+Filename: pool/main/c/curl/curl_7.88.1-10+deb12u1_amd64.deb
+SHA256: 1111
+
+Package: libcurl4
+Version: 9.9.9-1
+
+Package: curl
+Version: 1:7.1~rc1-1
+Filename: pool/main/c/curl/curl_7.1~rc1-1_amd64.deb
+SHA256: 3333
+
+Package: curl
+Version: 1:7.0-1
+Description: command line tool for transferring data with URL syntax
+ curl is a command line tool for transferring data with URL syntax.
+Filename: pool/main/c/curl/curl_7.0-1_amd64.deb
+SHA256: 2222
+
+Package: curl
+Version: 7.88.1-10
+Filename: pool/main/c/curl/curl_7.88.1-10_amd64.deb
+
+Package: openssl
+Version: 3.0.15-1~deb12u1
+
+Package: openssl
+Version: 3.0.14-1
+
+Package: backport
+Version: 2.4-1~bpo12+1
+
+Package: multiarch
+Version: 2.0-1
+Architecture: arm64
+
+Package: multiarch
+Version: 1.0-1
+Architecture: amd64
+
+Package: multiarch
+Version: 1.5-1
+Architecture: all
+`
+
+var testAPTInRelease = `-----BEGIN PGP SIGNED MESSAGE-----
+Hash: SHA512
+
+Origin: Debian
+Suite: stable
+Components: main contrib
+Architectures: amd64 arm64
+MD5Sum:
+ aaaa 100 contrib/binary-amd64/Packages
+ bbbb 100 main/binary-amd64/Packages
+SHA256:
+ cccc 100 contrib/binary-amd64/Packages
+ dddd 50 contrib/binary-amd64/Packages.gz
+ 1111 50 contrib/binary-arm64/Packages.gz
+ eeee 100 main/binary-amd64/Packages
+ ffff 50 main/binary-amd64/Packages.gz
+ 0000 50 main/debian-installer/binary-amd64/Packages.gz
+-----BEGIN PGP SIGNATURE-----
+
+iQIzBAEBCgAdFiEE
+-----END PGP SIGNATURE-----
+`
+
+// testGzip returns `data` gzipped.
+func testGzip(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	return buf.Bytes()
+}
+
+// testAPKIndex returns an APKINDEX.tar.gz containing `index`.
+func testAPKIndex(t *testing.T, index string) []byte {
+	var buf bytes.Buffer
+	tarWriter := tar.NewWriter(&buf)
+	for _, file := range []struct{ name, body string }{
+		{".SIGN.RSA.alpine-devel@lists.alpinelinux.org.pub", "signature"},
+		{"DESCRIPTION", "v3.18.0"},
+		{"APKINDEX", index}} {
+		tarWriter.WriteHeader(&tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.body))})
+		tarWriter.Write([]byte(file.body))
+	}
+	tarWriter.Close()
+	return testGzip(t, buf.Bytes())
+}
+
+func TestLookup_AptReleasePackagesURLs(t *testing.T) {
+	// GIVEN an InRelease file
+	body := []byte(testAPTInRelease)
+	tests := map[string]struct {
+		architecture string
+		want         []string
+	}{
+		"every architecture": {
+			want: []string{
+				"https://deb.example.com/debian/dists/stable/contrib/binary-amd64/Packages.gz",
+				"https://deb.example.com/debian/dists/stable/contrib/binary-arm64/Packages.gz",
+				"https://deb.example.com/debian/dists/stable/main/binary-amd64/Packages.gz"}},
+		"one architecture": {
+			architecture: "arm64",
+			want: []string{
+				"https://deb.example.com/debian/dists/stable/contrib/binary-arm64/Packages.gz"}},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN aptReleasePackagesURLs is called on it
+			got, err := aptReleasePackagesURLs("https://deb.example.com/debian/dists/stable/InRelease", body, tc.architecture)
+
+			// THEN the gzipped Packages indexes (of the architecture) are returned in order
+			if err != nil {
+				t.Fatalf("unexpected err: %s",
+					err)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want %v\ngot:  %v",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryAPT(t *testing.T) {
+	// GIVEN a Debian repository
+	testLogging("WARN")
+	tests := map[string]struct {
+		url             string
+		pkg             string
+		architecture    string
+		usePreRelease   bool
+		deployedVersion string
+		want            string
+		wantDigest      string
+		wantURL         string
+		errRegex        string
+	}{
+		"InRelease, highest epoch wins": {
+			url:        "/debian/dists/stable/InRelease",
+			pkg:        "curl",
+			want:       "1:7.0-1",
+			wantDigest: "sha256:2222",
+			wantURL:    "/debian/pool/main/c/curl/curl_7.0-1_amd64.deb",
+			errRegex:   "^$"},
+		"Packages.gz, tilde versions are prereleases": {
+			url:           "/debian/dists/stable/main/binary-amd64/Packages.gz",
+			pkg:           "curl",
+			usePreRelease: true,
+			want:          "1:7.1~rc1-1",
+			wantDigest:    "sha256:3333",
+			wantURL:       "/debian/pool/main/c/curl/curl_7.1~rc1-1_amd64.deb",
+			errRegex:      "^$"},
+		"tilde in the Debian revision isn't a prerelease": {
+			url:      "/flat/Packages",
+			pkg:      "openssl",
+			want:     "3.0.15-1~deb12u1",
+			errRegex: "^$"},
+		"tilde in the Debian revision of a backport isn't a prerelease": {
+			url:      "/flat/Packages",
+			pkg:      "backport",
+			want:     "2.4-1~bpo12+1",
+			errRegex: "^$"},
+		"flat repository Packages": {
+			url:        "/flat/Packages",
+			pkg:        "curl",
+			want:       "1:7.0-1",
+			wantDigest: "sha256:2222",
+			wantURL:    "/flat/pool/main/c/curl/curl_7.0-1_amd64.deb",
+			errRegex:   "^$"},
+		"unknown package": {
+			url:      "/debian/dists/stable/InRelease",
+			pkg:      "unknown",
+			errRegex: `package "unknown" not found`},
+		"older than the deployed version": {
+			url:             "/debian/dists/stable/InRelease",
+			pkg:             "curl",
+			deployedVersion: "2:1.0-1",
+			want:            "2:1.0-1",
+			errRegex:        `queried version "1:7.0-1" is less than the deployed version "2:1.0-1"`},
+		"packages of every architecture": {
+			url:      "/flat/Packages",
+			pkg:      "multiarch",
+			want:     "2.0-1",
+			errRegex: "^$"},
+		"packages of the architecture, or all": {
+			url:          "/flat/Packages",
+			pkg:          "multiarch",
+			architecture: "amd64",
+			want:         "1.5-1",
+			errRegex:     "^$"},
+		"missing index": {
+			url:      "/debian/dists/unknown/InRelease",
+			pkg:      "curl",
+			errRegex: `404 Not Found`},
+		"missing Packages index of an architecture is skipped": {
+			url:          "/debian/dists/stable/InRelease",
+			pkg:          "curl",
+			architecture: "amd64",
+			want:         "1:7.0-1",
+			errRegex:     "^$"},
+		"all Packages indexes missing": {
+			url:      "/debian/dists/broken/InRelease",
+			pkg:      "curl",
+			errRegex: `binary-amd64/Packages.gz - 404 Not Found`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/debian/dists/stable/InRelease", "/debian/dists/broken/InRelease":
+					w.Write([]byte(testAPTInRelease))
+				case "/debian/dists/stable/contrib/binary-amd64/Packages.gz":
+					w.Write(testGzip(t, []byte("Package: other\nVersion: 1.0\n")))
+				case "/debian/dists/stable/main/binary-amd64/Packages.gz":
+					w.Write(testGzip(t, []byte(testAPTPackages)))
+				case "/flat/Packages":
+					w.Write([]byte(testAPTPackages))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+			lookup := testOSPackageLookup(name, "apt", server.URL+tc.url, tc.pkg)
+			lookup.Architecture = tc.architecture
+			lookup.UsePreRelease = &tc.usePreRelease
+			if tc.deployedVersion != "" {
+				lookup.Status.SetLatestVersion(tc.deployedVersion, false)
+				lookup.Status.SetDeployedVersion(tc.deployedVersion, false)
+			}
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			if tc.wantDigest == "" {
+				return
+			}
+			// AND the package metadata is in the Status
+			if got := lookup.Status.GetLatestVersionDigest(); got != tc.wantDigest {
+				t.Errorf("want latest_version_digest %q, got %q",
+					tc.wantDigest, got)
+			}
+			if got := lookup.Status.GetLatestVersionURLs(); len(got) != 1 || got[0] != server.URL+tc.wantURL {
+				t.Errorf("want urls [%s], got %v",
+					server.URL+tc.wantURL, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryAPK(t *testing.T) {
+	// GIVEN an Alpine repository
+	testLogging("WARN")
+	index := `C:Q1AAAAAAAAAAAAAAAAAAAAAAAAAAA=
+P:curl
+V:8.1.0-r0
+t:1672531200
+
+C:Q1EjRWeJq83vASNFZ4mrze8BI0Vng=
+P:curl
+V:8.10.0-r0
+t:1675209600
+
+P:curl
+V:8.11.0_rc1-r0
+t:1677628800
+
+P:curl-dev
+V:9.0.0-r0
+
+P:curl
+V:8.2.0-r1
+`
+	tests := map[string]struct {
+		url           string
+		pkg           string
+		usePreRelease bool
+		want          string
+		wantDigest    string
+		wantCreated   string
+		wantURL       string
+		errRegex      string
+	}{
+		"repository URL": {
+			url:         "/alpine/v3.18/main/x86_64",
+			pkg:         "curl",
+			want:        "8.10.0-r0",
+			wantDigest:  "sha1:123456789abcdef0123456789abcdef012345678",
+			wantCreated: "2023-02-01T00:00:00Z",
+			wantURL:     "/alpine/v3.18/main/x86_64/curl-8.10.0-r0.apk",
+			errRegex:    "^$"},
+		"APKINDEX URL, _rc is a prerelease": {
+			url:           "/alpine/v3.18/main/x86_64/APKINDEX.tar.gz",
+			pkg:           "curl",
+			usePreRelease: true,
+			want:          "8.11.0_rc1-r0",
+			wantCreated:   "2023-03-01T00:00:00Z",
+			wantURL:       "/alpine/v3.18/main/x86_64/curl-8.11.0_rc1-r0.apk",
+			errRegex:      "^$"},
+		"unknown package": {
+			url:      "/alpine/v3.18/main/x86_64",
+			pkg:      "unknown",
+			errRegex: `package "unknown" not found`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/alpine/v3.18/main/x86_64/APKINDEX.tar.gz" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write(testAPKIndex(t, index))
+			}))
+			defer server.Close()
+			lookup := testOSPackageLookup(name, "apk", server.URL+tc.url, tc.pkg)
+			lookup.UsePreRelease = &tc.usePreRelease
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			if tc.want == "" {
+				return
+			}
+			// AND the package metadata is in the Status
+			if got := lookup.Status.GetLatestVersionDigest(); got != tc.wantDigest {
+				t.Errorf("want latest_version_digest %q, got %q",
+					tc.wantDigest, got)
+			}
+			if got := lookup.Status.GetLatestVersionCreated(); got != tc.wantCreated {
+				t.Errorf("want latest_version_created %q, got %q",
+					tc.wantCreated, got)
+			}
+			if got := lookup.Status.GetLatestVersionURLs(); len(got) != 1 || got[0] != server.URL+tc.wantURL {
+				t.Errorf("want urls [%s], got %v",
+					server.URL+tc.wantURL, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryRPM(t *testing.T) {
+	// GIVEN an RPM repository
+	testLogging("WARN")
+	primary := `<?xml version="1.0" encoding="UTF-8"?>
+<metadata xmlns="http://linux.duke.edu/metadata/common" xmlns:rpm="http://linux.duke.edu/metadata/rpm" packages="6">
+<package type="rpm">
+  <name>curl</name>
+  <arch>x86_64</arch>
+  <version epoch="0" ver="7.76.1" rel="26.el9"/>
+  <checksum type="sha256" pkgid="YES">1111</checksum>
+  <time file="1672531200" build="1672531200"/>
+  <location href="Packages/c/curl-7.76.1-26.el9.x86_64.rpm"/>
+</package>
+<package type="rpm">
+  <name>curl</name>
+  <arch>x86_64</arch>
+  <version epoch="0" ver="7.76.1" rel="100.el9"/>
+  <checksum type="sha256" pkgid="YES">2222</checksum>
+  <time file="1675209600" build="1675209600"/>
+  <location href="Packages/c/curl-7.76.1-100.el9.x86_64.rpm"/>
+</package>
+<package type="rpm">
+  <name>curl</name>
+  <arch>src</arch>
+  <version epoch="0" ver="9.0.0" rel="1.el9"/>
+</package>
+<package type="rpm">
+  <name>curl</name>
+  <arch>x86_64</arch>
+  <version epoch="0" ver="8.0.0~rc1" rel="1.el9"/>
+  <checksum type="sha256" pkgid="YES">3333</checksum>
+  <location href="Packages/c/curl-8.0.0~rc1-1.el9.x86_64.rpm"/>
+</package>
+<package type="rpm">
+  <name>backport</name>
+  <arch>noarch</arch>
+  <version epoch="0" ver="2.4" rel="1~bp1"/>
+</package>
+<package type="rpm">
+  <name>curl-minimal</name>
+  <arch>x86_64</arch>
+  <version epoch="1" ver="9.0.0" rel="1.el9"/>
+</package>
+</metadata>`
+	tests := map[string]struct {
+		url           string
+		primaryHref   string
+		pkg           string
+		usePreRelease bool
+		want          string
+		wantDigest    string
+		wantCreated   string
+		wantURL       string
+		errRegex      string
+	}{
+		"repository URL": {
+			url:         "/rocky/9/BaseOS/x86_64/os",
+			primaryHref: "repodata/abc-primary.xml.gz",
+			pkg:         "curl",
+			want:        "7.76.1-100.el9",
+			wantDigest:  "sha256:2222",
+			wantCreated: "2023-02-01T00:00:00Z",
+			wantURL:     "/rocky/9/BaseOS/x86_64/os/Packages/c/curl-7.76.1-100.el9.x86_64.rpm",
+			errRegex:    "^$"},
+		"repomd.xml URL, tilde versions are prereleases": {
+			url:           "/rocky/9/BaseOS/x86_64/os/repodata/repomd.xml",
+			primaryHref:   "repodata/abc-primary.xml.gz",
+			pkg:           "curl",
+			usePreRelease: true,
+			want:          "8.0.0~rc1-1.el9",
+			wantDigest:    "sha256:3333",
+			wantURL:       "/rocky/9/BaseOS/x86_64/os/Packages/c/curl-8.0.0~rc1-1.el9.x86_64.rpm",
+			errRegex:      "^$"},
+		"tilde in the release isn't a prerelease": {
+			url:         "/rocky/9/BaseOS/x86_64/os",
+			primaryHref: "repodata/abc-primary.xml.gz",
+			pkg:         "backport",
+			want:        "2.4-1~bp1",
+			errRegex:    "^$"},
+		"epoch": {
+			url:         "/rocky/9/BaseOS/x86_64/os/",
+			primaryHref: "repodata/abc-primary.xml.gz",
+			pkg:         "curl-minimal",
+			want:        "1:9.0.0-1.el9",
+			errRegex:    "^$"},
+		"unsupported compression": {
+			url:         "/rocky/9/BaseOS/x86_64/os",
+			primaryHref: "repodata/abc-primary.xml.zst",
+			pkg:         "curl",
+			errRegex:    `unsupported compression ".zst"`},
+		"unknown package": {
+			url:         "/rocky/9/BaseOS/x86_64/os",
+			primaryHref: "repodata/abc-primary.xml.gz",
+			pkg:         "unknown",
+			errRegex:    `package "unknown" not found`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/rocky/9/BaseOS/x86_64/os/repodata/repomd.xml":
+					w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<repomd xmlns="http://linux.duke.edu/metadata/repo">
+  <data type="filelists"><location href="repodata/abc-filelists.xml.gz"/></data>
+  <data type="primary"><location href="` + tc.primaryHref + `"/></data>
+</repomd>`))
+				case "/rocky/9/BaseOS/x86_64/os/repodata/abc-primary.xml.gz":
+					w.Write(testGzip(t, []byte(primary)))
+				case "/rocky/9/BaseOS/x86_64/os/repodata/abc-primary.xml.zst":
+					w.Write([]byte("zstd"))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+			lookup := testOSPackageLookup(name, "rpm", server.URL+tc.url, tc.pkg)
+			lookup.UsePreRelease = &tc.usePreRelease
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+			if tc.wantDigest == "" {
+				return
+			}
+			// AND the package metadata is in the Status
+			if got := lookup.Status.GetLatestVersionDigest(); got != tc.wantDigest {
+				t.Errorf("want latest_version_digest %q, got %q",
+					tc.wantDigest, got)
+			}
+			if got := lookup.Status.GetLatestVersionCreated(); got != tc.wantCreated {
+				t.Errorf("want latest_version_created %q, got %q",
+					tc.wantCreated, got)
+			}
+			if got := lookup.Status.GetLatestVersionURLs(); len(got) != 1 || got[0] != server.URL+tc.wantURL {
+				t.Errorf("want urls [%s], got %v",
+					server.URL+tc.wantURL, got)
+			}
+		})
+	}
+}
+
+// testOSPackageLookup returns a Lookup of `pkg` in the `lType` repository at `url`.
+func testOSPackageLookup(name string, lType string, url string, pkg string) *Lookup {
+	lookup := testLookup(false, false)
+	lookup.Type = lType
+	lookup.URL = url
+	lookup.Package = pkg
+	lookup.GitHubData = nil
+	lookup.URLCommands = nil
+	lookup.Status.ServiceID = &name
+	return lookup
+}
@@ -0,0 +1,422 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latestver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	net_url "net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-semver/semver"
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	mod_semver "golang.org/x/mod/semver"
+)
+
+// packageTypes are the Lookup types of language package registries.
+var packageTypes = []string{"crates", "go", "npm", "pypi"}
+
+// packageDefaultBaseURL is the base_url of the public registry of each package type.
+var packageDefaultBaseURL = map[string]string{
+	"crates": "https://crates.io",
+	"go":     "https://proxy.golang.org",
+	"npm":    "https://registry.npmjs.org",
+	"pypi":   "https://pypi.org"}
+
+// pep440PreReleaseRegex matches the pre-release/dev markers of a PEP 440 version, e.g. 1.2.3rc1 or 1.2.3.dev0.
+var pep440PreReleaseRegex = regexp.MustCompile(`(?i)[0-9][-_.]?(a|alpha|b|beta|c|rc|pre|preview|dev)[-_.]?[0-9]*`)
+
+// packageBaseURL returns the base_url of the package registry.
+func (l *Lookup) packageBaseURL() string {
+	return strings.TrimSuffix(
+		util.GetFirstNonDefault(l.BaseURL, packageDefaultBaseURL[l.Type]),
+		"/")
+}
+
+// packageAPIURL returns the URL of the metadata for the package at URL.
+func (l *Lookup) packageAPIURL() string {
+	base := l.packageBaseURL()
+	switch l.Type {
+	case "crates":
+		return fmt.Sprintf("%s/api/v1/crates/%s",
+			base, l.URL)
+	case "go":
+		escapedPath, err := module.EscapePath(l.URL)
+		if err != nil {
+			escapedPath = l.URL
+		}
+		return fmt.Sprintf("%s/%s/@v/list",
+			base, escapedPath)
+	case "npm":
+		// Scoped packages, e.g. @scope/name -> @scope%2Fname
+		return fmt.Sprintf("%s/%s",
+			base, strings.Replace(l.URL, "/", "%2F", 1))
+	case "pypi":
+		return fmt.Sprintf("%s/pypi/%s/json",
+			base, l.URL)
+	}
+	return ""
+}
+
+// packageWebURL returns the URL of the package's page on the public registry,
+// or the base_url for internal mirrors.
+func (l *Lookup) packageWebURL() string {
+	if l.BaseURL != "" && strings.TrimSuffix(l.BaseURL, "/") != packageDefaultBaseURL[l.Type] {
+		return l.packageBaseURL()
+	}
+
+	switch l.Type {
+	case "crates":
+		return "https://crates.io/crates/" + l.URL
+	case "go":
+		return "https://pkg.go.dev/" + l.URL
+	case "npm":
+		return "https://www.npmjs.com/package/" + l.URL
+	case "pypi":
+		return "https://pypi.org/project/" + l.URL
+	}
+	return l.URL
+}
+
+// setPackageAuth will set the credentials for the package registry on `req`.
+func (l *Lookup) setPackageAuth(req *http.Request) {
+	// Don't use the default access_token as that's for GitHub.
+	accessToken := util.DefaultIfNil(l.AccessToken)
+	switch {
+	case l.Username != "":
+		req.SetBasicAuth(l.Username, accessToken)
+	case accessToken == "":
+	case l.Type == "npm":
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	case l.Type == "crates":
+		req.Header.Set("Authorization", accessToken)
+	default:
+		req.SetBasicAuth("", accessToken)
+	}
+}
+
+// packageNotFound returns the error for a package that wasn't found in the registry.
+func (l *Lookup) packageNotFound(body *[]byte) error {
+	return fmt.Errorf("package %q not found at %s\n%s",
+		l.URL, l.packageBaseURL(), strings.TrimSpace(string(*body)))
+}
+
+// sortByCreated will sort `releases` newest first by their created date (RFC 3339),
+// with those that don't have a valid date last (in their original order).
+func sortByCreated(releases []github_types.Release) {
+	type datedRelease struct {
+		release github_types.Release
+		created time.Time
+		valid   bool
+	}
+	sorted := make([]datedRelease, len(releases))
+	for i := range releases {
+		created, err := time.Parse(time.RFC3339, releases[i].Created)
+		sorted[i] = datedRelease{release: releases[i], created: created, valid: err == nil}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].valid != sorted[j].valid {
+			return sorted[i].valid
+		}
+		return sorted[i].created.After(sorted[j].created)
+	})
+	for i := range sorted {
+		releases[i] = sorted[i].release
+	}
+}
+
+// isSemVerPreRelease returns whether `version` is a semantic version with a pre-release.
+func isSemVerPreRelease(version string) bool {
+	semVer, err := semver.NewVersion(strings.TrimPrefix(version, "v"))
+	return err == nil && semVer.PreRelease != ""
+}
+
+// checkNPMBody will convert the versions in the npm packument body to releases,
+// excluding deprecated versions.
+func (l *Lookup) checkNPMBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	var packument struct {
+		Versions map[string]struct {
+			Deprecated interface{} `json:"deprecated"`
+			Dist       struct {
+				Integrity string `json:"integrity"`
+				Shasum    string `json:"shasum"`
+				Tarball   string `json:"tarball"`
+			} `json:"dist"`
+		} `json:"versions"`
+		Time map[string]string `json:"time"`
+	}
+	if err = json.Unmarshal(*body, &packument); err != nil || packument.Versions == nil {
+		if err == nil {
+			err = l.packageNotFound(body)
+		} else {
+			err = fmt.Errorf("unmarshal of npm package data failed\n%w",
+				err)
+		}
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	releases = make([]github_types.Release, 0, len(packument.Versions))
+	for version, data := range packument.Versions {
+		// deprecated is the deprecation message.
+		if deprecated, _ := data.Deprecated.(string); deprecated != "" {
+			continue
+		}
+		release := github_types.Release{
+			TagName:    version,
+			PreRelease: isSemVerPreRelease(version),
+			Created:    packument.Time[version],
+			Digest:     util.GetFirstNonDefault(data.Dist.Integrity, data.Dist.Shasum)}
+		if data.Dist.Tarball != "" {
+			release.URLs = []string{data.Dist.Tarball}
+		}
+		releases = append(releases, release)
+	}
+	sortByCreated(releases)
+	return
+}
+
+// checkPyPIBody will convert the releases in the PyPI JSON body to releases,
+// excluding yanked releases.
+func (l *Lookup) checkPyPIBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	var project struct {
+		Releases map[string][]struct {
+			Digests struct {
+				SHA256 string `json:"sha256"`
+			} `json:"digests"`
+			UploadTime string `json:"upload_time_iso_8601"`
+			URL        string `json:"url"`
+			Yanked     bool   `json:"yanked"`
+		} `json:"releases"`
+	}
+	if err = json.Unmarshal(*body, &project); err != nil || project.Releases == nil {
+		if err == nil {
+			err = l.packageNotFound(body)
+		} else {
+			err = fmt.Errorf("unmarshal of PyPI package data failed\n%w",
+				err)
+		}
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	releases = make([]github_types.Release, 0, len(project.Releases))
+	for version, files := range project.Releases {
+		release := github_types.Release{
+			TagName:    version,
+			PreRelease: pep440PreReleaseRegex.MatchString(version)}
+		// A release is yanked when all of its files are (or it has none).
+		yanked := true
+		for _, file := range files {
+			if file.Yanked {
+				continue
+			}
+			yanked = false
+			if release.Created == "" || file.UploadTime < release.Created {
+				release.Created = file.UploadTime
+			}
+			if release.Digest == "" {
+				release.Digest = "sha256:" + file.Digests.SHA256
+			}
+			release.URLs = append(release.URLs, file.URL)
+		}
+		if yanked {
+			continue
+		}
+		releases = append(releases, release)
+	}
+	sortByCreated(releases)
+	return
+}
+
+// checkCratesBody will convert the versions in the crates.io API body to releases,
+// excluding yanked versions.
+func (l *Lookup) checkCratesBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	var crate struct {
+		Versions []struct {
+			Num       string `json:"num"`
+			Yanked    bool   `json:"yanked"`
+			CreatedAt string `json:"created_at"`
+			Checksum  string `json:"checksum"`
+			DLPath    string `json:"dl_path"`
+		} `json:"versions"`
+	}
+	if err = json.Unmarshal(*body, &crate); err != nil || crate.Versions == nil {
+		if err == nil {
+			err = l.packageNotFound(body)
+		} else {
+			err = fmt.Errorf("unmarshal of crates.io package data failed\n%w",
+				err)
+		}
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	releases = make([]github_types.Release, 0, len(crate.Versions))
+	for _, version := range crate.Versions {
+		if version.Yanked {
+			continue
+		}
+		release := github_types.Release{
+			TagName:    version.Num,
+			PreRelease: isSemVerPreRelease(version.Num),
+			Created:    version.CreatedAt}
+		if version.Checksum != "" {
+			release.Digest = "sha256:" + version.Checksum
+		}
+		if version.DLPath != "" {
+			release.URLs = []string{l.packageBaseURL() + version.DLPath}
+		}
+		releases = append(releases, release)
+	}
+	sortByCreated(releases)
+	return
+}
+
+// checkGoProxyBody will convert the versions in the Go module proxy /@v/list body to releases.
+func (l *Lookup) checkGoProxyBody(body *[]byte, logFrom *util.LogFrom) (releases []github_types.Release, err error) {
+	versions := strings.Fields(string(*body))
+	for _, version := range versions {
+		if !mod_semver.IsValid(version) {
+			err = l.packageNotFound(body)
+			jLog.Error(err, *logFrom, true)
+			return nil, err
+		}
+	}
+
+	// The list is unordered.
+	sort.SliceStable(versions, func(i, j int) bool {
+		return mod_semver.Compare(versions[i], versions[j]) > 0
+	})
+	for _, version := range versions {
+		releases = append(releases, github_types.Release{
+			// v1.2.3 -> 1.2.3
+			TagName:    strings.TrimPrefix(version, "v"),
+			PreRelease: mod_semver.Prerelease(version) != ""})
+	}
+	return
+}
+
+// goProxyRequest will list the versions of the module at URL, excluding any retracted
+// by the go.mod of its latest version.
+func (l *Lookup) goProxyRequest(logFrom *util.LogFrom) (rawBody []byte, err error) {
+	rawBody, status, err := l.packageGet(l.packageAPIURL(), logFrom)
+	if err != nil {
+		return
+	}
+	if status != http.StatusOK {
+		err = l.packageNotFound(&rawBody)
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+
+	// Retractions are in the go.mod of the latest version.
+	versions := strings.Fields(string(rawBody))
+	latest := ""
+	for _, version := range versions {
+		if mod_semver.IsValid(version) && (latest == "" || goLatestPreference(version, latest)) {
+			latest = version
+		}
+	}
+	if latest == "" {
+		return
+	}
+	modURL := strings.TrimSuffix(l.packageAPIURL(), "list") + latest + ".mod"
+	goMod, status, err := l.packageGet(modURL, logFrom)
+	if err != nil || status != http.StatusOK {
+		// Retractions are a nice to have.
+		jLog.Verbose(fmt.Sprintf("failed to get %s to check for retractions", modURL), *logFrom, true)
+		return rawBody, nil
+	}
+	modFile, err := modfile.ParseLax(modURL, goMod, nil)
+	if err != nil || len(modFile.Retract) == 0 {
+		return rawBody, nil
+	}
+
+	kept := make([]string, 0, len(versions))
+	for _, version := range versions {
+		retracted := false
+		for _, retract := range modFile.Retract {
+			if mod_semver.Compare(retract.Low, version) <= 0 && mod_semver.Compare(version, retract.High) <= 0 {
+				retracted = true
+				break
+			}
+		}
+		if !retracted {
+			kept = append(kept, version)
+		}
+	}
+	return []byte(strings.Join(kept, "\n")), nil
+}
+
+// goLatestPreference returns whether `version` is preferred over `than` as the latest version.
+// Like `go get`, releases are preferred over pre-releases.
+func goLatestPreference(version, than string) bool {
+	versionPre := mod_semver.Prerelease(version) != ""
+	thanPre := mod_semver.Prerelease(than) != ""
+	if versionPre != thanPre {
+		return !versionPre
+	}
+	return mod_semver.Compare(version, than) > 0
+}
+
+// packageGet will GET `url` with the package registry credentials.
+func (l *Lookup) packageGet(url string, logFrom *util.LogFrom) (body []byte, status int, err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+	req.Header.Set("Connection", "close")
+	l.setPackageAuth(req)
+
+	resp, err := l.httpClient().Do(req)
+	if err != nil {
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	status = resp.StatusCode
+	return
+}
+
+// checkPackageURL will check that the URL is a package name rather than a URL.
+func (l *Lookup) checkPackageURL() error {
+	if _, err := net_url.ParseRequestURI(l.URL); err == nil && strings.Contains(l.URL, "://") {
+		return fmt.Errorf("url: %q <invalid> (package name, e.g. %s)",
+			l.URL, map[string]string{
+				"crates": "serde",
+				"go":     "github.com/release-argus/Argus",
+				"npm":    "@scope/name",
+				"pypi":   "requests"}[l.Type])
+	}
+	if l.Type == "go" {
+		if err := module.CheckPath(l.URL); err != nil {
+			return fmt.Errorf("url: %q <invalid> (%s)",
+				l.URL, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package latestver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/util"
+)
+
+func TestLookup_PackageURLs(t *testing.T) {
+	// GIVEN a package registry Lookup
+	tests := map[string]struct {
+		lType      string
+		url        string
+		baseURL    string
+		wantAPIURL string
+		wantWebURL string
+	}{
+		"npm": {
+			lType:      "npm",
+			url:        "left-pad",
+			wantAPIURL: "https://registry.npmjs.org/left-pad",
+			wantWebURL: "https://www.npmjs.com/package/left-pad"},
+		"npm scoped package": {
+			lType:      "npm",
+			url:        "@types/node",
+			wantAPIURL: "https://registry.npmjs.org/@types%2Fnode",
+			wantWebURL: "https://www.npmjs.com/package/@types/node"},
+		"pypi": {
+			lType:      "pypi",
+			url:        "requests",
+			wantAPIURL: "https://pypi.org/pypi/requests/json",
+			wantWebURL: "https://pypi.org/project/requests"},
+		"go with uppercase path": {
+			lType:      "go",
+			url:        "github.com/BurntSushi/toml",
+			wantAPIURL: "https://proxy.golang.org/github.com/!burnt!sushi/toml/@v/list",
+			wantWebURL: "https://pkg.go.dev/github.com/BurntSushi/toml"},
+		"crates": {
+			lType:      "crates",
+			url:        "serde",
+			wantAPIURL: "https://crates.io/api/v1/crates/serde",
+			wantWebURL: "https://crates.io/crates/serde"},
+		"internal mirror": {
+			lType:      "pypi",
+			url:        "requests",
+			baseURL:    "https://pypi.example.com/",
+			wantAPIURL: "https://pypi.example.com/pypi/requests/json",
+			wantWebURL: "https://pypi.example.com"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := Lookup{Type: tc.lType, URL: tc.url, BaseURL: tc.baseURL}
+
+			// WHEN packageAPIURL/packageWebURL are called
+			apiURL := lookup.packageAPIURL()
+			webURL := lookup.packageWebURL()
+
+			// THEN the URLs are as expected
+			if apiURL != tc.wantAPIURL {
+				t.Errorf("API URL - want: %q\ngot:  %q",
+					tc.wantAPIURL, apiURL)
+			}
+			if webURL != tc.wantWebURL {
+				t.Errorf("Web URL - want: %q\ngot:  %q",
+					tc.wantWebURL, webURL)
+			}
+		})
+	}
+}
+
+func TestLookup_CheckPackageBodies(t *testing.T) {
+	// GIVEN a package registry body
+	testLogging("WARN")
+	tests := map[string]struct {
+		lType          string
+		body           string
+		want           []string
+		wantPreRelease []string
+		errRegex       string
+	}{
+		"npm - deprecated versions are excluded": {
+			lType: "npm",
+			body: `{"name":"pkg","versions":{
+				"1.0.0":{"dist":{"tarball":"https://registry.npmjs.org/pkg/-/pkg-1.0.0.tgz"}},
+				"1.1.0":{"deprecated":"security issue"},
+				"2.0.0-beta.1":{},
+				"1.2.0":{"deprecated":false}},
+				"time":{"1.0.0":"2023-01-01T00:00:00Z","1.1.0":"2023-02-01T00:00:00Z","1.2.0":"2023-03-01T00:00:00Z","2.0.0-beta.1":"2023-04-01T00:00:00Z"}}`,
+			want:           []string{"2.0.0-beta.1", "1.2.0", "1.0.0"},
+			wantPreRelease: []string{"2.0.0-beta.1"},
+			errRegex:       "^$"},
+		"npm - not found": {
+			lType:    "npm",
+			body:     `{"error":"Not found"}`,
+			errRegex: `package "pkg" not found at https://registry.npmjs.org\n.*Not found`},
+		"pypi - yanked releases are excluded": {
+			lType: "pypi",
+			body: `{"info":{},"releases":{
+				"1.0.0":[{"upload_time_iso_8601":"2023-01-01T00:00:00Z","url":"https://files/pkg-1.0.0.tar.gz","digests":{"sha256":"aaa"}}],
+				"1.1.0":[{"upload_time_iso_8601":"2023-02-01T00:00:00Z","yanked":true}],
+				"1.2.0":[],
+				"2.0.0rc1":[{"upload_time_iso_8601":"2023-04-01T00:00:00Z"}],
+				"1.3.0.post1":[{"upload_time_iso_8601":"2023-03-01T00:00:00Z"}]}}`,
+			want:           []string{"2.0.0rc1", "1.3.0.post1", "1.0.0"},
+			wantPreRelease: []string{"2.0.0rc1"},
+			errRegex:       "^$"},
+		"pypi - not found": {
+			lType:    "pypi",
+			body:     `{"message": "Not Found"}`,
+			errRegex: `package "pkg" not found`},
+		"crates - yanked versions are excluded": {
+			lType: "crates",
+			body: `{"crate":{},"versions":[
+				{"num":"2.0.0-alpha.1","created_at":"2023-04-01T00:00:00Z"},
+				{"num":"1.2.0","created_at":"2023-03-01T00:00:00Z","yanked":true},
+				{"num":"1.1.0","created_at":"2023-02-01T00:00:00Z","checksum":"bbb","dl_path":"/api/v1/crates/pkg/1.1.0/download"}]}`,
+			want:           []string{"2.0.0-alpha.1", "1.1.0"},
+			wantPreRelease: []string{"2.0.0-alpha.1"},
+			errRegex:       "^$"},
+		"crates - not found": {
+			lType:    "crates",
+			body:     `{"errors":[{"detail":"crate ` + "`pkg`" + ` does not exist"}]}`,
+			errRegex: `package "pkg" not found`},
+		"go - sorted by semver": {
+			lType:          "go",
+			body:           "v1.2.0\nv1.10.0\nv2.0.0-rc.1\nv1.9.0\n",
+			want:           []string{"2.0.0-rc.1", "1.10.0", "1.9.0", "1.2.0"},
+			wantPreRelease: []string{"2.0.0-rc.1"},
+			errRegex:       "^$"},
+		"go - not found": {
+			lType:    "go",
+			body:     "not found: module pkg: no matching versions",
+			errRegex: `package "pkg" not found`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			body := []byte(tc.body)
+			lookup := Lookup{Type: tc.lType, URL: "pkg"}
+
+			// WHEN the body is checked
+			var releases []github_types.Release
+			var err error
+			switch tc.lType {
+			case "crates":
+				releases, err = lookup.checkCratesBody(&body, &util.LogFrom{})
+			case "go":
+				releases, err = lookup.checkGoProxyBody(&body, &util.LogFrom{})
+			case "npm":
+				releases, err = lookup.checkNPMBody(&body, &util.LogFrom{})
+			case "pypi":
+				releases, err = lookup.checkPyPIBody(&body, &util.LogFrom{})
+			}
+
+			// THEN it err's when expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the releases are returned newest first
+			got := make([]string, len(releases))
+			var gotPreRelease []string
+			for i := range releases {
+				got[i] = releases[i].TagName
+				if releases[i].PreRelease {
+					gotPreRelease = append(gotPreRelease, releases[i].TagName)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want releases %v, got %v",
+					tc.want, got)
+			}
+			// AND the prereleases are flagged
+			if strings.Join(gotPreRelease, ",") != strings.Join(tc.wantPreRelease, ",") {
+				t.Errorf("want prereleases %v, got %v",
+					tc.wantPreRelease, gotPreRelease)
+			}
+		})
+	}
+}
+
+func TestSortByCreated(t *testing.T) {
+	// GIVEN releases with created dates
+	tests := map[string]struct {
+		created []string
+		want    []string
+	}{
+		"newest first": {
+			created: []string{"2023-01-01T00:00:00Z", "2023-03-01T00:00:00Z", "2023-02-01T00:00:00Z"},
+			want:    []string{"2023-03-01T00:00:00Z", "2023-02-01T00:00:00Z", "2023-01-01T00:00:00Z"}},
+		"fractional seconds": {
+			created: []string{"2023-01-01T00:00:00.1Z", "2023-01-01T00:00:00.25Z", "2023-01-01T00:00:00Z"},
+			want:    []string{"2023-01-01T00:00:00.25Z", "2023-01-01T00:00:00.1Z", "2023-01-01T00:00:00Z"}},
+		"different offsets": {
+			created: []string{"2023-01-01T10:00:00+02:00", "2023-01-01T09:00:00Z"},
+			want:    []string{"2023-01-01T09:00:00Z", "2023-01-01T10:00:00+02:00"}},
+		"invalid dates last, in their order": {
+			created: []string{"Mon, 02 Jan 2023 10:00:00 +0000", "2023-01-01T00:00:00Z", "", "2022-01-01T00:00:00Z"},
+			want:    []string{"2023-01-01T00:00:00Z", "2022-01-01T00:00:00Z", "Mon, 02 Jan 2023 10:00:00 +0000", ""}},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			releases := make([]github_types.Release, len(tc.created))
+			for i := range tc.created {
+				releases[i].Created = tc.created[i]
+			}
+
+			// WHEN sortByCreated is called on them
+			sortByCreated(releases)
+
+			// THEN they're sorted newest first
+			got := make([]string, len(releases))
+			for i := range releases {
+				got[i] = releases[i].Created
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("want %q\ngot:  %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryGoProxy(t *testing.T) {
+	// GIVEN a Go module proxy
+	testLogging("WARN")
+	tests := map[string]struct {
+		goMod         string
+		usePreRelease bool
+		want          string
+		errRegex      string
+	}{
+		"latest release": {
+			goMod:    "module example.com/mod\n",
+			want:     "1.3.0",
+			errRegex: "^$"},
+		"use_prerelease": {
+			goMod:         "module example.com/mod\n",
+			usePreRelease: true,
+			want:          "2.0.0-rc.1",
+			errRegex:      "^$"},
+		"retracted versions are excluded": {
+			goMod:    "module example.com/mod\n\nretract (\n\tv1.3.0 // broken\n\t[v1.2.0, v1.2.9]\n)\n",
+			want:     "1.1.0",
+			errRegex: "^$"},
+		"no go.mod for the latest version": {
+			want:     "1.3.0",
+			errRegex: "^$"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/example.com/mod/@v/list":
+					w.Write([]byte("v1.1.0\nv1.2.0\nv1.3.0\nv1.2.1\nv2.0.0-rc.1\n"))
+				case "/example.com/mod/@v/v1.3.0.mod":
+					if tc.goMod != "" {
+						w.Write([]byte(tc.goMod))
+						return
+					}
+					fallthrough
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte("not found"))
+				}
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "go"
+			lookup.URL = "example.com/mod"
+			lookup.BaseURL = server.URL
+			lookup.GitHubData = nil
+			lookup.URLCommands = nil
+			lookup.UsePreRelease = &tc.usePreRelease
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryNPM(t *testing.T) {
+	// GIVEN an npm registry mirror that requires a token
+	testLogging("WARN")
+	tests := map[string]struct {
+		accessToken *string
+		want        string
+		errRegex    string
+	}{
+		"token is sent": {
+			accessToken: stringPtr("secret"),
+			want:        "1.2.0",
+			errRegex:    "^$"},
+		"no token": {
+			errRegex: `package "@scope/pkg" not found`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.EscapedPath() != "/@scope%2Fpkg" || r.Header.Get("Authorization") != "Bearer secret" {
+					w.WriteHeader(http.StatusNotFound)
+					w.Write([]byte(`{"error":"Not found"}`))
+					return
+				}
+				w.Write([]byte(`{"versions":{
+					"1.1.0":{},
+					"1.2.0":{"dist":{"tarball":"https://npm.example.com/pkg-1.2.0.tgz","integrity":"sha512-abc"}},
+					"1.3.0":{"deprecated":"do not use"}},
+					"time":{"1.1.0":"2023-01-01T00:00:00Z","1.2.0":"2023-02-01T00:00:00Z","1.3.0":"2023-03-01T00:00:00Z"}}`))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "npm"
+			lookup.URL = "@scope/pkg"
+			lookup.BaseURL = server.URL
+			lookup.AccessToken = tc.accessToken
+			lookup.GitHubData = nil
+			lookup.URLCommands = nil
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is as expected
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
@@ -16,13 +16,16 @@ package latestver
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	net_url "net/url"
 	"strings"
 
 	"github.com/coreos/go-semver/semver"
 	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
+	"github.com/release-argus/Argus/service/latest_version/filter"
 	"github.com/release-argus/Argus/util"
 	metric "github.com/release-argus/Argus/web/metrics"
 )
@@ -36,47 +39,62 @@ func (l *Lookup) query(logFrom *util.LogFrom) (bool, error) {
 		return false, err
 	}
 
-	version, err := l.GetVersion(rawBody, logFrom)
+	version, release, err := l.GetVersion(rawBody, logFrom)
 	if err != nil {
 		return false, err
 	}
 
 	l.Status.SetLastQueried("")
-	wantSemanticVersioning := l.Options.GetSemanticVersioning()
+	scheme := l.versionScheme()
 
 	// If this version is different (new?).
 	latestVersion := l.Status.GetLatestVersion()
 	if version != latestVersion {
-		if wantSemanticVersioning {
-			// Check it's a valid semnatic version
-			newVersion, err := semver.NewVersion(version)
-			if err != nil {
-				err = fmt.Errorf("failed converting %q to a semantic version. If all versions are in this style, consider adding url_commands to get the version into the style of 'MAJOR.MINOR.PATCH' (https://semver.org/), or disabling semantic versioning (globally with defaults.service.semantic_versioning or just for this service with the semantic_versioning var)",
-					version)
+		if scheme != nil {
+			// Check it's a valid version of the scheme
+			if !scheme.Valid(version) {
+				err = fmt.Errorf("failed converting %q to %s. If all versions are in this style, consider adding url_commands to get the version into the style of %s, or changing the version_scheme (globally with defaults.service.options.version_scheme or just for this service with the version_scheme option)",
+					version, scheme.Description, scheme.Style)
 				jLog.Error(err, *logFrom, true)
 				return false, err
 			}
 
-			// Check for a progressive change in version.
-			if latestVersion != "" {
-				oldVersion, err := semver.NewVersion(l.Status.GetDeployedVersion())
-				// If the old version is not a semantic version, then we can't compare it.
-				// (if we switched to semantic versioning with non-semantic versions tracked)
-				if err == nil {
-					// e.g.
-					// newVersion = 1.2.9
-					// oldVersion = 1.2.10
-					// return false (don't notify anything and stay on oldVersion)
-					if newVersion.LessThan(*oldVersion) {
-						err := fmt.Errorf("queried version %q is less than the deployed version %q",
-							version, l.Status.GetLatestVersion())
-						jLog.Warn(err, *logFrom, true)
-						return false, err
-					}
+			// Check for a progressive change in version
+			// (unless ordered by date, where the newest release may be a lower version).
+			deployedVersion := l.Status.GetDeployedVersion()
+			// If the deployed version doesn't follow the scheme, then we can't compare it.
+			// (if we switched scheme with versions of another tracked)
+			if latestVersion != "" && l.GetOrderBy() == "semver" && scheme.Valid(deployedVersion) {
+				// e.g.
+				// version         = 1.2.9
+				// deployedVersion = 1.2.10
+				// return false (don't notify anything and stay on deployedVersion)
+				if scheme.Compare(version, deployedVersion) < 0 {
+					err := fmt.Errorf("queried version %q is less than the deployed version %q",
+						version, deployedVersion)
+					jLog.Warn(err, *logFrom, true)
+					return false, err
 				}
 			}
 		}
 
+		// Hold the version until it's old enough (require.min_age).
+		if l.holdForMinAge(version, release, logFrom) {
+			l.Status.AnnounceQuery()
+			return false, nil
+		}
+	} else {
+		// No longer pending (e.g. the pending release was pulled).
+		l.Status.SetPendingVersion("", "", true)
+	}
+
+	// Metadata of the version (Docker digests/Helm charts/packages/feed entries).
+	l.Status.SetLatestVersionDigest(release.Digest, release.Created, true)
+	l.Status.SetLatestVersionURLs(release.URLs, true)
+	l.Status.SetLatestVersionMessage(release.Message, true)
+	l.Status.SetLatestVersionRelease(release.ReleaseInfo(), true)
+
+	if version != latestVersion {
 		// Found new version, so reset regex misses.
 		l.Status.ResetRegexMisses()
 
@@ -131,7 +149,7 @@ func (l *Lookup) queryMetrics(err error) {
 			metric.SetPrometheusGauge(metric.LatestVersionQueryLiveness,
 				*l.Status.ServiceID,
 				2)
-		case strings.HasPrefix(e, "failed converting") && strings.Contains(e, " semantic version."):
+		case strings.HasPrefix(e, "failed converting") && strings.Contains(e, "version_scheme"):
 			metric.SetPrometheusGauge(metric.LatestVersionQueryLiveness,
 				*l.Status.ServiceID,
 				3)
@@ -161,7 +179,8 @@ func (l *Lookup) queryMetrics(err error) {
 	}
 }
 
-func (l *Lookup) httpRequest(logFrom *util.LogFrom) (rawBody []byte, err error) {
+// httpClient returns a http.Client that respects the Lookup's allow_invalid_certs.
+func (l *Lookup) httpClient() *http.Client {
 	customTransport := &http.Transport{}
 	// HTTPS insecure skip verify.
 	if l.GetAllowInvalidCerts() {
@@ -170,7 +189,142 @@ func (l *Lookup) httpRequest(logFrom *util.LogFrom) (rawBody []byte, err error)
 		customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
 
-	req, err := http.NewRequest(http.MethodGet, GetURL(l.URL, l.Type), nil)
+	return &http.Client{Transport: customTransport}
+}
+
+// setAuth sets the credentials of the Lookup on `req`.
+func (l *Lookup) setAuth(req *http.Request) error {
+	switch l.Type {
+	case "github":
+		// GitHub App installation token, or Access Token
+		authorization, err := l.gitHubAuthorization()
+		if err != nil {
+			return err
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+	case "gitea":
+		// Access Token (don't use the defaults as those are GitHub tokens)
+		if util.DefaultIfNil(l.AccessToken) != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("token %s", *l.AccessToken))
+		}
+	case "gitlab":
+		// Private Token (don't use the defaults as those are GitHub tokens)
+		if util.DefaultIfNil(l.AccessToken) != "" {
+			req.Header.Set("PRIVATE-TOKEN", *l.AccessToken)
+		}
+	case "crates", "npm", "pypi":
+		l.setPackageAuth(req)
+	case "feed", "git", "helm":
+		// Basic Auth
+		if l.Username != "" || util.DefaultIfNil(l.AccessToken) != "" {
+			req.SetBasicAuth(l.Username, util.DefaultIfNil(l.AccessToken))
+		}
+	}
+	return nil
+}
+
+// verifyDownloader returns the downloader for the assets of the releases being verified,
+// which uses the credentials of the Lookup only for those on its hosts.
+func (l *Lookup) verifyDownloader() *filter.VerifyDownloader {
+	hosts := map[string]bool{}
+	for _, rawURL := range []string{l.queryURL(), l.GetServiceURL(true)} {
+		if parsedURL, err := net_url.Parse(rawURL); err == nil && parsedURL.Host != "" {
+			hosts[parsedURL.Host] = true
+		}
+	}
+
+	client := l.httpClient()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		// Don't send the credentials to other hosts (only Authorization/Cookie are dropped by default).
+		if !hosts[req.URL.Host] {
+			req.Header.Del("Authorization")
+			req.Header.Del("PRIVATE-TOKEN")
+		}
+		return nil
+	}
+
+	return &filter.VerifyDownloader{
+		Client: client,
+		Authorize: func(req *http.Request) error {
+			if !hosts[req.URL.Host] {
+				return nil
+			}
+			// Download the GitHub API URL of assets rather than its JSON.
+			if l.Type == "github" && strings.Contains(req.URL.Path, "/releases/assets/") {
+				req.Header.Set("Accept", "application/octet-stream")
+			}
+			return l.setAuth(req)
+		}}
+}
+
+// verifyAssetCheck verifies the asset of `version` in `assets` if the Require has a VerifyCheck.
+func (l *Lookup) verifyAssetCheck(version string, assets []github_types.Asset) error {
+	if l.Require == nil || l.Require.Verify == nil {
+		return nil
+	}
+	return l.Require.VerifyAssetCheck(version, l.verifyAssets(assets), l.verifyDownloader()) //nolint:wrapcheck
+}
+
+// verifyAssets returns the `assets` to verify, preferring the API URLs of those on GitHub when authenticated,
+// as the browser_download_url of assets of private repos isn't accessible with a token.
+func (l *Lookup) verifyAssets(assets []github_types.Asset) []github_types.Asset {
+	if l.Type != "github" || (l.GetGitHubApp() == nil && l.gitHubAccessToken() == "") {
+		return assets
+	}
+
+	apiAssets := make([]github_types.Asset, len(assets))
+	for i, asset := range assets {
+		apiAssets[i] = asset
+		if asset.URL != "" {
+			apiAssets[i].BrowserDownloadURL = ""
+		}
+	}
+	return apiAssets
+}
+
+func (l *Lookup) httpRequest(logFrom *util.LogFrom) (rawBody []byte, err error) {
+	// Registry tags need a token exchange and pagination.
+	if l.Type == "docker" {
+		if l.trackDigest() {
+			return l.dockerDigestRequest(logFrom)
+		}
+		return l.dockerTagsRequest(logFrom)
+	}
+	// Retracted module versions need the go.mod of the latest version.
+	if l.Type == "go" {
+		return l.goProxyRequest(logFrom)
+	}
+	// OS package repository indexes need decompressing/parsing.
+	if osPackageVersionScheme[l.Type] != "" {
+		return l.osPackageRequest(logFrom)
+	}
+
+	// GitHub releases batched with those of other services.
+	if l.useGitHubGraphQL() {
+		return l.gitHubGraphQLRequest(logFrom)
+	}
+	// GitHub releases over multiple pages.
+	if l.Type == "github" && !l.trackBranch() && !l.GetUseLatestRelease() && l.GetMaxPages() > 1 {
+		return l.releasesPagesRequest(
+			fmt.Sprintf("%s?per_page=%d", l.gitHubReleasesURL(), releasesPerPage),
+			releasesPerPage,
+			logFrom)
+	}
+	// GitLab releases over (up to max_pages) pages.
+	if l.Type == "gitlab" {
+		return l.releasesPagesRequest(l.gitLabAPIURL(), releasesPerPage, logFrom)
+	}
+	// Gitea releases over (up to max_pages) pages.
+	if l.Type == "gitea" {
+		return l.releasesPagesRequest(l.giteaAPIURL(), giteaReleasesPerPage, logFrom)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, l.queryURL(), nil)
 	if err != nil {
 		jLog.Error(err, *logFrom, true)
 		return
@@ -178,18 +332,25 @@ func (l *Lookup) httpRequest(logFrom *util.LogFrom) (rawBody []byte, err error)
 
 	// Set headers
 	req.Header.Set("Connection", "close")
-	if l.Type == "github" {
-		// Access Token
-		if util.DefaultIfNil(l.GetAccessToken()) != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("token %s", *l.GetAccessToken()))
-		}
+	if err = l.setAuth(req); err != nil {
+		jLog.Error(err, *logFrom, true)
+		return
+	}
+	switch l.Type {
+	case "github":
 		// Conditional requests - https://docs.github.com/en/rest/overview/resources-in-the-rest-api#conditional-requests
 		if l.GitHubData.ETag != "" {
 			req.Header.Set("If-None-Match", l.GitHubData.ETag)
 		}
+	case "crates", "npm", "pypi":
+		// crates.io requires a User-Agent.
+		req.Header.Set("User-Agent", "release-argus/Argus")
+	case "git":
+		// Some git servers only serve smart HTTP to git clients.
+		req.Header.Set("User-Agent", "git/2.40.0 (release-argus/Argus)")
 	}
 
-	client := &http.Client{Transport: customTransport}
+	client := l.httpClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		// Don't crash on invalid certs.
@@ -207,6 +368,10 @@ func (l *Lookup) httpRequest(logFrom *util.LogFrom) (rawBody []byte, err error)
 	rawBody, err = io.ReadAll(resp.Body)
 	jLog.Error(err, *logFrom, err != nil)
 	if l.Type == "github" && err == nil {
+		// Installation token revoked, so get a new one next time.
+		if app := l.GetGitHubApp(); app != nil && resp.StatusCode == http.StatusUnauthorized {
+			l.forgetGitHubAppAccessToken(app)
+		}
 		newETag := strings.TrimPrefix(resp.Header.Get("etag"), "W/")
 		if l.GitHubData.ETag != newETag {
 			jLog.Verbose("Potentially found new releases (ETag changed)", *logFrom, true)
@@ -224,9 +389,17 @@ func (l *Lookup) GetVersions(
 ) (filteredReleases []github_types.Release, err error) {
 	var releases []github_types.Release
 	body := string(rawBody)
+	switch l.Type {
 	// GitHub service.
-	if l.Type == "github" {
-		releases, err = l.checkGitHubReleasesBody(&rawBody, logFrom)
+	case "github":
+		switch {
+		case l.trackBranch():
+			releases, err = l.checkGitHubCommitBody(&rawBody, logFrom)
+		case l.GetUseLatestRelease():
+			releases, err = l.checkGitHubLatestReleaseBody(&rawBody, logFrom)
+		default:
+			releases, err = l.checkGitHubReleasesBody(&rawBody, logFrom)
+		}
 		if err != nil {
 			return
 		}
@@ -243,21 +416,107 @@ func (l *Lookup) GetVersions(
 			return
 		}
 
-		// url service
-	} else {
-		var version string
-		version, err = l.URLCommands.Run(body, *logFrom)
+	// Gitea/GitLab/Docker/Helm/feed/git/package registry/OS package repository service.
+	case "apk", "apt", "crates", "docker", "feed", "git", "gitea", "gitlab", "go", "helm", "npm", "pypi", "rpm":
+		// Digest of a tag, so nothing to filter.
+		if l.trackDigest() {
+			filteredReleases, err = l.checkDockerDigestBody(&rawBody, logFrom)
+			return
+		}
+		switch l.Type {
+		case "docker":
+			releases, err = l.checkDockerTagsBody(&rawBody, logFrom)
+		case "gitea":
+			releases, err = l.checkGiteaReleasesBody(&rawBody, logFrom)
+		case "helm":
+			releases, err = l.checkHelmIndexBody(&rawBody, logFrom)
+		case "feed":
+			releases, err = l.checkFeedBody(&rawBody, logFrom)
+		case "git":
+			releases, err = l.checkGitRefsBody(&rawBody, logFrom)
+		case "crates":
+			releases, err = l.checkCratesBody(&rawBody, logFrom)
+		case "go":
+			releases, err = l.checkGoProxyBody(&rawBody, logFrom)
+		case "npm":
+			releases, err = l.checkNPMBody(&rawBody, logFrom)
+		case "pypi":
+			releases, err = l.checkPyPIBody(&rawBody, logFrom)
+		case "apk", "apt", "rpm":
+			releases, err = l.checkOSPackageBody(&rawBody, logFrom)
+		default:
+			releases, err = l.checkGitLabReleasesBody(&rawBody, logFrom)
+		}
+		if err != nil {
+			return
+		}
+		// Filter releases
+		filteredReleases = l.filterGitHubReleases(
+			releases,
+			logFrom,
+		)
+		if len(filteredReleases) == 0 {
+			err = fmt.Errorf("no releases were found matching the url_commands")
+			jLog.Warn(err, *logFrom, true)
+			return
+		}
+
+	// url service
+	default:
+		var versions []string
+		versions, err = l.URLCommands.RunAll(body, *logFrom)
 		if err != nil {
 			//nolint:wrapcheck
 			return
 		}
-		filteredReleases = []github_types.Release{{TagName: version}}
+		// Single version, so nothing to sort.
+		if len(versions) == 1 {
+			filteredReleases = []github_types.Release{{TagName: versions[0]}}
+			return
+		}
+		filteredReleases = l.filterURLVersions(versions)
+		if len(filteredReleases) == 0 {
+			err = fmt.Errorf("no releases were found matching the url_commands")
+			jLog.Warn(err, *logFrom, true)
+			return
+		}
+	}
+	return
+}
+
+// filterURLVersions will convert the candidate versions of a url service to releases,
+// dropping those that don't follow the version_scheme (if any). This list will be returned and be sorted descending
+// (when ordered by semver, otherwise they're kept in the order of the page).
+func (l *Lookup) filterURLVersions(versions []string) (filteredReleases []github_types.Release) {
+	scheme := l.versionScheme()
+	orderBySemVer := l.GetOrderBy() == "semver"
+
+	filteredReleases = make([]github_types.Release, 0, len(versions))
+	for _, version := range versions {
+		release := github_types.Release{TagName: version}
+		// If there's no version scheme, add without any sorting
+		if scheme == nil {
+			filteredReleases = append(filteredReleases, release)
+			continue
+		}
+
+		if !scheme.Valid(version) {
+			continue
+		}
+		if scheme.Name == "semver" {
+			release.SemanticVersion, _ = semver.NewVersion(version)
+		}
+		if len(filteredReleases) == 0 || !orderBySemVer {
+			filteredReleases = append(filteredReleases, release)
+			continue
+		}
+		insertionSortScheme(release, &filteredReleases, scheme)
 	}
 	return
 }
 
-// GetVersion will return the latest version from rawBody matching the URLCommands and Regex requirements
-func (l *Lookup) GetVersion(rawBody []byte, logFrom *util.LogFrom) (version string, err error) {
+// GetVersion will return the latest version (and its release) from rawBody matching the URLCommands and Regex requirements
+func (l *Lookup) GetVersion(rawBody []byte, logFrom *util.LogFrom) (version string, release github_types.Release, err error) {
 	var filteredReleases []github_types.Release
 	// rawBody length = 0 if GitHub ETag is unchanged
 	if len(rawBody) != 0 {
@@ -274,10 +533,10 @@ func (l *Lookup) GetVersion(rawBody []byte, logFrom *util.LogFrom) (version stri
 		)
 	}
 
-	wantSemanticVersioning := l.Options.GetSemanticVersioning()
 	for i := range filteredReleases {
+		release = filteredReleases[i]
 		version = filteredReleases[i].TagName
-		if wantSemanticVersioning && l.Type != "url" {
+		if filteredReleases[i].SemanticVersion != nil && l.Type != "url" {
 			version = filteredReleases[i].SemanticVersion.String()
 		}
 
@@ -291,13 +550,31 @@ func (l *Lookup) GetVersion(rawBody []byte, logFrom *util.LogFrom) (version stri
 			continue
 		}
 
+		// Version Constraint
+		if err = l.Require.VersionConstraintCheck(version, logFrom); err != nil {
+			continue
+		}
+
+		// Expression
+		if err = l.Require.ExpressionCheck(version, filteredReleases[i], logFrom); err != nil {
+			continue
+		}
+
 		// Content RegEx
 		var body interface{}
-		if l.Type == "github" {
-			// GitHub service
+		switch l.Type {
+		// GitHub/GitLab/Gitea service
+		case "gitea", "github", "gitlab":
 			body = filteredReleases[i].Assets
-			// Web service
-		} else {
+			// Commits have no assets, so search the commit (sha/date/message).
+			if l.trackBranch() {
+				body = release.String()
+			}
+		// Helm chart/package/feed entry/git ref, search its metadata (digest/created/urls)
+		case "apk", "apt", "crates", "feed", "git", "go", "helm", "npm", "pypi", "rpm":
+			body = release.String()
+		// Web service
+		default:
 			body = string(rawBody)
 		}
 		// If the Content doesn't match the provided RegEx
@@ -325,6 +602,12 @@ func (l *Lookup) GetVersion(rawBody []byte, logFrom *util.LogFrom) (version stri
 				*logFrom,
 				true)
 		}
+
+		// If the asset couldn't be verified against its checksums/signature
+		if err = l.verifyAssetCheck(version, filteredReleases[i].Assets); err != nil {
+			jLog.Warn(fmt.Sprintf("verify failed - %s", err), *logFrom, true)
+			continue
+		}
 		break
 	}
 	if version == "" {
@@ -17,15 +17,23 @@
 package latestver
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
 	"github.com/release-argus/Argus/service/latest_version/filter"
 	"github.com/release-argus/Argus/util"
+	metric "github.com/release-argus/Argus/web/metrics"
 )
 
 func TestLookup_HTTPRequest(t *testing.T) {
@@ -361,3 +369,527 @@ no releases were found matching the url_commands and/or require`},
 		})
 	}
 }
+
+func TestLookup_QueryURLCandidates(t *testing.T) {
+	// GIVEN a url service with multiple versions on the page
+	testLogging("ERROR")
+	tests := map[string]struct {
+		semanticVersioning bool
+		orderBy            string
+		requireVersion     string
+		requireConstraint  string
+		requireContent     string
+		want               string
+		errRegex           string
+	}{
+		"highest semantic version": {
+			semanticVersioning: true,
+			want:               "1.10.0",
+			errRegex:           "^$"},
+		"falls back to the next version that passes regex_version": {
+			semanticVersioning: true,
+			requireVersion:     `^1\.[0-9]\.`,
+			want:               "1.3.0",
+			errRegex:           "^$"},
+		"falls back to the next version that satisfies version_constraint": {
+			semanticVersioning: true,
+			requireConstraint:  ">=1.2 <1.10",
+			want:               "1.3.0",
+			errRegex:           "^$"},
+		"version_constraint with ||": {
+			semanticVersioning: true,
+			requireConstraint:  "~1.2 || ^2",
+			want:               "1.2.0",
+			errRegex:           "^$"},
+		"no version satisfies the version_constraint": {
+			semanticVersioning: true,
+			requireConstraint:  "^2",
+			errRegex:           `doesn't satisfy the version_constraint "\^2"`},
+		"falls back to the next version that passes regex_content": {
+			semanticVersioning: true,
+			requireContent:     `app-{{ version }}\.tar\.gz`,
+			want:               "1.3.0",
+			errRegex:           "^$"},
+		"order of the page without semantic versioning": {
+			semanticVersioning: false,
+			want:               "1.2.0",
+			errRegex:           "^$"},
+		"order of the page with order_by api_order": {
+			semanticVersioning: true,
+			orderBy:            "api_order",
+			want:               "1.2.0",
+			errRegex:           "^$"},
+		"no version passes the require": {
+			semanticVersioning: true,
+			requireVersion:     `^2\.`,
+			errRegex:           "regex not matched on version"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`Downloads: app-1.2.0.tar.gz app-1.10.0.zip app-1.3.0.tar.gz app-beta.tar.gz`))
+			}))
+			defer server.Close()
+			lookup := testLookup(true, false)
+			lookup.URL = server.URL
+			lookup.URLCommands = filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`app-([0-9a-z.]+?)\.(?:tar\.gz|zip)`), AllMatches: true}}
+			lookup.Options.SemanticVersioning = &tc.semanticVersioning
+			lookup.OrderBy = tc.orderBy
+			lookup.Require.RegexVersion = tc.requireVersion
+			lookup.Require.VersionConstraint = tc.requireConstraint
+			lookup.Require.RegexContent = tc.requireContent
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the first candidate (in order) that passes the require is used
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryVersionScheme(t *testing.T) {
+	// GIVEN a url service with versions of various schemes on the page
+	testLogging("ERROR")
+	tests := map[string]struct {
+		versionScheme   string
+		page            string
+		latestVersion   string
+		deployedVersion string
+		want            string
+		errRegex        string
+	}{
+		"semver ignores non-semantic versions": {
+			versionScheme: "semver",
+			page:          "v=1.2.0 v=1.10.0 v=2024.05 v=1.9.0.1",
+			want:          "1.10.0",
+			errRegex:      "^$"},
+		"calver": {
+			versionScheme: "calver",
+			page:          "v=2024.05.1 v=2024.12.0 v=1.2.0 v=2024.9.30",
+			want:          "2024.12.0",
+			errRegex:      "^$"},
+		"numeric with four parts": {
+			versionScheme: "numeric",
+			page:          "v=1.2.3.4 v=1.2.3.10 v=1.2.3 v=beta",
+			want:          "1.2.3.10",
+			errRegex:      "^$"},
+		"pep440 final after its release candidates": {
+			versionScheme: "pep440",
+			page:          "v=1.2.0rc1 v=1.2.0 v=1.2.0b3 v=1.1.0.post1",
+			want:          "1.2.0",
+			errRegex:      "^$"},
+		"pep440 post-release": {
+			versionScheme: "pep440",
+			page:          "v=1.2.0rc1 v=1.2.0 v=1.2.0.post1",
+			want:          "1.2.0.post1",
+			errRegex:      "^$"},
+		"none keeps the order of the page": {
+			versionScheme: "none",
+			page:          "v=1.2.0 v=1.10.0",
+			want:          "1.2.0",
+			errRegex:      "^$"},
+		"no version of the scheme": {
+			versionScheme: "calver",
+			page:          "v=1.2.0 v=1.10.0",
+			errRegex:      "no releases were found matching the url_commands"},
+		"less than the deployed version of the scheme": {
+			versionScheme:   "calver",
+			page:            "v=2024.05.1 v=2024.04.0",
+			latestVersion:   "2024.06.0",
+			deployedVersion: "2024.06.0",
+			want:            "2024.06.0",
+			errRegex:        `queried version "2024.05.1" is less than the deployed version "2024.06.0"`},
+		"deployed version not of the scheme isn't compared": {
+			versionScheme:   "calver",
+			page:            "v=2024.05.1 v=2024.04.0",
+			latestVersion:   "1.2.3",
+			deployedVersion: "1.2.3",
+			want:            "2024.05.1",
+			errRegex:        "^$"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.page))
+			}))
+			defer server.Close()
+			lookup := testLookup(true, false)
+			lookup.URL = server.URL
+			lookup.URLCommands = filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`v=([^ ]+)`), AllMatches: true}}
+			lookup.Options.SemanticVersioning = nil
+			lookup.Options.VersionScheme = tc.versionScheme
+			lookup.Require = nil
+			lookup.Status.ServiceID = &name
+			lookup.Status.SetLatestVersion(tc.latestVersion, false)
+			lookup.Status.SetDeployedVersion(tc.deployedVersion, false)
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the highest version of the scheme is used
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryVerify(t *testing.T) {
+	// GIVEN a url service that requires the asset of a version to match its checksum
+	testLogging("ERROR")
+	checksum := func(data string) string {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(data)))
+	}
+	tests := map[string]struct {
+		files    map[string]string
+		want     string
+		errRegex string
+	}{
+		"latest version verified": {
+			files: map[string]string{
+				"/argus-1.2.4":      "1.2.4",
+				"/SHA256SUMS-1.2.4": checksum("1.2.4") + "  argus-1.2.4",
+				"/argus-1.2.3":      "1.2.3",
+				"/SHA256SUMS-1.2.3": checksum("1.2.3") + "  argus-1.2.3"},
+			want:     "1.2.4",
+			errRegex: "^$"},
+		"latest version unverified, uses the one before": {
+			files: map[string]string{
+				"/argus-1.2.4":      "tampered",
+				"/SHA256SUMS-1.2.4": checksum("1.2.4") + "  argus-1.2.4",
+				"/argus-1.2.3":      "1.2.3",
+				"/SHA256SUMS-1.2.3": checksum("1.2.3") + "  argus-1.2.3"},
+			want:     "1.2.3",
+			errRegex: "^$"},
+		"latest version not published yet, uses the one before": {
+			files: map[string]string{
+				"/argus-1.2.4":      "1.2.4",
+				"/argus-1.2.3":      "1.2.3",
+				"/SHA256SUMS-1.2.3": checksum("1.2.3") + "  argus-1.2.3"},
+			want:     "1.2.3",
+			errRegex: "^$"},
+		"no version verified": {
+			errRegex: `^argus-1.2.3 - http://[^ ]+/SHA256SUMS-1.2.3 - 404 Not Found$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/" {
+					w.Write([]byte("v=1.2.4 v=1.2.3"))
+					return
+				}
+				body, ok := tc.files[r.URL.Path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write([]byte(body))
+			}))
+			defer server.Close()
+			lookup := testLookup(true, false)
+			lookup.URL = server.URL
+			lookup.URLCommands = filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`v=([^ ]+)`), AllMatches: true}}
+			lookup.Require = &filter.Require{
+				Verify: &filter.VerifyCheck{
+					Asset:     server.URL + "/argus-{{ version }}",
+					Checksums: server.URL + "/SHA256SUMS-{{ version }}"}}
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is the newest that was verified
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_VerifyDownloader(t *testing.T) {
+	// GIVEN a feed service with credentials, and an asset on its host, or another host
+	testLogging("ERROR")
+	var otherAuthorization atomic.Value
+	otherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherAuthorization.Store(r.Header.Get("Authorization"))
+		w.Write([]byte("other"))
+	}))
+	defer otherServer.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/redirect":
+			http.Redirect(w, r, otherServer.URL+"/asset", http.StatusFound)
+		case strings.HasPrefix(r.URL.Path, "/SHA256SUMS-"):
+			// Checksum of the asset content named after the dash.
+			checksum := sha256.Sum256([]byte(strings.TrimPrefix(r.URL.Path, "/SHA256SUMS-")))
+			fmt.Fprintf(w, "%x  asset\n%x  redirect\n",
+				checksum, checksum)
+		default:
+			w.Write([]byte("asset"))
+		}
+	}))
+	defer server.Close()
+	tests := map[string]struct {
+		url  string
+		want string
+	}{
+		"asset on the host of the service": {
+			url:  server.URL + "/asset",
+			want: "asset"},
+		"asset on another host": {
+			url:  otherServer.URL + "/asset",
+			want: "other"},
+		"redirected to another host": {
+			url:  server.URL + "/redirect",
+			want: "other"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			// Not parallel as they share otherServer.
+
+			lookup := testLookup(true, false)
+			lookup.Type = "feed"
+			lookup.URL = server.URL + "/feed.xml"
+			lookup.Username = "user"
+			lookup.AccessToken = stringPtr("secret")
+			otherAuthorization.Store("")
+			require := &filter.Require{
+				Verify: &filter.VerifyCheck{
+					Asset:     tc.url,
+					Checksums: server.URL + "/SHA256SUMS-" + tc.want}}
+
+			// WHEN the asset is verified with the verifyDownloader of the Lookup
+			err := require.VerifyAssetCheck("1.2.3", nil, lookup.verifyDownloader())
+
+			// THEN the asset is downloaded and verified
+			if err != nil {
+				t.Fatalf("unexpected err: %v",
+					err)
+			}
+			// AND the credentials are only sent to the host of the service
+			if got := otherAuthorization.Load().(string); got != "" {
+				t.Errorf("want no credentials sent to another host, got %q",
+					got)
+			}
+		})
+	}
+}
+
+func TestLookup_VerifyAssets(t *testing.T) {
+	// GIVEN the assets of a release
+	assets := []github_types.Asset{
+		{Name: "api", URL: "https://api.github.com/repos/o/r/releases/assets/1", BrowserDownloadURL: "https://github.com/o/r/releases/download/v1/api"},
+		{Name: "browser", BrowserDownloadURL: "https://github.com/o/r/releases/download/v1/browser"}}
+	tests := map[string]struct {
+		lookupType  string
+		accessToken string
+		want        []string
+	}{
+		"github, authenticated": {
+			lookupType:  "github",
+			accessToken: "token",
+			want:        []string{"", "https://github.com/o/r/releases/download/v1/browser"}},
+		"github, unauthenticated": {
+			lookupType: "github",
+			want:       []string{"https://github.com/o/r/releases/download/v1/api", "https://github.com/o/r/releases/download/v1/browser"}},
+		"gitea": {
+			lookupType:  "gitea",
+			accessToken: "token",
+			want:        []string{"https://github.com/o/r/releases/download/v1/api", "https://github.com/o/r/releases/download/v1/browser"}},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := testLookup(false, false)
+			lookup.Type = tc.lookupType
+			lookup.AccessToken = stringPtr(tc.accessToken)
+
+			// WHEN verifyAssets is called on them
+			got := lookup.verifyAssets(assets)
+
+			// THEN the API URLs of GitHub assets are preferred when authenticated
+			for i := range got {
+				if got[i].BrowserDownloadURL != tc.want[i] {
+					t.Errorf("asset %d: want browser_download_url %q, got %q",
+						i, tc.want[i], got[i].BrowserDownloadURL)
+				}
+				if got[i].URL != assets[i].URL {
+					t.Errorf("asset %d: want url %q, got %q",
+						i, assets[i].URL, got[i].URL)
+				}
+			}
+		})
+	}
+}
+
+func TestLookup_QueryExpression(t *testing.T) {
+	// GIVEN a url service that requires its versions to satisfy an expression
+	testLogging("ERROR")
+	tests := map[string]struct {
+		expression string
+		want       string
+		errRegex   string
+	}{
+		"latest version satisfies it": {
+			expression: `major == 1`,
+			want:       "1.2.4",
+			errRegex:   "^$"},
+		"latest version doesn't satisfy it, uses the one before": {
+			expression: `patch < 4 && version != status.deployed_version`,
+			want:       "1.2.3",
+			errRegex:   "^$"},
+		"no version satisfies it": {
+			expression: `major == 2`,
+			errRegex:   `^version "1.2.3" doesn't satisfy the expression "major == 2"$`},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("v=1.2.4 v=1.2.3"))
+			}))
+			defer server.Close()
+			lookup := testLookup(true, false)
+			lookup.URL = server.URL
+			lookup.URLCommands = filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`v=([^ ]+)`), AllMatches: true}}
+			lookup.Require = &filter.Require{
+				Expression: tc.expression}
+			lookup.Require.Init(lookup.Status)
+			lookup.Status.ServiceID = &name
+
+			// WHEN Query is called on it
+			_, err := lookup.Query(false, &util.LogFrom{})
+
+			// THEN any err is expected
+			e := util.ErrorToString(err)
+			re := regexp.MustCompile(tc.errRegex)
+			if !re.MatchString(e) {
+				t.Fatalf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+			// AND the latest version is the newest that satisfies the expression
+			if got := lookup.Status.GetLatestVersion(); got != tc.want {
+				t.Errorf("want latest_version %q, got %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLookup_QueryMetrics(t *testing.T) {
+	// GIVEN a url service with a version_scheme that the version may not follow
+	testLogging("ERROR")
+	tests := map[string]struct {
+		versionScheme   string
+		version         string
+		deployedVersion string
+		livenessMetric  int
+	}{
+		"success": {
+			versionScheme:  "semver",
+			version:        "1.2.3",
+			livenessMetric: 1},
+		"not a semantic version": {
+			versionScheme:  "semver",
+			version:        "1.2",
+			livenessMetric: 3},
+		"not a calendar version": {
+			versionScheme:  "calver",
+			version:        "1.2.3",
+			livenessMetric: 3},
+		"less than the deployed version": {
+			versionScheme:   "pep440",
+			version:         "1.2.0rc1",
+			deployedVersion: "1.2.0",
+			livenessMetric:  4},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.version))
+			}))
+			defer server.Close()
+			lookup := testLookup(true, false)
+			lookup.URL = server.URL
+			lookup.URLCommands = nil
+			lookup.Options.SemanticVersioning = nil
+			lookup.Options.VersionScheme = tc.versionScheme
+			lookup.Require = nil
+			lookup.Status.ServiceID = stringPtr("TestLookup_QueryMetrics " + name)
+			if tc.deployedVersion != "" {
+				lookup.Status.SetLatestVersion(tc.deployedVersion, false)
+				lookup.Status.SetDeployedVersion(tc.deployedVersion, false)
+			}
+
+			// WHEN Query is called on it with metrics
+			lookup.Query(true, &util.LogFrom{})
+
+			// THEN the liveness metric is set for the outcome
+			got := testutil.ToFloat64(metric.LatestVersionQueryLiveness.WithLabelValues(*lookup.Status.ServiceID))
+			if got != float64(tc.livenessMetric) {
+				t.Errorf("LatestVersionQueryLiveness should be %d, not %f",
+					tc.livenessMetric, got)
+			}
+		})
+	}
+}
@@ -29,6 +29,7 @@ func (l *Lookup) applyOverrides(
 	allowInvalidCerts *string,
 	require *string,
 	semanticVersioning *string,
+	versionScheme *string,
 	typeStr *string,
 	url *string,
 	urlCommands *string,
@@ -58,6 +59,12 @@ func (l *Lookup) applyOverrides(
 	if semanticVersioning != nil {
 		useSemanticVersioning = util.StringToBoolPtr(*semanticVersioning)
 	}
+	// version_scheme
+	useVersionScheme := util.GetValue(versionScheme, l.Options.VersionScheme)
+	// (a semantic_versioning override is of the scheme)
+	if semanticVersioning != nil && versionScheme == nil {
+		useVersionScheme = ""
+	}
 	// type
 	useType := util.GetValue(typeStr, l.Type)
 	if useType == "" {
@@ -84,13 +91,26 @@ func (l *Lookup) applyOverrides(
 	lookup := Lookup{
 		Type:              useType,
 		URL:               useURL,
+		BaseURL:           l.BaseURL,
+		Chart:             l.Chart,
+		UseAppVersion:     l.UseAppVersion,
+		Package:           l.Package,
+		Architecture:      l.Architecture,
+		Branch:            l.Branch,
+		Username:          l.Username,
 		AccessToken:       useAccessToken,
+		GitHubApp:         l.GitHubApp,
+		UseGraphQL:        l.UseGraphQL,
+		MaxPages:          l.MaxPages,
+		UseLatestRelease:  l.UseLatestRelease,
 		AllowInvalidCerts: useAllowInvalidCerts,
 		UsePreRelease:     useUsePreRelease,
+		OrderBy:           l.OrderBy,
 		URLCommands:       *useURLCommands,
 		Require:           useRequire,
 		Options: &opt.Options{
 			SemanticVersioning: useSemanticVersioning,
+			VersionScheme:      useVersionScheme,
 			Defaults:           l.Options.Defaults,
 			HardDefaults:       l.Options.HardDefaults},
 		Status: &svcstatus.Status{
@@ -102,17 +122,24 @@ func (l *Lookup) applyOverrides(
 		serviceID,
 		nil)
 	lookup.Status.SetLatestVersion(l.Status.GetLatestVersion(), false)
+	lookup.Status.SetLatestVersionDigest(l.Status.GetLatestVersionDigest(), l.Status.GetLatestVersionCreated(), false)
+	lookup.Status.SetPendingVersion(l.Status.GetPendingVersion(), l.Status.GetPendingVersionTimestamp(), false)
+	// Give a new Require the Status of this query (not the Service's).
+	if require != nil {
+		lookup.Require.Init(lookup.Status)
+	}
 
-	if lookup.Type == "github" {
+	if lookup.Type == "github" || lookup.Type == "gitlab" || lookup.Type == "gitea" {
 		// Use the current ETag/releases
 		// (if ETag is the same, won't count towards API limit)
-		if l.Type == "github" {
+		if l.Type == lookup.Type {
 			lookup.GitHubData = &GitHubData{
 				ETag: l.GitHubData.ETag}
 			lookup.GitHubData.ETag = l.GitHubData.ETag
 			lookup.GitHubData.Releases = l.GitHubData.Releases
+			lookup.GitHubData.Pages = l.GitHubData.Pages
 
-			// Type changed to github (or new service)
+			// Type changed to github/gitlab/gitea (or new service)
 		} else {
 			lookup.GitHubData = &GitHubData{}
 		}
@@ -135,6 +162,7 @@ func (l *Lookup) Refresh(
 	allowInvalidCerts *string,
 	require *string,
 	semanticVersioning *string,
+	versionScheme *string,
 	typeStr *string,
 	url *string,
 	urlCommands *string,
@@ -149,6 +177,7 @@ func (l *Lookup) Refresh(
 		allowInvalidCerts,
 		require,
 		semanticVersioning,
+		versionScheme,
 		typeStr,
 		url,
 		urlCommands,
@@ -169,6 +198,7 @@ func (l *Lookup) Refresh(
 	// Whether overrides were provided or not, we can update the status if not.
 	overrides := require != nil ||
 		semanticVersioning != nil ||
+		versionScheme != nil ||
 		url != nil ||
 		urlCommands != nil ||
 		usePreRelease != nil
@@ -180,13 +210,14 @@ func (l *Lookup) Refresh(
 	}
 	version = lookup.Status.GetLatestVersion()
 
-	// Querying the same GitHub repo
+	// Querying the same GitHub/GitLab/Gitea repo
 	if url == nil &&
-		lookup.Type == "github" && l.Type == "github" &&
+		(lookup.Type == "github" || lookup.Type == "gitlab" || lookup.Type == "gitea") && l.Type == lookup.Type &&
 		lookup.GitHubData.ETag != l.GitHubData.ETag {
 		// Update the ETag and releases
 		l.GitHubData.ETag = lookup.GitHubData.ETag
 		l.GitHubData.Releases = lookup.GitHubData.Releases
+		l.GitHubData.Pages = lookup.GitHubData.Pages
 	}
 
 	// If no overrides that may change a successful query were provided
@@ -194,11 +225,22 @@ func (l *Lookup) Refresh(
 	if !overrides {
 		// Update the last queried time.
 		l.Status.SetLastQueried(lookup.Status.GetLastQueried())
+		// Update the pending version (require.min_age).
+		l.Status.SetPendingVersion(lookup.Status.GetPendingVersion(), lookup.Status.GetPendingVersionTimestamp(), true)
+		// Update the digest of the latest version (a changed digest of a known version is an update too).
+		newDigest := lookup.Status.GetLatestVersionDigest()
+		oldDigest := l.Status.GetLatestVersionDigest()
+		digestChanged := oldDigest != "" && newDigest != oldDigest
+		l.Status.SetLatestVersionDigest(newDigest, lookup.Status.GetLatestVersionCreated(), true)
+		// Update the metadata of the latest version.
+		l.Status.SetLatestVersionURLs(lookup.Status.GetLatestVersionURLs(), true)
+		l.Status.SetLatestVersionMessage(lookup.Status.GetLatestVersionMessage(), true)
+		l.Status.SetLatestVersionRelease(lookup.Status.GetLatestVersionRelease(), true)
 		// Update the latest version if it has changed.
-		mewLatestVersion := lookup.Status.GetLatestVersion()
-		if mewLatestVersion != l.Status.GetLatestVersion() {
+		newLatestVersion := lookup.Status.GetLatestVersion()
+		if newLatestVersion != l.Status.GetLatestVersion() || digestChanged {
 			announceUpdate = true
-			l.Status.SetLatestVersion(mewLatestVersion, true)
+			l.Status.SetLatestVersion(newLatestVersion, true)
 		}
 	}
 
@@ -17,8 +17,11 @@
 package latestver
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 
 	github_types "github.com/release-argus/Argus/service/latest_version/api_type"
@@ -37,6 +40,7 @@ func TestLookup_ApplyOverrides(t *testing.T) {
 		allowInvalidCerts  *string
 		require            *string
 		semanticVersioning *string
+		versionScheme      *string
 		typeStr            *string
 		url                *string
 		urlCommands        *string
@@ -44,6 +48,7 @@ func TestLookup_ApplyOverrides(t *testing.T) {
 		previous           *Lookup
 		errRegex           string
 		want               *Lookup
+		wantVersionScheme  string
 	}{
 		"all nil": {
 			previous: testLookup(true, true),
@@ -122,6 +127,36 @@ func TestLookup_ApplyOverrides(t *testing.T) {
 				Status:            test.Status,
 			},
 		},
+		"version scheme": {
+			versionScheme: stringPtr("calver"),
+			previous:      testLookup(true, true),
+			want: &Lookup{
+				Type:              test.Type,
+				URL:               test.URL,
+				URLCommands:       test.URLCommands,
+				AllowInvalidCerts: test.AllowInvalidCerts,
+				Require:           test.Require,
+				Status:            test.Status,
+			},
+			wantVersionScheme: "calver",
+		},
+		"semantic versioning overrides the version scheme": {
+			semanticVersioning: stringPtr("false"),
+			previous: func() *Lookup {
+				lookup := testLookup(true, true)
+				lookup.Options.VersionScheme = "pep440"
+				return lookup
+			}(),
+			want: &Lookup{
+				Type:              test.Type,
+				URL:               test.URL,
+				URLCommands:       test.URLCommands,
+				AllowInvalidCerts: test.AllowInvalidCerts,
+				Require:           test.Require,
+				Status:            test.Status,
+			},
+			wantVersionScheme: "none",
+		},
 		"url": {
 			url:      stringPtr("https://valid.release-argus.io/json"),
 			previous: testLookup(true, true),
@@ -255,6 +290,7 @@ func TestLookup_ApplyOverrides(t *testing.T) {
 				tc.allowInvalidCerts,
 				tc.require,
 				tc.semanticVersioning,
+				tc.versionScheme,
 				tc.typeStr,
 				tc.url,
 				tc.urlCommands,
@@ -282,6 +318,11 @@ func TestLookup_ApplyOverrides(t *testing.T) {
 				t.Errorf("expected:\n%v\nbut got:\n%v",
 					tc.want, got)
 			}
+			// AND the version_scheme is overridden
+			if tc.wantVersionScheme != "" && got.Options.GetVersionScheme() != tc.wantVersionScheme {
+				t.Errorf("want version_scheme %q, got %q",
+					tc.wantVersionScheme, got.Options.GetVersionScheme())
+			}
 			// AND the GitHubData is only carried over to github types
 			if tc.want.GitHubData.String() != got.GitHubData.String() {
 				t.Errorf("expected:\n%v\nbut got:\n%v",
@@ -307,6 +348,7 @@ func TestLookup_Refresh(t *testing.T) {
 		allowInvalidCerts  *string
 		require            *string
 		semanticVersioning *string
+		versionScheme      *string
 		typeStr            *string
 		url                *string
 		urlCommands        *string
@@ -399,6 +441,7 @@ func TestLookup_Refresh(t *testing.T) {
 				tc.allowInvalidCerts,
 				tc.require,
 				tc.semanticVersioning,
+				tc.versionScheme,
 				tc.typeStr,
 				tc.url,
 				tc.urlCommands,
@@ -430,7 +473,7 @@ func TestLookup_Refresh(t *testing.T) {
 			// AND the timestamp only changes if the version changed
 			if previousStatus.GetLatestVersionTimestamp() != "" {
 				// If the possible query-changing overrides are nil
-				if tc.require == nil && tc.semanticVersioning == nil && tc.url == nil && tc.urlCommands == nil {
+				if tc.require == nil && tc.semanticVersioning == nil && tc.versionScheme == nil && tc.url == nil && tc.urlCommands == nil {
 					// The timestamp should change only if the version changed
 					if previousStatus.GetLatestVersion() != tc.previous.Status.GetLatestVersion() &&
 						previousStatus.GetLatestVersionTimestamp() == tc.previous.Status.GetLatestVersionTimestamp() {
@@ -453,3 +496,166 @@ func TestLookup_Refresh(t *testing.T) {
 		})
 	}
 }
+
+func TestLookup_RefreshDigest(t *testing.T) {
+	// GIVEN a Helm chart repository, and a Lookup of it with a latest version and digest
+	testLogging("WARN")
+	tests := map[string]struct {
+		latestVersion, latestDigest string
+		wantDigest, wantCreated     string
+		announce                    bool
+	}{
+		"same version and digest": {
+			latestVersion: "1.2.0",
+			latestDigest:  "2222",
+			wantDigest:    "2222",
+			wantCreated:   "2023-02-01T00:00:00Z",
+			announce:      false},
+		"same version, new digest": {
+			latestVersion: "1.2.0",
+			latestDigest:  "1111",
+			wantDigest:    "2222",
+			wantCreated:   "2023-02-01T00:00:00Z",
+			announce:      true},
+		"same version, digest unknown": {
+			latestVersion: "1.2.0",
+			wantDigest:    "2222",
+			wantCreated:   "2023-02-01T00:00:00Z",
+			announce:      false},
+		"new version": {
+			latestVersion: "1.1.0",
+			latestDigest:  "1111",
+			wantDigest:    "2222",
+			wantCreated:   "2023-02-01T00:00:00Z",
+			announce:      true},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(testHelmIndex))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "helm"
+			lookup.URL = server.URL
+			lookup.Chart = "argus"
+			lookup.GitHubData = nil
+			lookup.URLCommands = nil
+			lookup.Status.ServiceID = &name
+			lookup.Status.SetLatestVersion(tc.latestVersion, false)
+			lookup.Status.SetLatestVersionDigest(tc.latestDigest, "", false)
+			lookup.Status.SetLatestVersionURLs([]string{"https://example.com/old.tgz"}, false)
+
+			// WHEN Refresh is called on it without overrides
+			got, gotAnnounce, err := lookup.Refresh(
+				nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			// THEN the newest chart version is found
+			if err != nil || got != "1.2.0" {
+				t.Fatalf("want 1.2.0, got %q (err=%v)",
+					got, err)
+			}
+			// AND announce is only true when the version or digest changed
+			if gotAnnounce != tc.announce {
+				t.Errorf("want announce of %t, got %t",
+					tc.announce, gotAnnounce)
+			}
+			// AND the digest/created date are copied to the Status
+			if got := lookup.Status.GetLatestVersionDigest(); got != tc.wantDigest {
+				t.Errorf("want latest_version_digest %q, got %q",
+					tc.wantDigest, got)
+			}
+			if got := lookup.Status.GetLatestVersionCreated(); got != tc.wantCreated {
+				t.Errorf("want latest_version_created %q, got %q",
+					tc.wantCreated, got)
+			}
+			// AND the URLs of the chart are copied to the Status
+			wantURLs := "https://example.com/charts/argus-1.2.0.tgz"
+			if got := strings.Join(lookup.Status.GetLatestVersionURLs(), ","); got != wantURLs {
+				t.Errorf("want latest_version_urls %q, got %q",
+					wantURLs, got)
+			}
+		})
+	}
+}
+
+func TestLookup_RefreshRelease(t *testing.T) {
+	// GIVEN a Gitea repository, and a Lookup of it with the metadata of a previous release
+	testLogging("WARN")
+	tests := map[string]struct {
+		latestVersion string
+		announce      bool
+	}{
+		"same version": {
+			latestVersion: "1.2.0",
+			announce:      false},
+		"new version": {
+			latestVersion: "1.1.0",
+			announce:      true},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`[{
+					"tag_name": "v1.2.0",
+					"name": "Argus 1.2.0",
+					"body": "Bug fixes",
+					"html_url": "https://gitea.example.com/owner/repo/releases/tag/v1.2.0",
+					"published_at": "2023-02-01T00:00:00Z",
+					"author": {"login": "someone"}}]`))
+			}))
+			defer server.Close()
+			lookup := testLookup(false, false)
+			lookup.Type = "gitea"
+			lookup.URL = "owner/repo"
+			lookup.BaseURL = server.URL
+			lookup.GitHubData = &GitHubData{}
+			lookup.URLCommands = filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr(`^v(.+)$`)}}
+			lookup.Require = nil
+			lookup.Status.ServiceID = &name
+			lookup.Status.SetLatestVersion(tc.latestVersion, false)
+			lookup.Status.SetLatestVersionMessage("previous commit", false)
+			lookup.Status.SetLatestVersionRelease(util.ReleaseInfo{Name: "Argus " + tc.latestVersion}, false)
+
+			// WHEN Refresh is called on it without overrides
+			got, gotAnnounce, err := lookup.Refresh(
+				nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			// THEN the newest release is found
+			if err != nil || got != "1.2.0" {
+				t.Fatalf("want 1.2.0, got %q (err=%v)",
+					got, err)
+			}
+			// AND announce is only true when the version changed
+			if gotAnnounce != tc.announce {
+				t.Errorf("want announce of %t, got %t",
+					tc.announce, gotAnnounce)
+			}
+			// AND the release metadata is copied to the Status
+			want := util.ReleaseInfo{
+				Name:        "Argus 1.2.0",
+				Body:        "Bug fixes",
+				HTMLURL:     "https://gitea.example.com/owner/repo/releases/tag/v1.2.0",
+				PublishedAt: "2023-02-01T00:00:00Z",
+				Author:      "someone"}
+			if got := lookup.Status.GetLatestVersionRelease(); got != want {
+				t.Errorf("want latest_version_release %+v, got %+v",
+					want, got)
+			}
+			// AND the message of the previous release is cleared
+			if got := lookup.Status.GetLatestVersionMessage(); got != "" {
+				t.Errorf("want latest_version_message %q, got %q",
+					"", got)
+			}
+		})
+	}
+}
@@ -30,15 +30,27 @@ var (
 )
 
 type Lookup struct {
-	Type              string                 `yaml:"type,omitempty" json:"type,omitempty"`                               // "github"/"URL"
-	URL               string                 `yaml:"url,omitempty" json:"url,omitempty"`                                 // type:URL - "https://example.com", type:github - "owner/repo" or "https://github.com/owner/repo".
-	AccessToken       *string                `yaml:"access_token,omitempty" json:"access_token,omitempty"`               // GitHub access token to use
+	Type              string                 `yaml:"type,omitempty" json:"type,omitempty"`                               // "github"/"gitlab"/"gitea"/"docker"/"helm"/"npm"/"pypi"/"go"/"crates"/"apt"/"apk"/"rpm"/"feed"/"git"/"URL"
+	URL               string                 `yaml:"url,omitempty" json:"url,omitempty"`                                 // type:URL - "https://example.com", type:github - "owner/repo" or "https://github.com/owner/repo" (or a GitHub Enterprise Server repo), type:gitlab - "group/project" or project ID, type:gitea - "owner/repo", type:docker - "release-argus/argus" or "ghcr.io/release-argus/argus" (newest tag), "nginx:stable" (digest of the tag), type:helm - "https://charts.example.com", type:npm/pypi/go/crates - package name, e.g. "@scope/name" or "github.com/owner/repo", type:apt - "https://deb.debian.org/debian/dists/bookworm/InRelease" or a Packages(.gz) URL, type:apk - "https://dl-cdn.alpinelinux.org/alpine/v3.18/main/x86_64", type:rpm - "https://dl.rockylinux.org/pub/rocky/9/BaseOS/x86_64/os", type:feed - "https://example.com/releases.atom", type:git - "https://git.example.com/repo.git".
+	BaseURL           string                 `yaml:"base_url,omitempty" json:"base_url,omitempty"`                       // type:github - GitHub Enterprise Server, "https://github.example.com" (default https://github.com, or the base_url of the defaults), type:gitlab - "https://gitlab.example.com" (default https://gitlab.com), type:gitea - "https://gitea.example.com", type:npm/pypi/go/crates - registry mirror (default the public registry)
+	Chart             string                 `yaml:"chart,omitempty" json:"chart,omitempty"`                             // type:helm - Name of the chart in the repository
+	UseAppVersion     *bool                  `yaml:"use_app_version,omitempty" json:"use_app_version,omitempty"`         // type:helm - Use the appVersion of the chart rather than its version
+	Package           string                 `yaml:"package,omitempty" json:"package,omitempty"`                         // type:apt/apk/rpm - Name of the package in the repository
+	Architecture      string                 `yaml:"architecture,omitempty" json:"architecture,omitempty"`               // type:apt/rpm - Only use packages of this architecture (and those for all architectures), e.g. "amd64"/"x86_64"
+	Branch            string                 `yaml:"branch,omitempty" json:"branch,omitempty"`                           // type:git/github - Track the head commit of this branch rather than the tags/releases
+	Username          string                 `yaml:"username,omitempty" json:"username,omitempty"`                       // type:docker/helm/npm/pypi/go/crates/apt/apk/rpm/feed/git - Username for the registry/repository (type:docker defaults to that of a require.docker on the same registry)
+	AccessToken       *string                `yaml:"access_token,omitempty" json:"access_token,omitempty"`               // GitHub/Gitea access token / GitLab private token / Docker registry/Helm repository password to use
+	GitHubApp         *GitHubApp             `yaml:"github_app,omitempty" json:"github_app,omitempty"`                   // type:github - GitHub App to authenticate as (instead of an access_token)
+	UseGraphQL        *bool                  `yaml:"use_graphql,omitempty" json:"use_graphql,omitempty"`                 // type:github - Batch the release queries with those of other services due in the same 15s through the GraphQL API (no ETags)
+	MaxPages          *uint                  `yaml:"max_pages,omitempty" json:"max_pages,omitempty"`                     // type:github/gitlab/gitea - Maximum number of pages of releases to query (default 1)
+	UseLatestRelease  *bool                  `yaml:"use_latest_release,omitempty" json:"use_latest_release,omitempty"`   // type:github - Use the release the repo has marked as latest (releases/latest) rather than sorting the releases
 	AllowInvalidCerts *bool                  `yaml:"allow_invalid_certs,omitempty" json:"allow_invalid_certs,omitempty"` // default - false = Disallows invalid HTTPS certificates
 	UsePreRelease     *bool                  `yaml:"use_prerelease,omitempty" json:"use_prerelease,omitempty"`           // Whether the prerelease tag should be used (prereleases are ignored by default)
+	OrderBy           string                 `yaml:"order_by,omitempty" json:"order_by,omitempty"`                       // How the releases are ordered to choose the latest - "semver" (default with semantic_versioning), "published_date", "api_order" (default without semantic_versioning) or "tag_date"
 	URLCommands       filter.URLCommandSlice `yaml:"url_commands,omitempty" json:"url_commands,omitempty"`               // Commands to filter the release from the URL request
 	Require           *filter.Require        `yaml:"require,omitempty" json:"require,omitempty"`                         // Options to require before a release is considered valid
 	Options           *opt.Options           `yaml:"-" json:"-"`                                                         // Options
-	GitHubData        *GitHubData            `yaml:"-" json:"-"`                                                         // GitHub Conditional Request vars
+	GitHubData        *GitHubData            `yaml:"-" json:"-"`                                                         // GitHub/GitLab/Gitea Conditional Request vars
 	Status            *svcstatus.Status      `yaml:"-" json:"-"`                                                         // Service Status
 	Defaults          *Lookup                `yaml:"-" json:"-"`                                                         // Defaults
 	HardDefaults      *Lookup                `yaml:"-" json:"-"`                                                         // Hard Defaults
@@ -54,10 +66,18 @@ func (l *Lookup) String() string {
 	return string(yamlBytes)
 }
 
-// GitHubData is data needed in GitHub requests
+// GitHubData is data needed in GitHub (and GitLab/Gitea) requests
 type GitHubData struct {
 	ETag     string                 `json:"etag"`               // GitHub ETag for conditional requests https://docs.github.com/en/rest/overview/resources-in-the-rest-api#conditional-requestsl
 	Releases []github_types.Release `json:"releases,omitempty"` // Track the ETag releases until they're usable
+	Pages    []GitHubPage           `json:"pages,omitempty"`    // Pages of releases, and their ETags (when max_pages > 1)
+}
+
+// GitHubPage is a page of releases from the GitHub/GitLab/Gitea API, kept for conditional requests of that page.
+type GitHubPage struct {
+	ETag string `json:"etag"`
+	Next string `json:"-"` // URL of the next page (not given in Not Modified responses)
+	Body []byte `json:"-"`
 }
 
 // String returns a string representation of the Status.
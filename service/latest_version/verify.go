@@ -16,11 +16,15 @@ package latestver
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/release-argus/Argus/util"
 )
 
+// validTypes of Lookup.
+var validTypes = append([]string{"apk", "apt", "docker", "feed", "git", "gitea", "github", "gitlab", "helm", "rpm", "url"}, packageTypes...)
+
 // Print the struct.
 func (l *Lookup) Print(prefix string) {
 	fmt.Printf("%slatest_version:\n", prefix)
@@ -29,12 +33,35 @@ func (l *Lookup) Print(prefix string) {
 		fmt.Sprintf("%stype: %s", prefix, l.Type))
 	util.PrintlnIfNotDefault(l.URL,
 		fmt.Sprintf("%surl: %s", prefix, l.URL))
+	util.PrintlnIfNotDefault(l.BaseURL,
+		fmt.Sprintf("%sbase_url: %s", prefix, l.BaseURL))
+	util.PrintlnIfNotDefault(l.Chart,
+		fmt.Sprintf("%schart: %s", prefix, l.Chart))
+	util.PrintlnIfNotNil(l.UseAppVersion,
+		fmt.Sprintf("%suse_app_version: %t", prefix, util.DefaultIfNil(l.UseAppVersion)))
+	util.PrintlnIfNotDefault(l.Package,
+		fmt.Sprintf("%spackage: %s", prefix, l.Package))
+	util.PrintlnIfNotDefault(l.Architecture,
+		fmt.Sprintf("%sarchitecture: %s", prefix, l.Architecture))
+	util.PrintlnIfNotDefault(l.Branch,
+		fmt.Sprintf("%sbranch: %s", prefix, l.Branch))
+	util.PrintlnIfNotDefault(l.Username,
+		fmt.Sprintf("%susername: %s", prefix, l.Username))
 	util.PrintlnIfNotNil(l.AccessToken,
 		fmt.Sprintf("%saccess_token: %q", prefix, util.DefaultIfNil(l.AccessToken)))
+	l.GitHubApp.Print(prefix)
+	util.PrintlnIfNotNil(l.UseGraphQL,
+		fmt.Sprintf("%suse_graphql: %t", prefix, util.DefaultIfNil(l.UseGraphQL)))
+	util.PrintlnIfNotNil(l.MaxPages,
+		fmt.Sprintf("%smax_pages: %d", prefix, util.DefaultIfNil(l.MaxPages)))
+	util.PrintlnIfNotNil(l.UseLatestRelease,
+		fmt.Sprintf("%suse_latest_release: %t", prefix, util.DefaultIfNil(l.UseLatestRelease)))
 	util.PrintlnIfNotNil(l.AllowInvalidCerts,
 		fmt.Sprintf("%sallow_invalid_certs: %t", prefix, util.DefaultIfNil(l.AllowInvalidCerts)))
 	util.PrintlnIfNotNil(l.UsePreRelease,
 		fmt.Sprintf("%suse_prerelease: %t", prefix, util.DefaultIfNil(l.UsePreRelease)))
+	util.PrintlnIfNotDefault(l.OrderBy,
+		fmt.Sprintf("%sorder_by: %s", prefix, l.OrderBy))
 	l.URLCommands.Print(prefix)
 	l.Require.Print(prefix)
 }
@@ -46,17 +73,153 @@ func (l *Lookup) CheckValues(prefix string) (errs error) {
 			errs = fmt.Errorf("%s%s  url: <required> e.g. github:'release-argus/Argus' or url:'https://example.com'\\",
 				util.ErrorToString(errs), prefix)
 		}
-	} else if l.Type != "url" && l.Type != "github" {
+	} else if !util.Contains(validTypes, l.Type) {
 		errType := "<required>"
 		if l.Type != "" {
 			errType = fmt.Sprintf("%q <invalid>", l.Type)
 		}
-		errs = fmt.Errorf("%s%s  type: %s e.g. github or url\\",
+		errs = fmt.Errorf("%s%s  type: %s e.g. github, gitlab, gitea, docker, helm, npm, pypi, go, crates, apt, apk, rpm, feed, git or url\\",
 			util.ErrorToString(errs), prefix, errType)
 	}
-	if l.Type == "github" && strings.Count(l.URL, "/") > 1 {
-		parts := strings.Split(l.URL, "/")
-		l.URL = strings.Join(parts[len(parts)-2:], "/")
+	if l.OrderBy != "" && !util.Contains(orderByValues, l.OrderBy) {
+		errs = fmt.Errorf("%s%s  order_by: %q <invalid> (expected one of [%s])\\",
+			util.ErrorToString(errs), prefix, l.OrderBy, strings.Join(orderByValues, ", "))
+	}
+	if l.Type != "" && l.Type != "url" && l.URLCommands.HasAllMatches() {
+		errs = fmt.Errorf("%s%s  url_commands: <invalid> (all_matches is only for the url type)\\",
+			util.ErrorToString(errs), prefix)
+	}
+	if l.Architecture != "" && l.Type != "apt" && l.Type != "rpm" {
+		errs = fmt.Errorf("%s%s  architecture: %q <invalid> (only for the apt and rpm types)\\",
+			util.ErrorToString(errs), prefix, l.Architecture)
+	}
+	if l.Branch != "" && l.Type != "git" && l.Type != "github" {
+		errs = fmt.Errorf("%s%s  branch: %q <invalid> (only for the git and github types)\\",
+			util.ErrorToString(errs), prefix, l.Branch)
+	}
+	// Options only used by the github type.
+	if l.Type != "" && l.Type != "github" {
+		for _, option := range []struct {
+			key string
+			set bool
+		}{
+			{key: "github_app", set: l.GitHubApp != nil},
+			{key: "use_graphql", set: l.UseGraphQL != nil},
+			{key: "use_latest_release", set: l.UseLatestRelease != nil}} {
+			if option.set {
+				errs = fmt.Errorf("%s%s  %s: <invalid> (only for the github type)\\",
+					util.ErrorToString(errs), prefix, option.key)
+			}
+		}
+	} else if appErrs := l.GitHubApp.CheckValues(prefix + "  "); appErrs != nil {
+		errs = fmt.Errorf("%s%w",
+			util.ErrorToString(errs), appErrs)
+	}
+	if l.MaxPages != nil {
+		switch {
+		case l.Type != "" && l.Type != "github" && l.Type != "gitlab" && l.Type != "gitea":
+			errs = fmt.Errorf("%s%s  max_pages: <invalid> (only for the github, gitlab and gitea types)\\",
+				util.ErrorToString(errs), prefix)
+		case *l.MaxPages == 0:
+			errs = fmt.Errorf("%s%s  max_pages: 0 <invalid> (must be at least 1)\\",
+				util.ErrorToString(errs), prefix)
+		}
+	}
+	switch l.Type {
+	// GitHub, or the defaults (where base_url is only used by the github type).
+	case "", "github":
+		// Full URL of a github.com repository
+		// (those on a GitHub Enterprise Server keep their host, which is used as the base URL).
+		_, enterpriseRepo := l.gitHubEnterpriseRepo()
+		if strings.Count(l.URL, "/") > 1 && enterpriseRepo == "" {
+			parts := strings.Split(l.URL, "/")
+			l.URL = strings.Join(parts[len(parts)-2:], "/")
+		}
+		if l.BaseURL != "" {
+			if _, err := url.ParseRequestURI(l.BaseURL); err != nil || !strings.HasPrefix(l.BaseURL, "http") {
+				errs = fmt.Errorf("%s%s  base_url: %q <invalid> (GitHub Enterprise Server, e.g. https://github.example.com)\\",
+					util.ErrorToString(errs), prefix, l.BaseURL)
+			}
+		}
+		// The GraphQL API requires authentication.
+		if l.Type == "github" && l.Defaults != nil && l.HardDefaults != nil &&
+			l.GetUseGraphQL() && l.gitHubAccessToken() == "" && l.GetGitHubApp() == nil {
+			errs = fmt.Errorf("%s%s  use_graphql: true <invalid> (requires an access_token or github_app)\\",
+				util.ErrorToString(errs), prefix)
+		}
+		// The GraphQL API only gives the browser URLs of assets, which can't be downloaded from private repos.
+		if l.Type == "github" && l.Defaults != nil && l.HardDefaults != nil &&
+			l.GetUseGraphQL() && l.Require != nil && l.Require.Verify != nil {
+			errs = fmt.Errorf("%s%s  use_graphql: true <invalid> (can't be used with require.verify)\\",
+				util.ErrorToString(errs), prefix)
+		}
+	case "docker":
+		if l.URL != "" {
+			if err := l.checkDockerURL(); err != nil {
+				errs = fmt.Errorf("%s%s  %s\\",
+					util.ErrorToString(errs), prefix, err)
+			}
+		}
+	case "feed":
+		if l.URL != "" {
+			if _, err := url.ParseRequestURI(l.URL); err != nil || !strings.HasPrefix(l.URL, "http") {
+				errs = fmt.Errorf("%s%s  url: %q <invalid> (RSS/Atom feed, e.g. https://example.com/releases.atom)\\",
+					util.ErrorToString(errs), prefix, l.URL)
+			}
+		}
+	case "git":
+		if l.URL != "" {
+			if _, err := url.ParseRequestURI(l.URL); err != nil || !strings.HasPrefix(l.URL, "http") {
+				errs = fmt.Errorf("%s%s  url: %q <invalid> (git repository over HTTP(S), e.g. https://git.example.com/repo.git)\\",
+					util.ErrorToString(errs), prefix, l.URL)
+			}
+		}
+	case "helm":
+		if l.URL != "" {
+			if _, err := url.ParseRequestURI(l.URL); err != nil || !strings.HasPrefix(l.URL, "http") {
+				errs = fmt.Errorf("%s%s  url: %q <invalid> (Helm repository, e.g. https://charts.example.com)\\",
+					util.ErrorToString(errs), prefix, l.URL)
+			}
+		}
+		if l.Chart == "" {
+			errs = fmt.Errorf("%s%s  chart: <required> e.g. 'argus'\\",
+				util.ErrorToString(errs), prefix)
+		}
+	case "apk", "apt", "rpm":
+		if l.URL != "" {
+			if err := l.checkOSPackageURL(); err != nil {
+				errs = fmt.Errorf("%s%s  %s\\",
+					util.ErrorToString(errs), prefix, err)
+			}
+		}
+		if l.Package == "" {
+			errs = fmt.Errorf("%s%s  package: <required> e.g. 'curl'\\",
+				util.ErrorToString(errs), prefix)
+		}
+	case "crates", "go", "npm", "pypi":
+		if l.URL != "" {
+			if err := l.checkPackageURL(); err != nil {
+				errs = fmt.Errorf("%s%s  %s\\",
+					util.ErrorToString(errs), prefix, err)
+			}
+		}
+		if l.BaseURL != "" {
+			if _, err := url.ParseRequestURI(l.BaseURL); err != nil {
+				errs = fmt.Errorf("%s%s  base_url: %q <invalid> (e.g. %s)\\",
+					util.ErrorToString(errs), prefix, l.BaseURL, packageDefaultBaseURL[l.Type])
+			}
+		}
+	case "gitea", "gitlab":
+		l.normaliseProjectURL()
+		if l.BaseURL != "" {
+			if _, err := url.ParseRequestURI(l.BaseURL); err != nil {
+				errs = fmt.Errorf("%s%s  base_url: %q <invalid> (e.g. https://%s.example.com)\\",
+					util.ErrorToString(errs), prefix, l.BaseURL, l.Type)
+			}
+		} else if l.Type == "gitea" {
+			errs = fmt.Errorf("%s%s  base_url: <required> e.g. 'https://gitea.example.com'\\",
+				util.ErrorToString(errs), prefix)
+		}
 	}
 
 	if requireErrs := l.Require.CheckValues(prefix + "  "); requireErrs != nil {
@@ -75,3 +238,24 @@ func (l *Lookup) CheckValues(prefix string) (errs error) {
 
 	return
 }
+
+// normaliseProjectURL will split a full project URL (e.g. "https://gitlab.example.com/group/project")
+// into its BaseURL and project path.
+func (l *Lookup) normaliseProjectURL() {
+	if !strings.HasPrefix(l.URL, "http://") && !strings.HasPrefix(l.URL, "https://") {
+		return
+	}
+
+	parsedURL, err := url.Parse(l.URL)
+	if err != nil || parsedURL.Host == "" {
+		return
+	}
+
+	if l.BaseURL == "" {
+		l.BaseURL = fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
+	}
+	path := strings.TrimSuffix(parsedURL.Path, "/")
+	path = strings.TrimSuffix(path, "/releases")
+	path = strings.TrimSuffix(path, "/-")
+	l.URL = strings.Trim(path, "/")
+}
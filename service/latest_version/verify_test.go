@@ -123,7 +123,18 @@ func TestLookup_CheckValues(t *testing.T) {
 	tests := map[string]struct {
 		lType       *string
 		url         *string
+		baseURL     string
+		chart       string
+		pkg         string
+		arch        string
+		branch      string
+		githubApp   *GitHubApp
+		accessToken *string
+		useGraphQL  *bool
+		maxPages    *uint
+		orderBy     string
 		wantURL     *string
+		wantBaseURL *string
 		require     *filter.Require
 		urlCommands *filter.URLCommandSlice
 		errRegex    string
@@ -148,6 +159,237 @@ func TestLookup_CheckValues(t *testing.T) {
 			url:      stringPtr("https://github.com/release-argus/Argus"),
 			wantURL:  stringPtr("release-argus/Argus"),
 		},
+		"corrects www.github.com url": {
+			errRegex:    `^$`,
+			url:         stringPtr("https://www.github.com/release-argus/Argus"),
+			wantURL:     stringPtr("release-argus/Argus"),
+			wantBaseURL: stringPtr(""),
+		},
+		"corrects api.github.com url": {
+			errRegex:    `^$`,
+			url:         stringPtr("https://api.github.com/repos/release-argus/Argus"),
+			wantURL:     stringPtr("release-argus/Argus"),
+			wantBaseURL: stringPtr(""),
+		},
+		"keeps GitHub Enterprise Server url without changing base_url": {
+			errRegex:    `^$`,
+			url:         stringPtr("https://github.example.com/owner/repo"),
+			wantURL:     stringPtr("https://github.example.com/owner/repo"),
+			wantBaseURL: stringPtr(""),
+		},
+		"github type with invalid base_url": {
+			errRegex: `base_url: .* <invalid> \(GitHub Enterprise Server`,
+			baseURL:  "github.example.com",
+		},
+		"defaults with invalid base_url": {
+			errRegex: `base_url: .* <invalid> \(GitHub Enterprise Server`,
+			lType:    stringPtr(""),
+			url:      stringPtr(""),
+			baseURL:  "github.example.com",
+		},
+		"gitlab type": {
+			errRegex: `^$`,
+			lType:    stringPtr("gitlab"),
+			url:      stringPtr("https://gitlab.example.com/group/project"),
+			wantURL:  stringPtr("group/project"),
+		},
+		"gitlab type with invalid base_url": {
+			errRegex: `base_url: .* <invalid>`,
+			lType:    stringPtr("gitlab"),
+			url:      stringPtr("group/project"),
+			baseURL:  "gitlab.example.com",
+		},
+		"gitea type without base_url": {
+			errRegex: `base_url: <required>`,
+			lType:    stringPtr("gitea"),
+			url:      stringPtr("owner/repo"),
+		},
+		"gitea type with full url": {
+			errRegex: `^$`,
+			lType:    stringPtr("gitea"),
+			url:      stringPtr("https://gitea.example.com/owner/repo"),
+			wantURL:  stringPtr("owner/repo"),
+		},
+		"docker type": {
+			errRegex: `^$`,
+			lType:    stringPtr("docker"),
+			url:      stringPtr("ghcr.io/release-argus/argus"),
+		},
+		"docker type with invalid image": {
+			errRegex: `url: .* <invalid>`,
+			lType:    stringPtr("docker"),
+			url:      stringPtr("release-argus/Argus"),
+		},
+		"helm type": {
+			errRegex: `^$`,
+			lType:    stringPtr("helm"),
+			url:      stringPtr("https://charts.example.com"),
+			chart:    "argus",
+		},
+		"helm type without chart": {
+			errRegex: `chart: <required>`,
+			lType:    stringPtr("helm"),
+			url:      stringPtr("https://charts.example.com"),
+		},
+		"helm type with invalid url": {
+			errRegex: `url: .* <invalid>`,
+			lType:    stringPtr("helm"),
+			url:      stringPtr("oci://registry.example.com/charts"),
+			chart:    "argus",
+		},
+		"npm type": {
+			errRegex: `^$`,
+			lType:    stringPtr("npm"),
+			url:      stringPtr("@scope/name"),
+		},
+		"pypi type with a url": {
+			errRegex: `url: .* <invalid> \(package name`,
+			lType:    stringPtr("pypi"),
+			url:      stringPtr("https://pypi.org/project/requests"),
+		},
+		"go type with invalid module path": {
+			errRegex: `url: .* <invalid>`,
+			lType:    stringPtr("go"),
+			url:      stringPtr("example.com/mod/"),
+		},
+		"crates type with invalid base_url": {
+			errRegex: `base_url: .* <invalid>`,
+			lType:    stringPtr("crates"),
+			url:      stringPtr("serde"),
+			baseURL:  "crates.example.com",
+		},
+		"apt type": {
+			errRegex: `^$`,
+			lType:    stringPtr("apt"),
+			url:      stringPtr("https://deb.debian.org/debian/dists/bookworm/InRelease"),
+			pkg:      "curl",
+		},
+		"apk type without package": {
+			errRegex: `package: <required>`,
+			lType:    stringPtr("apk"),
+			url:      stringPtr("https://dl-cdn.alpinelinux.org/alpine/v3.18/main/x86_64"),
+		},
+		"rpm type with invalid url": {
+			errRegex: `url: .* <invalid>`,
+			lType:    stringPtr("rpm"),
+			url:      stringPtr("dl.rockylinux.org/pub/rocky/9/BaseOS/x86_64/os"),
+			pkg:      "curl",
+		},
+		"feed type with invalid url": {
+			errRegex: `url: .* <invalid> \(RSS/Atom feed`,
+			lType:    stringPtr("feed"),
+			url:      stringPtr("example.com/feed"),
+		},
+		"git type with invalid url": {
+			errRegex: `url: .* <invalid> \(git repository`,
+			lType:    stringPtr("git"),
+			url:      stringPtr("git@git.example.com:repo.git"),
+		},
+		"apt type with architecture": {
+			errRegex: `^$`,
+			lType:    stringPtr("apt"),
+			url:      stringPtr("https://deb.debian.org/debian/dists/bookworm/InRelease"),
+			pkg:      "curl",
+			arch:     "amd64",
+		},
+		"architecture on a type without architectures": {
+			errRegex: `architecture: "amd64" <invalid>`,
+			lType:    stringPtr("npm"),
+			url:      stringPtr("@scope/name"),
+			arch:     "amd64",
+		},
+		"github type with branch": {
+			errRegex: `^$`,
+			branch:   "main",
+		},
+		"branch on a type without branches": {
+			errRegex: `branch: "main" <invalid>`,
+			lType:    stringPtr("npm"),
+			url:      stringPtr("@scope/name"),
+			branch:   "main",
+		},
+		"github_app on a type without GitHub Apps": {
+			errRegex:  `github_app: <invalid>`,
+			lType:     stringPtr("npm"),
+			url:       stringPtr("@scope/name"),
+			githubApp: &GitHubApp{AppID: "1"},
+		},
+		"invalid github_app": {
+			errRegex:  `github_app:.*installation_id: <required>`,
+			githubApp: &GitHubApp{AppID: "1"},
+		},
+		"max_pages on a type without pages": {
+			errRegex: `max_pages: <invalid>`,
+			lType:    stringPtr("npm"),
+			url:      stringPtr("@scope/name"),
+			maxPages: uintPtr(2),
+		},
+		"github type with max_pages": {
+			errRegex: `^$`,
+			maxPages: uintPtr(3),
+		},
+		"gitlab type with max_pages": {
+			errRegex: `^$`,
+			lType:    stringPtr("gitlab"),
+			url:      stringPtr("group/project"),
+			maxPages: uintPtr(3),
+		},
+		"use_graphql with an access_token": {
+			errRegex:    `^$`,
+			accessToken: stringPtr("ghp_token"),
+			useGraphQL:  boolPtr(true),
+		},
+		"use_graphql with a github_app": {
+			errRegex:    `^latest_version:\\  github_app:\\  private_key: [^\\]+\\$`,
+			accessToken: stringPtr(""),
+			githubApp:   &GitHubApp{AppID: "123", PrivateKey: "key.pem", InstallationID: "456"},
+			useGraphQL:  boolPtr(true),
+		},
+		"use_graphql without an access_token or github_app": {
+			errRegex:    `use_graphql: true <invalid> \(requires an access_token or github_app\)`,
+			accessToken: stringPtr(""),
+			useGraphQL:  boolPtr(true),
+		},
+		"use_graphql false without an access_token or github_app": {
+			errRegex:    `^$`,
+			accessToken: stringPtr(""),
+			useGraphQL:  boolPtr(false),
+		},
+		"use_graphql with require.verify": {
+			errRegex:    `use_graphql: true <invalid> \(can't be used with require.verify\)`,
+			accessToken: stringPtr("ghp_token"),
+			useGraphQL:  boolPtr(true),
+			require: &filter.Require{
+				Verify: &filter.VerifyCheck{
+					Asset:     "argus-{{ version }}.linux-amd64",
+					Checksums: "SHA256SUMS"}},
+		},
+		"use_graphql false with require.verify": {
+			errRegex:    `^$`,
+			accessToken: stringPtr("ghp_token"),
+			useGraphQL:  boolPtr(false),
+			require: &filter.Require{
+				Verify: &filter.VerifyCheck{
+					Asset:     "argus-{{ version }}.linux-amd64",
+					Checksums: "SHA256SUMS"}},
+		},
+		"max_pages of 0": {
+			errRegex: `max_pages: 0 <invalid>`,
+			maxPages: uintPtr(0),
+		},
+		"valid order_by": {
+			errRegex: `^$`,
+			orderBy:  "published_date",
+		},
+		"invalid order_by": {
+			errRegex: `order_by: "foo" <invalid> \(expected one of \[semver, published_date, api_order, tag_date\]\)`,
+			orderBy:  "foo",
+		},
+		"all_matches on a type without candidates from a page": {
+			errRegex: `url_commands: <invalid> \(all_matches is only for the url type\)`,
+			urlCommands: &filter.URLCommandSlice{
+				{Type: "regex", Regex: stringPtr("([0-9.]+)"), AllMatches: true}},
+		},
 		"invalid require": {
 			errRegex: `regex_content: .* <invalid>`,
 			require:  &filter.Require{RegexContent: "[0-"},
@@ -176,6 +418,18 @@ func TestLookup_CheckValues(t *testing.T) {
 			if tc.url != nil {
 				lookup.URL = *tc.url
 			}
+			lookup.BaseURL = tc.baseURL
+			lookup.Chart = tc.chart
+			lookup.Package = tc.pkg
+			lookup.Architecture = tc.arch
+			lookup.Branch = tc.branch
+			lookup.GitHubApp = tc.githubApp
+			if tc.accessToken != nil {
+				lookup.AccessToken = tc.accessToken
+			}
+			lookup.UseGraphQL = tc.useGraphQL
+			lookup.MaxPages = tc.maxPages
+			lookup.OrderBy = tc.orderBy
 			if tc.require != nil {
 				lookup.Require = tc.require
 			}
@@ -194,6 +448,65 @@ func TestLookup_CheckValues(t *testing.T) {
 				t.Fatalf("want match for %q\nnot: %q",
 					tc.errRegex, e)
 			}
+			if tc.wantURL != nil && lookup.URL != *tc.wantURL {
+				t.Errorf("want url %q, got %q",
+					*tc.wantURL, lookup.URL)
+			}
+			if tc.wantBaseURL != nil && lookup.BaseURL != *tc.wantBaseURL {
+				t.Errorf("want base_url %q, got %q",
+					*tc.wantBaseURL, lookup.BaseURL)
+			}
+		})
+	}
+}
+
+func TestLookup_NormaliseProjectURL(t *testing.T) {
+	// GIVEN a GitLab/Gitea Lookup
+	tests := map[string]struct {
+		url, baseURL         string
+		wantURL, wantBaseURL string
+	}{
+		"project path is unchanged": {
+			url:     "group/project",
+			wantURL: "group/project"},
+		"full URL is split": {
+			url:         "https://gitlab.example.com/group/project",
+			wantURL:     "group/project",
+			wantBaseURL: "https://gitlab.example.com"},
+		"releases page URL is split": {
+			url:         "https://gitlab.example.com/group/project/-/releases",
+			wantURL:     "group/project",
+			wantBaseURL: "https://gitlab.example.com"},
+		"gitea releases page URL is split": {
+			url:         "https://gitea.example.com/owner/repo/releases/",
+			wantURL:     "owner/repo",
+			wantBaseURL: "https://gitea.example.com"},
+		"base_url is kept": {
+			url:         "https://gitlab.example.com/group/project",
+			baseURL:     "https://gitlab.example.com:8443",
+			wantURL:     "group/project",
+			wantBaseURL: "https://gitlab.example.com:8443"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lookup := Lookup{Type: "gitlab", URL: tc.url, BaseURL: tc.baseURL}
+
+			// WHEN normaliseProjectURL is called
+			lookup.normaliseProjectURL()
+
+			// THEN the URL and BaseURL are as expected
+			if lookup.URL != tc.wantURL {
+				t.Errorf("URL - want: %q\ngot:  %q",
+					tc.wantURL, lookup.URL)
+			}
+			if lookup.BaseURL != tc.wantBaseURL {
+				t.Errorf("BaseURL - want: %q\ngot:  %q",
+					tc.wantBaseURL, lookup.BaseURL)
+			}
 		})
 	}
 }
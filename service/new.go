@@ -146,8 +146,9 @@ func (s *Service) giveSecretsLatestVersion(oldLatestVersion *latestver.Lookup) {
 			s.LatestVersion.Require.Docker.Token = oldLatestVersion.Require.Docker.Token
 		}
 	}
-	// GitHubData
-	if s.LatestVersion.Type == "github" && oldLatestVersion.Type == "github" {
+	// GitHubData (of the GitHub/GitLab/Gitea releases)
+	if (s.LatestVersion.Type == "github" || s.LatestVersion.Type == "gitlab" || s.LatestVersion.Type == "gitea") &&
+		oldLatestVersion.Type == s.LatestVersion.Type {
 		s.LatestVersion.GitHubData = oldLatestVersion.GitHubData
 	}
 }
@@ -323,11 +324,17 @@ func (s *Service) giveSecrets(oldService *Service, secretRefs oldSecretRefs) {
 		s.Status.SetApprovedVersion(oldService.Status.GetApprovedVersion(), false)
 		s.Status.SetLatestVersion(oldService.Status.GetLatestVersion(), false)
 		s.Status.SetLatestVersionTimestamp(oldService.Status.GetLatestVersionTimestamp())
+		s.Status.SetLatestVersionDigest(oldService.Status.GetLatestVersionDigest(), oldService.Status.GetLatestVersionCreated(), false)
+		s.Status.SetLatestVersionURLs(oldService.Status.GetLatestVersionURLs(), false)
+		s.Status.SetLatestVersionMessage(oldService.Status.GetLatestVersionMessage(), false)
+		s.Status.SetLatestVersionRelease(oldService.Status.GetLatestVersionRelease(), false)
+		s.Status.SetPendingVersion(oldService.Status.GetPendingVersion(), oldService.Status.GetPendingVersionTimestamp(), false)
 		s.Status.SetLastQueried(oldService.Status.GetLastQueried())
 	}
 	// Keep DeployedVersion if the DeployedVersionLookup is unchanged
 	if s.DeployedVersionLookup.IsEqual(oldService.DeployedVersionLookup) &&
-		oldService.Options.SemanticVersioning == s.Options.SemanticVersioning {
+		oldService.Options.SemanticVersioning == s.Options.SemanticVersioning &&
+		oldService.Options.VersionScheme == s.Options.VersionScheme {
 		s.Status.SetDeployedVersion(oldService.Status.GetDeployedVersion(), false)
 		s.Status.SetDeployedVersionTimestamp(oldService.Status.GetDeployedVersionTimestamp())
 	}
@@ -146,6 +146,25 @@ func TestService_GiveSecretsLatestVersion(t *testing.T) {
 				Type:       "github",
 				GitHubData: &githubData},
 		},
+		"GitHubData carried over if type still 'gitlab'": {
+			latestVersion: latestver.Lookup{
+				Type: "gitlab"},
+			otherLV: latestver.Lookup{
+				Type:       "gitlab",
+				GitHubData: &githubData},
+			expected: latestver.Lookup{
+				Type:       "gitlab",
+				GitHubData: &githubData},
+		},
+		"GitHubData not carried over if type changed from 'gitlab' to 'github'": {
+			latestVersion: latestver.Lookup{
+				Type: "github"},
+			otherLV: latestver.Lookup{
+				Type:       "gitlab",
+				GitHubData: &githubData},
+			expected: latestver.Lookup{
+				Type: "github"},
+		},
 		"GitHubData not carried over if type wasn't 'github'": {
 			latestVersion: latestver.Lookup{
 				Type: "github"},
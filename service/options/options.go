@@ -17,16 +17,19 @@ package opt
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/release-argus/Argus/util"
+	"github.com/release-argus/Argus/util/vercmp"
 	"gopkg.in/yaml.v3"
 )
 
 type Options struct {
 	Active             *bool    `yaml:"active,omitempty" json:"active,omitempty"`                           // Disable the service.
 	Interval           string   `yaml:"interval,omitempty" json:"interval,omitempty"`                       // AhBmCs = Sleep A hours, B minutes and C seconds between queries.
-	SemanticVersioning *bool    `yaml:"semantic_versioning,omitempty" json:"semantic_versioning,omitempty"` // default - true = Version has to follow semantic versioning (https://semver.org/) and be greater than the previous to trigger anything.
+	SemanticVersioning *bool    `yaml:"semantic_versioning,omitempty" json:"semantic_versioning,omitempty"` // DEPRECATED - use version_scheme (true = semver, false = none)
+	VersionScheme      string   `yaml:"version_scheme,omitempty" json:"version_scheme,omitempty"`           // default - semver = Version has to follow this scheme (e.g. https://semver.org/) and be greater than the previous to trigger anything.
 	Defaults           *Options `yaml:"-" json:"-"`                                                         // Defaults
 	HardDefaults       *Options `yaml:"-" json:"-"`                                                         // Hard Defaults
 }
@@ -55,10 +58,28 @@ func (o *Options) GetInterval() string {
 
 // GetSemanticVersioning will return whether Semantic Versioning should be used for this Service.
 func (o *Options) GetSemanticVersioning() bool {
-	return *util.GetFirstNonNilPtr(
-		o.SemanticVersioning,
-		o.Defaults.SemanticVersioning,
-		o.HardDefaults.SemanticVersioning)
+	return o.GetVersionScheme() == "semver"
+}
+
+// GetVersionScheme will return the scheme that the versions of this Service follow ("none" for no scheme).
+//
+// A version_scheme takes precedence over the (deprecated) semantic_versioning at the same level.
+func (o *Options) GetVersionScheme() string {
+	for _, options := range []*Options{o, o.Defaults, o.HardDefaults} {
+		if options == nil {
+			continue
+		}
+		if options.VersionScheme != "" {
+			return options.VersionScheme
+		}
+		if options.SemanticVersioning != nil {
+			if *options.SemanticVersioning {
+				return "semver"
+			}
+			return "none"
+		}
+	}
+	return "semver"
 }
 
 // GetIntervalPointer returns a pointer to the interval between queries on this Service's version.
@@ -92,6 +113,12 @@ func (o *Options) CheckValues(prefix string) (errs error) {
 		}
 	}
 
+	// Version Scheme
+	if o.VersionScheme != "" && !util.Contains(vercmp.SchemeNames, o.VersionScheme) {
+		errs = fmt.Errorf("%s%s  version_scheme: %q <invalid> (expected one of [%s])\\",
+			util.ErrorToString(errs), prefix, o.VersionScheme, strings.Join(vercmp.SchemeNames, ", "))
+	}
+
 	if errs != nil {
 		errs = fmt.Errorf("%soptions:\\%w",
 			prefix, errs)
@@ -102,7 +129,7 @@ func (o *Options) CheckValues(prefix string) (errs error) {
 
 // Print the struct.
 func (o *Options) Print(prefix string) {
-	if o.Active == nil && o.Interval == "" && o.SemanticVersioning == nil {
+	if o.Active == nil && o.Interval == "" && o.SemanticVersioning == nil && o.VersionScheme == "" {
 		return
 	}
 
@@ -113,4 +140,6 @@ func (o *Options) Print(prefix string) {
 		fmt.Sprintf("%s  interval: %s", prefix, o.Interval))
 	util.PrintlnIfNotNil(o.SemanticVersioning,
 		fmt.Sprintf("%s  semantic_versioning: %t", prefix, util.DefaultIfNil(o.SemanticVersioning)))
+	util.PrintlnIfNotDefault(o.VersionScheme,
+		fmt.Sprintf("%s  version_scheme: %s", prefix, o.VersionScheme))
 }
@@ -164,6 +164,69 @@ func TestOptions_GetSemanticVersioning(t *testing.T) {
 	}
 }
 
+func TestOptions_GetVersionScheme(t *testing.T) {
+	// GIVEN Options
+	tests := map[string]struct {
+		root, dflt, hardDefault Options
+		want                    string
+	}{
+		"root overrides all": {
+			root:        Options{VersionScheme: "calver"},
+			dflt:        Options{VersionScheme: "pep440"},
+			hardDefault: Options{VersionScheme: "semver"},
+			want:        "calver"},
+		"default overrides hardDefault": {
+			dflt:        Options{VersionScheme: "pep440"},
+			hardDefault: Options{VersionScheme: "semver"},
+			want:        "pep440"},
+		"hardDefault is last resort": {
+			hardDefault: Options{VersionScheme: "numeric"},
+			want:        "numeric"},
+		"semver if nothing is set": {
+			want: "semver"},
+		"semantic_versioning=true is semver": {
+			root: Options{SemanticVersioning: boolPtr(true)},
+			dflt: Options{VersionScheme: "calver"},
+			want: "semver"},
+		"semantic_versioning=false is none": {
+			root:        Options{SemanticVersioning: boolPtr(false)},
+			hardDefault: Options{VersionScheme: "semver"},
+			want:        "none"},
+		"version_scheme overrides semantic_versioning at the same level": {
+			root: Options{SemanticVersioning: boolPtr(false), VersionScheme: "calver"},
+			want: "calver"},
+		"semantic_versioning overrides a lower version_scheme": {
+			dflt:        Options{SemanticVersioning: boolPtr(false)},
+			hardDefault: Options{VersionScheme: "semver"},
+			want:        "none"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			options := tc.root
+			options.Defaults = &tc.dflt
+			options.HardDefaults = &tc.hardDefault
+
+			// WHEN GetVersionScheme is called
+			got := options.GetVersionScheme()
+
+			// THEN the function returns the correct result
+			if got != tc.want {
+				t.Errorf("want: %q\ngot:  %q",
+					tc.want, got)
+			}
+			// AND GetSemanticVersioning agrees
+			if gotSemVer := options.GetSemanticVersioning(); gotSemVer != (tc.want == "semver") {
+				t.Errorf("GetSemanticVersioning - want: %t\ngot:  %t",
+					tc.want == "semver", gotSemVer)
+			}
+		})
+	}
+}
+
 func TestOptions_GetIntervalPointer(t *testing.T) {
 	// GIVEN options
 	tests := map[string]struct {
@@ -251,12 +314,18 @@ func TestOptions_Print(t *testing.T) {
 				SemanticVersioning: boolPtr(false)},
 			lines: 2,
 		},
+		"only version_scheme": {
+			options: Options{
+				VersionScheme: "calver"},
+			lines: 2,
+		},
 		"all options defined": {
 			options: Options{
 				Active:             boolPtr(false),
 				Interval:           "10s",
-				SemanticVersioning: boolPtr(false)},
-			lines: 4,
+				SemanticVersioning: boolPtr(false),
+				VersionScheme:      "pep440"},
+			lines: 5,
 		},
 	}
 
@@ -312,6 +381,21 @@ func TestOptions_CheckValues(t *testing.T) {
 				Interval:           "10",
 				SemanticVersioning: boolPtr(false)},
 		},
+		"valid version_scheme": {
+			errRegex: `^$`,
+			options: Options{
+				VersionScheme: "pep440"},
+		},
+		"version_scheme of none": {
+			errRegex: `^$`,
+			options: Options{
+				VersionScheme: "none"},
+		},
+		"invalid version_scheme": {
+			errRegex: `version_scheme: "foo" <invalid> \(expected one of \[semver, calver, pep440, numeric, debian, rpm, apk, none\]\)`,
+			options: Options{
+				VersionScheme: "foo"},
+		},
 	}
 
 	for name, tc := range tests {
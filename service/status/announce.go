@@ -34,7 +34,8 @@ func (s *Status) AnnounceFirstVersion() {
 			WebURL: s.GetWebURL(),
 			Status: &api_type.Status{
 				LatestVersion:          s.GetLatestVersion(),
-				LatestVersionTimestamp: s.GetLatestVersionTimestamp()}}})
+				LatestVersionTimestamp: s.GetLatestVersionTimestamp(),
+				LatestVersionRelease:   s.GetLatestVersionReleaseSummary()}}})
 
 	s.SendAnnounce(&payloadData)
 }
@@ -51,7 +52,9 @@ func (s *Status) AnnounceQuery() {
 		ServiceData: &api_type.ServiceSummary{
 			ID: *s.ServiceID,
 			Status: &api_type.Status{
-				LastQueried: s.GetLastQueried()}}})
+				LastQueried:             s.GetLastQueried(),
+				PendingVersion:          s.GetPendingVersion(),
+				PendingVersionTimestamp: s.GetPendingVersionTimestamp()}}})
 
 	s.SendAnnounce(&payloadData)
 }
@@ -71,7 +74,8 @@ func (s *Status) AnnounceQueryNewVersion() {
 			WebURL: s.GetWebURL(),
 			Status: &api_type.Status{
 				LatestVersion:          s.GetLatestVersion(),
-				LatestVersionTimestamp: s.GetLatestVersionTimestamp()}}})
+				LatestVersionTimestamp: s.GetLatestVersionTimestamp(),
+				LatestVersionRelease:   s.GetLatestVersionReleaseSummary()}}})
 
 	s.SendAnnounce(&payloadData)
 }
@@ -16,6 +16,7 @@ package svcstatus
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -23,21 +24,29 @@ import (
 
 	dbtype "github.com/release-argus/Argus/db/types"
 	"github.com/release-argus/Argus/util"
+	api_type "github.com/release-argus/Argus/web/api/types"
 )
 
 // Status is the current state of the Service element (version and regex misses).
 type Status struct {
-	approvedVersion          string       // The version that's been approved
-	deployedVersion          string       // Track the deployed version of the service from the last successful WebHook.
-	deployedVersionTimestamp string       // UTC timestamp of DeployedVersion being changed.
-	latestVersion            string       // Latest version found from query().
-	latestVersionTimestamp   string       // UTC timestamp of LatestVersion being changed.
-	lastQueried              string       // UTC timestamp that version was last queried/checked.
-	regexMissesContent       uint         // Counter for the number of regex misses on URL content.
-	regexMissesVersion       uint         // Counter for the number of regex misses on version.
-	Fails                    Fails        // Track the Notify/WebHook fails
-	deleting                 bool         // Flag to indicate the service is being deleted
-	mutex                    sync.RWMutex // Lock for the Status
+	approvedVersion          string           // The version that's been approved
+	deployedVersion          string           // Track the deployed version of the service from the last successful WebHook.
+	deployedVersionTimestamp string           // UTC timestamp of DeployedVersion being changed.
+	latestVersion            string           // Latest version found from query().
+	latestVersionTimestamp   string           // UTC timestamp of LatestVersion being changed.
+	latestVersionDigest      string           // Digest of LatestVersion (Docker tag digest/Helm lookups).
+	latestVersionCreated     string           // Created date of the image/chart of LatestVersion (Docker tag digest/Helm lookups).
+	latestVersionURLs        []string         // Download URLs of LatestVersion (Helm lookups).
+	latestVersionMessage     string           // Commit message of LatestVersion (GitHub branch lookups).
+	latestVersionRelease     util.ReleaseInfo // Release metadata of LatestVersion (GitHub lookups).
+	pendingVersion           string           // Version found that's waiting for require.min_age before becoming LatestVersion.
+	pendingVersionTimestamp  string           // UTC timestamp that PendingVersion was published (or first seen).
+	lastQueried              string           // UTC timestamp that version was last queried/checked.
+	regexMissesContent       uint             // Counter for the number of regex misses on URL content.
+	regexMissesVersion       uint             // Counter for the number of regex misses on version.
+	Fails                    Fails            // Track the Notify/WebHook fails
+	deleting                 bool             // Flag to indicate the service is being deleted
+	mutex                    sync.RWMutex     // Lock for the Status
 
 	// Announces
 	AnnounceChannel *chan []byte         `yaml:"-" json:"-"` // Announce to the WebSocket
@@ -56,6 +65,13 @@ func (s *Status) String() string {
 		{Name: "deployed_version_timestamp", Value: s.deployedVersionTimestamp},
 		{Name: "latest_version", Value: s.latestVersion},
 		{Name: "latest_version_timestamp", Value: s.latestVersionTimestamp},
+		{Name: "latest_version_digest", Value: s.latestVersionDigest},
+		{Name: "latest_version_created", Value: s.latestVersionCreated},
+		{Name: "latest_version_urls", Value: strings.Join(s.latestVersionURLs, ",")},
+		{Name: "latest_version_message", Value: s.latestVersionMessage},
+		{Name: "latest_version_release", Value: releaseJSON(s.latestVersionRelease)},
+		{Name: "pending_version", Value: s.pendingVersion},
+		{Name: "pending_version_timestamp", Value: s.pendingVersionTimestamp},
 		{Name: "last_queried", Value: s.lastQueried},
 		{Name: "regex_misses_content", Value: s.regexMissesContent},
 		{Name: "regex_misses_version", Value: s.regexMissesVersion},
@@ -239,6 +255,209 @@ func (s *Status) SetLatestVersionTimestamp(timestamp string) {
 	s.mutex.Unlock()
 }
 
+// GetLatestVersionDigest returns the manifest digest of the latest version.
+func (s *Status) GetLatestVersionDigest() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.latestVersionDigest
+}
+
+// GetLatestVersionCreated returns the created date of the image of the latest version.
+func (s *Status) GetLatestVersionCreated() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.latestVersionCreated
+}
+
+// SetLatestVersionDigest will set LatestVersionDigest to `digest` and LatestVersionCreated to `created`
+// (writing them to the database if `writeToDB` and they've changed).
+func (s *Status) SetLatestVersionDigest(digest string, created string, writeToDB bool) {
+	s.mutex.Lock()
+	changed := s.latestVersionDigest != digest || s.latestVersionCreated != created
+	s.latestVersionDigest = digest
+	s.latestVersionCreated = created
+	s.mutex.Unlock()
+
+	if writeToDB && changed {
+		message := dbtype.Message{
+			ServiceID: *s.ServiceID,
+			Cells: []dbtype.Cell{
+				{Column: "latest_version_digest", Value: digest},
+				{Column: "latest_version_created", Value: created}}}
+		s.SendDatabase(&message)
+	}
+}
+
+// GetLatestVersionURLs returns the download URLs of the latest version.
+func (s *Status) GetLatestVersionURLs() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.latestVersionURLs == nil {
+		return nil
+	}
+	urls := make([]string, len(s.latestVersionURLs))
+	copy(urls, s.latestVersionURLs)
+	return urls
+}
+
+// SetLatestVersionURLs will set LatestVersionURLs to `urls`
+// (writing them to the database if `writeToDB` and they've changed).
+func (s *Status) SetLatestVersionURLs(urls []string, writeToDB bool) {
+	s.mutex.Lock()
+	changed := strings.Join(s.latestVersionURLs, "\n") != strings.Join(urls, "\n")
+	s.latestVersionURLs = urls
+	s.mutex.Unlock()
+
+	if writeToDB && changed {
+		message := dbtype.Message{
+			ServiceID: *s.ServiceID,
+			Cells: []dbtype.Cell{
+				{Column: "latest_version_urls", Value: urlsJSON(urls)}}}
+		s.SendDatabase(&message)
+	}
+}
+
+// SetLatestVersionURLsJSON will set LatestVersionURLs to the URLs in `urlsJSON`
+// (from the database).
+func (s *Status) SetLatestVersionURLsJSON(urlsJSON string) (err error) {
+	var urls []string
+	if urlsJSON != "" {
+		if err = json.Unmarshal([]byte(urlsJSON), &urls); err != nil {
+			return fmt.Errorf("latest_version_urls: %w", err)
+		}
+	}
+	s.SetLatestVersionURLs(urls, false)
+	return
+}
+
+// urlsJSON returns the JSON of `urls`, or an empty string if there are none.
+func urlsJSON(urls []string) string {
+	if len(urls) == 0 {
+		return ""
+	}
+	jsonBytes, _ := json.Marshal(urls)
+	return string(jsonBytes)
+}
+
+// GetLatestVersionMessage returns the commit message of the latest version.
+func (s *Status) GetLatestVersionMessage() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.latestVersionMessage
+}
+
+// SetLatestVersionMessage will set LatestVersionMessage to `commitMessage`
+// (writing it to the database if `writeToDB` and it's changed).
+func (s *Status) SetLatestVersionMessage(commitMessage string, writeToDB bool) {
+	s.mutex.Lock()
+	changed := s.latestVersionMessage != commitMessage
+	s.latestVersionMessage = commitMessage
+	s.mutex.Unlock()
+
+	if writeToDB && changed {
+		message := dbtype.Message{
+			ServiceID: *s.ServiceID,
+			Cells: []dbtype.Cell{
+				{Column: "latest_version_message", Value: commitMessage}}}
+		s.SendDatabase(&message)
+	}
+}
+
+// GetLatestVersionRelease returns the release metadata of the latest version.
+func (s *Status) GetLatestVersionRelease() util.ReleaseInfo {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.latestVersionRelease
+}
+
+// SetLatestVersionRelease will set LatestVersionRelease to `release`
+// (writing it to the database if `writeToDB` and it's changed).
+func (s *Status) SetLatestVersionRelease(release util.ReleaseInfo, writeToDB bool) {
+	s.mutex.Lock()
+	changed := s.latestVersionRelease != release
+	s.latestVersionRelease = release
+	s.mutex.Unlock()
+
+	if writeToDB && changed {
+		message := dbtype.Message{
+			ServiceID: *s.ServiceID,
+			Cells: []dbtype.Cell{
+				{Column: "latest_version_release", Value: releaseJSON(release)}}}
+		s.SendDatabase(&message)
+	}
+}
+
+// SetLatestVersionReleaseJSON will set LatestVersionRelease to the release metadata in `releaseJSON`
+// (from the database).
+func (s *Status) SetLatestVersionReleaseJSON(releaseJSON string) (err error) {
+	var release util.ReleaseInfo
+	if releaseJSON != "" {
+		if err = json.Unmarshal([]byte(releaseJSON), &release); err != nil {
+			return fmt.Errorf("latest_version_release: %w", err)
+		}
+	}
+	s.SetLatestVersionRelease(release, false)
+	return
+}
+
+// GetLatestVersionReleaseSummary returns the release metadata of the latest version for the API
+// (nil if there's none).
+func (s *Status) GetLatestVersionReleaseSummary() *api_type.Release {
+	release := s.GetLatestVersionRelease()
+	if release == (util.ReleaseInfo{}) {
+		return nil
+	}
+	return &api_type.Release{
+		Name:        release.Name,
+		Body:        release.Body,
+		HTMLURL:     release.HTMLURL,
+		PublishedAt: release.PublishedAt,
+		Draft:       release.Draft,
+		Author:      release.Author}
+}
+
+// releaseJSON returns the JSON of `release`, or an empty string if it has no metadata.
+func releaseJSON(release util.ReleaseInfo) string {
+	if release == (util.ReleaseInfo{}) {
+		return ""
+	}
+	jsonBytes, _ := json.Marshal(release)
+	return string(jsonBytes)
+}
+
+// GetPendingVersion returns the version that's waiting to become the latest version.
+func (s *Status) GetPendingVersion() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.pendingVersion
+}
+
+// GetPendingVersionTimestamp returns the timestamp that the pending version was published (or first seen).
+func (s *Status) GetPendingVersionTimestamp() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.pendingVersionTimestamp
+}
+
+// SetPendingVersion will set PendingVersion to `version` and PendingVersionTimestamp to `timestamp`
+// (writing them to the database if `writeToDB` and they've changed).
+func (s *Status) SetPendingVersion(version string, timestamp string, writeToDB bool) {
+	s.mutex.Lock()
+	changed := s.pendingVersion != version || s.pendingVersionTimestamp != timestamp
+	s.pendingVersion = version
+	s.pendingVersionTimestamp = timestamp
+	s.mutex.Unlock()
+
+	if writeToDB && changed {
+		message := dbtype.Message{
+			ServiceID: *s.ServiceID,
+			Cells: []dbtype.Cell{
+				{Column: "pending_version", Value: version},
+				{Column: "pending_version_timestamp", Value: timestamp}}}
+		s.SendDatabase(&message)
+	}
+}
+
 // RegexMissContent will increment the count of RegEx misses on content.
 func (s *Status) RegexMissContent() {
 	s.mutex.Lock()
@@ -348,7 +567,7 @@ func (s *Status) GetWebURL() string {
 
 	return util.TemplateString(
 		*s.WebURL,
-		util.ServiceInfo{LatestVersion: s.GetLatestVersion()})
+		s.GetServiceInfo())
 }
 
 // Print will print the Status.
@@ -365,4 +584,34 @@ func (s *Status) Print(prefix string) {
 		fmt.Sprintf("%slatest_version: %s", prefix, s.latestVersion))
 	util.PrintlnIfNotDefault(s.latestVersionTimestamp,
 		fmt.Sprintf("%slatest_version_timestamp: %q", prefix, s.latestVersionTimestamp))
+	util.PrintlnIfNotDefault(s.latestVersionDigest,
+		fmt.Sprintf("%slatest_version_digest: %s", prefix, s.latestVersionDigest))
+	util.PrintlnIfNotDefault(s.latestVersionCreated,
+		fmt.Sprintf("%slatest_version_created: %q", prefix, s.latestVersionCreated))
+	if len(s.latestVersionURLs) != 0 {
+		fmt.Printf("%slatest_version_urls: [%s]\n", prefix, strings.Join(s.latestVersionURLs, ", "))
+	}
+	util.PrintlnIfNotDefault(s.latestVersionMessage,
+		fmt.Sprintf("%slatest_version_message: %q", prefix, s.latestVersionMessage))
+	if release := releaseJSON(s.latestVersionRelease); release != "" {
+		fmt.Printf("%slatest_version_release: %s\n", prefix, release)
+	}
+	util.PrintlnIfNotDefault(s.pendingVersion,
+		fmt.Sprintf("%spending_version: %s", prefix, s.pendingVersion))
+	util.PrintlnIfNotDefault(s.pendingVersionTimestamp,
+		fmt.Sprintf("%spending_version_timestamp: %q", prefix, s.pendingVersionTimestamp))
+}
+
+// GetServiceInfo returns the ServiceInfo of the latest version for templating.
+func (s *Status) GetServiceInfo() util.ServiceInfo {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return util.ServiceInfo{
+		ID:            util.DefaultIfNil(s.ServiceID),
+		LatestVersion: s.latestVersion,
+		Digest:        s.latestVersionDigest,
+		Created:       s.latestVersionCreated,
+		URLs:          s.latestVersionURLs,
+		Message:       s.latestVersionMessage,
+		Release:       s.latestVersionRelease}
 }
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	dbtype "github.com/release-argus/Argus/db/types"
+	"github.com/release-argus/Argus/util"
 )
 
 func TestStatus_Init(t *testing.T) {
@@ -354,6 +355,341 @@ func TestStatus_LatestVersion(t *testing.T) {
 	}
 }
 
+func TestStatus_LatestVersionDigest(t *testing.T) {
+	// GIVEN a Status tracking a digest
+	status := Status{ServiceID: stringPtr("test")}
+	digest := "sha256:0123456789abcdef"
+	created := "2023-01-02T03:04:05Z"
+	status.SetLatestVersion(digest, false)
+
+	// WHEN SetLatestVersionDigest is called on it
+	status.SetLatestVersionDigest(digest, created, false)
+
+	// THEN the digest and created date are set
+	if got := status.GetLatestVersionDigest(); got != digest {
+		t.Errorf("want LatestVersionDigest %q, got %q",
+			digest, got)
+	}
+	if got := status.GetLatestVersionCreated(); got != created {
+		t.Errorf("want LatestVersionCreated %q, got %q",
+			created, got)
+	}
+	// WHEN SetLatestVersionURLs is called on it
+	urls := []string{"https://example.com/chart-1.2.3.tgz"}
+	status.SetLatestVersionURLs(urls, false)
+
+	// THEN the URLs are set
+	if got := status.GetLatestVersionURLs(); len(got) != 1 || got[0] != urls[0] {
+		t.Errorf("want LatestVersionURLs %v, got %v",
+			urls, got)
+	}
+	// WHEN SetLatestVersionMessage is called on it
+	message := "fix: something"
+	status.SetLatestVersionMessage(message, false)
+
+	// THEN the message is set
+	if got := status.GetLatestVersionMessage(); got != message {
+		t.Errorf("want LatestVersionMessage %q, got %q",
+			message, got)
+	}
+	// AND they're in the ServiceInfo for templating
+	serviceInfo := status.GetServiceInfo()
+	if serviceInfo.ID != "test" || serviceInfo.LatestVersion != digest ||
+		serviceInfo.Digest != digest || serviceInfo.Created != created || len(serviceInfo.URLs) != 1 ||
+		serviceInfo.Message != message {
+		t.Errorf("ServiceInfo not as expected, got %+v",
+			serviceInfo)
+	}
+}
+
+func TestStatus_SetLatestVersionDigestWriteToDB(t *testing.T) {
+	// GIVEN a Status with a digest
+	digest := "sha256:0123456789abcdef"
+	created := "2023-01-02T03:04:05Z"
+	tests := map[string]struct {
+		previousDigest string
+		digest         string
+		writeToDB      bool
+		wantMessages   int
+	}{
+		"new digest is written to the db": {
+			digest:       digest,
+			writeToDB:    true,
+			wantMessages: 1},
+		"unchanged digest isn't written to the db": {
+			previousDigest: digest,
+			digest:         digest,
+			writeToDB:      true,
+			wantMessages:   0},
+		"not written to the db when not wanted": {
+			digest:       digest,
+			wantMessages: 0},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dbChannel := make(chan dbtype.Message, 4)
+			status := Status{
+				DatabaseChannel: &dbChannel,
+				ServiceID:       stringPtr("test")}
+			status.SetLatestVersionDigest(tc.previousDigest, created, false)
+
+			// WHEN SetLatestVersionDigest is called on it
+			status.SetLatestVersionDigest(tc.digest, created, tc.writeToDB)
+
+			// THEN it's only written to the db when it changed
+			if got := len(dbChannel); got != tc.wantMessages {
+				t.Fatalf("want %d db messages, got %d",
+					tc.wantMessages, got)
+			}
+			if tc.wantMessages != 0 {
+				msg := <-dbChannel
+				if len(msg.Cells) != 2 ||
+					msg.Cells[0].Column != "latest_version_digest" || msg.Cells[0].Value != tc.digest ||
+					msg.Cells[1].Column != "latest_version_created" || msg.Cells[1].Value != created {
+					t.Errorf("want latest_version_digest=%q, latest_version_created=%q, got %+v",
+						tc.digest, created, msg.Cells)
+				}
+			}
+		})
+	}
+}
+func TestStatus_SetLatestVersionURLsAndMessageWriteToDB(t *testing.T) {
+	// GIVEN a Status with urls and a commit message
+	urls := []string{"https://example.com/chart-1.2.3.tgz", "https://example.com/chart-1.2.3.tgz.prov"}
+	tests := map[string]struct {
+		previousURLs, urls       []string
+		previousMessage, message string
+		writeToDB                bool
+		wantCells                []dbtype.Cell
+	}{
+		"new urls and message are written to the db": {
+			urls:      urls,
+			message:   "fix: something",
+			writeToDB: true,
+			wantCells: []dbtype.Cell{
+				{Column: "latest_version_urls", Value: `["https://example.com/chart-1.2.3.tgz","https://example.com/chart-1.2.3.tgz.prov"]`},
+				{Column: "latest_version_message", Value: "fix: something"}}},
+		"unchanged urls and message aren't written to the db": {
+			previousURLs:    urls,
+			urls:            urls,
+			previousMessage: "fix: something",
+			message:         "fix: something",
+			writeToDB:       true},
+		"cleared urls and message are written to the db": {
+			previousURLs:    urls,
+			previousMessage: "fix: something",
+			writeToDB:       true,
+			wantCells: []dbtype.Cell{
+				{Column: "latest_version_urls", Value: ""},
+				{Column: "latest_version_message", Value: ""}}},
+		"not written to the db when not wanted": {
+			urls:    urls,
+			message: "fix: something"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dbChannel := make(chan dbtype.Message, 4)
+			status := Status{
+				DatabaseChannel: &dbChannel,
+				ServiceID:       stringPtr("test")}
+			status.SetLatestVersionURLs(tc.previousURLs, false)
+			status.SetLatestVersionMessage(tc.previousMessage, false)
+
+			// WHEN SetLatestVersionURLs and SetLatestVersionMessage are called on it
+			status.SetLatestVersionURLs(tc.urls, tc.writeToDB)
+			status.SetLatestVersionMessage(tc.message, tc.writeToDB)
+
+			// THEN they're only written to the db when they changed
+			if got := len(dbChannel); got != len(tc.wantCells) {
+				t.Fatalf("want %d db messages, got %d",
+					len(tc.wantCells), got)
+			}
+			for _, want := range tc.wantCells {
+				msg := <-dbChannel
+				if len(msg.Cells) != 1 || msg.Cells[0] != want {
+					t.Errorf("want %+v, got %+v",
+						want, msg.Cells)
+				}
+				// AND the urls can be read back from the db value
+				if want.Column == "latest_version_urls" {
+					other := Status{}
+					if err := other.SetLatestVersionURLsJSON(want.Value); err != nil {
+						t.Fatalf("unexpected err: %v", err)
+					}
+					if got := other.GetLatestVersionURLs(); strings.Join(got, ",") != strings.Join(tc.urls, ",") {
+						t.Errorf("want urls %q from the db, got %q",
+							tc.urls, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestStatus_LatestVersionRelease(t *testing.T) {
+	// GIVEN a Status
+	release := util.ReleaseInfo{
+		Name:        "v1.2.3",
+		Body:        "## Changelog\n- fix: something",
+		HTMLURL:     "https://github.com/owner/repo/releases/tag/v1.2.3",
+		PublishedAt: "2023-01-02T03:04:05Z",
+		Author:      "someone"}
+	tests := map[string]struct {
+		previous     util.ReleaseInfo
+		release      util.ReleaseInfo
+		writeToDB    bool
+		wantMessages int
+		wantDBValue  string
+	}{
+		"new release metadata is written to the db": {
+			release:      release,
+			writeToDB:    true,
+			wantMessages: 1,
+			wantDBValue:  `{"name":"v1.2.3","body":"## Changelog\n- fix: something","html_url":"https://github.com/owner/repo/releases/tag/v1.2.3","published_at":"2023-01-02T03:04:05Z","author":"someone"}`},
+		"unchanged release metadata isn't written to the db": {
+			previous:     release,
+			release:      release,
+			writeToDB:    true,
+			wantMessages: 0},
+		"cleared release metadata is written to the db": {
+			previous:     release,
+			writeToDB:    true,
+			wantMessages: 1,
+			wantDBValue:  ""},
+		"not written to the db when not wanted": {
+			release:      release,
+			wantMessages: 0},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dbChannel := make(chan dbtype.Message, 4)
+			status := Status{
+				DatabaseChannel: &dbChannel,
+				ServiceID:       stringPtr("test")}
+			status.SetLatestVersionRelease(tc.previous, false)
+
+			// WHEN SetLatestVersionRelease is called on it
+			status.SetLatestVersionRelease(tc.release, tc.writeToDB)
+
+			// THEN the release metadata is set
+			if got := status.GetLatestVersionRelease(); got != tc.release {
+				t.Errorf("want LatestVersionRelease %+v, got %+v",
+					tc.release, got)
+			}
+			// AND it's in the ServiceInfo for templating
+			if got := status.GetServiceInfo().Release; got != tc.release {
+				t.Errorf("want ServiceInfo.Release %+v, got %+v",
+					tc.release, got)
+			}
+			// AND it's only written to the db when it changed
+			if got := len(dbChannel); got != tc.wantMessages {
+				t.Fatalf("want %d db messages, got %d",
+					tc.wantMessages, got)
+			}
+			if tc.wantMessages != 0 {
+				msg := <-dbChannel
+				if got := msg.Cells[0]; got.Column != "latest_version_release" || got.Value != tc.wantDBValue {
+					t.Errorf("want latest_version_release=%q, got %s=%q",
+						tc.wantDBValue, got.Column, got.Value)
+				}
+				// AND it can be read back from the db value
+				other := Status{}
+				if err := other.SetLatestVersionReleaseJSON(msg.Cells[0].Value); err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				if got := other.GetLatestVersionRelease(); got != tc.release {
+					t.Errorf("want %+v from the db value, got %+v",
+						tc.release, got)
+				}
+			}
+		})
+	}
+}
+
+func TestStatus_PendingVersion(t *testing.T) {
+	// GIVEN a Status
+	tests := map[string]struct {
+		previousVersion, previousTimestamp string
+		version, timestamp                 string
+		writeToDB                          bool
+		wantMessages                       int
+	}{
+		"new pending version is written to the db": {
+			version:      "1.2.3",
+			timestamp:    "2023-01-02T03:04:05Z",
+			writeToDB:    true,
+			wantMessages: 1},
+		"unchanged pending version isn't written to the db": {
+			previousVersion:   "1.2.3",
+			previousTimestamp: "2023-01-02T03:04:05Z",
+			version:           "1.2.3",
+			timestamp:         "2023-01-02T03:04:05Z",
+			writeToDB:         true,
+			wantMessages:      0},
+		"cleared pending version is written to the db": {
+			previousVersion:   "1.2.3",
+			previousTimestamp: "2023-01-02T03:04:05Z",
+			writeToDB:         true,
+			wantMessages:      1},
+		"not written to the db when not wanted": {
+			version:      "1.2.3",
+			timestamp:    "2023-01-02T03:04:05Z",
+			wantMessages: 0},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dbChannel := make(chan dbtype.Message, 4)
+			status := Status{
+				DatabaseChannel: &dbChannel,
+				ServiceID:       stringPtr("test")}
+			status.SetPendingVersion(tc.previousVersion, tc.previousTimestamp, false)
+
+			// WHEN SetPendingVersion is called on it
+			status.SetPendingVersion(tc.version, tc.timestamp, tc.writeToDB)
+
+			// THEN the pending version and its timestamp are set
+			if got := status.GetPendingVersion(); got != tc.version {
+				t.Errorf("want PendingVersion %q, got %q",
+					tc.version, got)
+			}
+			if got := status.GetPendingVersionTimestamp(); got != tc.timestamp {
+				t.Errorf("want PendingVersionTimestamp %q, got %q",
+					tc.timestamp, got)
+			}
+			// AND they're only written to the db when they changed
+			if got := len(dbChannel); got != tc.wantMessages {
+				t.Fatalf("want %d db messages, got %d",
+					tc.wantMessages, got)
+			}
+			if tc.wantMessages != 0 {
+				msg := <-dbChannel
+				if len(msg.Cells) != 2 ||
+					msg.Cells[0].Column != "pending_version" || msg.Cells[0].Value != tc.version ||
+					msg.Cells[1].Column != "pending_version_timestamp" || msg.Cells[1].Value != tc.timestamp {
+					t.Errorf("want pending_version=%q, pending_version_timestamp=%q, got %+v",
+						tc.version, tc.timestamp, msg.Cells)
+				}
+			}
+		})
+	}
+}
+
 func TestStatus_RegexMissesContent(t *testing.T) {
 	// GIVEN a Status
 	status := Status{}
@@ -682,6 +1018,7 @@ func TestStatus_PrintFull(t *testing.T) {
 	status.SetDeployedVersionTimestamp("2022-01-01T01:01:01Z")
 	status.SetLatestVersion("1.2.4", false)
 	status.SetLatestVersionTimestamp("2022-01-01T01:01:01Z")
+	status.SetPendingVersion("1.2.5", "2022-01-02T01:01:01Z", false)
 	stdout := os.Stdout
 	r, w, _ := os.Pipe()
 	os.Stdout = w
@@ -693,7 +1030,7 @@ func TestStatus_PrintFull(t *testing.T) {
 	w.Close()
 	out, _ := io.ReadAll(r)
 	os.Stdout = stdout
-	want := 5
+	want := 7
 	got := strings.Count(string(out), "\n")
 	if got != want {
 		t.Errorf("Print should have given %d lines, but gave %d\n%s",
@@ -173,5 +173,12 @@ func (s *Service) Summary() *apitype.ServiceSummary {
 			DeployedVersionTimestamp: s.Status.GetDeployedVersionTimestamp(),
 			LatestVersion:            s.Status.GetLatestVersion(),
 			LatestVersionTimestamp:   s.Status.GetLatestVersionTimestamp(),
+			LatestVersionDigest:      s.Status.GetLatestVersionDigest(),
+			LatestVersionCreated:     s.Status.GetLatestVersionCreated(),
+			LatestVersionURLs:        s.Status.GetLatestVersionURLs(),
+			LatestVersionMessage:     s.Status.GetLatestVersionMessage(),
+			LatestVersionRelease:     s.Status.GetLatestVersionReleaseSummary(),
+			PendingVersion:           s.Status.GetPendingVersion(),
+			PendingVersionTimestamp:  s.Status.GetPendingVersionTimestamp(),
 			LastQueried:              s.Status.GetLastQueried()}}
 }
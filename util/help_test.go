@@ -26,5 +26,15 @@ func testServiceInfo() ServiceInfo {
 		URL:           "example.com",
 		WebURL:        "other.com",
 		LatestVersion: "NEW",
+		Digest:        "sha256:abc",
+		Created:       "2023-01-02T03:04:05Z",
+		URLs:          []string{"https://example.com/chart-1.2.3.tgz"},
+		Message:       "fix: something",
+		Release: ReleaseInfo{
+			Name:        "v1.2.3 - Something",
+			Body:        "## Changelog\n- fix: something",
+			HTMLURL:     "https://github.com/owner/repo/releases/tag/v1.2.3",
+			PublishedAt: "2023-01-02T03:04:05Z",
+			Author:      "someone"},
 	}
 }
@@ -31,3 +31,17 @@ func RegexCheckWithParams(re string, text string, version string) bool {
 	re = TemplateString(re, ServiceInfo{LatestVersion: version})
 	return RegexCheck(re, text)
 }
+
+// RegexHasNamedGroups returns whether `re` has any named capture groups, e.g. (?P<major>[0-9]+).
+func RegexHasNamedGroups(re string) bool {
+	regex, err := regexp.Compile(re)
+	if err != nil {
+		return false
+	}
+	for _, name := range regex.SubexpNames() {
+		if name != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -82,3 +82,33 @@ func TestRegexCheckWithParams(t *testing.T) {
 		})
 	}
 }
+
+func TestRegexHasNamedGroups(t *testing.T) {
+	// GIVEN a variety of regexes
+	tests := map[string]struct {
+		regex string
+		want  bool
+	}{
+		"no groups":         {regex: `[0-9]+`, want: false},
+		"unnamed group":     {regex: `v([0-9]+)`, want: false},
+		"named group":       {regex: `v(?P<major>[0-9]+)`, want: true},
+		"invalid regex":     {regex: `v(?P<major>[0-9]+`, want: false},
+		"named and unnamed": {regex: `([a-z]+)-(?P<version>[0-9.]+)`, want: true},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN RegexHasNamedGroups is called
+			got := RegexHasNamedGroups(tc.regex)
+
+			// THEN the result is whether there are named groups
+			if got != tc.want {
+				t.Errorf("want %t, got %t",
+					tc.want, got)
+			}
+		})
+	}
+}
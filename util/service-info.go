@@ -20,4 +20,19 @@ type ServiceInfo struct {
 	URL           string
 	WebURL        string
 	LatestVersion string
+	Digest        string      // Digest of the LatestVersion (Docker tag digest/Helm lookups)
+	Created       string      // Created date of the image/chart of the LatestVersion (Docker tag digest/Helm lookups)
+	URLs          []string    // Download URLs of the LatestVersion (Helm lookups)
+	Message       string      // Commit message of the LatestVersion (GitHub branch lookups)
+	Release       ReleaseInfo // Release metadata of the LatestVersion (GitHub lookups)
+}
+
+// ReleaseInfo is the metadata of a release.
+type ReleaseInfo struct {
+	Name        string `json:"name,omitempty"`         // Name/title of the release
+	Body        string `json:"body,omitempty"`         // Release notes
+	HTMLURL     string `json:"html_url,omitempty"`     // Web page of the release
+	PublishedAt string `json:"published_at,omitempty"` // Date the release was published
+	Draft       bool   `json:"draft,omitempty"`        // Whether the release is a draft
+	Author      string `json:"author,omitempty"`       // Username of the author of the release
 }
@@ -15,6 +15,7 @@
 package util
 
 import (
+	"regexp"
 	"strings"
 	"sync"
 
@@ -51,13 +52,49 @@ func TemplateString(template string, context ServiceInfo) (result string) {
 		"service_id":  context.ID,
 		"service_url": context.URL,
 		"web_url":     context.WebURL,
-		"version":     context.LatestVersion})
+		"version":     context.LatestVersion,
+		"digest":      context.Digest,
+		"created":     context.Created,
+		"urls":        context.URLs,
+		"message":     context.Message,
+		"short_sha":   shortSHA(context.Digest),
+		"release": map[string]interface{}{
+			"name":         context.Release.Name,
+			"body":         context.Release.Body,
+			"html_url":     context.Release.HTMLURL,
+			"published_at": context.Release.PublishedAt,
+			"draft":        context.Release.Draft,
+			"author":       context.Release.Author}})
 	if err != nil {
 		panic(err)
 	}
 	return
 }
 
+// TemplateRegexGroups with pongo2 and the named capture groups of `re` in `match`
+// (from re.FindStringSubmatch), e.g. "{{ major }}.{{ minor }}-{{ build }}".
+func TemplateRegexGroups(template string, re *regexp.Regexp, match []string) (string, error) {
+	context := pongo2.Context{}
+	for i, name := range re.SubexpNames() {
+		if name != "" && i < len(match) {
+			context[name] = match[i]
+		}
+	}
+
+	// pongo2 DATA RACE
+	pongoMutex.Lock()
+	defer pongoMutex.Unlock()
+
+	// Compile the template.
+	tpl, err := pongo2.FromString(template)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+
+	// Render the template.
+	return tpl.Execute(context) //nolint:wrapcheck
+}
+
 // CheckTemplate will compile
 //
 // true == pass
@@ -71,3 +108,11 @@ func CheckTemplate(template string) (pass bool) {
 	_, err := pongo2.FromString(template)
 	return err == nil
 }
+
+// shortSHA returns the abbreviated form of `digest` if it's a git commit SHA.
+func shortSHA(digest string) string {
+	if len(digest) != 40 || strings.Trim(digest, "0123456789abcdef") != "" {
+		return ""
+	}
+	return digest[:7]
+}
@@ -36,6 +36,18 @@ func TestTemplate_String(t *testing.T) {
 		"valid jinja template": {
 			tmpl: "-{% if 'a' == 'a' %}{{ service_id }}{% endif %}-{{ service_url }}-{{ web_url }}-{{ version }}",
 			want: "-something-example.com-other.com-NEW"},
+		"digest and created date": {
+			tmpl: "{{ digest }} built {{ created }}",
+			want: "sha256:abc built 2023-01-02T03:04:05Z"},
+		"urls": {
+			tmpl: "{{ urls.0 }}",
+			want: "https://example.com/chart-1.2.3.tgz"},
+		"message, and no short_sha for a non-commit digest": {
+			tmpl: "{{ short_sha }}{{ message }}",
+			want: "fix: something"},
+		"release metadata": {
+			tmpl: "{{ release.name }} by {{ release.author }} ({{ release.html_url }}){% if not release.draft %}\n{{ release.body }}{% endif %}",
+			want: "v1.2.3 - Something by someone (https://github.com/owner/repo/releases/tag/v1.2.3)\n## Changelog\n- fix: something"},
 		"invalid jinja template panic": {
 			tmpl:       "-{% 'a' == 'a' %}{{ service_id }}{% endif %}-{{ service_url }}-{{ web_url }}-{{ version }}",
 			panicRegex: stringPtr("Tag name must be an identifier")},
@@ -72,6 +84,43 @@ func TestTemplate_String(t *testing.T) {
 	}
 }
 
+func TestShortSHA(t *testing.T) {
+	// GIVEN a variety of digests
+	tests := map[string]struct {
+		digest string
+		want   string
+	}{
+		"commit SHA": {
+			digest: "0123456789abcdef0123456789abcdef01234567",
+			want:   "0123456"},
+		"image digest": {
+			digest: "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			want:   ""},
+		"not hex": {
+			digest: "0123456789abcdef0123456789abcdef0123456z",
+			want:   ""},
+		"empty": {
+			digest: "",
+			want:   ""},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN shortSHA is called
+			got := shortSHA(tc.digest)
+
+			// THEN the abbreviated commit is returned
+			if got != tc.want {
+				t.Errorf("want: %q\ngot:  %q",
+					tc.want, got)
+			}
+		})
+	}
+}
+
 func TestCheckTemplate(t *testing.T) {
 	// GIVEN a variety of string templates
 	tests := map[string]struct {
@@ -99,3 +148,59 @@ func TestCheckTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateRegexGroups(t *testing.T) {
+	// GIVEN a regex match and a template for its named groups
+	text := "Version 5.6 (Build 1234)"
+	tests := map[string]struct {
+		regex    string
+		template string
+		want     string
+		errRegex string
+	}{
+		"named groups": {
+			regex:    `Version (?P<major>[0-9]+)\.(?P<minor>[0-9]+) \(Build (?P<build>[0-9]+)\)`,
+			template: "{{ major }}.{{ minor }}-{{ build }}",
+			want:     "5.6-1234",
+			errRegex: "^$"},
+		"unnamed groups aren't in the context": {
+			regex:    `Version ([0-9]+)\.(?P<minor>[0-9]+)`,
+			template: "{{ major }}.{{ minor }}",
+			want:     ".6",
+			errRegex: "^$"},
+		"optional group that didn't match": {
+			regex:    `Version (?P<major>[0-9]+)\.(?P<minor>[0-9]+)(?:\.(?P<patch>[0-9]+))?`,
+			template: "{{ major }}.{{ minor }}.{{ patch|default:'0' }}",
+			want:     "5.6.0",
+			errRegex: "^$"},
+		"invalid template": {
+			regex:    `Version (?P<major>[0-9]+)`,
+			template: "{{ major }",
+			errRegex: ".+"},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			re := regexp.MustCompile(tc.regex)
+			match := re.FindStringSubmatch(text)
+
+			// WHEN TemplateRegexGroups is called on the match
+			got, err := TemplateRegexGroups(tc.template, re, match)
+
+			// THEN the template is rendered with the named groups
+			if got != tc.want {
+				t.Errorf("want %q, got %q",
+					tc.want, got)
+			}
+			// AND any err is expected
+			e := ErrorToString(err)
+			if !regexp.MustCompile(tc.errRegex).MatchString(e) {
+				t.Errorf("want match for %q\nnot: %q",
+					tc.errRegex, e)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vercmp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pep440Regex matches PEP 440 versions ([N!]N(.N)*[{a|b|rc}N][.postN][.devN][+local]),
+// including the alternative spellings that normalise to them (1.0-alpha.1, 1.0.r1, v1.0, ...).
+var pep440Regex = regexp.MustCompile(`(?i)^v?` +
+	`(?:([0-9]+)!)?` + // epoch
+	`([0-9]+(?:\.[0-9]+)*)` + // release
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?([0-9]+)?)?` + // pre-release
+	`(?:-([0-9]+)|[-_.]?(post|rev|r)[-_.]?([0-9]+)?)?` + // post-release
+	`(?:[-_.]?(dev)[-_.]?([0-9]+)?)?` + // dev-release
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`) // local
+
+// pep440Version is a parsed PEP 440 version.
+type pep440Version struct {
+	epoch    string
+	release  []string
+	hasPre   bool
+	prePhase int // a=0, b=1, rc=2
+	pre      string
+	hasPost  bool
+	post     string
+	hasDev   bool
+	dev      string
+	local    []string
+}
+
+// pep440PrePhases are the order of the pre-release phases (by their normalised spellings).
+var pep440PrePhases = map[string]int{
+	"a": 0, "alpha": 0,
+	"b": 1, "beta": 1,
+	"c": 2, "rc": 2, "pre": 2, "preview": 2}
+
+// parsePEP440 parses `version` as a PEP 440 version, returning false if it isn't one.
+func parsePEP440(version string) (pep440Version, bool) {
+	match := pep440Regex.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return pep440Version{}, false
+	}
+
+	// Implicit numbers are 0 (1.0a = 1.0a0).
+	zeroIfEmpty := func(number string) string {
+		if number == "" {
+			return "0"
+		}
+		return number
+	}
+	parsed := pep440Version{
+		epoch:   zeroIfEmpty(match[1]),
+		release: strings.Split(match[2], ".")}
+	if match[3] != "" {
+		parsed.hasPre = true
+		parsed.prePhase = pep440PrePhases[strings.ToLower(match[3])]
+		parsed.pre = zeroIfEmpty(match[4])
+	}
+	if match[5] != "" || match[6] != "" {
+		parsed.hasPost = true
+		parsed.post = zeroIfEmpty(match[5] + match[7])
+	}
+	if match[8] != "" {
+		parsed.hasDev = true
+		parsed.dev = zeroIfEmpty(match[9])
+	}
+	if match[10] != "" {
+		parsed.local = strings.FieldsFunc(strings.ToLower(match[10]), func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+	return parsed, true
+}
+
+// ValidPEP440 returns whether `version` is a PEP 440 version (Python packages, e.g. 1.2.0rc1).
+func ValidPEP440(version string) bool {
+	_, valid := parsePEP440(version)
+	return valid
+}
+
+// PEP440 compares PEP 440 versions, returning -1, 0 or 1 if `a` is less than, equal to or greater than `b`.
+//
+// dev < pre (a < b < rc) < final < post, e.g. 1.0.dev1 < 1.0a1 < 1.0rc1 < 1.0 < 1.0.post1.
+// Versions that aren't PEP 440 sort before those that are.
+func PEP440(a, b string) int {
+	versionA, validA := parsePEP440(a)
+	versionB, validB := parsePEP440(b)
+	switch {
+	case !validA && !validB:
+		return 0
+	case !validA:
+		return -1
+	case !validB:
+		return 1
+	}
+
+	if cmp := compareNumeric(versionA.epoch, versionB.epoch); cmp != 0 {
+		return cmp
+	}
+	// Trailing zeros are insignificant (1.0 = 1.0.0).
+	for i := 0; i < len(versionA.release) || i < len(versionB.release); i++ {
+		partA, partB := "0", "0"
+		if i < len(versionA.release) {
+			partA = versionA.release[i]
+		}
+		if i < len(versionB.release) {
+			partB = versionB.release[i]
+		}
+		if cmp := compareNumeric(partA, partB); cmp != 0 {
+			return cmp
+		}
+	}
+	if cmp := sign(versionA.preRank() - versionB.preRank()); cmp != 0 {
+		return cmp
+	}
+	if versionA.hasPre && versionB.hasPre {
+		if cmp := compareNumeric(versionA.pre, versionB.pre); cmp != 0 {
+			return cmp
+		}
+	}
+	if cmp := compareOptionalNumber(versionA.hasPost, versionA.post, versionB.hasPost, versionB.post, -1); cmp != 0 {
+		return cmp
+	}
+	if cmp := compareOptionalNumber(versionA.hasDev, versionA.dev, versionB.hasDev, versionB.dev, 1); cmp != 0 {
+		return cmp
+	}
+	return comparePEP440Local(versionA.local, versionB.local)
+}
+
+// preRank returns the sort weight of the pre-release of the version.
+//
+// A dev-release of the final release (1.0.dev1) sorts before its pre-releases,
+// and the final release after them.
+func (v pep440Version) preRank() int {
+	switch {
+	case v.hasPre:
+		return v.prePhase
+	case v.hasDev && !v.hasPost:
+		return -1
+	}
+	return 3
+}
+
+// compareOptionalNumber compares the numbers of a segment that may be missing,
+// with a missing segment sorting `missing` (-1 = before, 1 = after) any number.
+func compareOptionalNumber(hasA bool, a string, hasB bool, b string, missing int) int {
+	switch {
+	case !hasA && !hasB:
+		return 0
+	case !hasA:
+		return missing
+	case !hasB:
+		return -missing
+	}
+	return compareNumeric(a, b)
+}
+
+// comparePEP440Local compares local version labels (+ubuntu.1), which sort after no label.
+//
+// Numeric segments sort after alphanumeric ones, and are compared numerically.
+func comparePEP440Local(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		numericA := strings.TrimLeftFunc(a[i], isDigitRune) == ""
+		numericB := strings.TrimLeftFunc(b[i], isDigitRune) == ""
+		switch {
+		case numericA && numericB:
+			if cmp := compareNumeric(a[i], b[i]); cmp != 0 {
+				return cmp
+			}
+		case numericA:
+			return 1
+		case numericB:
+			return -1
+		default:
+			if cmp := strings.Compare(a[i], b[i]); cmp != 0 {
+				return cmp
+			}
+		}
+	}
+	return sign(len(a) - len(b))
+}
@@ -0,0 +1,163 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vercmp
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+)
+
+// Scheme is a style of versioning, with which versions are valid and how they're ordered.
+type Scheme struct {
+	Name        string                // Name of the scheme, e.g. semver.
+	Description string                // Description of a version of the scheme, e.g. "a semantic version".
+	Style       string                // Style of the versions, e.g. "'MAJOR.MINOR.PATCH' (https://semver.org/)".
+	Valid       func(string) bool     // Valid returns whether the version follows the scheme.
+	Compare     func(a, b string) int // Compare returns -1, 0 or 1 if `a` is less than, equal to or greater than `b`.
+}
+
+var (
+	// calVerRegex matches calendar versions (YYYY.MM.DD, YY.0M.MICRO, ...), with an optional "-modifier".
+	calVerRegex = regexp.MustCompile(`^v?[0-9]{2,4}(\.[0-9]+){1,3}(-[0-9A-Za-z.]+)?$`)
+	// numericRegex matches dot-separated numbers of any length (1.2.3.4), with an optional "-modifier".
+	numericRegex = regexp.MustCompile(`^v?[0-9]+(\.[0-9]+)*(-[0-9A-Za-z.]+)?$`)
+	// debianRegex matches Debian package versions ([epoch:]upstream_version[-debian_revision]).
+	debianRegex = regexp.MustCompile(`^([0-9]+:)?[0-9][0-9A-Za-z.+~-]*$`)
+	// rpmRegex matches RPM package versions ([epoch:]version[-release]).
+	rpmRegex = regexp.MustCompile(`^([0-9]+:)?[0-9A-Za-z][0-9A-Za-z._+~^-]*$`)
+	// apkRegex matches APK package versions (1.2.3[a][_suffix[N]][-rN]).
+	apkRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*[a-z]?(_(alpha|beta|pre|rc|cvs|svn|git|hg|p)[0-9]*)*(-r[0-9]+)?$`)
+)
+
+// schemes are the Schemes by their name.
+var schemes = map[string]*Scheme{
+	"apk": {
+		Name:        "apk",
+		Description: "an APK package version",
+		Style:       "'1.2.3[_suffix][-rN]'",
+		Valid:       apkRegex.MatchString,
+		Compare:     APK},
+	"calver": {
+		Name:        "calver",
+		Description: "a calendar version",
+		Style:       "'YYYY.MM.DD' (https://calver.org/)",
+		Valid:       calVerRegex.MatchString,
+		Compare:     Numeric},
+	"debian": {
+		Name:        "debian",
+		Description: "a Debian package version",
+		Style:       "'[epoch:]upstream_version[-debian_revision]'",
+		Valid:       debianRegex.MatchString,
+		Compare:     Debian},
+	"numeric": {
+		Name:        "numeric",
+		Description: "a numeric version",
+		Style:       "'1.2.3.4'",
+		Valid:       numericRegex.MatchString,
+		Compare:     Numeric},
+	"pep440": {
+		Name:        "pep440",
+		Description: "a PEP 440 version",
+		Style:       "'1.2.0rc1' (https://peps.python.org/pep-0440/)",
+		Valid:       ValidPEP440,
+		Compare:     PEP440},
+	"rpm": {
+		Name:        "rpm",
+		Description: "an RPM package version",
+		Style:       "'[epoch:]version[-release]'",
+		Valid:       rpmRegex.MatchString,
+		Compare:     RPM},
+	"semver": {
+		Name:        "semver",
+		Description: "a semantic version",
+		Style:       "'MAJOR.MINOR.PATCH' (https://semver.org/)",
+		Valid:       ValidSemVer,
+		Compare:     SemVer},
+}
+
+// SchemeNames are the names of all the version schemes ("none" for no scheme).
+var SchemeNames = []string{"semver", "calver", "pep440", "numeric", "debian", "rpm", "apk", "none"}
+
+// GetScheme returns the Scheme called `name` (nil for "none", or an unknown scheme).
+func GetScheme(name string) *Scheme {
+	return schemes[name]
+}
+
+// ValidSemVer returns whether `version` is a semantic version (MAJOR.MINOR.PATCH[-pre.release][+metadata]).
+func ValidSemVer(version string) bool {
+	_, err := semver.NewVersion(version)
+	return err == nil
+}
+
+// SemVer compares semantic versions, returning -1, 0 or 1 if `a` is less than, equal to or greater than `b`.
+//
+// Versions that aren't semantic sort before those that are.
+func SemVer(a, b string) int {
+	semVerA, errA := semver.NewVersion(a)
+	semVerB, errB := semver.NewVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return semVerA.Compare(*semVerB)
+}
+
+// splitNumeric splits `version` into its dot-separated numbers and any "-modifier".
+func splitNumeric(version string) (parts []string, modifier string) {
+	version = strings.TrimPrefix(version, "v")
+	if index := strings.Index(version, "-"); index != -1 {
+		modifier = version[index+1:]
+		version = version[:index]
+	}
+	return strings.Split(version, "."), modifier
+}
+
+// Numeric compares versions of dot-separated numbers (e.g. 1.2.3.4, or CalVer 2024.05.1),
+// returning -1, 0 or 1 if `a` is less than, equal to or greater than `b`.
+//
+// Missing parts are 0 (1.2 = 1.2.0), and a "-modifier" sorts before the version without one (1.2-rc1 < 1.2).
+func Numeric(a, b string) int {
+	partsA, modifierA := splitNumeric(a)
+	partsB, modifierB := splitNumeric(b)
+
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		partA, partB := "0", "0"
+		if i < len(partsA) {
+			partA = partsA[i]
+		}
+		if i < len(partsB) {
+			partB = partsB[i]
+		}
+		if cmp := compareNumeric(partA, partB); cmp != 0 {
+			return cmp
+		}
+	}
+
+	switch {
+	case modifierA == modifierB:
+		return 0
+	case modifierA == "":
+		return 1
+	case modifierB == "":
+		return -1
+	}
+	return debianCompareFragment(modifierA, modifierB)
+}
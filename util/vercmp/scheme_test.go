@@ -0,0 +1,206 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package vercmp
+
+import (
+	"testing"
+)
+
+func TestGetScheme(t *testing.T) {
+	// GIVEN the names of version schemes
+	for _, name := range SchemeNames {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN GetScheme is called with it
+			got := GetScheme(name)
+
+			// THEN only "none" has no Scheme
+			if name == "none" {
+				if got != nil {
+					t.Fatalf("want nil Scheme, got %q",
+						got.Name)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("want the %q Scheme, got nil",
+					name)
+			}
+			// AND the Scheme is complete
+			if got.Name != name || got.Description == "" || got.Style == "" ||
+				got.Valid == nil || got.Compare == nil {
+				t.Errorf("incomplete Scheme: %+v",
+					got)
+			}
+		})
+	}
+
+	// GIVEN an unknown scheme
+	// WHEN GetScheme is called with it
+	// THEN nil is returned
+	if got := GetScheme("unknown"); got != nil {
+		t.Errorf("want nil Scheme for an unknown scheme, got %q",
+			got.Name)
+	}
+}
+
+func TestScheme_Valid(t *testing.T) {
+	// GIVEN version schemes and versions to validate
+	tests := map[string]struct {
+		valid   []string
+		invalid []string
+	}{
+		"semver": {
+			valid:   []string{"1.2.3", "1.2.3-beta.1", "1.2.3+build.4"},
+			invalid: []string{"1.2", "v1.2.3", "1.2.3.4", "2024.05"}},
+		"calver": {
+			valid:   []string{"2024.05.01", "24.5", "v2024.5.1.2", "2024.05.1-rc1"},
+			invalid: []string{"1.2.3", "2024", "2024-05-01", "release"}},
+		"pep440": {
+			valid:   []string{"1.2", "1.2.0rc1", "1!2.0", "1.0.post1", "1.0.dev2", "1.0+ubuntu.1", "v1.0-alpha.1"},
+			invalid: []string{"1.2.x", "release", "1.0+"}},
+		"numeric": {
+			valid:   []string{"1", "1.2.3.4", "v1.2", "1.2-beta"},
+			invalid: []string{"1.2.x", "1..2", "release"}},
+		"debian": {
+			valid:   []string{"1.2.3-1", "1:2.0~rc1", "2.34-0ubuntu3.2"},
+			invalid: []string{"v1.2", "1.2 3"}},
+		"rpm": {
+			valid:   []string{"1.2.3-1.el9", "1:2.0^20230101"},
+			invalid: []string{"-1.2", "1.2 3"}},
+		"apk": {
+			valid:   []string{"1.2.3-r0", "1.2.3a", "1.2.3_rc1-r2"},
+			invalid: []string{"v1.2.3", "1.2.3_foo"}},
+	}
+
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			scheme := GetScheme(name)
+			for _, want := range []bool{true, false} {
+				versions := tc.valid
+				if !want {
+					versions = tc.invalid
+				}
+				for _, version := range versions {
+					// WHEN Valid is called on the version
+					got := scheme.Valid(version)
+
+					// THEN it's valid when expected
+					if got != want {
+						t.Errorf("%s.Valid(%q) - want %t, got %t",
+							name, version, want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSemVer(t *testing.T) {
+	// GIVEN semantic versions
+	tests := map[string]compareTest{
+		"equal": {
+			a: "1.2.3", b: "1.2.3", want: 0},
+		"numeric, not lexical": {
+			a: "1.10.0", b: "1.9.0", want: 1},
+		"prerelease before release": {
+			a: "1.2.3-rc.1", b: "1.2.3", want: -1},
+		"invalid before valid": {
+			a: "latest", b: "0.0.1", want: -1},
+		"both invalid": {
+			a: "foo", b: "bar", want: 0},
+	}
+
+	testCompare(t, SemVer, tests)
+}
+
+func TestNumeric(t *testing.T) {
+	// GIVEN numeric/calendar versions
+	tests := map[string]compareTest{
+		"equal": {
+			a: "1.2.3.4", b: "1.2.3.4", want: 0},
+		"numeric, not lexical": {
+			a: "1.2.3.10", b: "1.2.3.9", want: 1},
+		"fourth part": {
+			a: "1.2.3.1", b: "1.2.3", want: 1},
+		"missing parts are 0": {
+			a: "1.2", b: "1.2.0.0", want: 0},
+		"leading zeros": {
+			a: "2024.05.01", b: "2024.5.1", want: 0},
+		"calver": {
+			a: "2024.12.1", b: "2025.01.0", want: -1},
+		"v prefix": {
+			a: "v1.2", b: "1.2", want: 0},
+		"modifier before the version": {
+			a: "2024.05-rc1", b: "2024.05", want: -1},
+		"modifiers": {
+			a: "1.2-rc1", b: "1.2-rc2", want: -1},
+	}
+
+	testCompare(t, Numeric, tests)
+}
+
+func TestPEP440(t *testing.T) {
+	// GIVEN PEP 440 versions
+	tests := map[string]compareTest{
+		"equal": {
+			a: "1.2.0", b: "1.2.0", want: 0},
+		"trailing zeros": {
+			a: "1.2", b: "1.2.0.0", want: 0},
+		"normalised spellings": {
+			a: "1.0-alpha.1", b: "1.0a1", want: 0},
+		"implicit number": {
+			a: "1.0rc", b: "1.0rc0", want: 0},
+		"numeric, not lexical": {
+			a: "1.10", b: "1.9", want: 1},
+		"epoch beats release": {
+			a: "1!1.0", b: "2.0", want: 1},
+		"dev before pre": {
+			a: "1.0.dev1", b: "1.0a1", want: -1},
+		"alpha before beta": {
+			a: "1.0a2", b: "1.0b1", want: -1},
+		"beta before rc": {
+			a: "1.0b2", b: "1.0rc1", want: -1},
+		"pre before final": {
+			a: "1.0rc1", b: "1.0", want: -1},
+		"final before post": {
+			a: "1.0", b: "1.0.post1", want: -1},
+		"implicit post": {
+			a: "1.0-1", b: "1.0.post1", want: 0},
+		"dev of pre before pre": {
+			a: "1.0a1.dev1", b: "1.0a1", want: -1},
+		"dev of post before post": {
+			a: "1.0.post1.dev1", b: "1.0.post1", want: -1},
+		"dev of post after final": {
+			a: "1.0.post1.dev1", b: "1.0", want: 1},
+		"local after no local": {
+			a: "1.0+local", b: "1.0", want: 1},
+		"numeric local after alphanumeric": {
+			a: "1.0+1", b: "1.0+abc", want: 1},
+		"longer local": {
+			a: "1.0+abc.1", b: "1.0+abc", want: 1},
+		"invalid before valid": {
+			a: "latest", b: "0.1", want: -1},
+	}
+
+	testCompare(t, PEP440, tests)
+}
@@ -0,0 +1,338 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vercmp compares versions using the ordering of distro package managers.
+package vercmp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// sign returns -1, 0 or 1 for the sign of `n`.
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// isDigit returns whether `c` is an ASCII digit.
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// isDigitRune returns whether `r` is an ASCII digit.
+func isDigitRune(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+// isAlpha returns whether `c` is an ASCII letter.
+func isAlpha(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// compareNumeric compares two strings of digits by their numeric value.
+func compareNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return sign(len(a) - len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+// splitEpoch splits `version` into its "epoch:" and the rest.
+func splitEpoch(version string) (epoch int, rest string) {
+	rest = version
+	if index := strings.Index(version, ":"); index != -1 {
+		epoch, _ = strconv.Atoi(version[:index])
+		rest = version[index+1:]
+	}
+	return
+}
+
+// splitRevision splits `version` into its upstream version and "-revision".
+func splitRevision(version string) (upstream string, revision string) {
+	upstream = version
+	if index := strings.LastIndex(version, "-"); index != -1 {
+		upstream = version[:index]
+		revision = version[index+1:]
+	}
+	return
+}
+
+// Debian compares Debian package versions ([epoch:]upstream_version[-debian_revision])
+// like dpkg --compare-versions, returning -1, 0 or 1 if `a` is less than, equal to or greater than `b`.
+//
+// A '~' sorts before anything, even the end of the version (1.0~rc1 < 1.0).
+func Debian(a, b string) int {
+	epochA, a := splitEpoch(a)
+	epochB, b := splitEpoch(b)
+	if epochA != epochB {
+		return sign(epochA - epochB)
+	}
+
+	upstreamA, revisionA := splitRevision(a)
+	upstreamB, revisionB := splitRevision(b)
+	if cmp := debianCompareFragment(upstreamA, upstreamB); cmp != 0 {
+		return cmp
+	}
+	return debianCompareFragment(revisionA, revisionB)
+}
+
+// debianOrder returns the sort weight of the first character of `s` in a non-digit part.
+func debianOrder(s string) int {
+	if s == "" {
+		return 0
+	}
+	switch c := s[0]; {
+	case c == '~':
+		return -1
+	case isDigit(c):
+		return 0
+	case isAlpha(c):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+// debianCompareFragment is dpkg's verrevcmp, comparing alternating non-digit/digit parts.
+func debianCompareFragment(a, b string) int {
+	for a != "" || b != "" {
+		// Non-digit part.
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			orderA, orderB := debianOrder(a), debianOrder(b)
+			if orderA != orderB {
+				return sign(orderA - orderB)
+			}
+			// Same weight, so neither has ended.
+			a, b = a[1:], b[1:]
+		}
+
+		// Digit part.
+		digitsA := len(a) - len(strings.TrimLeftFunc(a, isDigitRune))
+		digitsB := len(b) - len(strings.TrimLeftFunc(b, isDigitRune))
+		if cmp := compareNumeric(a[:digitsA], b[:digitsB]); cmp != 0 {
+			return cmp
+		}
+		a, b = a[digitsA:], b[digitsB:]
+	}
+	return 0
+}
+
+// RPM compares RPM package versions ([epoch:]version[-release]) like rpmvercmp,
+// returning -1, 0 or 1 if `a` is less than, equal to or greater than `b`.
+//
+// A '~' sorts before anything (1.0~rc1 < 1.0) and a '^' after the version, but before
+// any further segment (1.0 < 1.0^git1 < 1.0.1).
+func RPM(a, b string) int {
+	epochA, a := splitEpoch(a)
+	epochB, b := splitEpoch(b)
+	if epochA != epochB {
+		return sign(epochA - epochB)
+	}
+
+	versionA, releaseA := splitRevision(a)
+	versionB, releaseB := splitRevision(b)
+	if cmp := rpmvercmp(versionA, versionB); cmp != 0 {
+		return cmp
+	}
+	// Only compare the release if both have one.
+	if releaseA == "" || releaseB == "" {
+		return 0
+	}
+	return rpmvercmp(releaseA, releaseB)
+}
+
+// rpmvercmp compares alternating alphabetic/numeric segments of RPM versions.
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	isSeparator := func(r rune) bool {
+		return r > 127 || !(isDigit(byte(r)) || isAlpha(byte(r)) || r == '~' || r == '^')
+	}
+	for a != "" || b != "" {
+		a = strings.TrimLeftFunc(a, isSeparator)
+		b = strings.TrimLeftFunc(b, isSeparator)
+
+		// '~' sorts before everything.
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		// '^' sorts after the end, but before anything else.
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			if a == "" {
+				return -1
+			}
+			if b == "" {
+				return 1
+			}
+			if !strings.HasPrefix(a, "^") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "^") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		// Take the segment of the same type from both.
+		numeric := isDigit(a[0])
+		inSegment := func(r rune) bool {
+			if r > 127 {
+				return false
+			}
+			if numeric {
+				return isDigit(byte(r))
+			}
+			return isAlpha(byte(r))
+		}
+		segmentA := a[:len(a)-len(strings.TrimLeftFunc(a, inSegment))]
+		segmentB := b[:len(b)-len(strings.TrimLeftFunc(b, inSegment))]
+		a, b = a[len(segmentA):], b[len(segmentB):]
+
+		// Different types, numeric segments are newer.
+		if segmentB == "" {
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		var cmp int
+		if numeric {
+			cmp = compareNumeric(segmentA, segmentB)
+		} else {
+			cmp = strings.Compare(segmentA, segmentB)
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+
+	// Whichever has characters left over is newer.
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+// apkSuffixes are the ranks of the suffixes of an APK version, relative to no suffix.
+var apkSuffixes = map[string]int{
+	"alpha": -4, "beta": -3, "pre": -2, "rc": -1,
+	"cvs": 1, "svn": 2, "git": 3, "hg": 4, "p": 5}
+
+// apkVersion is an APK version (e.g. 1.2.3a_rc1_p2-r4) split into its parts.
+type apkVersion struct {
+	numbers  []string // 1, 2, 3
+	letter   string   // a
+	suffixes [][2]int // [rc 1] [p 2]
+	revision int      // 4
+}
+
+// parseAPK splits an APK version into its parts.
+func parseAPK(version string) (v apkVersion) {
+	if index := strings.LastIndex(version, "-r"); index != -1 {
+		if revision, err := strconv.Atoi(version[index+2:]); err == nil {
+			v.revision = revision
+			version = version[:index]
+		}
+	}
+
+	parts := strings.Split(version, "_")
+	for _, suffix := range parts[1:] {
+		name := strings.TrimRightFunc(suffix, isDigitRune)
+		number, _ := strconv.Atoi(suffix[len(name):])
+		v.suffixes = append(v.suffixes, [2]int{apkSuffixes[name], number})
+	}
+
+	numbers := parts[0]
+	if numbers != "" && isAlpha(numbers[len(numbers)-1]) {
+		v.letter = numbers[len(numbers)-1:]
+		numbers = numbers[:len(numbers)-1]
+	}
+	v.numbers = strings.Split(numbers, ".")
+	return
+}
+
+// APK compares Alpine package versions (e.g. 1.2.3_rc1-r0) like apk version -t,
+// returning -1, 0 or 1 if `a` is less than, equal to or greater than `b`.
+//
+// Pre-release suffixes (_alpha, _beta, _pre, _rc) sort before no suffix,
+// and the others (_cvs, _svn, _git, _hg, _p) after.
+func APK(a, b string) int {
+	versionA, versionB := parseAPK(a), parseAPK(b)
+
+	// Numbers, after the first, with a leading zero are compared as decimal fractions.
+	for i := 0; i < len(versionA.numbers) && i < len(versionB.numbers); i++ {
+		numberA, numberB := versionA.numbers[i], versionB.numbers[i]
+		var cmp int
+		if i != 0 && (strings.HasPrefix(numberA, "0") || strings.HasPrefix(numberB, "0")) {
+			cmp = strings.Compare(strings.TrimRight(numberA, "0"), strings.TrimRight(numberB, "0"))
+		} else {
+			cmp = compareNumeric(numberA, numberB)
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	if len(versionA.numbers) != len(versionB.numbers) {
+		return sign(len(versionA.numbers) - len(versionB.numbers))
+	}
+
+	if cmp := strings.Compare(versionA.letter, versionB.letter); cmp != 0 {
+		return cmp
+	}
+
+	for i := 0; i < len(versionA.suffixes) || i < len(versionB.suffixes); i++ {
+		var suffixA, suffixB [2]int
+		if i < len(versionA.suffixes) {
+			suffixA = versionA.suffixes[i]
+		}
+		if i < len(versionB.suffixes) {
+			suffixB = versionB.suffixes[i]
+		}
+		if suffixA[0] != suffixB[0] {
+			return sign(suffixA[0] - suffixB[0])
+		}
+		if suffixA[1] != suffixB[1] {
+			return sign(suffixA[1] - suffixB[1])
+		}
+	}
+
+	return sign(versionA.revision - versionB.revision)
+}
@@ -0,0 +1,153 @@
+// Copyright [2023] [Argus]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unit
+
+package vercmp
+
+import (
+	"testing"
+)
+
+type compareTest struct {
+	a, b string
+	want int
+}
+
+func testCompare(t *testing.T, compare func(a, b string) int, tests map[string]compareTest) {
+	for name, tc := range tests {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN the versions are compared both ways
+			got := compare(tc.a, tc.b)
+			gotReversed := compare(tc.b, tc.a)
+
+			// THEN the order is as expected
+			if got != tc.want {
+				t.Errorf("compare(%q, %q) - want %d, got %d",
+					tc.a, tc.b, tc.want, got)
+			}
+			if gotReversed != -tc.want {
+				t.Errorf("compare(%q, %q) - want %d, got %d",
+					tc.b, tc.a, -tc.want, gotReversed)
+			}
+		})
+	}
+}
+
+func TestDebian(t *testing.T) {
+	// GIVEN Debian versions
+	tests := map[string]compareTest{
+		"equal": {
+			a: "1.2.3-1", b: "1.2.3-1", want: 0},
+		"numeric, not lexical": {
+			a: "1.10", b: "1.9", want: 1},
+		"leading zeros": {
+			a: "1.01", b: "1.1", want: 0},
+		"epoch beats version": {
+			a: "1:1.0", b: "2.0", want: 1},
+		"no epoch is epoch 0": {
+			a: "0:1.0", b: "1.0", want: 0},
+		"revision": {
+			a: "1.0-2", b: "1.0-10", want: -1},
+		"revision with a hyphen in the upstream version": {
+			a: "1.0-beta-2", b: "1.0-beta-1", want: 1},
+		"tilde before the end": {
+			a: "1.0~rc1", b: "1.0", want: -1},
+		"tilde before a tilde": {
+			a: "1.0~~", b: "1.0~", want: -1},
+		"letter after the end": {
+			a: "1.0a", b: "1.0", want: 1},
+		"letters before other characters": {
+			a: "1.0a", b: "1.0+", want: -1},
+		"plus after the end": {
+			a: "1.0+dfsg", b: "1.0", want: 1},
+		"ubuntu revisions": {
+			a: "2.34-0ubuntu3.2", b: "2.34-0ubuntu3.10", want: -1},
+	}
+
+	testCompare(t, Debian, tests)
+}
+
+func TestRPM(t *testing.T) {
+	// GIVEN RPM versions
+	tests := map[string]compareTest{
+		"equal": {
+			a: "1.2.3-1.el9", b: "1.2.3-1.el9", want: 0},
+		"numeric, not lexical": {
+			a: "1.10", b: "1.9", want: 1},
+		"leading zeros": {
+			a: "1.01", b: "1.1", want: 0},
+		"epoch beats version": {
+			a: "1:1.0-1", b: "2.0-1", want: 1},
+		"release": {
+			a: "1.0-2.el9", b: "1.0-10.el9", want: -1},
+		"no release matches any release": {
+			a: "1.0", b: "1.0-5", want: 0},
+		"separators are equal": {
+			a: "1.0_1", b: "1.0.1", want: 0},
+		"numeric newer than alpha": {
+			a: "1.0.1", b: "1.0.a", want: 1},
+		"more segments newer": {
+			a: "1.0.1", b: "1.0", want: 1},
+		"tilde before the end": {
+			a: "1.0~rc1", b: "1.0", want: -1},
+		"tilde before a tilde": {
+			a: "1.0~~", b: "1.0~", want: -1},
+		"caret after the end": {
+			a: "1.0^git1", b: "1.0", want: 1},
+		"caret before another segment": {
+			a: "1.0^git1", b: "1.0.1", want: -1},
+		"letter after the end": {
+			a: "1.0a", b: "1.0", want: 1},
+	}
+
+	testCompare(t, RPM, tests)
+}
+
+func TestAPK(t *testing.T) {
+	// GIVEN APK versions
+	tests := map[string]compareTest{
+		"equal": {
+			a: "1.2.3-r0", b: "1.2.3-r0", want: 0},
+		"numeric, not lexical": {
+			a: "1.10", b: "1.9", want: 1},
+		"more numbers newer": {
+			a: "1.2.1", b: "1.2", want: 1},
+		"leading zero compared as a fraction": {
+			a: "1.02", b: "1.1", want: -1},
+		"letter": {
+			a: "1.2b", b: "1.2a", want: 1},
+		"letter after none": {
+			a: "1.2a", b: "1.2", want: 1},
+		"_rc before none": {
+			a: "1.2_rc1", b: "1.2", want: -1},
+		"_alpha before _beta": {
+			a: "1.2_alpha2", b: "1.2_beta1", want: -1},
+		"_rc number": {
+			a: "1.2_rc10", b: "1.2_rc2", want: 1},
+		"_p after none": {
+			a: "1.2_p1", b: "1.2", want: 1},
+		"_git before _p": {
+			a: "1.2_git20230101", b: "1.2_p1", want: -1},
+		"revision": {
+			a: "1.2-r10", b: "1.2-r2", want: 1},
+		"version beats revision": {
+			a: "1.3-r0", b: "1.2-r9", want: 1},
+	}
+
+	testCompare(t, APK, tests)
+}
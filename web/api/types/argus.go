@@ -98,24 +98,42 @@ func (s *ServiceSummary) RemoveUnchanged(other *ServiceSummary) {
 	if other.Status.LatestVersion == s.Status.LatestVersion {
 		s.Status.LatestVersion = ""
 		s.Status.LatestVersionTimestamp = ""
+		s.Status.LatestVersionDigest = ""
+		s.Status.LatestVersionCreated = ""
+		s.Status.LatestVersionURLs = nil
+		s.Status.LatestVersionMessage = ""
+		s.Status.LatestVersionRelease = nil
+		statusSameCount++
+	}
+	// Status.PendingVersion
+	if other.Status.PendingVersion == s.Status.PendingVersion {
+		s.Status.PendingVersion = ""
+		s.Status.PendingVersionTimestamp = ""
 		statusSameCount++
 	}
 	// nil Status if all fields are the same
-	if statusSameCount == 3 {
+	if statusSameCount == 4 {
 		s.Status = nil
 	}
 }
 
 // Status is the Status of a Service.
 type Status struct {
-	ApprovedVersion          string `json:"approved_version,omitempty"`           // The version that's been approved
-	DeployedVersion          string `json:"deployed_version,omitempty"`           // Track the deployed version of the service from the last successful WebHook
-	DeployedVersionTimestamp string `json:"deployed_version_timestamp,omitempty"` // UTC timestamp that the deployed version change was noticed
-	LatestVersion            string `json:"latest_version,omitempty"`             // Latest version found from query()
-	LatestVersionTimestamp   string `json:"latest_version_timestamp,omitempty"`   // UTC timestamp that the latest version change was noticed
-	LastQueried              string `json:"last_queried,omitempty"`               // UTC timestamp that version was last queried/checked
-	RegexMissesContent       uint   `json:"regex_misses_content,omitempty"`       // Counter for the number of regex misses on URL content
-	RegexMissesVersion       uint   `json:"regex_misses_version,omitempty"`       // Counter for the number of regex misses on version
+	ApprovedVersion          string   `json:"approved_version,omitempty"`           // The version that's been approved
+	DeployedVersion          string   `json:"deployed_version,omitempty"`           // Track the deployed version of the service from the last successful WebHook
+	DeployedVersionTimestamp string   `json:"deployed_version_timestamp,omitempty"` // UTC timestamp that the deployed version change was noticed
+	LatestVersion            string   `json:"latest_version,omitempty"`             // Latest version found from query()
+	LatestVersionTimestamp   string   `json:"latest_version_timestamp,omitempty"`   // UTC timestamp that the latest version change was noticed
+	LatestVersionDigest      string   `json:"latest_version_digest,omitempty"`      // Digest of the latest version (Docker tag digest/Helm lookups)
+	LatestVersionCreated     string   `json:"latest_version_created,omitempty"`     // Created date of the image/chart of the latest version (Docker tag digest/Helm lookups)
+	LatestVersionURLs        []string `json:"latest_version_urls,omitempty"`        // Download URLs of the latest version (Helm lookups)
+	LatestVersionMessage     string   `json:"latest_version_message,omitempty"`     // Commit message of the latest version (GitHub branch lookups)
+	LatestVersionRelease     *Release `json:"latest_version_release,omitempty"`     // Release metadata of the latest version (GitHub lookups)
+	PendingVersion           string   `json:"pending_version,omitempty"`            // Version found that's waiting for require.min_age before becoming the latest version
+	PendingVersionTimestamp  string   `json:"pending_version_timestamp,omitempty"`  // UTC timestamp that the pending version was published (or first seen)
+	LastQueried              string   `json:"last_queried,omitempty"`               // UTC timestamp that version was last queried/checked
+	RegexMissesContent       uint     `json:"regex_misses_content,omitempty"`       // Counter for the number of regex misses on URL content
+	RegexMissesVersion       uint     `json:"regex_misses_version,omitempty"`       // Counter for the number of regex misses on version
 }
 
 // String returns a JSON string representation of the Status.
@@ -127,6 +145,16 @@ func (s *Status) String() string {
 	return string(jsonBytes)
 }
 
+// Release metadata of a version.
+type Release struct {
+	Name        string `json:"name,omitempty"`         // Name/title of the release
+	Body        string `json:"body,omitempty"`         // Release notes
+	HTMLURL     string `json:"html_url,omitempty"`     // Web page of the release
+	PublishedAt string `json:"published_at,omitempty"` // Date the release was published
+	Draft       bool   `json:"draft,omitempty"`        // Whether the release is a draft
+	Author      string `json:"author,omitempty"`       // Username of the author of the release
+}
+
 // StatusFails keeps track of whether each of the notifications failed on the last version change.
 type StatusFails struct {
 	Notify  *[]bool `json:"notify,omitempty"`  // Track whether any of the Slice failed
@@ -355,7 +383,8 @@ type Service struct {
 type ServiceOptions struct {
 	Active             *bool  `json:"active,omitempty"`              // Active Service?
 	Interval           string `json:"interval,omitempty"`            // AhBmCs = Sleep A hours, B minutes and C seconds between queries
-	SemanticVersioning *bool  `json:"semantic_versioning,omitempty"` // default - true = Version has to be greater than the previous to trigger alerts/WebHooks
+	SemanticVersioning *bool  `json:"semantic_versioning,omitempty"` // DEPRECATED - use version_scheme
+	VersionScheme      string `json:"version_scheme,omitempty"`      // default - semver = Version has to follow this scheme and be greater than the previous to trigger alerts/WebHooks
 }
 
 // DashboardOptions.
@@ -368,21 +397,44 @@ type DashboardOptions struct {
 
 // LatestVersion lookup of the service.
 type LatestVersion struct {
-	Type              string                `json:"type,omitempty"`                // Service Type, github/url
+	Type              string                `json:"type,omitempty"`                // Service Type, github/gitlab/gitea/docker/helm/npm/pypi/go/crates/apt/apk/rpm/feed/git/url
 	URL               string                `json:"url,omitempty"`                 // URL to query
+	BaseURL           string                `json:"base_url,omitempty"`            // Base URL of the GitHub Enterprise Server/GitLab/Gitea instance
+	Chart             string                `json:"chart,omitempty"`               // Name of the Helm chart
+	UseAppVersion     *bool                 `json:"use_app_version,omitempty"`     // Whether to use the appVersion of the Helm chart
+	Package           string                `json:"package,omitempty"`             // Name of the OS package
+	Architecture      string                `json:"architecture,omitempty"`        // Architecture of the OS package
+	Branch            string                `json:"branch,omitempty"`              // Branch to track the head commit of
+	Username          string                `json:"username,omitempty"`            // Username for the Docker registry/Helm repository/package registry/OS package repository
 	AccessToken       string                `json:"access_token,omitempty"`        // GitHub access token to use
+	GitHubApp         *GitHubApp            `json:"github_app,omitempty"`          // GitHub App to authenticate as
+	UseGraphQL        *bool                 `json:"use_graphql,omitempty"`         // Whether to batch GitHub queries through the GraphQL API
+	MaxPages          *uint                 `json:"max_pages,omitempty"`           // Maximum number of pages of GitHub/GitLab/Gitea releases to query
+	UseLatestRelease  *bool                 `json:"use_latest_release,omitempty"`  // Whether to use the release GitHub has marked as latest
 	AllowInvalidCerts *bool                 `json:"allow_invalid_certs,omitempty"` // default - false = Disallows invalid HTTPS certificates
 	UsePreRelease     *bool                 `json:"use_prerelease,omitempty"`      // Whether GitHub prereleases should be used
+	OrderBy           string                `json:"order_by,omitempty"`            // How the releases are ordered to choose the latest
 	URLCommands       *URLCommandSlice      `json:"url_commands,omitempty"`        // Commands to filter the release from the URL request
 	Require           *LatestVersionRequire `json:"require,omitempty"`             // Requirements for the version to be considered valid
 }
 
+// GitHubApp to authenticate GitHub lookups as.
+type GitHubApp struct {
+	AppID          string `json:"app_id,omitempty"`          // ID of the GitHub App
+	PrivateKey     string `json:"private_key,omitempty"`     // Path to the private key of the GitHub App
+	InstallationID string `json:"installation_id,omitempty"` // ID of the installation of the GitHub App
+}
+
 // LatestVersionRequire commands, regex etc for the release to be considered valid.
 type LatestVersionRequire struct {
-	Command      []string            `json:"command,omitempty"`       // Require Command to pass
-	Docker       *RequireDockerCheck `json:"docker,omitempty"`        // Docker image tag requirements
-	RegexContent string              `json:"regex_content,omitempty"` // "abc-[a-z]+-{{ version }}_amd64.deb" This regex must exist in the body of the URL to trigger new version actions
-	RegexVersion string              `json:"regex_version,omitempty"` // "v*[0-9.]+" The version found must match this release to trigger new version actions
+	Command           []string            `json:"command,omitempty"`            // Require Command to pass
+	Docker            *RequireDockerCheck `json:"docker,omitempty"`             // Docker image tag requirements
+	RegexContent      string              `json:"regex_content,omitempty"`      // "abc-[a-z]+-{{ version }}_amd64.deb" This regex must exist in the body of the URL to trigger new version actions
+	RegexVersion      string              `json:"regex_version,omitempty"`      // "v*[0-9.]+" The version found must match this release to trigger new version actions
+	VersionConstraint string              `json:"version_constraint,omitempty"` // ">=2.3 <3 || ^4" The version found must satisfy this semantic version range
+	Expression        string              `json:"expression,omitempty"`         // "!prerelease && assets.exists(a, a.name.endsWith('.deb'))" The release found must satisfy this expression
+	MinAge            string              `json:"min_age,omitempty"`            // "48h" The version found must have been published (or first seen) this long ago to trigger new version actions
+	Verify            *RequireVerifyCheck `json:"verify,omitempty"`             // Asset checksum/signature requirements
 }
 
 type RequireDockerCheck struct {
@@ -393,6 +445,15 @@ type RequireDockerCheck struct {
 	Token    string `json:"token,omitempty"`    // Token to get the token for the queries
 }
 
+// RequireVerifyCheck of an asset against its checksums/signature.
+type RequireVerifyCheck struct {
+	Asset         string `json:"asset,omitempty"`          // Name (or URL) of the asset to verify
+	Checksums     string `json:"checksums,omitempty"`      // Name (or URL) of the SHA256SUMS-style file with the checksum of the Asset
+	Signature     string `json:"signature,omitempty"`      // Name (or URL) of the detached signature of the Checksums (or the Asset if there are none)
+	SignatureType string `json:"signature_type,omitempty"` // Type of the Signature, pgp/minisign
+	PublicKey     string `json:"public_key,omitempty"`     // Key to verify the Signature with
+}
+
 // DeployedVersionLookup of the service.
 type DeployedVersionLookup struct {
 	URL               string                 `json:"url,omitempty"`                 // URL to query
@@ -401,6 +462,7 @@ type DeployedVersionLookup struct {
 	Headers           []Header               `json:"headers,omitempty"`             // Headers for the HTTP(S) request
 	JSON              string                 `json:"json,omitempty"`                // JSON key to use e.g. version_current
 	Regex             string                 `json:"regex,omitempty"`               // Regex to get the DeployedVersion
+	RegexTemplate     string                 `json:"regex_template,omitempty"`      // Template for the DeployedVersion with the named capture groups of the regex
 	HardDefaults      *DeployedVersionLookup `json:"-"`                             // Hardcoded default values
 	Defaults          *DeployedVersionLookup `json:"-"`                             // Default values
 }
@@ -440,12 +502,17 @@ func (slice *URLCommandSlice) String() string {
 
 // URLCommand is a command to be ran to filter version from the URL body.
 type URLCommand struct {
-	Type  string  `json:"type,omitempty"`  // regex/replace/split
-	Regex *string `json:"regex,omitempty"` // regex: regexp.MustCompile(Regex)
-	Index int     `json:"index,omitempty"` // regex/split: re.FindAllString(URL_content, -1)[Index]  /  strings.Split("text")[Index]
-	Text  *string `json:"text,omitempty"`  // split:       strings.Split(tgtString, "Text")
-	New   *string `json:"new,omitempty"`   // replace:     strings.ReplaceAll(tgtString, "Old", "New")
-	Old   *string `json:"old,omitempty"`   // replace:     strings.ReplaceAll(tgtString, "Old", "New")
+	Type       string  `json:"type,omitempty"`        // regex/replace/split/jsonpath/css/xpath
+	Regex      *string `json:"regex,omitempty"`       // regex: regexp.MustCompile(Regex)
+	Template   *string `json:"template,omitempty"`    // regex: "{{ major }}.{{ minor }}", rendered with the named capture groups
+	Path       *string `json:"path,omitempty"`        // jsonpath: "$.releases[0].version", xpath: "//span[@class='version']"
+	Selector   *string `json:"selector,omitempty"`    // css: "span.version"
+	Attribute  *string `json:"attribute,omitempty"`   // css/xpath: attribute of the selected elements (default their text)
+	Index      int     `json:"index,omitempty"`       // regex/split/jsonpath/css/xpath: re.FindAllString(URL_content, -1)[Index]  /  strings.Split("text")[Index]  /  matches(URL_content)[Index]
+	AllMatches bool    `json:"all_matches,omitempty"` // regex/jsonpath/css/xpath: every match is a candidate version
+	Text       *string `json:"text,omitempty"`        // split:       strings.Split(tgtString, "Text")
+	New        *string `json:"new,omitempty"`         // replace:     strings.ReplaceAll(tgtString, "Old", "New")
+	Old        *string `json:"old,omitempty"`         // replace:     strings.ReplaceAll(tgtString, "Old", "New")
 }
 
 type Command []string
@@ -712,6 +712,31 @@ func TestServiceSummary_RemoveUnchanged(t *testing.T) {
 					LatestVersion:          "4.5.6",
 					LatestVersionTimestamp: "2020-02-02T00:00:00Z"}},
 		},
+		"same pending_version": {
+			old: &ServiceSummary{
+				Status: &Status{
+					PendingVersion:          "1.2.4",
+					PendingVersionTimestamp: "2020-01-01T00:00:00Z"}},
+			new: &ServiceSummary{
+				Status: &Status{
+					PendingVersion:          "1.2.4",
+					PendingVersionTimestamp: "2020-01-01T00:00:00Z"}},
+			want: &ServiceSummary{},
+		},
+		"different pending_version": {
+			old: &ServiceSummary{
+				Status: &Status{
+					PendingVersion:          "1.2.4",
+					PendingVersionTimestamp: "2020-01-01T00:00:00Z"}},
+			new: &ServiceSummary{
+				Status: &Status{
+					PendingVersion:          "1.2.5",
+					PendingVersionTimestamp: "2020-02-02T00:00:00Z"}},
+			want: &ServiceSummary{
+				Status: &Status{
+					PendingVersion:          "1.2.5",
+					PendingVersionTimestamp: "2020-02-02T00:00:00Z"}},
+		},
 		"mmultiple differences": {
 			old: &ServiceSummary{
 				IconLinkTo: stringPtr("https://release-argus.io"),
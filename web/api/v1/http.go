@@ -167,7 +167,9 @@ func (api *API) httpVersionRefreshUncreated(w http.ResponseWriter, r *http.Reque
 			getParam(&queryParams, "headers"),
 			getParam(&queryParams, "json"),
 			getParam(&queryParams, "regex"),
+			getParam(&queryParams, "regex_template"),
 			getParam(&queryParams, "semantic_versioning"),
+			getParam(&queryParams, "version_scheme"),
 			getParam(&queryParams, "url"))
 	} else {
 		latestVersion := latestver.Lookup{
@@ -183,6 +185,7 @@ func (api *API) httpVersionRefreshUncreated(w http.ResponseWriter, r *http.Reque
 			getParam(&queryParams, "allow_invalid_certs"),
 			getParam(&queryParams, "require"),
 			getParam(&queryParams, "semantic_versioning"),
+			getParam(&queryParams, "version_scheme"),
 			getParam(&queryParams, "type"),
 			getParam(&queryParams, "url"),
 			getParam(&queryParams, "url_commands"),
@@ -260,7 +263,9 @@ func (api *API) httpVersionRefresh(w http.ResponseWriter, r *http.Request) {
 			getParam(&queryParams, "headers"),
 			getParam(&queryParams, "json"),
 			getParam(&queryParams, "regex"),
+			getParam(&queryParams, "regex_template"),
 			getParam(&queryParams, "semantic_versioning"),
+			getParam(&queryParams, "version_scheme"),
 			getParam(&queryParams, "url"),
 		)
 
@@ -274,6 +279,7 @@ func (api *API) httpVersionRefresh(w http.ResponseWriter, r *http.Request) {
 			getParam(&queryParams, "allow_invalid_certs"),
 			getParam(&queryParams, "require"),
 			getParam(&queryParams, "semantic_versioning"),
+			getParam(&queryParams, "version_scheme"),
 			getParam(&queryParams, "type"),
 			getParam(&queryParams, "url"),
 			getParam(&queryParams, "url_commands"),
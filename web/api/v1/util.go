@@ -24,6 +24,7 @@ import (
 	"github.com/release-argus/Argus/notifiers/shoutrrr"
 	"github.com/release-argus/Argus/service"
 	deployedver "github.com/release-argus/Argus/service/deployed_version"
+	latestver "github.com/release-argus/Argus/service/latest_version"
 	"github.com/release-argus/Argus/service/latest_version/filter"
 	"github.com/release-argus/Argus/util"
 	api_type "github.com/release-argus/Argus/web/api/types"
@@ -76,11 +77,18 @@ func convertAndCensorDefaults(input *config.Defaults) (defaults *api_type.Defaul
 		Service: api_type.Service{
 			Options: &api_type.ServiceOptions{
 				Interval:           input.Service.Options.Interval,
-				SemanticVersioning: input.Service.Options.SemanticVersioning},
+				SemanticVersioning: input.Service.Options.SemanticVersioning,
+				VersionScheme:      input.Service.Options.VersionScheme},
 			LatestVersion: &api_type.LatestVersion{
+				BaseURL:           input.Service.LatestVersion.BaseURL,
 				AccessToken:       util.DefaultOrValue(input.Service.LatestVersion.AccessToken, "<secret>"),
+				GitHubApp:         convertGitHubAppToAPITypeGitHubApp(input.Service.LatestVersion.GitHubApp),
+				UseGraphQL:        input.Service.LatestVersion.UseGraphQL,
+				MaxPages:          input.Service.LatestVersion.MaxPages,
+				UseLatestRelease:  input.Service.LatestVersion.UseLatestRelease,
 				AllowInvalidCerts: input.Service.LatestVersion.AllowInvalidCerts,
-				UsePreRelease:     input.Service.LatestVersion.UsePreRelease},
+				UsePreRelease:     input.Service.LatestVersion.UsePreRelease,
+				OrderBy:           input.Service.LatestVersion.OrderBy},
 			DeployedVersionLookup: &api_type.DeployedVersionLookup{
 				AllowInvalidCerts: input.Service.DeployedVersionLookup.AllowInvalidCerts},
 			Dashboard: &api_type.DashboardOptions{
@@ -169,7 +177,8 @@ func convertDeployedVersionLookupToAPITypeDeployedVersionLookup(dvl *deployedver
 		AllowInvalidCerts: dvl.AllowInvalidCerts,
 		Headers:           headers,
 		JSON:              dvl.JSON,
-		Regex:             dvl.Regex}
+		Regex:             dvl.Regex,
+		RegexTemplate:     dvl.RegexTemplate}
 	// Basic auth
 	if dvl.BasicAuth != nil {
 		apiDVL.BasicAuth = &api_type.BasicAuth{
@@ -186,6 +195,16 @@ func convertDeployedVersionLookupToAPITypeDeployedVersionLookup(dvl *deployedver
 	return
 }
 
+func convertGitHubAppToAPITypeGitHubApp(app *latestver.GitHubApp) *api_type.GitHubApp {
+	if app == nil {
+		return nil
+	}
+	return &api_type.GitHubApp{
+		AppID:          app.AppID,
+		PrivateKey:     app.PrivateKey,
+		InstallationID: app.InstallationID}
+}
+
 func convertURLCommandSliceToAPITypeURLCommandSlice(commands *filter.URLCommandSlice) *api_type.URLCommandSlice {
 	if commands == nil {
 		return nil
@@ -193,12 +212,17 @@ func convertURLCommandSliceToAPITypeURLCommandSlice(commands *filter.URLCommandS
 	slice := make(api_type.URLCommandSlice, len(*commands))
 	for index := range *commands {
 		slice[index] = api_type.URLCommand{
-			Type:  (*commands)[index].Type,
-			Regex: (*commands)[index].Regex,
-			Index: (*commands)[index].Index,
-			Text:  (*commands)[index].Text,
-			Old:   (*commands)[index].Old,
-			New:   (*commands)[index].New}
+			Type:       (*commands)[index].Type,
+			Regex:      (*commands)[index].Regex,
+			Template:   (*commands)[index].Template,
+			Path:       (*commands)[index].Path,
+			Selector:   (*commands)[index].Selector,
+			Attribute:  (*commands)[index].Attribute,
+			Index:      (*commands)[index].Index,
+			AllMatches: (*commands)[index].AllMatches,
+			Text:       (*commands)[index].Text,
+			Old:        (*commands)[index].Old,
+			New:        (*commands)[index].New}
 	}
 	return &slice
 }
@@ -280,14 +304,27 @@ func convertServiceToAPITypeService(service *service.Service) (apiService *api_t
 	apiService.Options = &api_type.ServiceOptions{
 		Active:             service.Options.Active,
 		Interval:           service.Options.Interval,
-		SemanticVersioning: service.Options.SemanticVersioning}
+		SemanticVersioning: service.Options.SemanticVersioning,
+		VersionScheme:      service.Options.VersionScheme}
 
 	apiService.LatestVersion = &api_type.LatestVersion{
 		Type:              service.LatestVersion.Type,
 		URL:               service.LatestVersion.URL,
+		BaseURL:           service.LatestVersion.BaseURL,
+		Chart:             service.LatestVersion.Chart,
+		UseAppVersion:     service.LatestVersion.UseAppVersion,
+		Package:           service.LatestVersion.Package,
+		Architecture:      service.LatestVersion.Architecture,
+		Branch:            service.LatestVersion.Branch,
+		Username:          service.LatestVersion.Username,
 		AccessToken:       util.DefaultOrValue(service.LatestVersion.AccessToken, "<secret>"),
+		GitHubApp:         convertGitHubAppToAPITypeGitHubApp(service.LatestVersion.GitHubApp),
+		UseGraphQL:        service.LatestVersion.UseGraphQL,
+		MaxPages:          service.LatestVersion.MaxPages,
+		UseLatestRelease:  service.LatestVersion.UseLatestRelease,
 		AllowInvalidCerts: service.LatestVersion.AllowInvalidCerts,
 		UsePreRelease:     service.LatestVersion.UsePreRelease,
+		OrderBy:           service.LatestVersion.OrderBy,
 		URLCommands:       convertURLCommandSliceToAPITypeURLCommandSlice(&service.LatestVersion.URLCommands)}
 	if service.LatestVersion.Require != nil {
 		var docker *api_type.RequireDockerCheck
@@ -299,11 +336,24 @@ func convertServiceToAPITypeService(service *service.Service) (apiService *api_t
 				Username: service.LatestVersion.Require.Docker.Username,
 				Token:    util.ValueIfNotDefault(service.LatestVersion.Require.Docker.Token, "<secret>")}
 		}
+		var verify *api_type.RequireVerifyCheck
+		if service.LatestVersion.Require.Verify != nil {
+			verify = &api_type.RequireVerifyCheck{
+				Asset:         service.LatestVersion.Require.Verify.Asset,
+				Checksums:     service.LatestVersion.Require.Verify.Checksums,
+				Signature:     service.LatestVersion.Require.Verify.Signature,
+				SignatureType: service.LatestVersion.Require.Verify.SignatureType,
+				PublicKey:     service.LatestVersion.Require.Verify.PublicKey}
+		}
 		apiService.LatestVersion.Require = &api_type.LatestVersionRequire{
-			Command:      service.LatestVersion.Require.Command,
-			Docker:       docker,
-			RegexContent: service.LatestVersion.Require.RegexContent,
-			RegexVersion: service.LatestVersion.Require.RegexVersion}
+			Command:           service.LatestVersion.Require.Command,
+			Docker:            docker,
+			RegexContent:      service.LatestVersion.Require.RegexContent,
+			RegexVersion:      service.LatestVersion.Require.RegexVersion,
+			VersionConstraint: service.LatestVersion.Require.VersionConstraint,
+			Expression:        service.LatestVersion.Require.Expression,
+			MinAge:            service.LatestVersion.Require.MinAge,
+			Verify:            verify}
 	}
 
 	// DeployedVersionLookup
@@ -299,11 +299,18 @@ func (api *API) wsConfigDefaults(client *Client) {
 				Service: api_type.Service{
 					Options: &api_type.ServiceOptions{
 						Interval:           api.Config.Defaults.Service.Options.Interval,
-						SemanticVersioning: api.Config.Defaults.Service.Options.SemanticVersioning},
+						SemanticVersioning: api.Config.Defaults.Service.Options.SemanticVersioning,
+						VersionScheme:      api.Config.Defaults.Service.Options.VersionScheme},
 					LatestVersion: &api_type.LatestVersion{
+						BaseURL:           api.Config.Defaults.Service.LatestVersion.BaseURL,
 						AccessToken:       util.DefaultOrValue(api.Config.Defaults.Service.LatestVersion.AccessToken, "<secret>"),
+						GitHubApp:         convertGitHubAppToAPITypeGitHubApp(api.Config.Defaults.Service.LatestVersion.GitHubApp),
+						UseGraphQL:        api.Config.Defaults.Service.LatestVersion.UseGraphQL,
+						MaxPages:          api.Config.Defaults.Service.LatestVersion.MaxPages,
+						UseLatestRelease:  api.Config.Defaults.Service.LatestVersion.UseLatestRelease,
 						AllowInvalidCerts: api.Config.Defaults.Service.LatestVersion.AllowInvalidCerts,
-						UsePreRelease:     api.Config.Defaults.Service.LatestVersion.UsePreRelease},
+						UsePreRelease:     api.Config.Defaults.Service.LatestVersion.UsePreRelease,
+						OrderBy:           api.Config.Defaults.Service.LatestVersion.OrderBy},
 					DeployedVersionLookup: &api_type.DeployedVersionLookup{
 						AllowInvalidCerts: api.Config.Defaults.Service.DeployedVersionLookup.AllowInvalidCerts},
 					Dashboard: &api_type.DashboardOptions{
@@ -27,6 +27,9 @@ import (
 )
 
 // SetGitHubHeaders of the req based on the payload and secret.
+//
+// These mimic a GitHub push event sent to the WebHook's receiver, so are signed with the secret
+// and never use GitHub API credentials (access_token/github_app).
 func SetGitHubHeaders(req *http.Request, payload []byte, secret string) {
 	req.Header.Set("X-Github-Event", "push")
 	req.Header.Set("X-Github-Hook-Id", util.RandNumeric(9))
@@ -41,9 +41,7 @@ func (w *WebHook) setCustomHeaders(req *http.Request) {
 		return
 	}
 
-	serviceInfo := util.ServiceInfo{
-		ID:            *w.ServiceStatus.ServiceID,
-		LatestVersion: w.ServiceStatus.GetLatestVersion()}
+	serviceInfo := w.ServiceStatus.GetServiceInfo()
 	for _, header := range *customHeaders {
 		value := util.TemplateString(header.Value, serviceInfo)
 		req.Header[header.Key] = []string{value}
@@ -285,7 +285,7 @@ func (w *WebHook) GetURL() string {
 		url = strings.Clone(
 			util.TemplateString(
 				url,
-				util.ServiceInfo{LatestVersion: w.ServiceStatus.GetLatestVersion()}))
+				w.ServiceStatus.GetServiceInfo()))
 	}
 	return url
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/release-argus/Argus/notifiers/shoutrrr"
 	svcstatus "github.com/release-argus/Argus/service/status"
+	"github.com/release-argus/Argus/util"
 	metric "github.com/release-argus/Argus/web/metrics"
 )
 
@@ -931,6 +932,9 @@ func TestWebHook_GetURL(t *testing.T) {
 		urlHardDefault string
 		want           string
 		latestVersion  string
+		digest         string
+		created        string
+		releaseName    string
 	}{
 		"root overrides all": {
 			want:           "https://release-argus.io",
@@ -968,6 +972,17 @@ func TestWebHook_GetURL(t *testing.T) {
 			urlHardDefault: "",
 			latestVersion:  "1.2.3",
 		},
+		"uses the digest, created date and release of the latest version": {
+			want:           "https://release-argus.io/1.2.3?digest=sha256:abc&created=2023-01-02&release=Argus+1.2.3",
+			urlRoot:        "https://release-argus.io/{{ version }}?digest={{ digest }}&created={{ created }}&release={{ release.name | urlencode }}",
+			urlMain:        "",
+			urlDefault:     "",
+			urlHardDefault: "",
+			latestVersion:  "1.2.3",
+			digest:         "sha256:abc",
+			created:        "2023-01-02",
+			releaseName:    "Argus 1.2.3",
+		},
 		"empty version when unfound": {
 			want:           "https://release-argus.io/",
 			urlRoot:        "https://release-argus.io/{{ version }}",
@@ -988,6 +1003,8 @@ func TestWebHook_GetURL(t *testing.T) {
 			webhook.Defaults.URL = tc.urlDefault
 			webhook.HardDefaults.URL = tc.urlHardDefault
 			webhook.ServiceStatus.SetLatestVersion(tc.latestVersion, false)
+			webhook.ServiceStatus.SetLatestVersionDigest(tc.digest, tc.created, false)
+			webhook.ServiceStatus.SetLatestVersionRelease(util.ReleaseInfo{Name: tc.releaseName}, false)
 
 			// WHEN GetURL is called
 			got := webhook.GetURL()